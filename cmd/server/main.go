@@ -3,30 +3,50 @@ package main
 import (
 	"log"
 	"os"
+	"time"
+	_ "time/tzdata" // Zone data for images without it
 
 	"software-backend/internal/api"
 	"software-backend/internal/api/handlers"
+	"software-backend/internal/clinic"
 	"software-backend/internal/database"
 
+	"software-backend/internal/repository"
 	"software-backend/internal/repository/appointment"
+	"software-backend/internal/repository/appointmenttype"
+	"software-backend/internal/repository/bookingrule"
 	bh "software-backend/internal/repository/business_hour"
+	"software-backend/internal/repository/calendar"
+	"software-backend/internal/repository/consent"
 	"software-backend/internal/repository/consultation"
 	"software-backend/internal/repository/diagnostic"
 	"software-backend/internal/repository/exam"
 	"software-backend/internal/repository/patient"
 	"software-backend/internal/repository/questionnaire"
+	"software-backend/internal/repository/resource"
+	"software-backend/internal/repository/timeline"
 	"software-backend/internal/repository/user"
+	"software-backend/internal/repository/waitlist"
+	"software-backend/internal/scheduler"
 
+	"software-backend/internal/service"
 	appointmentservice "software-backend/internal/service/appointment"
+	appointmenttypeservice "software-backend/internal/service/appointmenttype"
 	authservice "software-backend/internal/service/auth"
+	bookingruleservice "software-backend/internal/service/bookingrule"
 	businesshourservice "software-backend/internal/service/businesshour"
+	calendarservice "software-backend/internal/service/calendar"
+	consentservice "software-backend/internal/service/consent"
 	consultationservice "software-backend/internal/service/consultation"
 	diagnosticService "software-backend/internal/service/diagnostic"
 	examservice "software-backend/internal/service/exam"
 	patientservice "software-backend/internal/service/patient"
 	questionnaireservice "software-backend/internal/service/questionnaire"
+	resourceservice "software-backend/internal/service/resource"
 	s3Service "software-backend/internal/service/s3"
+	timelineservice "software-backend/internal/service/timeline"
 	userservice "software-backend/internal/service/user"
+	waitlistservice "software-backend/internal/service/waitlist"
 
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
@@ -41,6 +61,23 @@ func main() {
 		log.Fatal("JWT_SECRET environment variable not set")
 	}
 
+	// Load clinic timezone, scheduling is computed in it whatever the server's TZ
+	clinicTimezone := os.Getenv("CLINIC_TIMEZONE")
+	if clinicTimezone == "" {
+		clinicTimezone = "UTC"
+	}
+	if err := clinic.SetTimezone(clinicTimezone); err != nil {
+		log.Fatalf("FATAL: %v", err)
+	}
+
+	// Load the country code given to local phone numbers, e.g. 502. Without it only
+	// numbers with their country code are accepted
+	if countryCode := os.Getenv("CLINIC_COUNTRY_CODE"); countryCode != "" {
+		if err := clinic.SetCountryCode(countryCode); err != nil {
+			log.Fatalf("FATAL: %v", err)
+		}
+	}
+
 	// Create database connection
 	dbConn, err := database.NewDatabaseConnection()
 	if err != nil {
@@ -57,19 +94,75 @@ func main() {
 
 	// Initialize business hours dependencies
 	businessHoursRepo := bh.NewBusinessHoursRepository(dbConn)
-	businessHoursService := businesshourservice.NewBusinessHoursService(businessHoursRepo)
+	appointmentRepo := appointment.NewAppointmentRepository(dbConn)
+	businessHoursService := businesshourservice.NewBusinessHoursService(businessHoursRepo, appointmentRepo)
 	businessHoursHandler := handlers.NewBusinessHoursHandler(businessHoursService)
 
+	// Initialize provider & room dependencies
+	resourceRepo := resource.NewResourceRepository(dbConn)
+	resourceService := resourceservice.NewResourceService(resourceRepo)
+	resourceHandler := handlers.NewResourceHandler(resourceService)
+
+	// Initialize questionnaire dependencies
+	questionnaireRepo := questionnaire.NewQuestionnaireRepository(dbConn)
+	questionnaireService := questionnaireservice.NewQuestionnaireService(questionnaireRepo)
+	questionnaireHandler := handlers.NewQuestionnaireHandler(questionnaireService)
+
+	// Initialize appointment type dependencies
+	appointmentTypeRepo := appointmenttype.NewAppointmentTypeRepository(dbConn)
+	appointmentTypeService := appointmenttypeservice.NewAppointmentTypeService(appointmentTypeRepo, questionnaireService)
+	appointmentTypeHandler := handlers.NewAppointmentTypeHandler(appointmentTypeService)
+
+	// Initialize booking rule dependencies
+	bookingRuleRepo := bookingrule.NewBookingRuleRepository(dbConn)
+	bookingRuleService := bookingruleservice.NewBookingRuleService(bookingRuleRepo)
+	bookingRuleHandler := handlers.NewBookingRuleHandler(bookingRuleService)
+
 	// Initialize appointment dependencies
-	appointmentRepo := appointment.NewAppointmentRepository(dbConn)
-	appointmentService := appointmentservice.NewAppointmentService(appointmentRepo, businessHoursService)
+	appointmentService := appointmentservice.NewAppointmentService(appointmentRepo, appointmentTypeRepo, bookingRuleRepo, businessHoursService)
 	appointmentHandler := handlers.NewAppointmentHandler(appointmentService)
 
+	// Flag missed appointments as no-shows in the background
+	noShowScheduler := scheduler.NewNoShowScheduler(appointmentService, 15*time.Minute)
+	noShowScheduler.Start()
+	defer noShowScheduler.Stop()
+
+	// Initialize calendar feed dependencies
+	calendarRepo := calendar.NewCalendarRepository(dbConn)
+	calendarService := calendarservice.NewCalendarService(calendarRepo, appointmentRepo)
+	calendarHandler := handlers.NewCalendarHandler(calendarService)
+
 	// Initialize patient dependencies
 	patientRepo := patient.NewPatientRepository(dbConn)
-	patientService := patientservice.NewPatientService(patientRepo)
+	patientService := patientservice.NewPatientService(patientRepo, appointmentService)
 	patientHandler := handlers.NewPatientHandler(patientService)
 
+	// Initialize patient timeline dependencies
+	timelineRepo := timeline.NewTimelineRepository(dbConn)
+	timelineService := timelineservice.NewTimelineService(timelineRepo, appointmentRepo, patientRepo)
+	timelineHandler := handlers.NewTimelineHandler(timelineService)
+
+	// Initialize contact preference dependencies, WhatsApp messages are only sent when allowed
+	consentRepo := consent.NewConsentRepository(dbConn)
+	consentService := consentservice.NewConsentService(consentRepo, patientRepo)
+	consentHandler := handlers.NewConsentHandler(consentService)
+
+	// Initialize waitlist dependencies, freed slots are offered over WhatsApp
+	whatsAppRepo := repository.NewWhatsAppRepository(dbConn)
+	offerNotifier := service.NewWhatsAppOfferNotifier(whatsAppRepo, consentRepo, patientRepo)
+	waitlistRepo := waitlist.NewWaitlistRepository(dbConn)
+	waitlistService := waitlistservice.NewWaitlistService(waitlistRepo, patientRepo, appointmentService, offerNotifier)
+	appointmentService.OnSlotReleased(waitlistService.OfferSlot)
+	waitlistHandler := handlers.NewWaitlistHandler(waitlistService)
+
+	// Patients are told over WhatsApp when a reschedule moves their appointment, notices are
+	// queued & sent in the background so failed sends are retried
+	rescheduleNotifier := service.NewWhatsAppRescheduleNotifier(whatsAppRepo, appointmentRepo, consentRepo, patientRepo)
+	appointmentService.OnRescheduled(rescheduleNotifier.NotifyRescheduled)
+	rescheduleNoticeScheduler := scheduler.NewRescheduleNoticeScheduler(rescheduleNotifier, time.Minute)
+	rescheduleNoticeScheduler.Start()
+	defer rescheduleNoticeScheduler.Stop()
+
 	// Initialize exam dependencies
 	s3config := s3Service.NewS3Config()
 	s3service := s3Service.NewS3Service(s3config)
@@ -80,26 +173,30 @@ func main() {
 	diagnosticRepo := diagnostic.NewDiagnosticRepository(dbConn)
 	diagnosticService := diagnosticService.NewDiagnosticService(diagnosticRepo)
 	diagnosticHandler := handlers.NewDiagnosticHandler(diagnosticService)
-	questionnaireRepo := questionnaire.NewQuestionnaireRepository(dbConn)
-	questionnaireService := questionnaireservice.NewQuestionnaireService(questionnaireRepo)
-	questionnaireHandler := handlers.NewQuestionnaireHandler(questionnaireService)
 
 	// Initialize consultation dependencies
 	consultationRepo := consultation.NewConsultationRepository(dbConn)
-	consultationService := consultationservice.NewConsultationService(consultationRepo, diagnosticRepo, questionnaireService)
+	consultationService := consultationservice.NewConsultationService(consultationRepo, diagnosticRepo, questionnaireService, appointmentTypeRepo, appointmentService)
 	consultationHandler := handlers.NewConsultationHandler(consultationService)
 
 	// Configure app router with dependencies
 	routerConfig := &api.RouterConfig{
-		AuthHandler:          authHandler,
-		UserHandler:          userHandler,
-		AppointmentHandler:   appointmentHandler,
-		PatientHandler:       patientHandler,
-		BusinessHoursHandler: businessHoursHandler,
-		ExamHandler:          examHandler,
-		ConsultationHandler:  consultationHandler,
-		DiagnosticHandler:    diagnosticHandler,
-		QuestionnaireHandler: questionnaireHandler,
+		AuthHandler:            authHandler,
+		UserHandler:            userHandler,
+		AppointmentHandler:     appointmentHandler,
+		PatientHandler:         patientHandler,
+		BusinessHoursHandler:   businessHoursHandler,
+		ExamHandler:            examHandler,
+		ConsultationHandler:    consultationHandler,
+		DiagnosticHandler:      diagnosticHandler,
+		QuestionnaireHandler:   questionnaireHandler,
+		ResourceHandler:        resourceHandler,
+		WaitlistHandler:        waitlistHandler,
+		AppointmentTypeHandler: appointmentTypeHandler,
+		CalendarHandler:        calendarHandler,
+		BookingRuleHandler:     bookingRuleHandler,
+		TimelineHandler:        timelineHandler,
+		ConsentHandler:         consentHandler,
 	}
 
 	// Creation + middleware setup
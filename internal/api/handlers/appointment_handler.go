@@ -1,17 +1,24 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
 	"time"
 
+	"software-backend/internal/clinic"
+	"software-backend/internal/middleware"
 	"software-backend/internal/models"
+	repository "software-backend/internal/repository/appointment"
 	service "software-backend/internal/service/appointment"
 
 	"github.com/labstack/echo/v4"
 )
 
+// Role allowed to book appointments despite the booking rules
+const overrideRole = "admin"
+
 // Struct to manage dependencies
 type AppointmentHandler struct {
 	appointmentService service.AppointmentService
@@ -24,6 +31,20 @@ func NewAppointmentHandler(svc service.AppointmentService) *AppointmentHandler {
 	}
 }
 
+// Get an appointment by ID
+func (h *AppointmentHandler) GetAppointment(c echo.Context) error {
+	// Get ID & perform basic input validation
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid appointment ID"})
+	}
+	appt, err := h.appointmentService.GetAppointment(id)
+	if err != nil {
+		return appointmentErrorResponse(c, err)
+	}
+	return c.JSON(http.StatusOK, appt)
+}
+
 // Create an appointment
 func (h *AppointmentHandler) CreateAppointment(c echo.Context) error {
 	// Bind payload to appointment
@@ -31,14 +52,66 @@ func (h *AppointmentHandler) CreateAppointment(c echo.Context) error {
 	if err := c.Bind(&appt); err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid input"})
 	}
+	if !authorizeOverride(c, &appt) {
+		return c.JSON(http.StatusForbidden, map[string]string{"error": "Not allowed to override booking rules"})
+	}
 	// Create appointment via Service
-	created, err := h.appointmentService.CreateAppointment(appt)
+	created, err := h.appointmentService.CreateAppointment(appt, currentUserID(c))
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return appointmentErrorResponse(c, err)
 	}
 	return c.JSON(http.StatusCreated, created)
 }
 
+// Get bookable slots between two dates for a given duration (minutes)
+func (h *AppointmentHandler) GetAvailability(c echo.Context) error {
+	// Get params & perform basic input validation
+	fromStr := c.QueryParam("from")
+	toStr := c.QueryParam("to")
+	durationStr := c.QueryParam("duration")
+	if fromStr == "" || toStr == "" || durationStr == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Missing 'from', 'to' or 'duration' parameter"})
+	}
+	from, err := clinic.ParseDate(fromStr)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid 'from' format, expected YYYY-MM-DD"})
+	}
+	to, err := clinic.ParseDate(toStr)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid 'to' format, expected YYYY-MM-DD"})
+	}
+	durationMinutes, err := strconv.Atoi(durationStr)
+	if err != nil || durationMinutes <= 0 {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid 'duration', expected minutes"})
+	}
+
+	// Granularity is optional
+	granularity := service.DefaultSlotGranularity
+	if granularityStr := c.QueryParam("granularity"); granularityStr != "" {
+		granularityMinutes, err := strconv.Atoi(granularityStr)
+		if err != nil || granularityMinutes <= 0 {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid 'granularity', expected minutes"})
+		}
+		granularity = time.Duration(granularityMinutes) * time.Minute
+	}
+
+	// Provider is optional too
+	providerID, err := optionalIntQueryParam(c, "provider_id")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid 'provider_id'"})
+	}
+
+	// Get slots via Service
+	slots, err := h.appointmentService.GetAvailableSlots(from, to, time.Duration(durationMinutes)*time.Minute, granularity, providerID)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidAppointment) {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, slots)
+}
+
 // Update an appointment
 func (h *AppointmentHandler) UpdateAppointment(c echo.Context) error {
 	// Get ID
@@ -56,10 +129,13 @@ func (h *AppointmentHandler) UpdateAppointment(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid input"})
 	}
 	appt.ID = id
+	if !authorizeOverride(c, &appt) {
+		return c.JSON(http.StatusForbidden, map[string]string{"error": "Not allowed to override booking rules"})
+	}
 
 	// Update appointment via Service
-	if err := h.appointmentService.UpdateAppointment(appt); err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	if err := h.appointmentService.UpdateAppointment(appt, currentUserID(c)); err != nil {
+		return appointmentErrorResponse(c, err)
 	}
 	return c.NoContent(http.StatusNoContent)
 }
@@ -89,9 +165,13 @@ func (h *AppointmentHandler) GetAppointmentsInDateRange(c echo.Context) error {
 	if !startTime.Before(endTime) {
 		return echo.NewHTTPError(http.StatusBadRequest, "'start_time' must be before 'end_time'")
 	}
+	providerID, err := optionalIntQueryParam(c, "provider_id")
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid 'provider_id'")
+	}
 
 	// Get grouped appointments via Service
-	appointmentsGrouped, err := h.appointmentService.GetAppointmentsInDateRangeAndGroupedByDay(startTime, endTime)
+	appointmentsGrouped, err := h.appointmentService.GetAppointmentsInDateRangeAndGroupedByDay(startTime, endTime, providerID)
 	if err != nil {
 		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to retrieve appointments") // Generic error for API
 	}
@@ -133,9 +213,13 @@ func (h *AppointmentHandler) GetAppointmentsForMonth(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, "Invalid month format or value (1-12)")
 	}
 	month := time.Month(monthInt)
+	providerID, err := optionalIntQueryParam(c, "provider_id")
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid 'provider_id'")
+	}
 
 	// Get appointments via Service
-	appointmentsGrouped, err := h.appointmentService.GetAppointmentsForMonth(year, month)
+	appointmentsGrouped, err := h.appointmentService.GetAppointmentsForMonth(year, month, providerID)
 	if err != nil {
 		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to retrieve appointments for month")
 	}
@@ -151,19 +235,23 @@ func (h *AppointmentHandler) GetAppointmentsForDate(c echo.Context) error {
 	if dateStr == "" {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Missing date parameter"})
 	}
-	date, err := time.Parse("2006-01-02", dateStr)
+	date, err := clinic.ParseDate(dateStr)
 	if err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid date format, expected YYYY-MM-DD"})
 	}
+	providerID, err := optionalIntQueryParam(c, "provider_id")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid 'provider_id'"})
+	}
 	// Get appointments via Service
-	appts, err := h.appointmentService.GetAppointmentsForDate(date)
+	appts, err := h.appointmentService.GetAppointmentsForDate(date, providerID)
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
 	}
 	return c.JSON(http.StatusOK, appts)
 }
 
-// Delete an appointment via ID
+// Delete an appointment via ID, the appointment is cancelled rather than removed
 func (h *AppointmentHandler) DeleteAppointment(c echo.Context) error {
 	// Get ID & perform basic input validation
 	idStr := c.Param("id")
@@ -171,10 +259,217 @@ func (h *AppointmentHandler) DeleteAppointment(c echo.Context) error {
 	if err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid appointment ID"})
 	}
-	// Delete appointment via Service
-	err = h.appointmentService.DeleteAppointment(id)
+	// Cancel appointment via Service
+	_, err = h.appointmentService.CancelAppointment(id, currentUserID(c))
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return appointmentErrorResponse(c, err)
 	}
 	return c.NoContent(http.StatusNoContent)
 }
+
+// Request body to change an appointment's status
+type ChangeStatusRequest struct {
+	Status models.AppointmentStatus `json:"status"`
+}
+
+// Move an appointment to a new status
+func (h *AppointmentHandler) ChangeAppointmentStatus(c echo.Context) error {
+	// Get ID & perform basic input validation
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid appointment ID"})
+	}
+
+	// Bind payload to request
+	var req ChangeStatusRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid input"})
+	}
+
+	// Change status via Service
+	appt, err := h.appointmentService.ChangeAppointmentStatus(id, req.Status, currentUserID(c))
+	if err != nil {
+		return appointmentErrorResponse(c, err)
+	}
+	return c.JSON(http.StatusOK, appt)
+}
+
+// Get the status changes of an appointment
+func (h *AppointmentHandler) GetStatusHistory(c echo.Context) error {
+	// Get ID & perform basic input validation
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid appointment ID"})
+	}
+
+	// Get history via Service
+	history, err := h.appointmentService.GetStatusHistory(id)
+	if err != nil {
+		return appointmentErrorResponse(c, err)
+	}
+	return c.JSON(http.StatusOK, history)
+}
+
+// Get every change made to an appointment, who made it & when
+func (h *AppointmentHandler) GetAuditTrail(c echo.Context) error {
+	// Get ID & perform basic input validation
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid appointment ID"})
+	}
+
+	// Get audit trail via Service
+	trail, err := h.appointmentService.GetAuditTrail(id)
+	if err != nil {
+		return appointmentErrorResponse(c, err)
+	}
+	return c.JSON(http.StatusOK, trail)
+}
+
+// Map appointment service errors onto HTTP responses
+func appointmentErrorResponse(c echo.Context, err error) error {
+	// List the broken rules so they can be overridden
+	var ruleErr *service.BookingRuleError
+	if errors.As(err, &ruleErr) {
+		return c.JSON(http.StatusConflict, map[string]interface{}{"error": err.Error(), "violations": ruleErr.Violations})
+	}
+	// Suggest the next free slot when the requested one is taken
+	var conflictErr *service.SlotConflictError
+	if errors.As(err, &conflictErr) {
+		response := map[string]interface{}{"error": err.Error()}
+		if conflictErr.NextAvailable != nil {
+			response["next_available"] = conflictErr.NextAvailable
+		}
+		return c.JSON(http.StatusConflict, response)
+	}
+	switch {
+	case errors.Is(err, service.ErrInvalidStatus), errors.Is(err, service.ErrInvalidRecurrenceRule),
+		errors.Is(err, service.ErrInvalidAppointment), errors.Is(err, service.ErrNotInSeries):
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	case errors.Is(err, service.ErrInvalidTransition), errors.Is(err, repository.ErrStatusChanged),
+		errors.Is(err, service.ErrAppointmentConflict), errors.Is(err, service.ErrOutsideBusinessHours):
+		return c.JSON(http.StatusConflict, map[string]string{"error": err.Error()})
+	case errors.Is(err, repository.ErrAppointmentNotFound), errors.Is(err, repository.ErrSeriesNotFound):
+		return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+	default:
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+}
+
+// Create a recurring appointment series, responds with the booked occurrences & the ones that conflicted
+func (h *AppointmentHandler) CreateAppointmentSeries(c echo.Context) error {
+	// Bind payload to series
+	var series models.AppointmentSeries
+	if err := c.Bind(&series); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid input"})
+	}
+	// Create series via Service
+	result, err := h.appointmentService.CreateAppointmentSeries(series, currentUserID(c))
+	if err != nil {
+		return appointmentErrorResponse(c, err)
+	}
+	// Nothing could be booked
+	if len(result.Booked) == 0 {
+		return c.JSON(http.StatusConflict, result)
+	}
+	return c.JSON(http.StatusCreated, result)
+}
+
+// Replace an occurrence & the following ones of its series with a new definition
+func (h *AppointmentHandler) UpdateFollowingOccurrences(c echo.Context) error {
+	// Get ID & perform basic input validation
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid appointment ID"})
+	}
+
+	// Bind payload to series
+	var series models.AppointmentSeries
+	if err := c.Bind(&series); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid input"})
+	}
+
+	// Update occurrences via Service
+	result, err := h.appointmentService.UpdateFollowingOccurrences(id, series, currentUserID(c))
+	if err != nil {
+		return appointmentErrorResponse(c, err)
+	}
+	// Nothing could be booked, the original series is left as it was
+	if len(result.Booked) == 0 {
+		return c.JSON(http.StatusConflict, result)
+	}
+	return c.JSON(http.StatusOK, result)
+}
+
+// Cancel an occurrence & the following ones of its series
+func (h *AppointmentHandler) CancelFollowingOccurrences(c echo.Context) error {
+	// Get ID & perform basic input validation
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid appointment ID"})
+	}
+
+	// Cancel occurrences via Service
+	cancelled, err := h.appointmentService.CancelFollowingOccurrences(id, currentUserID(c))
+	if err != nil {
+		return appointmentErrorResponse(c, err)
+	}
+	return c.JSON(http.StatusOK, map[string]int{"cancelled": cancelled})
+}
+
+// Attach the logged in user to the appointment's rule override, returns false if the
+// user isn't allowed to override booking rules
+func authorizeOverride(c echo.Context, appt *models.Appointment) bool {
+	if appt.Override == nil {
+		return true
+	}
+	if !middleware.HasRole(c, overrideRole) {
+		return false
+	}
+	appt.Override.UserID = currentUserID(c)
+	return true
+}
+
+// List appointments left outside business hours between two dates, with a proposed new slot each
+func (h *AppointmentHandler) PreviewReschedule(c echo.Context) error {
+	// Get params & perform basic input validation
+	fromStr := c.QueryParam("from")
+	toStr := c.QueryParam("to")
+	if fromStr == "" || toStr == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Missing 'from' or 'to' parameter"})
+	}
+	from, err := clinic.ParseDate(fromStr)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid 'from' format, expected YYYY-MM-DD"})
+	}
+	to, err := clinic.ParseDate(toStr)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid 'to' format, expected YYYY-MM-DD"})
+	}
+
+	proposals, err := h.appointmentService.PreviewReschedule(from, to)
+	if err != nil {
+		return appointmentErrorResponse(c, err)
+	}
+	return c.JSON(http.StatusOK, proposals)
+}
+
+// Payload to move several appointments at once
+type ConfirmRescheduleRequest struct {
+	Moves []models.RescheduleMove `json:"moves"`
+}
+
+// Move several appointments at once, all of them or none
+func (h *AppointmentHandler) ConfirmReschedule(c echo.Context) error {
+	// Bind payload to request
+	var req ConfirmRescheduleRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid input"})
+	}
+
+	moved, err := h.appointmentService.ConfirmReschedule(req.Moves, currentUserID(c))
+	if err != nil {
+		return appointmentErrorResponse(c, err)
+	}
+	return c.JSON(http.StatusOK, moved)
+}
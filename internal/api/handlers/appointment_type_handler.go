@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"software-backend/internal/models"
+	repository "software-backend/internal/repository/appointmenttype"
+	service "software-backend/internal/service/appointmenttype"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Struct to manage dependencies
+type AppointmentTypeHandler struct {
+	service service.AppointmentTypeService
+}
+
+// Constructor to pass on dependencies
+func NewAppointmentTypeHandler(service service.AppointmentTypeService) *AppointmentTypeHandler {
+	return &AppointmentTypeHandler{service: service}
+}
+
+// List appointment types, inactive ones are included with ?all=true
+func (h *AppointmentTypeHandler) ListAppointmentTypes(c echo.Context) error {
+	types, err := h.service.ListAppointmentTypes(c.QueryParam("all") != "true")
+	if err != nil {
+		return appointmentTypeErrorResponse(c, err)
+	}
+	return c.JSON(http.StatusOK, types)
+}
+
+// Get an appointment type by ID
+func (h *AppointmentTypeHandler) GetAppointmentType(c echo.Context) error {
+	// Get ID & perform basic input validation
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid appointment type ID"})
+	}
+	apptType, err := h.service.GetAppointmentType(id)
+	if err != nil {
+		return appointmentTypeErrorResponse(c, err)
+	}
+	return c.JSON(http.StatusOK, apptType)
+}
+
+// Create an appointment type
+func (h *AppointmentTypeHandler) CreateAppointmentType(c echo.Context) error {
+	// Bind payload to appointment type
+	var apptType models.AppointmentType
+	if err := c.Bind(&apptType); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid input"})
+	}
+	created, err := h.service.CreateAppointmentType(apptType)
+	if err != nil {
+		return appointmentTypeErrorResponse(c, err)
+	}
+	return c.JSON(http.StatusCreated, created)
+}
+
+// Update an appointment type
+func (h *AppointmentTypeHandler) UpdateAppointmentType(c echo.Context) error {
+	// Get ID & perform basic input validation
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid appointment type ID"})
+	}
+
+	// Bind payload to appointment type
+	var apptType models.AppointmentType
+	if err := c.Bind(&apptType); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid input"})
+	}
+	apptType.ID = id
+
+	if err := h.service.UpdateAppointmentType(apptType); err != nil {
+		return appointmentTypeErrorResponse(c, err)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// Map appointment type service errors onto HTTP responses
+func appointmentTypeErrorResponse(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, service.ErrInvalidAppointmentType):
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	case errors.Is(err, repository.ErrAppointmentTypeNotFound):
+		return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+	default:
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+}
@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"software-backend/internal/models"
+	service "software-backend/internal/service/bookingrule"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Struct to manage dependencies
+type BookingRuleHandler struct {
+	service service.BookingRuleService
+}
+
+// Constructor to pass on dependencies
+func NewBookingRuleHandler(service service.BookingRuleService) *BookingRuleHandler {
+	return &BookingRuleHandler{service: service}
+}
+
+// Get the booking rules
+func (h *BookingRuleHandler) GetRules(c echo.Context) error {
+	rules, err := h.service.GetRules()
+	if err != nil {
+		return bookingRuleErrorResponse(c, err)
+	}
+	return c.JSON(http.StatusOK, rules)
+}
+
+// Replace the booking rules
+func (h *BookingRuleHandler) UpdateRules(c echo.Context) error {
+	// Bind payload to rules
+	var rules models.BookingRules
+	if err := c.Bind(&rules); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid input"})
+	}
+	updated, err := h.service.UpdateRules(rules, currentUserID(c))
+	if err != nil {
+		return bookingRuleErrorResponse(c, err)
+	}
+	return c.JSON(http.StatusOK, updated)
+}
+
+// List the booking rules overridden for an appointment
+func (h *BookingRuleHandler) ListOverrides(c echo.Context) error {
+	// Get ID & perform basic input validation
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid appointment ID"})
+	}
+	overrides, err := h.service.ListOverrides(id)
+	if err != nil {
+		return bookingRuleErrorResponse(c, err)
+	}
+	return c.JSON(http.StatusOK, overrides)
+}
+
+// Map booking rule service errors onto HTTP responses
+func bookingRuleErrorResponse(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, service.ErrInvalidBookingRules):
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	default:
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+}
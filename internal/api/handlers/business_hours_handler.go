@@ -1,9 +1,16 @@
 package handlers
 
 import (
+	"errors"
+	"io"
 	"net/http"
-	"time"
+	"path/filepath"
+	"strconv"
+	"strings"
 
+	"software-backend/internal/clinic"
+	"software-backend/internal/models"
+	bh "software-backend/internal/repository/business_hour"
 	service "software-backend/internal/service/businesshour"
 
 	"github.com/labstack/echo/v4"
@@ -26,14 +33,216 @@ func (h *BusinessHoursHandler) GetBusinessHours(c echo.Context) error {
 	if dateStr == "" {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Missing date parameter"})
 	}
-	date, err := time.Parse("2006-01-02", dateStr)
+	date, err := clinic.ParseDate(dateStr)
 	if err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid date format, expected YYYY-MM-DD"})
 	}
-	// Get intervals for business hours from Service
-	intervals, err := h.service.GetBusinessHoursForDate(date)
+	providerID, err := optionalIntQueryParam(c, "provider_id")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid 'provider_id'"})
+	}
+	// Get intervals for business hours from Service, the provider's if one is given
+	var intervals []models.BusinessHourInterval
+	if providerID != nil {
+		intervals, err = h.service.GetProviderHoursForDate(*providerID, date)
+	} else {
+		intervals, err = h.service.GetBusinessHoursForDate(date)
+	}
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
 	}
 	return c.JSON(http.StatusOK, intervals)
 }
+
+// Get the effective hours of each date between 'from' & 'to', the provider's if one is given
+func (h *BusinessHoursHandler) GetBusinessHoursRange(c echo.Context) error {
+	// Get dates & perform basic input validation
+	from, err := clinic.ParseDate(c.QueryParam("from"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid 'from', expected YYYY-MM-DD"})
+	}
+	to, err := clinic.ParseDate(c.QueryParam("to"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid 'to', expected YYYY-MM-DD"})
+	}
+	providerID, err := optionalIntQueryParam(c, "provider_id")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid 'provider_id'"})
+	}
+
+	days, err := h.service.GetBusinessHoursForRange(from, to, providerID)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidBusinessHours) {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, days)
+}
+
+// Get the weekly hours of a provider
+func (h *BusinessHoursHandler) GetProviderWeeklyHours(c echo.Context) error {
+	// Get ID & perform basic input validation
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid provider ID"})
+	}
+	hours, err := h.service.GetProviderWeeklyHours(id)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, hours)
+}
+
+// Replace the weekly hours of a provider, an empty list makes it follow the clinic hours
+func (h *BusinessHoursHandler) SetProviderWeeklyHours(c echo.Context) error {
+	// Get ID & perform basic input validation
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid provider ID"})
+	}
+
+	// Bind payload to weekly hours
+	var hours []models.WeeklyHourInterval
+	if err := c.Bind(&hours); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid input"})
+	}
+
+	if err := h.service.SetProviderWeeklyHours(id, hours); err != nil {
+		if errors.Is(err, service.ErrInvalidBusinessHours) {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// Get the clinic's weekly schedule
+func (h *BusinessHoursHandler) GetWeeklyHours(c echo.Context) error {
+	hours, err := h.service.GetWeeklyHours()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, hours)
+}
+
+// Replace the clinic's weekly schedule, weekdays left out are closed
+func (h *BusinessHoursHandler) SetWeeklyHours(c echo.Context) error {
+	// Bind payload to weekly hours
+	var hours []models.WeeklyHourInterval
+	if err := c.Bind(&hours); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid input"})
+	}
+
+	if err := h.service.SetWeeklyHours(hours); err != nil {
+		if errors.Is(err, service.ErrInvalidBusinessHours) {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// List the special hours between two dates, both included
+func (h *BusinessHoursHandler) ListSpecialHours(c echo.Context) error {
+	// Get dates & perform basic input validation
+	from, err := clinic.ParseDate(c.QueryParam("from"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid 'from', expected YYYY-MM-DD"})
+	}
+	to, err := clinic.ParseDate(c.QueryParam("to"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid 'to', expected YYYY-MM-DD"})
+	}
+
+	specials, err := h.service.ListSpecialHours(from, to)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidBusinessHours) {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, specials)
+}
+
+// Set the special hours of a date, closed all day or open on the given intervals
+func (h *BusinessHoursHandler) SetSpecialHours(c echo.Context) error {
+	// Bind payload to special hours, the date comes from the path
+	var special models.SpecialHours
+	if err := c.Bind(&special); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid input"})
+	}
+	special.Date = c.Param("date")
+
+	if err := h.service.SetSpecialHours(special); err != nil {
+		if errors.Is(err, service.ErrInvalidBusinessHours) {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// Delete the special hours of a date, it follows the weekly schedule again
+func (h *BusinessHoursHandler) DeleteSpecialHours(c echo.Context) error {
+	date, err := clinic.ParseDate(c.Param("date"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid date format, expected YYYY-MM-DD"})
+	}
+
+	if err := h.service.DeleteSpecialHours(date); err != nil {
+		if errors.Is(err, bh.ErrSpecialHoursNotFound) {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "Special hours not found"})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// Largest holiday file accepted
+const maxHolidayFileSize = 1 << 20
+
+// Import a holiday calendar (.ics or .csv) as special hours. It's a dry run unless
+// dry_run=false, so the report can be reviewed before anything is saved
+func (h *BusinessHoursHandler) ImportHolidays(c echo.Context) error {
+	// Get options & perform basic input validation
+	dryRun := true
+	if value := c.QueryParam("dry_run"); value != "" {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid 'dry_run', expected true or false"})
+		}
+		dryRun = parsed
+	}
+	file, err := c.FormFile("file")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "No holiday file provided"})
+	}
+	if file.Size > maxHolidayFileSize {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Holiday file is too large"})
+	}
+	// Format from the file name unless given
+	format := strings.ToLower(c.QueryParam("format"))
+	if format == "" {
+		format = strings.TrimPrefix(strings.ToLower(filepath.Ext(file.Filename)), ".")
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Could not read holiday file"})
+	}
+	defer src.Close()
+	data, err := io.ReadAll(io.LimitReader(src, maxHolidayFileSize))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Could not read holiday file"})
+	}
+
+	report, err := h.service.ImportHolidays(data, format, dryRun)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidHolidayFile) || errors.Is(err, service.ErrInvalidBusinessHours) {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusOK, report)
+}
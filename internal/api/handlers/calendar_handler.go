@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	repository "software-backend/internal/repository/calendar"
+	service "software-backend/internal/service/calendar"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Struct to manage dependencies
+type CalendarHandler struct {
+	service service.CalendarService
+}
+
+// Constructor to pass on dependencies
+func NewCalendarHandler(service service.CalendarService) *CalendarHandler {
+	return &CalendarHandler{service: service}
+}
+
+// Payload to create a feed token
+type createFeedTokenRequest struct {
+	Private    bool `json:"private"`
+	ProviderID *int `json:"provider_id,omitempty"`
+}
+
+// Serve the iCalendar feed of a token, the URL is /calendar/{token}.ics
+func (h *CalendarHandler) GetFeed(c echo.Context) error {
+	token, ok := strings.CutSuffix(c.Param("token"), ".ics")
+	if !ok || token == "" {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Calendar feed not found"})
+	}
+
+	feed, err := h.service.RenderFeed(token)
+	if err != nil {
+		return calendarErrorResponse(c, err)
+	}
+	c.Response().Header().Set("Cache-Control", "no-store")
+	return c.Blob(http.StatusOK, "text/calendar; charset=utf-8", feed)
+}
+
+// Create a feed token for the logged in user
+func (h *CalendarHandler) CreateFeedToken(c echo.Context) error {
+	userID := currentUserID(c)
+	if userID == nil {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Not logged in"})
+	}
+
+	// Bind payload, an empty body creates a regular feed
+	var req createFeedTokenRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid input"})
+	}
+
+	token, err := h.service.CreateFeedToken(*userID, req.Private, req.ProviderID)
+	if err != nil {
+		return calendarErrorResponse(c, err)
+	}
+	return c.JSON(http.StatusCreated, map[string]interface{}{
+		"token": token,
+		"url":   "/calendar/" + token.Token + ".ics",
+	})
+}
+
+// List the feed tokens of the logged in user
+func (h *CalendarHandler) ListFeedTokens(c echo.Context) error {
+	userID := currentUserID(c)
+	if userID == nil {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Not logged in"})
+	}
+
+	tokens, err := h.service.ListFeedTokens(*userID)
+	if err != nil {
+		return calendarErrorResponse(c, err)
+	}
+	return c.JSON(http.StatusOK, tokens)
+}
+
+// Revoke one of the logged in user's feed tokens
+func (h *CalendarHandler) RevokeFeedToken(c echo.Context) error {
+	userID := currentUserID(c)
+	if userID == nil {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "Not logged in"})
+	}
+
+	// Get ID & perform basic input validation
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid token ID"})
+	}
+
+	if err := h.service.RevokeFeedToken(id, *userID); err != nil {
+		return calendarErrorResponse(c, err)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// Map calendar service errors onto HTTP responses
+func calendarErrorResponse(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, repository.ErrFeedTokenNotFound):
+		return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+	default:
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+}
@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"software-backend/internal/models"
+	repository "software-backend/internal/repository/patient"
+	service "software-backend/internal/service/consent"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Struct to manage dependencies
+type ConsentHandler struct {
+	service service.ConsentService
+}
+
+// Constructor to pass on dependencies
+func NewConsentHandler(service service.ConsentService) *ConsentHandler {
+	return &ConsentHandler{service: service}
+}
+
+// Get how a patient wants to be contacted, with the consent in force per channel
+func (h *ConsentHandler) GetContactPreferences(c echo.Context) error {
+	// Get ID & perform basic input validation
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid patient ID"})
+	}
+
+	preferences, err := h.service.GetContactPreferences(id)
+	if err != nil {
+		return consentErrorResponse(c, err)
+	}
+	return c.JSON(http.StatusOK, preferences)
+}
+
+// Replace a patient's preferred channel, language & do-not-contact flag
+func (h *ConsentHandler) SetContactPreferences(c echo.Context) error {
+	// Get ID & perform basic input validation
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid patient ID"})
+	}
+	var preferences models.ContactPreferences
+	if err := c.Bind(&preferences); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid input"})
+	}
+	preferences.PatientID = id
+
+	updated, err := h.service.SetContactPreferences(preferences, currentUserID(c))
+	if err != nil {
+		return consentErrorResponse(c, err)
+	}
+	return c.JSON(http.StatusOK, updated)
+}
+
+// List every consent record of a patient, latest first
+func (h *ConsentHandler) ListConsents(c echo.Context) error {
+	// Get ID & perform basic input validation
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid patient ID"})
+	}
+
+	records, err := h.service.ListConsents(id)
+	if err != nil {
+		return consentErrorResponse(c, err)
+	}
+	return c.JSON(http.StatusOK, records)
+}
+
+// Record a patient opting into or out of a channel
+func (h *ConsentHandler) RecordConsent(c echo.Context) error {
+	// Get ID & perform basic input validation
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid patient ID"})
+	}
+	var record models.ConsentRecord
+	if err := c.Bind(&record); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid input"})
+	}
+	record.PatientID = id
+
+	created, err := h.service.RecordConsent(record, currentUserID(c))
+	if err != nil {
+		return consentErrorResponse(c, err)
+	}
+	return c.JSON(http.StatusCreated, created)
+}
+
+// Map service & repository errors onto HTTP responses
+func consentErrorResponse(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, service.ErrInvalidConsent):
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	case errors.Is(err, repository.ErrPatientNotFound):
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Patient not found"})
+	default:
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+}
@@ -1,10 +1,13 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
 
+	apptrepository "software-backend/internal/repository/appointment"
+	apptservice "software-backend/internal/service/appointment"
 	service "software-backend/internal/service/consultation"
 
 	"github.com/labstack/echo/v4"
@@ -111,3 +114,34 @@ func (h *ConsultationHandler) Delete(c echo.Context) error {
 
 	return c.JSON(http.StatusNoContent, nil)
 }
+
+// Start the visit of an appointment, creating its consultation
+func (h *ConsultationHandler) StartVisit(c echo.Context) error {
+	appointmentID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid appointment ID"})
+	}
+
+	// Every field is optional, an empty body is fine
+	var req service.StartVisitRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+	}
+
+	consultation, err := h.service.StartVisit(appointmentID, req, currentUserID(c))
+	if err != nil {
+		switch {
+		case errors.Is(err, apptrepository.ErrAppointmentNotFound):
+			return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+		case errors.Is(err, service.ErrVisitAlreadyStarted), errors.Is(err, apptservice.ErrInvalidTransition),
+			errors.Is(err, apptrepository.ErrStatusChanged):
+			return c.JSON(http.StatusConflict, map[string]string{"error": err.Error()})
+		case errors.Is(err, service.ErrInvalidVisit), errors.Is(err, service.ErrAppointmentNoPatient):
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		default:
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		}
+	}
+
+	return c.JSON(http.StatusCreated, consultation)
+}
@@ -0,0 +1,29 @@
+package handlers
+
+import (
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Get the ID of the logged in user set by the JWT middleware, nil if there is none
+func currentUserID(c echo.Context) *int {
+	userID, ok := c.Get("user_id").(int)
+	if !ok {
+		return nil
+	}
+	return &userID
+}
+
+// Parse an optional integer query param, nil if it wasn't given
+func optionalIntQueryParam(c echo.Context, name string) (*int, error) {
+	value := c.QueryParam(name)
+	if value == "" {
+		return nil, nil
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		return nil, err
+	}
+	return &parsed, nil
+}
@@ -5,12 +5,16 @@ import (
 	"net/http"
 	"strconv"
 
+	"software-backend/internal/models"
 	repository "software-backend/internal/repository/patient"
 	service "software-backend/internal/service/patient"
 
 	"github.com/labstack/echo/v4"
 )
 
+// Duplicates scoring less are left out unless asked for
+const defaultDuplicateScore = 0.6
+
 // Struct to manage dependencies
 type PatientHandler struct {
 	patientService service.PatientService
@@ -64,3 +68,333 @@ func (h *PatientHandler) SearchPatients(c echo.Context) error {
 	}
 	return c.JSON(http.StatusOK, patients)
 }
+
+// List patients a page at a time, ?limit= & ?offset=
+func (h *PatientHandler) ListPatients(c echo.Context) error {
+	// Get paging & perform basic input validation
+	limit, err := optionalIntQueryParam(c, "limit")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid 'limit'"})
+	}
+	offset, err := optionalIntQueryParam(c, "offset")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid 'offset'"})
+	}
+	page := service.DefaultPageSize
+	if limit != nil {
+		page = *limit
+	}
+	skip := 0
+	if offset != nil {
+		skip = *offset
+	}
+
+	patients, err := h.patientService.ListPatients(page, skip)
+	if err != nil {
+		return patientErrorResponse(c, err)
+	}
+	return c.JSON(http.StatusOK, patients)
+}
+
+// Create a patient
+func (h *PatientHandler) CreatePatient(c echo.Context) error {
+	// Bind payload to patient
+	var patient models.Patient
+	if err := c.Bind(&patient); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid input"})
+	}
+	patient.ID = 0
+	patient.Attendance = nil
+
+	created, err := h.patientService.CreatePatient(patient, currentUserID(c))
+	if err != nil {
+		return patientErrorResponse(c, err)
+	}
+	return c.JSON(http.StatusCreated, created)
+}
+
+// Update a patient by ID
+func (h *PatientHandler) UpdatePatient(c echo.Context) error {
+	// Get ID & perform basic input validation
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid patient ID"})
+	}
+
+	// Bind payload to patient, the ID comes from the path
+	var patient models.Patient
+	if err := c.Bind(&patient); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid input"})
+	}
+	patient.ID = id
+	patient.Attendance = nil
+
+	updated, err := h.patientService.UpdatePatient(patient, currentUserID(c))
+	if err != nil {
+		return patientErrorResponse(c, err)
+	}
+	return c.JSON(http.StatusOK, updated)
+}
+
+// Delete a patient by ID
+func (h *PatientHandler) DeletePatient(c echo.Context) error {
+	// Get ID & perform basic input validation
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid patient ID"})
+	}
+
+	if err := h.patientService.DeletePatient(id); err != nil {
+		return patientErrorResponse(c, err)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// Find patients that may be the same person, ?min_score= from 0 to 1 & ?limit=
+func (h *PatientHandler) FindDuplicates(c echo.Context) error {
+	// Get options & perform basic input validation
+	minScore := defaultDuplicateScore
+	if value := c.QueryParam("min_score"); value != "" {
+		parsed, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid 'min_score'"})
+		}
+		minScore = parsed
+	}
+	limit, err := optionalIntQueryParam(c, "limit")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid 'limit'"})
+	}
+	page := service.DefaultPageSize
+	if limit != nil {
+		page = *limit
+	}
+
+	duplicates, err := h.patientService.FindDuplicates(minScore, page)
+	if err != nil {
+		return patientErrorResponse(c, err)
+	}
+	return c.JSON(http.StatusOK, duplicates)
+}
+
+// Payload for merging a duplicate into the patient in the path
+type MergePatientsRequest struct {
+	DuplicateID int `json:"duplicate_id"`
+}
+
+// Merge a duplicate into a patient, the duplicate is deleted once its records are moved
+func (h *PatientHandler) MergePatients(c echo.Context) error {
+	// Get ID & perform basic input validation
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid patient ID"})
+	}
+	var req MergePatientsRequest
+	if err := c.Bind(&req); err != nil || req.DuplicateID <= 0 {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid input, 'duplicate_id' is required"})
+	}
+
+	merge, err := h.patientService.MergePatients(id, req.DuplicateID, currentUserID(c))
+	if err != nil {
+		return patientErrorResponse(c, err)
+	}
+	return c.JSON(http.StatusOK, merge)
+}
+
+// List the patients merged into a patient
+func (h *PatientHandler) ListMerges(c echo.Context) error {
+	// Get ID & perform basic input validation
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid patient ID"})
+	}
+
+	merges, err := h.patientService.ListMerges(id)
+	if err != nil {
+		return patientErrorResponse(c, err)
+	}
+	return c.JSON(http.StatusOK, merges)
+}
+
+// List every version of a patient's antecedentes, latest first
+func (h *PatientHandler) ListAntecedentes(c echo.Context) error {
+	// Get ID & perform basic input validation
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid patient ID"})
+	}
+
+	versions, err := h.patientService.ListAntecedentes(id)
+	if err != nil {
+		return patientErrorResponse(c, err)
+	}
+	return c.JSON(http.StatusOK, versions)
+}
+
+// Get one version of a patient's antecedentes
+func (h *PatientHandler) GetAntecedentesVersion(c echo.Context) error {
+	// Get IDs & perform basic input validation
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid patient ID"})
+	}
+	version, err := strconv.Atoi(c.Param("version"))
+	if err != nil || version <= 0 {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid version"})
+	}
+
+	antecedentes, err := h.patientService.GetAntecedentesVersion(id, version)
+	if err != nil {
+		return patientErrorResponse(c, err)
+	}
+	return c.JSON(http.StatusOK, antecedentes)
+}
+
+// Compare two versions of a patient's antecedentes, ?from= & ?to= default to the
+// current version & the one before it
+func (h *PatientHandler) DiffAntecedentes(c echo.Context) error {
+	// Get IDs & perform basic input validation
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid patient ID"})
+	}
+	from, err := optionalIntQueryParam(c, "from")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid 'from'"})
+	}
+	to, err := optionalIntQueryParam(c, "to")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid 'to'"})
+	}
+	var fromVersion, toVersion int
+	if from != nil {
+		fromVersion = *from
+	}
+	if to != nil {
+		toVersion = *to
+	}
+
+	diff, err := h.patientService.DiffAntecedentes(id, fromVersion, toVersion)
+	if err != nil {
+		return patientErrorResponse(c, err)
+	}
+	return c.JSON(http.StatusOK, diff)
+}
+
+// Rewrite every stored phone to E.164, ?dry_run=false to write the changes. Phones
+// that can't be parsed are reported
+func (h *PatientHandler) NormalizePhones(c echo.Context) error {
+	dryRun := true
+	if value := c.QueryParam("dry_run"); value != "" {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid 'dry_run', expected true or false"})
+		}
+		dryRun = parsed
+	}
+
+	report, err := h.patientService.NormalizePhones(dryRun)
+	if err != nil {
+		return patientErrorResponse(c, err)
+	}
+	return c.JSON(http.StatusOK, report)
+}
+
+// List the patients related to a patient, e.g. their guardian
+func (h *PatientHandler) ListRelationships(c echo.Context) error {
+	// Get ID & perform basic input validation
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid patient ID"})
+	}
+
+	relationships, err := h.patientService.ListRelationships(id)
+	if err != nil {
+		return patientErrorResponse(c, err)
+	}
+	return c.JSON(http.StatusOK, relationships)
+}
+
+// Relate the patient in the path to another patient
+func (h *PatientHandler) CreateRelationship(c echo.Context) error {
+	// Get ID & perform basic input validation
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid patient ID"})
+	}
+	var relationship models.PatientRelationship
+	if err := c.Bind(&relationship); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid input"})
+	}
+	relationship.ID = 0
+	relationship.PatientID = id
+	relationship.RelatedPatient = nil
+
+	created, err := h.patientService.CreateRelationship(relationship)
+	if err != nil {
+		return patientErrorResponse(c, err)
+	}
+	return c.JSON(http.StatusCreated, created)
+}
+
+// Delete one of a patient's relationships
+func (h *PatientHandler) DeleteRelationship(c echo.Context) error {
+	// Get IDs & perform basic input validation
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid patient ID"})
+	}
+	relationshipID, err := strconv.Atoi(c.Param("relationshipId"))
+	if err != nil || relationshipID <= 0 {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid relationship ID"})
+	}
+
+	if err := h.patientService.DeleteRelationship(id, relationshipID); err != nil {
+		return patientErrorResponse(c, err)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// Payload for choosing a patient's notification contact, null for none
+type NotificationContactRequest struct {
+	RelationshipID *int `json:"relationship_id"`
+}
+
+// Choose which related patient gets the notifications about a patient
+func (h *PatientHandler) SetNotificationContact(c echo.Context) error {
+	// Get ID & perform basic input validation
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid patient ID"})
+	}
+	var req NotificationContactRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid input"})
+	}
+
+	relationships, err := h.patientService.SetNotificationContact(id, req.RelationshipID)
+	if err != nil {
+		return patientErrorResponse(c, err)
+	}
+	return c.JSON(http.StatusOK, relationships)
+}
+
+// Map service & repository errors onto HTTP responses
+func patientErrorResponse(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, service.ErrInvalidPatient):
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	case errors.Is(err, repository.ErrPatientNotFound):
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Patient not found"})
+	case errors.Is(err, service.ErrAntecedentesNotFound):
+		return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+	case errors.Is(err, repository.ErrRelationshipNotFound):
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Relationship not found"})
+	case errors.Is(err, repository.ErrRelationshipExists):
+		return c.JSON(http.StatusConflict, map[string]string{"error": err.Error()})
+	case errors.Is(err, repository.ErrPatientInUse):
+		return c.JSON(http.StatusConflict, map[string]string{"error": err.Error()})
+	default:
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+}
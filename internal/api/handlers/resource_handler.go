@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"software-backend/internal/models"
+	repository "software-backend/internal/repository/resource"
+	service "software-backend/internal/service/resource"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Struct to manage dependencies
+type ResourceHandler struct {
+	service service.ResourceService
+}
+
+// Constructor to pass on dependencies
+func NewResourceHandler(service service.ResourceService) *ResourceHandler {
+	return &ResourceHandler{service: service}
+}
+
+// List providers, inactive ones are included with ?all=true
+func (h *ResourceHandler) ListProviders(c echo.Context) error {
+	providers, err := h.service.ListProviders(c.QueryParam("all") != "true")
+	if err != nil {
+		return resourceErrorResponse(c, err)
+	}
+	return c.JSON(http.StatusOK, providers)
+}
+
+// Get a provider by ID
+func (h *ResourceHandler) GetProvider(c echo.Context) error {
+	// Get ID & perform basic input validation
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid provider ID"})
+	}
+	provider, err := h.service.GetProvider(id)
+	if err != nil {
+		return resourceErrorResponse(c, err)
+	}
+	return c.JSON(http.StatusOK, provider)
+}
+
+// Create a provider
+func (h *ResourceHandler) CreateProvider(c echo.Context) error {
+	// Bind payload to provider
+	var provider models.Provider
+	if err := c.Bind(&provider); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid input"})
+	}
+	created, err := h.service.CreateProvider(provider)
+	if err != nil {
+		return resourceErrorResponse(c, err)
+	}
+	return c.JSON(http.StatusCreated, created)
+}
+
+// Update a provider
+func (h *ResourceHandler) UpdateProvider(c echo.Context) error {
+	// Get ID & perform basic input validation
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid provider ID"})
+	}
+
+	// Bind payload to provider
+	var provider models.Provider
+	if err := c.Bind(&provider); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid input"})
+	}
+	provider.ID = id
+
+	if err := h.service.UpdateProvider(provider); err != nil {
+		return resourceErrorResponse(c, err)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// List rooms, inactive ones are included with ?all=true
+func (h *ResourceHandler) ListRooms(c echo.Context) error {
+	rooms, err := h.service.ListRooms(c.QueryParam("all") != "true")
+	if err != nil {
+		return resourceErrorResponse(c, err)
+	}
+	return c.JSON(http.StatusOK, rooms)
+}
+
+// Get a room by ID
+func (h *ResourceHandler) GetRoom(c echo.Context) error {
+	// Get ID & perform basic input validation
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid room ID"})
+	}
+	room, err := h.service.GetRoom(id)
+	if err != nil {
+		return resourceErrorResponse(c, err)
+	}
+	return c.JSON(http.StatusOK, room)
+}
+
+// Create a room
+func (h *ResourceHandler) CreateRoom(c echo.Context) error {
+	// Bind payload to room
+	var room models.Room
+	if err := c.Bind(&room); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid input"})
+	}
+	created, err := h.service.CreateRoom(room)
+	if err != nil {
+		return resourceErrorResponse(c, err)
+	}
+	return c.JSON(http.StatusCreated, created)
+}
+
+// Update a room
+func (h *ResourceHandler) UpdateRoom(c echo.Context) error {
+	// Get ID & perform basic input validation
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid room ID"})
+	}
+
+	// Bind payload to room
+	var room models.Room
+	if err := c.Bind(&room); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid input"})
+	}
+	room.ID = id
+
+	if err := h.service.UpdateRoom(room); err != nil {
+		return resourceErrorResponse(c, err)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// Map resource service errors onto HTTP responses
+func resourceErrorResponse(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, service.ErrInvalidResource):
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	case errors.Is(err, repository.ErrProviderNotFound), errors.Is(err, repository.ErrRoomNotFound):
+		return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+	default:
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+}
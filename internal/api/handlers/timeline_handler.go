@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"software-backend/internal/clinic"
+	"software-backend/internal/models"
+	repository "software-backend/internal/repository/patient"
+	service "software-backend/internal/service/timeline"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Struct to manage dependencies
+type TimelineHandler struct {
+	service service.TimelineService
+}
+
+// Constructor to pass on dependencies
+func NewTimelineHandler(service service.TimelineService) *TimelineHandler {
+	return &TimelineHandler{service: service}
+}
+
+// Get a patient's timeline, latest first. Filtered by ?types=appointment,exam & by
+// ?from= & ?to= dates (both included), paged with ?limit= & ?offset=
+func (h *TimelineHandler) GetPatientTimeline(c echo.Context) error {
+	// Get ID & perform basic input validation
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid patient ID"})
+	}
+
+	var filter models.TimelineFilter
+	if types := c.QueryParam("types"); types != "" {
+		for _, t := range strings.Split(types, ",") {
+			filter.Types = append(filter.Types, strings.TrimSpace(t))
+		}
+	}
+	if value := c.QueryParam("from"); value != "" {
+		from, err := clinic.ParseDate(value)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid 'from', expected YYYY-MM-DD"})
+		}
+		filter.From = &from
+	}
+	if value := c.QueryParam("to"); value != "" {
+		to, err := clinic.ParseDate(value)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid 'to', expected YYYY-MM-DD"})
+		}
+		// The whole day is included
+		to = to.AddDate(0, 0, 1)
+		filter.To = &to
+	}
+	limit, err := optionalIntQueryParam(c, "limit")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid 'limit'"})
+	}
+	offset, err := optionalIntQueryParam(c, "offset")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid 'offset'"})
+	}
+	if limit != nil {
+		filter.Limit = *limit
+	}
+	if offset != nil {
+		filter.Offset = *offset
+	}
+
+	timeline, err := h.service.GetPatientTimeline(id, filter)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidTimelineFilter):
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		case errors.Is(err, repository.ErrPatientNotFound):
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "Patient not found"})
+		default:
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		}
+	}
+	return c.JSON(http.StatusOK, timeline)
+}
@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"software-backend/internal/models"
+	repository "software-backend/internal/repository/waitlist"
+	appointmentservice "software-backend/internal/service/appointment"
+	service "software-backend/internal/service/waitlist"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Struct to manage dependencies
+type WaitlistHandler struct {
+	service service.WaitlistService
+}
+
+// Constructor to pass on dependencies
+func NewWaitlistHandler(service service.WaitlistService) *WaitlistHandler {
+	return &WaitlistHandler{service: service}
+}
+
+// Add a patient to the waitlist
+func (h *WaitlistHandler) CreateEntry(c echo.Context) error {
+	// Bind payload to entry
+	var entry models.WaitlistEntry
+	if err := c.Bind(&entry); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid input"})
+	}
+	created, err := h.service.CreateEntry(entry)
+	if err != nil {
+		return waitlistErrorResponse(c, err)
+	}
+	return c.JSON(http.StatusCreated, created)
+}
+
+// List waitlist entries, optionally filtered by ?status=
+func (h *WaitlistHandler) ListEntries(c echo.Context) error {
+	entries, err := h.service.ListEntries(c.QueryParam("status"))
+	if err != nil {
+		return waitlistErrorResponse(c, err)
+	}
+	return c.JSON(http.StatusOK, entries)
+}
+
+// Take a patient off the waitlist
+func (h *WaitlistHandler) CancelEntry(c echo.Context) error {
+	// Get ID & perform basic input validation
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid waitlist entry ID"})
+	}
+	if err := h.service.CancelEntry(id); err != nil {
+		return waitlistErrorResponse(c, err)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// Accept a slot offer via the token sent to the patient, books the slot
+func (h *WaitlistHandler) AcceptOffer(c echo.Context) error {
+	booked, err := h.service.AcceptOffer(c.Param("token"))
+	if err != nil {
+		return waitlistErrorResponse(c, err)
+	}
+	return c.JSON(http.StatusCreated, booked)
+}
+
+// Map waitlist service errors onto HTTP responses
+func waitlistErrorResponse(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, service.ErrInvalidEntry):
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	case errors.Is(err, repository.ErrEntryNotFound), errors.Is(err, repository.ErrOfferNotFound):
+		return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+	case errors.Is(err, service.ErrOfferTaken), errors.Is(err, repository.ErrOfferClosed),
+		errors.Is(err, appointmentservice.ErrOutsideBusinessHours):
+		return c.JSON(http.StatusConflict, map[string]string{"error": err.Error()})
+	case errors.Is(err, service.ErrOfferExpired):
+		return c.JSON(http.StatusGone, map[string]string{"error": err.Error()})
+	default:
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	}
+}
@@ -4,21 +4,29 @@ import (
 	"net/http"
 
 	"software-backend/internal/api/handlers"
+	"software-backend/internal/middleware"
 
 	"github.com/labstack/echo/v4"
 )
 
 // Set up handlers & other dependencies
 type RouterConfig struct {
-	AuthHandler          *handlers.AuthHandler
-	UserHandler          *handlers.UserHandler
-	AppointmentHandler   *handlers.AppointmentHandler
-	PatientHandler       *handlers.PatientHandler
-	BusinessHoursHandler *handlers.BusinessHoursHandler
-	ExamHandler          *handlers.ExamHandler
-	ConsultationHandler  *handlers.ConsultationHandler
-	DiagnosticHandler    *handlers.DiagnosticHandler
-	QuestionnaireHandler *handlers.QuestionnaireHandler
+	AuthHandler            *handlers.AuthHandler
+	UserHandler            *handlers.UserHandler
+	AppointmentHandler     *handlers.AppointmentHandler
+	PatientHandler         *handlers.PatientHandler
+	BusinessHoursHandler   *handlers.BusinessHoursHandler
+	ExamHandler            *handlers.ExamHandler
+	ConsultationHandler    *handlers.ConsultationHandler
+	DiagnosticHandler      *handlers.DiagnosticHandler
+	QuestionnaireHandler   *handlers.QuestionnaireHandler
+	ResourceHandler        *handlers.ResourceHandler
+	WaitlistHandler        *handlers.WaitlistHandler
+	AppointmentTypeHandler *handlers.AppointmentTypeHandler
+	CalendarHandler        *handlers.CalendarHandler
+	BookingRuleHandler     *handlers.BookingRuleHandler
+	TimelineHandler        *handlers.TimelineHandler
+	ConsentHandler         *handlers.ConsentHandler
 }
 
 // Sets up routes for the application
@@ -35,16 +43,105 @@ func SetupRoutes(e *echo.Echo, config *RouterConfig) {
 	e.GET("/appointments/today", config.AppointmentHandler.GetTodaysAppointments)
 	e.GET("/appointments/month", config.AppointmentHandler.GetAppointmentsForMonth)
 	e.GET("appointments/day", config.AppointmentHandler.GetAppointmentsForDate)
-	e.DELETE("/appointments/:id", config.AppointmentHandler.DeleteAppointment)
-	e.PUT("/appointments/:id", config.AppointmentHandler.UpdateAppointment)
-	e.POST("/appointments", config.AppointmentHandler.CreateAppointment)
+	e.GET("/appointments/availability", config.AppointmentHandler.GetAvailability)
+	e.GET("/appointments/:id", config.AppointmentHandler.GetAppointment)
+	e.DELETE("/appointments/:id", config.AppointmentHandler.DeleteAppointment, middleware.OptionalJWTAuth())
+	e.PUT("/appointments/:id", config.AppointmentHandler.UpdateAppointment, middleware.OptionalJWTAuth())
+	e.POST("/appointments", config.AppointmentHandler.CreateAppointment, middleware.OptionalJWTAuth())
+
+	// Move the appointments left outside business hours, e.g. after special hours close the clinic
+	e.GET("/appointments/reschedule", config.AppointmentHandler.PreviewReschedule)
+	e.POST("/appointments/reschedule", config.AppointmentHandler.ConfirmReschedule, middleware.OptionalJWTAuth())
+
+	// Booking rules, only admins change them or override them when booking
+	e.GET("/booking-rules", config.BookingRuleHandler.GetRules)
+	e.PUT("/booking-rules", config.BookingRuleHandler.UpdateRules, middleware.JWTAuth(), middleware.RequireRole("admin"))
+	e.GET("/appointments/:id/rule-overrides", config.BookingRuleHandler.ListOverrides)
+
+	// Appointment status lifecycle
+	e.PATCH("/appointments/:id/status", config.AppointmentHandler.ChangeAppointmentStatus, middleware.OptionalJWTAuth())
+	e.GET("/appointments/:id/status-history", config.AppointmentHandler.GetStatusHistory)
+
+	// Audit trail of every change made to an appointment
+	e.GET("/appointments/:id/history", config.AppointmentHandler.GetAuditTrail)
+
+	// Start the visit of a checked in appointment, creating its consultation
+	e.POST("/appointments/:id/consultation", config.ConsultationHandler.StartVisit, middleware.OptionalJWTAuth())
+
+	// Recurring appointment series
+	e.POST("/appointments/series", config.AppointmentHandler.CreateAppointmentSeries, middleware.OptionalJWTAuth())
+	e.PUT("/appointments/:id/following", config.AppointmentHandler.UpdateFollowingOccurrences, middleware.OptionalJWTAuth())
+	e.DELETE("/appointments/:id/following", config.AppointmentHandler.CancelFollowingOccurrences, middleware.OptionalJWTAuth())
+
+	// Calendar feed, read-only & authenticated by its token. Tokens are managed by their owner
+	e.GET("/calendar/:token", config.CalendarHandler.GetFeed)
+	e.GET("/calendar/tokens", config.CalendarHandler.ListFeedTokens, middleware.JWTAuth())
+	e.POST("/calendar/tokens", config.CalendarHandler.CreateFeedToken, middleware.JWTAuth())
+	e.DELETE("/calendar/tokens/:id", config.CalendarHandler.RevokeFeedToken, middleware.JWTAuth())
+
+	// Waitlist routes, offers are accepted with the token sent to the patient
+	e.POST("/waitlist", config.WaitlistHandler.CreateEntry, middleware.JWTAuth())
+	e.GET("/waitlist", config.WaitlistHandler.ListEntries, middleware.JWTAuth())
+	e.DELETE("/waitlist/:id", config.WaitlistHandler.CancelEntry, middleware.JWTAuth())
+	e.POST("/waitlist/offers/:token/accept", config.WaitlistHandler.AcceptOffer)
 
 	// Patient routes
 	e.GET("/patients/search", config.PatientHandler.SearchPatients)
+	e.GET("/patients", config.PatientHandler.ListPatients)
+	e.POST("/patients", config.PatientHandler.CreatePatient, middleware.OptionalJWTAuth())
+	e.GET("/patients/duplicates", config.PatientHandler.FindDuplicates)
+	e.POST("/patients/phones/normalize", config.PatientHandler.NormalizePhones, middleware.JWTAuth(), middleware.RequireRole("admin"))
 	e.GET("/patients/:id", config.PatientHandler.GetPatient)
+	e.PUT("/patients/:id", config.PatientHandler.UpdatePatient, middleware.OptionalJWTAuth())
+	e.DELETE("/patients/:id", config.PatientHandler.DeletePatient, middleware.JWTAuth(), middleware.RequireRole("admin"))
+	e.POST("/patients/:id/merge", config.PatientHandler.MergePatients, middleware.JWTAuth())
+	e.GET("/patients/:id/merges", config.PatientHandler.ListMerges)
+	e.GET("/patients/:id/timeline", config.TimelineHandler.GetPatientTimeline)
+
+	// Antecedentes history, every change is kept as a version
+	e.GET("/patients/:id/antecedentes", config.PatientHandler.ListAntecedentes)
+	e.GET("/patients/:id/antecedentes/diff", config.PatientHandler.DiffAntecedentes)
+	e.GET("/patients/:id/antecedentes/:version", config.PatientHandler.GetAntecedentesVersion)
+
+	// Guardians & family, notifications about minors go to their guardian
+	e.GET("/patients/:id/relationships", config.PatientHandler.ListRelationships)
+	e.POST("/patients/:id/relationships", config.PatientHandler.CreateRelationship, middleware.JWTAuth())
+	e.DELETE("/patients/:id/relationships/:relationshipId", config.PatientHandler.DeleteRelationship, middleware.JWTAuth())
+	e.PUT("/patients/:id/notification-contact", config.PatientHandler.SetNotificationContact, middleware.JWTAuth())
 
-	// Business hours routes
+	// Contact preferences & consents, outbound messages honour them
+	e.GET("/patients/:id/contact-preferences", config.ConsentHandler.GetContactPreferences)
+	e.PUT("/patients/:id/contact-preferences", config.ConsentHandler.SetContactPreferences, middleware.OptionalJWTAuth())
+	e.GET("/patients/:id/consents", config.ConsentHandler.ListConsents)
+	e.POST("/patients/:id/consents", config.ConsentHandler.RecordConsent, middleware.OptionalJWTAuth())
+
+	// Business hours routes, the schedule is managed by admins
 	e.GET("/business-hours", config.BusinessHoursHandler.GetBusinessHours)
+	e.GET("/business-hours/range", config.BusinessHoursHandler.GetBusinessHoursRange)
+	e.GET("/business-hours/weekly", config.BusinessHoursHandler.GetWeeklyHours, middleware.JWTAuth(), middleware.RequireRole("admin"))
+	e.PUT("/business-hours/weekly", config.BusinessHoursHandler.SetWeeklyHours, middleware.JWTAuth(), middleware.RequireRole("admin"))
+	e.GET("/business-hours/special", config.BusinessHoursHandler.ListSpecialHours, middleware.JWTAuth(), middleware.RequireRole("admin"))
+	e.PUT("/business-hours/special/:date", config.BusinessHoursHandler.SetSpecialHours, middleware.JWTAuth(), middleware.RequireRole("admin"))
+	e.DELETE("/business-hours/special/:date", config.BusinessHoursHandler.DeleteSpecialHours, middleware.JWTAuth(), middleware.RequireRole("admin"))
+	e.POST("/business-hours/holidays/import", config.BusinessHoursHandler.ImportHolidays, middleware.JWTAuth(), middleware.RequireRole("admin"))
+
+	// Provider & room routes, managed by admins
+	e.GET("/providers", config.ResourceHandler.ListProviders)
+	e.GET("/providers/:id", config.ResourceHandler.GetProvider)
+	e.POST("/providers", config.ResourceHandler.CreateProvider, middleware.JWTAuth(), middleware.RequireRole("admin"))
+	e.PUT("/providers/:id", config.ResourceHandler.UpdateProvider, middleware.JWTAuth(), middleware.RequireRole("admin"))
+	e.GET("/providers/:id/business-hours", config.BusinessHoursHandler.GetProviderWeeklyHours)
+	e.PUT("/providers/:id/business-hours", config.BusinessHoursHandler.SetProviderWeeklyHours, middleware.JWTAuth(), middleware.RequireRole("admin"))
+	e.GET("/rooms", config.ResourceHandler.ListRooms)
+	e.GET("/rooms/:id", config.ResourceHandler.GetRoom)
+	e.POST("/rooms", config.ResourceHandler.CreateRoom, middleware.JWTAuth(), middleware.RequireRole("admin"))
+	e.PUT("/rooms/:id", config.ResourceHandler.UpdateRoom, middleware.JWTAuth(), middleware.RequireRole("admin"))
+
+	// Appointment type catalog
+	e.GET("/appointment-types", config.AppointmentTypeHandler.ListAppointmentTypes)
+	e.GET("/appointment-types/:id", config.AppointmentTypeHandler.GetAppointmentType)
+	e.POST("/appointment-types", config.AppointmentTypeHandler.CreateAppointmentType)
+	e.PUT("/appointment-types/:id", config.AppointmentTypeHandler.UpdateAppointmentType)
 
 	// Consultation routes
 	e.GET("/consultations/patient/:patient_id", config.ConsultationHandler.GetByPatientID)
@@ -99,6 +99,12 @@ func (h *WhatsAppHandler) UpdateConfig(c echo.Context) error {
 	if v, ok := config["template_lang_code"].(string); ok {
 		existingConfig.TemplateLangCode = v
 	}
+	if v, ok := config["template_name_waitlist_offer"].(string); ok {
+		existingConfig.TemplateNameOffer = v
+	}
+	if v, ok := config["template_name_rescheduled"].(string); ok {
+		existingConfig.TemplateNameRescheduled = v
+	}
 
 	if err := h.service.UpdateConfig(c.Request().Context(), existingConfig); err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{
@@ -152,6 +158,11 @@ func (h *WhatsAppHandler) HandleWebhook(c echo.Context) error {
 		c.Logger().Errorf("Failed to process webhook: %v", err)
 	}
 
+	// STOP-like replies opt the sender out of messages
+	if err := h.service.ProcessInboundMessages(c.Request().Context(), &payload); err != nil {
+		c.Logger().Errorf("Failed to process webhook messages: %v", err)
+	}
+
 	// Always return 200 to Meta
 	return c.JSON(http.StatusOK, map[string]string{"status": "ok"})
 }
@@ -0,0 +1,72 @@
+package clinic
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Returned for phone numbers that can't be turned into E.164
+var ErrInvalidPhone = errors.New("invalid phone number")
+
+// Country calling code of numbers given without one, none unless configured
+var countryCode = ""
+
+// Country calling codes, 1 to 3 digits
+var countryCodePattern = regexp.MustCompile(`^[1-9][0-9]{0,2}$`)
+
+// E.164 numbers, a + & up to 15 digits. Shorter than 8 isn't a real subscriber number
+var e164Pattern = regexp.MustCompile(`^\+[1-9][0-9]{7,14}$`)
+
+// National numbers are at most this long, longer ones starting with the country code
+// already carry it & are missing the +
+const maxNationalDigits = 10
+
+// Set the country calling code of local numbers, e.g. 502 for Guatemala. Without one
+// only numbers long enough to carry their own country code are accepted
+func SetCountryCode(code string) error {
+	code = strings.TrimPrefix(strings.TrimSpace(code), "+")
+	if code != "" && !countryCodePattern.MatchString(code) {
+		return fmt.Errorf("invalid clinic country code %q", code)
+	}
+	countryCode = code
+	return nil
+}
+
+// Get the country calling code of local numbers, empty unless configured
+func CountryCode() string {
+	return countryCode
+}
+
+// Turn a phone number as people type it into E.164, e.g. "5555-1234" into
+// "+50255551234". Numbers starting with + or 00 are international, others are local
+// to the clinic's country & lose their trunk 0. Without a country code, numbers longer
+// than a national one are taken as stored without their +
+func NormalizePhone(phone string) (string, error) {
+	digits := strings.Map(func(r rune) rune {
+		if strings.ContainsRune(" -()./", r) {
+			return -1
+		}
+		return r
+	}, strings.TrimSpace(phone))
+
+	switch {
+	case strings.HasPrefix(digits, "+"):
+	case strings.HasPrefix(digits, "00"):
+		digits = "+" + digits[2:]
+	case countryCode != "" && len(digits) > maxNationalDigits && strings.HasPrefix(digits, countryCode):
+		digits = "+" + digits
+	case countryCode != "":
+		digits = "+" + countryCode + strings.TrimPrefix(digits, "0")
+	case len(digits) > maxNationalDigits:
+		digits = "+" + digits
+	default:
+		return "", fmt.Errorf("%w: %q has no country code & there's no default one", ErrInvalidPhone, phone)
+	}
+
+	if !e164Pattern.MatchString(digits) {
+		return "", fmt.Errorf("%w: %q", ErrInvalidPhone, phone)
+	}
+	return digits, nil
+}
@@ -0,0 +1,50 @@
+package clinic
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNormalizePhone(t *testing.T) {
+	if err := SetCountryCode("502"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer func() { countryCode = "" }()
+
+	cases := map[string]string{
+		"5555-1234":         "+50255551234",
+		"(0) 5555 1234":     "+50255551234",
+		"+502 5555-1234":    "+50255551234",
+		"00 1 555 123 4567": "+15551234567",
+		"50255551234":       "+50255551234",
+	}
+	for phone, expected := range cases {
+		got, err := NormalizePhone(phone)
+		if err != nil {
+			t.Errorf("%q: unexpected error: %v", phone, err)
+			continue
+		}
+		if got != expected {
+			t.Errorf("%q: expected %s, got %s", phone, expected, got)
+		}
+	}
+
+	for _, phone := range []string{"12-ab", "123", "+0123456789", "+1234567890123456"} {
+		if _, err := NormalizePhone(phone); !errors.Is(err, ErrInvalidPhone) {
+			t.Errorf("%q: expected invalid phone error, got %v", phone, err)
+		}
+	}
+}
+
+func TestNormalizePhone_NoCountryCode(t *testing.T) {
+	if _, err := NormalizePhone("5555-1234"); !errors.Is(err, ErrInvalidPhone) {
+		t.Errorf("expected local numbers to be refused without a country code, got %v", err)
+	}
+	if got, err := NormalizePhone("+502 5555 1234"); err != nil || got != "+50255551234" {
+		t.Errorf("expected international numbers to still work, got %q & %v", got, err)
+	}
+	// Numbers stored without their + carry the country code already
+	if got, err := NormalizePhone("50255551234"); err != nil || got != "+50255551234" {
+		t.Errorf("expected long numbers to get their +, got %q & %v", got, err)
+	}
+}
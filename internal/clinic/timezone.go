@@ -0,0 +1,41 @@
+package clinic
+
+import (
+	"fmt"
+	"time"
+)
+
+// Time zone the clinic works in. Day boundaries, business hours, reminders & dates
+// returned by the API are computed in it so nothing depends on the server's TZ
+var location = time.UTC
+
+// Set the clinic time zone from an IANA name, e.g. America/Guatemala
+func SetTimezone(name string) error {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return fmt.Errorf("invalid clinic timezone %q: %w", name, err)
+	}
+	location = loc
+	return nil
+}
+
+// Get the clinic time zone, UTC unless configured
+func Location() *time.Location {
+	return location
+}
+
+// Express a time in the clinic time zone
+func In(t time.Time) time.Time {
+	return t.In(location)
+}
+
+// Midnight, clinic time, of the day the given time falls on in the clinic
+func StartOfDay(t time.Time) time.Time {
+	t = t.In(location)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, location)
+}
+
+// Parse a YYYY-MM-DD date as midnight, clinic time
+func ParseDate(value string) (time.Time, error) {
+	return time.ParseInLocation("2006-01-02", value, location)
+}
@@ -0,0 +1,36 @@
+package clinic
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStartOfDay_UsesClinicDay(t *testing.T) {
+	if err := SetTimezone("America/Guatemala"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer func() { location = time.UTC }()
+
+	// 03:00 UTC is still the previous evening in Guatemala (UTC-6)
+	start := StartOfDay(time.Date(2024, 7, 18, 3, 0, 0, 0, time.UTC))
+	if start.Format(time.RFC3339) != "2024-07-17T00:00:00-06:00" {
+		t.Errorf("unexpected start of day: %s", start.Format(time.RFC3339))
+	}
+
+	date, err := ParseDate("2024-07-18")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if date.Format(time.RFC3339) != "2024-07-18T00:00:00-06:00" {
+		t.Errorf("unexpected date: %s", date.Format(time.RFC3339))
+	}
+}
+
+func TestSetTimezone_Invalid(t *testing.T) {
+	if err := SetTimezone("Not/AZone"); err == nil {
+		t.Error("expected an error for an unknown timezone")
+	}
+	if Location() != time.UTC {
+		t.Errorf("expected the timezone to be left alone, got %v", Location())
+	}
+}
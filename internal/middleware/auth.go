@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"errors"
 	"net/http"
 	"strings"
 	"time"
@@ -36,27 +37,62 @@ func JWTAuth() echo.MiddlewareFunc {
 				return echo.NewHTTPError(http.StatusUnauthorized, "missing token")
 			}
 
-			tokenString := strings.Replace(auth, "Bearer ", "", 1)
+			claims, err := parseToken(auth)
+			if err != nil {
+				return echo.NewHTTPError(http.StatusUnauthorized, "invalid token")
+			}
+			setClaims(c, claims)
 
-			token, err := jwt.ParseWithClaims(tokenString, &models.JWTClaims{},
-				func(token *jwt.Token) (interface{}, error) {
-					return jwtSecret, nil
-				})
+			return next(c)
+		}
+	}
+}
 
-			if err != nil || !token.Valid {
-				return echo.NewHTTPError(http.StatusUnauthorized, "invalid token")
+// Like JWTAuth but lets requests without a token through, so handlers can
+// attribute changes to a user when one is logged in
+func OptionalJWTAuth() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			auth := c.Request().Header.Get("Authorization")
+			if auth == "" {
+				return next(c)
 			}
 
-			claims := token.Claims.(*models.JWTClaims)
-			c.Set("user_id", int(claims.UserID))
-			c.Set("username", claims.Username)
-			c.Set("roles", claims.Roles)
+			// A token that is present must still be valid
+			claims, err := parseToken(auth)
+			if err != nil {
+				return echo.NewHTTPError(http.StatusUnauthorized, "invalid token")
+			}
+			setClaims(c, claims)
 
 			return next(c)
 		}
 	}
 }
 
+// Parse & validate a "Bearer <token>" header value
+func parseToken(auth string) (*models.JWTClaims, error) {
+	tokenString := strings.Replace(auth, "Bearer ", "", 1)
+
+	token, err := jwt.ParseWithClaims(tokenString, &models.JWTClaims{},
+		func(token *jwt.Token) (interface{}, error) {
+			return jwtSecret, nil
+		})
+
+	if err != nil || !token.Valid {
+		return nil, errors.New("invalid token")
+	}
+
+	return token.Claims.(*models.JWTClaims), nil
+}
+
+// Store token claims on the request context
+func setClaims(c echo.Context, claims *models.JWTClaims) {
+	c.Set("user_id", int(claims.UserID))
+	c.Set("username", claims.Username)
+	c.Set("roles", claims.Roles)
+}
+
 func RequireRole(allowedRoles ...string) echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
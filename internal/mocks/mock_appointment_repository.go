@@ -7,6 +7,7 @@ package mocks
 import (
 	reflect "reflect"
 	models "software-backend/internal/models"
+	appointment "software-backend/internal/repository/appointment"
 	time "time"
 
 	gomock "github.com/golang/mock/gomock"
@@ -35,6 +36,51 @@ func (m *MockAppointmentRepository) EXPECT() *MockAppointmentRepositoryMockRecor
 	return m.recorder
 }
 
+// CancelSeriesAppointmentsFrom mocks base method.
+func (m *MockAppointmentRepository) CancelSeriesAppointmentsFrom(seriesID int, from time.Time, userID *int) ([]models.Appointment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CancelSeriesAppointmentsFrom", seriesID, from, userID)
+	ret0, _ := ret[0].([]models.Appointment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CancelSeriesAppointmentsFrom indicates an expected call of CancelSeriesAppointmentsFrom.
+func (mr *MockAppointmentRepositoryMockRecorder) CancelSeriesAppointmentsFrom(seriesID, from, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelSeriesAppointmentsFrom", reflect.TypeOf((*MockAppointmentRepository)(nil).CancelSeriesAppointmentsFrom), seriesID, from, userID)
+}
+
+// CountNewPatientAppointmentsInRange mocks base method.
+func (m *MockAppointmentRepository) CountNewPatientAppointmentsInRange(start, end time.Time, excludeID *int) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountNewPatientAppointmentsInRange", start, end, excludeID)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountNewPatientAppointmentsInRange indicates an expected call of CountNewPatientAppointmentsInRange.
+func (mr *MockAppointmentRepositoryMockRecorder) CountNewPatientAppointmentsInRange(start, end, excludeID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountNewPatientAppointmentsInRange", reflect.TypeOf((*MockAppointmentRepository)(nil).CountNewPatientAppointmentsInRange), start, end, excludeID)
+}
+
+// CountPatientAppointmentsInRange mocks base method.
+func (m *MockAppointmentRepository) CountPatientAppointmentsInRange(patientID int, start, end time.Time, excludeID *int) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountPatientAppointmentsInRange", patientID, start, end, excludeID)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountPatientAppointmentsInRange indicates an expected call of CountPatientAppointmentsInRange.
+func (mr *MockAppointmentRepositoryMockRecorder) CountPatientAppointmentsInRange(patientID, start, end, excludeID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountPatientAppointmentsInRange", reflect.TypeOf((*MockAppointmentRepository)(nil).CountPatientAppointmentsInRange), patientID, start, end, excludeID)
+}
+
 // CreateAppointment mocks base method.
 func (m *MockAppointmentRepository) CreateAppointment(appointment models.Appointment) (*models.Appointment, error) {
 	m.ctrl.T.Helper()
@@ -50,6 +96,65 @@ func (mr *MockAppointmentRepositoryMockRecorder) CreateAppointment(appointment i
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAppointment", reflect.TypeOf((*MockAppointmentRepository)(nil).CreateAppointment), appointment)
 }
 
+// CreateAuditEntry mocks base method.
+func (m *MockAppointmentRepository) CreateAuditEntry(entry models.AppointmentAuditEntry) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateAuditEntry", entry)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateAuditEntry indicates an expected call of CreateAuditEntry.
+func (mr *MockAppointmentRepositoryMockRecorder) CreateAuditEntry(entry interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAuditEntry", reflect.TypeOf((*MockAppointmentRepository)(nil).CreateAuditEntry), entry)
+}
+
+// CreateConsultation mocks base method.
+func (m *MockAppointmentRepository) CreateConsultation(consultation models.Consultation) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateConsultation", consultation)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateConsultation indicates an expected call of CreateConsultation.
+func (mr *MockAppointmentRepositoryMockRecorder) CreateConsultation(consultation interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateConsultation", reflect.TypeOf((*MockAppointmentRepository)(nil).CreateConsultation), consultation)
+}
+
+// CreateRuleOverride mocks base method.
+func (m *MockAppointmentRepository) CreateRuleOverride(override models.BookingRuleOverride) (*models.BookingRuleOverride, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateRuleOverride", override)
+	ret0, _ := ret[0].(*models.BookingRuleOverride)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateRuleOverride indicates an expected call of CreateRuleOverride.
+func (mr *MockAppointmentRepositoryMockRecorder) CreateRuleOverride(override interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateRuleOverride", reflect.TypeOf((*MockAppointmentRepository)(nil).CreateRuleOverride), override)
+}
+
+// CreateSeries mocks base method.
+func (m *MockAppointmentRepository) CreateSeries(series models.AppointmentSeries) (*models.AppointmentSeries, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateSeries", series)
+	ret0, _ := ret[0].(*models.AppointmentSeries)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateSeries indicates an expected call of CreateSeries.
+func (mr *MockAppointmentRepositoryMockRecorder) CreateSeries(series interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateSeries", reflect.TypeOf((*MockAppointmentRepository)(nil).CreateSeries), series)
+}
+
 // DeleteAppointment mocks base method.
 func (m *MockAppointmentRepository) DeleteAppointment(id int) error {
 	m.ctrl.T.Helper()
@@ -79,19 +184,109 @@ func (mr *MockAppointmentRepositoryMockRecorder) GetAppointmentByID(id interface
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAppointmentByID", reflect.TypeOf((*MockAppointmentRepository)(nil).GetAppointmentByID), id)
 }
 
+// GetAttendanceStats mocks base method.
+func (m *MockAppointmentRepository) GetAttendanceStats(patientID int, lateCancellation time.Duration) (*models.AttendanceStats, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAttendanceStats", patientID, lateCancellation)
+	ret0, _ := ret[0].(*models.AttendanceStats)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAttendanceStats indicates an expected call of GetAttendanceStats.
+func (mr *MockAppointmentRepositoryMockRecorder) GetAttendanceStats(patientID, lateCancellation interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAttendanceStats", reflect.TypeOf((*MockAppointmentRepository)(nil).GetAttendanceStats), patientID, lateCancellation)
+}
+
+// GetAuditTrail mocks base method.
+func (m *MockAppointmentRepository) GetAuditTrail(id int) ([]models.AppointmentAuditEntry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAuditTrail", id)
+	ret0, _ := ret[0].([]models.AppointmentAuditEntry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAuditTrail indicates an expected call of GetAuditTrail.
+func (mr *MockAppointmentRepositoryMockRecorder) GetAuditTrail(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAuditTrail", reflect.TypeOf((*MockAppointmentRepository)(nil).GetAuditTrail), id)
+}
+
+// GetSeriesByID mocks base method.
+func (m *MockAppointmentRepository) GetSeriesByID(id int) (*models.AppointmentSeries, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSeriesByID", id)
+	ret0, _ := ret[0].(*models.AppointmentSeries)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSeriesByID indicates an expected call of GetSeriesByID.
+func (mr *MockAppointmentRepositoryMockRecorder) GetSeriesByID(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSeriesByID", reflect.TypeOf((*MockAppointmentRepository)(nil).GetSeriesByID), id)
+}
+
+// GetStatusHistory mocks base method.
+func (m *MockAppointmentRepository) GetStatusHistory(id int) ([]models.AppointmentStatusChange, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetStatusHistory", id)
+	ret0, _ := ret[0].([]models.AppointmentStatusChange)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetStatusHistory indicates an expected call of GetStatusHistory.
+func (mr *MockAppointmentRepositoryMockRecorder) GetStatusHistory(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetStatusHistory", reflect.TypeOf((*MockAppointmentRepository)(nil).GetStatusHistory), id)
+}
+
+// HasCompletedAppointmentBefore mocks base method.
+func (m *MockAppointmentRepository) HasCompletedAppointmentBefore(patientID int, before time.Time) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HasCompletedAppointmentBefore", patientID, before)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// HasCompletedAppointmentBefore indicates an expected call of HasCompletedAppointmentBefore.
+func (mr *MockAppointmentRepositoryMockRecorder) HasCompletedAppointmentBefore(patientID, before interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HasCompletedAppointmentBefore", reflect.TypeOf((*MockAppointmentRepository)(nil).HasCompletedAppointmentBefore), patientID, before)
+}
+
 // HasOverlappingAppointment mocks base method.
-func (m *MockAppointmentRepository) HasOverlappingAppointment(start, end time.Time, excludeID *int) (bool, error) {
+func (m *MockAppointmentRepository) HasOverlappingAppointment(start, end time.Time, excludeID, providerID, roomID *int) (bool, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "HasOverlappingAppointment", start, end, excludeID)
+	ret := m.ctrl.Call(m, "HasOverlappingAppointment", start, end, excludeID, providerID, roomID)
 	ret0, _ := ret[0].(bool)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // HasOverlappingAppointment indicates an expected call of HasOverlappingAppointment.
-func (mr *MockAppointmentRepositoryMockRecorder) HasOverlappingAppointment(start, end, excludeID interface{}) *gomock.Call {
+func (mr *MockAppointmentRepositoryMockRecorder) HasOverlappingAppointment(start, end, excludeID, providerID, roomID interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HasOverlappingAppointment", reflect.TypeOf((*MockAppointmentRepository)(nil).HasOverlappingAppointment), start, end, excludeID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HasOverlappingAppointment", reflect.TypeOf((*MockAppointmentRepository)(nil).HasOverlappingAppointment), start, end, excludeID, providerID, roomID)
+}
+
+// ListAppointmentsByIDs mocks base method.
+func (m *MockAppointmentRepository) ListAppointmentsByIDs(ids []int) ([]models.Appointment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAppointmentsByIDs", ids)
+	ret0, _ := ret[0].([]models.Appointment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAppointmentsByIDs indicates an expected call of ListAppointmentsByIDs.
+func (mr *MockAppointmentRepositoryMockRecorder) ListAppointmentsByIDs(ids interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAppointmentsByIDs", reflect.TypeOf((*MockAppointmentRepository)(nil).ListAppointmentsByIDs), ids)
 }
 
 // ListAppointmentsInDateRange mocks base method.
@@ -109,6 +304,50 @@ func (mr *MockAppointmentRepositoryMockRecorder) ListAppointmentsInDateRange(sta
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAppointmentsInDateRange", reflect.TypeOf((*MockAppointmentRepository)(nil).ListAppointmentsInDateRange), startTime, endTime)
 }
 
+// ListProviderAppointmentsInDateRange mocks base method.
+func (m *MockAppointmentRepository) ListProviderAppointmentsInDateRange(startTime, endTime time.Time, providerID int) ([]models.Appointment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListProviderAppointmentsInDateRange", startTime, endTime, providerID)
+	ret0, _ := ret[0].([]models.Appointment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListProviderAppointmentsInDateRange indicates an expected call of ListProviderAppointmentsInDateRange.
+func (mr *MockAppointmentRepositoryMockRecorder) ListProviderAppointmentsInDateRange(startTime, endTime, providerID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListProviderAppointmentsInDateRange", reflect.TypeOf((*MockAppointmentRepository)(nil).ListProviderAppointmentsInDateRange), startTime, endTime, providerID)
+}
+
+// ListUncheckedAppointments mocks base method.
+func (m *MockAppointmentRepository) ListUncheckedAppointments(from, to time.Time) ([]models.Appointment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListUncheckedAppointments", from, to)
+	ret0, _ := ret[0].([]models.Appointment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListUncheckedAppointments indicates an expected call of ListUncheckedAppointments.
+func (mr *MockAppointmentRepositoryMockRecorder) ListUncheckedAppointments(from, to interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUncheckedAppointments", reflect.TypeOf((*MockAppointmentRepository)(nil).ListUncheckedAppointments), from, to)
+}
+
+// RescheduleAppointments mocks base method.
+func (m *MockAppointmentRepository) RescheduleAppointments(moves []models.RescheduleMove) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RescheduleAppointments", moves)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RescheduleAppointments indicates an expected call of RescheduleAppointments.
+func (mr *MockAppointmentRepositoryMockRecorder) RescheduleAppointments(moves interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RescheduleAppointments", reflect.TypeOf((*MockAppointmentRepository)(nil).RescheduleAppointments), moves)
+}
+
 // UpdateAppointment mocks base method.
 func (m *MockAppointmentRepository) UpdateAppointment(appointment models.Appointment) error {
 	m.ctrl.T.Helper()
@@ -122,3 +361,45 @@ func (mr *MockAppointmentRepositoryMockRecorder) UpdateAppointment(appointment i
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateAppointment", reflect.TypeOf((*MockAppointmentRepository)(nil).UpdateAppointment), appointment)
 }
+
+// UpdateAppointmentStatus mocks base method.
+func (m *MockAppointmentRepository) UpdateAppointmentStatus(id int, from, to models.AppointmentStatus, userID *int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateAppointmentStatus", id, from, to, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateAppointmentStatus indicates an expected call of UpdateAppointmentStatus.
+func (mr *MockAppointmentRepositoryMockRecorder) UpdateAppointmentStatus(id, from, to, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateAppointmentStatus", reflect.TypeOf((*MockAppointmentRepository)(nil).UpdateAppointmentStatus), id, from, to, userID)
+}
+
+// UpdateSeries mocks base method.
+func (m *MockAppointmentRepository) UpdateSeries(series models.AppointmentSeries) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateSeries", series)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateSeries indicates an expected call of UpdateSeries.
+func (mr *MockAppointmentRepositoryMockRecorder) UpdateSeries(series interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateSeries", reflect.TypeOf((*MockAppointmentRepository)(nil).UpdateSeries), series)
+}
+
+// WithTransaction mocks base method.
+func (m *MockAppointmentRepository) WithTransaction(fn func(appointment.AppointmentRepository) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WithTransaction", fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WithTransaction indicates an expected call of WithTransaction.
+func (mr *MockAppointmentRepositoryMockRecorder) WithTransaction(fn interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WithTransaction", reflect.TypeOf((*MockAppointmentRepository)(nil).WithTransaction), fn)
+}
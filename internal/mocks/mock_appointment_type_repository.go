@@ -0,0 +1,94 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/repository/appointmenttype/repository.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	reflect "reflect"
+	models "software-backend/internal/models"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockAppointmentTypeRepository is a mock of AppointmentTypeRepository interface.
+type MockAppointmentTypeRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockAppointmentTypeRepositoryMockRecorder
+}
+
+// MockAppointmentTypeRepositoryMockRecorder is the mock recorder for MockAppointmentTypeRepository.
+type MockAppointmentTypeRepositoryMockRecorder struct {
+	mock *MockAppointmentTypeRepository
+}
+
+// NewMockAppointmentTypeRepository creates a new mock instance.
+func NewMockAppointmentTypeRepository(ctrl *gomock.Controller) *MockAppointmentTypeRepository {
+	mock := &MockAppointmentTypeRepository{ctrl: ctrl}
+	mock.recorder = &MockAppointmentTypeRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAppointmentTypeRepository) EXPECT() *MockAppointmentTypeRepositoryMockRecorder {
+	return m.recorder
+}
+
+// CreateAppointmentType mocks base method.
+func (m *MockAppointmentTypeRepository) CreateAppointmentType(apptType models.AppointmentType) (*models.AppointmentType, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateAppointmentType", apptType)
+	ret0, _ := ret[0].(*models.AppointmentType)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateAppointmentType indicates an expected call of CreateAppointmentType.
+func (mr *MockAppointmentTypeRepositoryMockRecorder) CreateAppointmentType(apptType interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAppointmentType", reflect.TypeOf((*MockAppointmentTypeRepository)(nil).CreateAppointmentType), apptType)
+}
+
+// GetAppointmentTypeByID mocks base method.
+func (m *MockAppointmentTypeRepository) GetAppointmentTypeByID(id int) (*models.AppointmentType, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAppointmentTypeByID", id)
+	ret0, _ := ret[0].(*models.AppointmentType)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAppointmentTypeByID indicates an expected call of GetAppointmentTypeByID.
+func (mr *MockAppointmentTypeRepositoryMockRecorder) GetAppointmentTypeByID(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAppointmentTypeByID", reflect.TypeOf((*MockAppointmentTypeRepository)(nil).GetAppointmentTypeByID), id)
+}
+
+// ListAppointmentTypes mocks base method.
+func (m *MockAppointmentTypeRepository) ListAppointmentTypes(onlyActive bool) ([]models.AppointmentType, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAppointmentTypes", onlyActive)
+	ret0, _ := ret[0].([]models.AppointmentType)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAppointmentTypes indicates an expected call of ListAppointmentTypes.
+func (mr *MockAppointmentTypeRepositoryMockRecorder) ListAppointmentTypes(onlyActive interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAppointmentTypes", reflect.TypeOf((*MockAppointmentTypeRepository)(nil).ListAppointmentTypes), onlyActive)
+}
+
+// UpdateAppointmentType mocks base method.
+func (m *MockAppointmentTypeRepository) UpdateAppointmentType(apptType models.AppointmentType) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateAppointmentType", apptType)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateAppointmentType indicates an expected call of UpdateAppointmentType.
+func (mr *MockAppointmentTypeRepositoryMockRecorder) UpdateAppointmentType(apptType interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateAppointmentType", reflect.TypeOf((*MockAppointmentTypeRepository)(nil).UpdateAppointmentType), apptType)
+}
@@ -0,0 +1,80 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/repository/bookingrule/repository.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	reflect "reflect"
+	models "software-backend/internal/models"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockBookingRuleRepository is a mock of BookingRuleRepository interface.
+type MockBookingRuleRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockBookingRuleRepositoryMockRecorder
+}
+
+// MockBookingRuleRepositoryMockRecorder is the mock recorder for MockBookingRuleRepository.
+type MockBookingRuleRepositoryMockRecorder struct {
+	mock *MockBookingRuleRepository
+}
+
+// NewMockBookingRuleRepository creates a new mock instance.
+func NewMockBookingRuleRepository(ctrl *gomock.Controller) *MockBookingRuleRepository {
+	mock := &MockBookingRuleRepository{ctrl: ctrl}
+	mock.recorder = &MockBookingRuleRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockBookingRuleRepository) EXPECT() *MockBookingRuleRepositoryMockRecorder {
+	return m.recorder
+}
+
+// GetRules mocks base method.
+func (m *MockBookingRuleRepository) GetRules() (*models.BookingRules, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRules")
+	ret0, _ := ret[0].(*models.BookingRules)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRules indicates an expected call of GetRules.
+func (mr *MockBookingRuleRepositoryMockRecorder) GetRules() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRules", reflect.TypeOf((*MockBookingRuleRepository)(nil).GetRules))
+}
+
+// ListOverrides mocks base method.
+func (m *MockBookingRuleRepository) ListOverrides(appointmentID int) ([]models.BookingRuleOverride, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListOverrides", appointmentID)
+	ret0, _ := ret[0].([]models.BookingRuleOverride)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListOverrides indicates an expected call of ListOverrides.
+func (mr *MockBookingRuleRepositoryMockRecorder) ListOverrides(appointmentID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListOverrides", reflect.TypeOf((*MockBookingRuleRepository)(nil).ListOverrides), appointmentID)
+}
+
+// UpdateRules mocks base method.
+func (m *MockBookingRuleRepository) UpdateRules(rules models.BookingRules) (*models.BookingRules, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateRules", rules)
+	ret0, _ := ret[0].(*models.BookingRules)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateRules indicates an expected call of UpdateRules.
+func (mr *MockBookingRuleRepositoryMockRecorder) UpdateRules(rules interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateRules", reflect.TypeOf((*MockBookingRuleRepository)(nil).UpdateRules), rules)
+}
@@ -35,6 +35,35 @@ func (m *MockBusinessHoursRepository) EXPECT() *MockBusinessHoursRepositoryMockR
 	return m.recorder
 }
 
+// CreateSpecialHours mocks base method.
+func (m *MockBusinessHoursRepository) CreateSpecialHours(specials []models.SpecialHours) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateSpecialHours", specials)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateSpecialHours indicates an expected call of CreateSpecialHours.
+func (mr *MockBusinessHoursRepositoryMockRecorder) CreateSpecialHours(specials interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateSpecialHours", reflect.TypeOf((*MockBusinessHoursRepository)(nil).CreateSpecialHours), specials)
+}
+
+// DeleteSpecialHours mocks base method.
+func (m *MockBusinessHoursRepository) DeleteSpecialHours(date time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteSpecialHours", date)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteSpecialHours indicates an expected call of DeleteSpecialHours.
+func (mr *MockBusinessHoursRepositoryMockRecorder) DeleteSpecialHours(date interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteSpecialHours", reflect.TypeOf((*MockBusinessHoursRepository)(nil).DeleteSpecialHours), date)
+}
+
 // GetBusinessHoursForDate mocks base method.
 func (m *MockBusinessHoursRepository) GetBusinessHoursForDate(date time.Time) ([]models.BusinessHourInterval, error) {
 	m.ctrl.T.Helper()
@@ -49,3 +78,90 @@ func (mr *MockBusinessHoursRepositoryMockRecorder) GetBusinessHoursForDate(date
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBusinessHoursForDate", reflect.TypeOf((*MockBusinessHoursRepository)(nil).GetBusinessHoursForDate), date)
 }
+
+// GetProviderWeeklyHours mocks base method.
+func (m *MockBusinessHoursRepository) GetProviderWeeklyHours(providerID int) ([]models.WeeklyHourInterval, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetProviderWeeklyHours", providerID)
+	ret0, _ := ret[0].([]models.WeeklyHourInterval)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetProviderWeeklyHours indicates an expected call of GetProviderWeeklyHours.
+func (mr *MockBusinessHoursRepositoryMockRecorder) GetProviderWeeklyHours(providerID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetProviderWeeklyHours", reflect.TypeOf((*MockBusinessHoursRepository)(nil).GetProviderWeeklyHours), providerID)
+}
+
+// GetWeeklyHours mocks base method.
+func (m *MockBusinessHoursRepository) GetWeeklyHours() ([]models.WeeklyHourInterval, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWeeklyHours")
+	ret0, _ := ret[0].([]models.WeeklyHourInterval)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWeeklyHours indicates an expected call of GetWeeklyHours.
+func (mr *MockBusinessHoursRepositoryMockRecorder) GetWeeklyHours() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWeeklyHours", reflect.TypeOf((*MockBusinessHoursRepository)(nil).GetWeeklyHours))
+}
+
+// ListSpecialHours mocks base method.
+func (m *MockBusinessHoursRepository) ListSpecialHours(from, to time.Time) ([]models.SpecialHours, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListSpecialHours", from, to)
+	ret0, _ := ret[0].([]models.SpecialHours)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListSpecialHours indicates an expected call of ListSpecialHours.
+func (mr *MockBusinessHoursRepositoryMockRecorder) ListSpecialHours(from, to interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListSpecialHours", reflect.TypeOf((*MockBusinessHoursRepository)(nil).ListSpecialHours), from, to)
+}
+
+// ReplaceProviderWeeklyHours mocks base method.
+func (m *MockBusinessHoursRepository) ReplaceProviderWeeklyHours(providerID int, hours []models.WeeklyHourInterval) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReplaceProviderWeeklyHours", providerID, hours)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReplaceProviderWeeklyHours indicates an expected call of ReplaceProviderWeeklyHours.
+func (mr *MockBusinessHoursRepositoryMockRecorder) ReplaceProviderWeeklyHours(providerID, hours interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReplaceProviderWeeklyHours", reflect.TypeOf((*MockBusinessHoursRepository)(nil).ReplaceProviderWeeklyHours), providerID, hours)
+}
+
+// ReplaceWeeklyHours mocks base method.
+func (m *MockBusinessHoursRepository) ReplaceWeeklyHours(hours []models.WeeklyHourInterval) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReplaceWeeklyHours", hours)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReplaceWeeklyHours indicates an expected call of ReplaceWeeklyHours.
+func (mr *MockBusinessHoursRepositoryMockRecorder) ReplaceWeeklyHours(hours interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReplaceWeeklyHours", reflect.TypeOf((*MockBusinessHoursRepository)(nil).ReplaceWeeklyHours), hours)
+}
+
+// SetSpecialHours mocks base method.
+func (m *MockBusinessHoursRepository) SetSpecialHours(special models.SpecialHours) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetSpecialHours", special)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetSpecialHours indicates an expected call of SetSpecialHours.
+func (mr *MockBusinessHoursRepositoryMockRecorder) SetSpecialHours(special interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetSpecialHours", reflect.TypeOf((*MockBusinessHoursRepository)(nil).SetSpecialHours), special)
+}
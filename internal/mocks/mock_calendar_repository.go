@@ -0,0 +1,94 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/repository/calendar/repository.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	reflect "reflect"
+	models "software-backend/internal/models"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockCalendarRepository is a mock of CalendarRepository interface.
+type MockCalendarRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockCalendarRepositoryMockRecorder
+}
+
+// MockCalendarRepositoryMockRecorder is the mock recorder for MockCalendarRepository.
+type MockCalendarRepositoryMockRecorder struct {
+	mock *MockCalendarRepository
+}
+
+// NewMockCalendarRepository creates a new mock instance.
+func NewMockCalendarRepository(ctrl *gomock.Controller) *MockCalendarRepository {
+	mock := &MockCalendarRepository{ctrl: ctrl}
+	mock.recorder = &MockCalendarRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCalendarRepository) EXPECT() *MockCalendarRepositoryMockRecorder {
+	return m.recorder
+}
+
+// CreateFeedToken mocks base method.
+func (m *MockCalendarRepository) CreateFeedToken(token models.CalendarFeedToken) (*models.CalendarFeedToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateFeedToken", token)
+	ret0, _ := ret[0].(*models.CalendarFeedToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateFeedToken indicates an expected call of CreateFeedToken.
+func (mr *MockCalendarRepositoryMockRecorder) CreateFeedToken(token interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateFeedToken", reflect.TypeOf((*MockCalendarRepository)(nil).CreateFeedToken), token)
+}
+
+// GetActiveFeedToken mocks base method.
+func (m *MockCalendarRepository) GetActiveFeedToken(token string) (*models.CalendarFeedToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetActiveFeedToken", token)
+	ret0, _ := ret[0].(*models.CalendarFeedToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetActiveFeedToken indicates an expected call of GetActiveFeedToken.
+func (mr *MockCalendarRepositoryMockRecorder) GetActiveFeedToken(token interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetActiveFeedToken", reflect.TypeOf((*MockCalendarRepository)(nil).GetActiveFeedToken), token)
+}
+
+// ListFeedTokens mocks base method.
+func (m *MockCalendarRepository) ListFeedTokens(userID int) ([]models.CalendarFeedToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListFeedTokens", userID)
+	ret0, _ := ret[0].([]models.CalendarFeedToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListFeedTokens indicates an expected call of ListFeedTokens.
+func (mr *MockCalendarRepositoryMockRecorder) ListFeedTokens(userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListFeedTokens", reflect.TypeOf((*MockCalendarRepository)(nil).ListFeedTokens), userID)
+}
+
+// RevokeFeedToken mocks base method.
+func (m *MockCalendarRepository) RevokeFeedToken(id, userID int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeFeedToken", id, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeFeedToken indicates an expected call of RevokeFeedToken.
+func (mr *MockCalendarRepositoryMockRecorder) RevokeFeedToken(id, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeFeedToken", reflect.TypeOf((*MockCalendarRepository)(nil).RevokeFeedToken), id, userID)
+}
@@ -0,0 +1,109 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/repository/consent/repository.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	reflect "reflect"
+	models "software-backend/internal/models"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockConsentRepository is a mock of ConsentRepository interface.
+type MockConsentRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockConsentRepositoryMockRecorder
+}
+
+// MockConsentRepositoryMockRecorder is the mock recorder for MockConsentRepository.
+type MockConsentRepositoryMockRecorder struct {
+	mock *MockConsentRepository
+}
+
+// NewMockConsentRepository creates a new mock instance.
+func NewMockConsentRepository(ctrl *gomock.Controller) *MockConsentRepository {
+	mock := &MockConsentRepository{ctrl: ctrl}
+	mock.recorder = &MockConsentRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockConsentRepository) EXPECT() *MockConsentRepositoryMockRecorder {
+	return m.recorder
+}
+
+// CreateConsent mocks base method.
+func (m *MockConsentRepository) CreateConsent(record models.ConsentRecord) (*models.ConsentRecord, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateConsent", record)
+	ret0, _ := ret[0].(*models.ConsentRecord)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateConsent indicates an expected call of CreateConsent.
+func (mr *MockConsentRepositoryMockRecorder) CreateConsent(record interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateConsent", reflect.TypeOf((*MockConsentRepository)(nil).CreateConsent), record)
+}
+
+// CreateConsentByPhone mocks base method.
+func (m *MockConsentRepository) CreateConsentByPhone(phone string, record models.ConsentRecord) ([]int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateConsentByPhone", phone, record)
+	ret0, _ := ret[0].([]int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateConsentByPhone indicates an expected call of CreateConsentByPhone.
+func (mr *MockConsentRepositoryMockRecorder) CreateConsentByPhone(phone, record interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateConsentByPhone", reflect.TypeOf((*MockConsentRepository)(nil).CreateConsentByPhone), phone, record)
+}
+
+// GetContactPreferences mocks base method.
+func (m *MockConsentRepository) GetContactPreferences(patientID int) (*models.ContactPreferences, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetContactPreferences", patientID)
+	ret0, _ := ret[0].(*models.ContactPreferences)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetContactPreferences indicates an expected call of GetContactPreferences.
+func (mr *MockConsentRepositoryMockRecorder) GetContactPreferences(patientID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetContactPreferences", reflect.TypeOf((*MockConsentRepository)(nil).GetContactPreferences), patientID)
+}
+
+// ListConsents mocks base method.
+func (m *MockConsentRepository) ListConsents(patientID int) ([]models.ConsentRecord, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListConsents", patientID)
+	ret0, _ := ret[0].([]models.ConsentRecord)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListConsents indicates an expected call of ListConsents.
+func (mr *MockConsentRepositoryMockRecorder) ListConsents(patientID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListConsents", reflect.TypeOf((*MockConsentRepository)(nil).ListConsents), patientID)
+}
+
+// SetContactPreferences mocks base method.
+func (m *MockConsentRepository) SetContactPreferences(preferences models.ContactPreferences) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetContactPreferences", preferences)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetContactPreferences indicates an expected call of SetContactPreferences.
+func (mr *MockConsentRepositoryMockRecorder) SetContactPreferences(preferences interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetContactPreferences", reflect.TypeOf((*MockConsentRepository)(nil).SetContactPreferences), preferences)
+}
@@ -34,6 +34,65 @@ func (m *MockConsultationRepository) EXPECT() *MockConsultationRepositoryMockRec
 	return m.recorder
 }
 
+// Create mocks base method.
+func (m *MockConsultationRepository) Create(consultation models.Consultation) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", consultation)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockConsultationRepositoryMockRecorder) Create(consultation interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockConsultationRepository)(nil).Create), consultation)
+}
+
+// Delete mocks base method.
+func (m *MockConsultationRepository) Delete(id int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockConsultationRepositoryMockRecorder) Delete(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockConsultationRepository)(nil).Delete), id)
+}
+
+// GetByAppointmentID mocks base method.
+func (m *MockConsultationRepository) GetByAppointmentID(appointmentID int) (*models.Consultation, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByAppointmentID", appointmentID)
+	ret0, _ := ret[0].(*models.Consultation)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByAppointmentID indicates an expected call of GetByAppointmentID.
+func (mr *MockConsultationRepositoryMockRecorder) GetByAppointmentID(appointmentID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByAppointmentID", reflect.TypeOf((*MockConsultationRepository)(nil).GetByAppointmentID), appointmentID)
+}
+
+// GetByID mocks base method.
+func (m *MockConsultationRepository) GetByID(id int) (*models.Consultation, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByID", id)
+	ret0, _ := ret[0].(*models.Consultation)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByID indicates an expected call of GetByID.
+func (mr *MockConsultationRepositoryMockRecorder) GetByID(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*MockConsultationRepository)(nil).GetByID), id)
+}
+
 // GetByPatientID mocks base method.
 func (m *MockConsultationRepository) GetByPatientID(patientID int) ([]models.Consultation, error) {
 	m.ctrl.T.Helper()
@@ -48,3 +107,32 @@ func (mr *MockConsultationRepositoryMockRecorder) GetByPatientID(patientID inter
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByPatientID", reflect.TypeOf((*MockConsultationRepository)(nil).GetByPatientID), patientID)
 }
+
+// GetComplete mocks base method.
+func (m *MockConsultationRepository) GetComplete(id int) (*models.CompleteConsultation, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetComplete", id)
+	ret0, _ := ret[0].(*models.CompleteConsultation)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetComplete indicates an expected call of GetComplete.
+func (mr *MockConsultationRepositoryMockRecorder) GetComplete(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetComplete", reflect.TypeOf((*MockConsultationRepository)(nil).GetComplete), id)
+}
+
+// Update mocks base method.
+func (m *MockConsultationRepository) Update(id int, consultation models.Consultation) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", id, consultation)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Update indicates an expected call of Update.
+func (mr *MockConsultationRepositoryMockRecorder) Update(id, consultation interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockConsultationRepository)(nil).Update), id, consultation)
+}
@@ -7,6 +7,7 @@ package mocks
 import (
 	reflect "reflect"
 	models "software-backend/internal/models"
+	time "time"
 
 	gomock "github.com/golang/mock/gomock"
 )
@@ -49,6 +50,21 @@ func (mr *MockPatientRepositoryMockRecorder) CreatePatient(patient interface{})
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreatePatient", reflect.TypeOf((*MockPatientRepository)(nil).CreatePatient), patient)
 }
 
+// CreateRelationship mocks base method.
+func (m *MockPatientRepository) CreateRelationship(relationship models.PatientRelationship) (*models.PatientRelationship, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateRelationship", relationship)
+	ret0, _ := ret[0].(*models.PatientRelationship)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateRelationship indicates an expected call of CreateRelationship.
+func (mr *MockPatientRepositoryMockRecorder) CreateRelationship(relationship interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateRelationship", reflect.TypeOf((*MockPatientRepository)(nil).CreateRelationship), relationship)
+}
+
 // DeletePatient mocks base method.
 func (m *MockPatientRepository) DeletePatient(id int) error {
 	m.ctrl.T.Helper()
@@ -63,6 +79,35 @@ func (mr *MockPatientRepositoryMockRecorder) DeletePatient(id interface{}) *gomo
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeletePatient", reflect.TypeOf((*MockPatientRepository)(nil).DeletePatient), id)
 }
 
+// DeleteRelationship mocks base method.
+func (m *MockPatientRepository) DeleteRelationship(patientID, relationshipID int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteRelationship", patientID, relationshipID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteRelationship indicates an expected call of DeleteRelationship.
+func (mr *MockPatientRepositoryMockRecorder) DeleteRelationship(patientID, relationshipID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteRelationship", reflect.TypeOf((*MockPatientRepository)(nil).DeleteRelationship), patientID, relationshipID)
+}
+
+// GetNotificationContact mocks base method.
+func (m *MockPatientRepository) GetNotificationContact(patientID int) (*models.PatientRelationship, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetNotificationContact", patientID)
+	ret0, _ := ret[0].(*models.PatientRelationship)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetNotificationContact indicates an expected call of GetNotificationContact.
+func (mr *MockPatientRepositoryMockRecorder) GetNotificationContact(patientID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetNotificationContact", reflect.TypeOf((*MockPatientRepository)(nil).GetNotificationContact), patientID)
+}
+
 // GetPatientByID mocks base method.
 func (m *MockPatientRepository) GetPatientByID(id int) (*models.Patient, error) {
 	m.ctrl.T.Helper()
@@ -78,34 +123,169 @@ func (mr *MockPatientRepositoryMockRecorder) GetPatientByID(id interface{}) *gom
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPatientByID", reflect.TypeOf((*MockPatientRepository)(nil).GetPatientByID), id)
 }
 
-// ListPatients mocks base method.
-func (m *MockPatientRepository) ListPatients() ([]models.Patient, error) {
+// ListAntecedentes mocks base method.
+func (m *MockPatientRepository) ListAntecedentes(patientID int) ([]models.Antecedentes, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAntecedentes", patientID)
+	ret0, _ := ret[0].([]models.Antecedentes)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAntecedentes indicates an expected call of ListAntecedentes.
+func (mr *MockPatientRepositoryMockRecorder) ListAntecedentes(patientID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAntecedentes", reflect.TypeOf((*MockPatientRepository)(nil).ListAntecedentes), patientID)
+}
+
+// ListDuplicateCandidates mocks base method.
+func (m *MockPatientRepository) ListDuplicateCandidates(afterID, afterDuplicateID, limit int) ([]models.DuplicateCandidate, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListDuplicateCandidates", afterID, afterDuplicateID, limit)
+	ret0, _ := ret[0].([]models.DuplicateCandidate)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListDuplicateCandidates indicates an expected call of ListDuplicateCandidates.
+func (mr *MockPatientRepositoryMockRecorder) ListDuplicateCandidates(afterID, afterDuplicateID, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListDuplicateCandidates", reflect.TypeOf((*MockPatientRepository)(nil).ListDuplicateCandidates), afterID, afterDuplicateID, limit)
+}
+
+// ListMerges mocks base method.
+func (m *MockPatientRepository) ListMerges(patientID int) ([]models.PatientMerge, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ListPatients")
+	ret := m.ctrl.Call(m, "ListMerges", patientID)
+	ret0, _ := ret[0].([]models.PatientMerge)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListMerges indicates an expected call of ListMerges.
+func (mr *MockPatientRepositoryMockRecorder) ListMerges(patientID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListMerges", reflect.TypeOf((*MockPatientRepository)(nil).ListMerges), patientID)
+}
+
+// ListPatientPhones mocks base method.
+func (m *MockPatientRepository) ListPatientPhones() ([]models.Patient, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListPatientPhones")
 	ret0, _ := ret[0].([]models.Patient)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
+// ListPatientPhones indicates an expected call of ListPatientPhones.
+func (mr *MockPatientRepositoryMockRecorder) ListPatientPhones() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPatientPhones", reflect.TypeOf((*MockPatientRepository)(nil).ListPatientPhones))
+}
+
+// ListPatients mocks base method.
+func (m *MockPatientRepository) ListPatients(limit, offset int) ([]models.Patient, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListPatients", limit, offset)
+	ret0, _ := ret[0].([]models.Patient)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
 // ListPatients indicates an expected call of ListPatients.
-func (mr *MockPatientRepositoryMockRecorder) ListPatients() *gomock.Call {
+func (mr *MockPatientRepositoryMockRecorder) ListPatients(limit, offset interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPatients", reflect.TypeOf((*MockPatientRepository)(nil).ListPatients), limit, offset)
+}
+
+// ListRelationships mocks base method.
+func (m *MockPatientRepository) ListRelationships(patientID int) ([]models.PatientRelationship, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListRelationships", patientID)
+	ret0, _ := ret[0].([]models.PatientRelationship)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListRelationships indicates an expected call of ListRelationships.
+func (mr *MockPatientRepositoryMockRecorder) ListRelationships(patientID interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPatients", reflect.TypeOf((*MockPatientRepository)(nil).ListPatients))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListRelationships", reflect.TypeOf((*MockPatientRepository)(nil).ListRelationships), patientID)
 }
 
-// SearchPatients mocks base method.
-func (m *MockPatientRepository) SearchPatients(query string, limit int) ([]models.Patient, error) {
+// MergePatients mocks base method.
+func (m *MockPatientRepository) MergePatients(merge models.PatientMerge) (*models.PatientMerge, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "SearchPatients", query, limit)
+	ret := m.ctrl.Call(m, "MergePatients", merge)
+	ret0, _ := ret[0].(*models.PatientMerge)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MergePatients indicates an expected call of MergePatients.
+func (mr *MockPatientRepositoryMockRecorder) MergePatients(merge interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MergePatients", reflect.TypeOf((*MockPatientRepository)(nil).MergePatients), merge)
+}
+
+// SearchPatientsByBirthDate mocks base method.
+func (m *MockPatientRepository) SearchPatientsByBirthDate(date time.Time, limit int) ([]models.Patient, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SearchPatientsByBirthDate", date, limit)
 	ret0, _ := ret[0].([]models.Patient)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// SearchPatients indicates an expected call of SearchPatients.
-func (mr *MockPatientRepositoryMockRecorder) SearchPatients(query, limit interface{}) *gomock.Call {
+// SearchPatientsByBirthDate indicates an expected call of SearchPatientsByBirthDate.
+func (mr *MockPatientRepositoryMockRecorder) SearchPatientsByBirthDate(date, limit interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchPatients", reflect.TypeOf((*MockPatientRepository)(nil).SearchPatients), query, limit)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchPatientsByBirthDate", reflect.TypeOf((*MockPatientRepository)(nil).SearchPatientsByBirthDate), date, limit)
+}
+
+// SearchPatientsByName mocks base method.
+func (m *MockPatientRepository) SearchPatientsByName(words []string, limit int) ([]models.Patient, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SearchPatientsByName", words, limit)
+	ret0, _ := ret[0].([]models.Patient)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SearchPatientsByName indicates an expected call of SearchPatientsByName.
+func (mr *MockPatientRepositoryMockRecorder) SearchPatientsByName(words, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchPatientsByName", reflect.TypeOf((*MockPatientRepository)(nil).SearchPatientsByName), words, limit)
+}
+
+// SearchPatientsByPhone mocks base method.
+func (m *MockPatientRepository) SearchPatientsByPhone(digits string, limit int) ([]models.Patient, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SearchPatientsByPhone", digits, limit)
+	ret0, _ := ret[0].([]models.Patient)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SearchPatientsByPhone indicates an expected call of SearchPatientsByPhone.
+func (mr *MockPatientRepositoryMockRecorder) SearchPatientsByPhone(digits, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchPatientsByPhone", reflect.TypeOf((*MockPatientRepository)(nil).SearchPatientsByPhone), digits, limit)
+}
+
+// SetNotificationContact mocks base method.
+func (m *MockPatientRepository) SetNotificationContact(patientID int, relationshipID *int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetNotificationContact", patientID, relationshipID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetNotificationContact indicates an expected call of SetNotificationContact.
+func (mr *MockPatientRepositoryMockRecorder) SetNotificationContact(patientID, relationshipID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetNotificationContact", reflect.TypeOf((*MockPatientRepository)(nil).SetNotificationContact), patientID, relationshipID)
 }
 
 // UpdatePatient mocks base method.
@@ -121,3 +301,18 @@ func (mr *MockPatientRepositoryMockRecorder) UpdatePatient(patient interface{})
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePatient", reflect.TypeOf((*MockPatientRepository)(nil).UpdatePatient), patient)
 }
+
+// UpdatePatientPhones mocks base method.
+func (m *MockPatientRepository) UpdatePatientPhones(changes []models.PhoneChange) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdatePatientPhones", changes)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdatePatientPhones indicates an expected call of UpdatePatientPhones.
+func (mr *MockPatientRepositoryMockRecorder) UpdatePatientPhones(changes interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePatientPhones", reflect.TypeOf((*MockPatientRepository)(nil).UpdatePatientPhones), changes)
+}
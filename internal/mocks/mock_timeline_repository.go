@@ -0,0 +1,96 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/repository/timeline/repository.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	reflect "reflect"
+	models "software-backend/internal/models"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockTimelineRepository is a mock of TimelineRepository interface.
+type MockTimelineRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockTimelineRepositoryMockRecorder
+}
+
+// MockTimelineRepositoryMockRecorder is the mock recorder for MockTimelineRepository.
+type MockTimelineRepositoryMockRecorder struct {
+	mock *MockTimelineRepository
+}
+
+// NewMockTimelineRepository creates a new mock instance.
+func NewMockTimelineRepository(ctrl *gomock.Controller) *MockTimelineRepository {
+	mock := &MockTimelineRepository{ctrl: ctrl}
+	mock.recorder = &MockTimelineRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockTimelineRepository) EXPECT() *MockTimelineRepositoryMockRecorder {
+	return m.recorder
+}
+
+// ListConsultations mocks base method.
+func (m *MockTimelineRepository) ListConsultations(ids []int) ([]models.ConsultationWithDetails, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListConsultations", ids)
+	ret0, _ := ret[0].([]models.ConsultationWithDetails)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListConsultations indicates an expected call of ListConsultations.
+func (mr *MockTimelineRepositoryMockRecorder) ListConsultations(ids interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListConsultations", reflect.TypeOf((*MockTimelineRepository)(nil).ListConsultations), ids)
+}
+
+// ListEvents mocks base method.
+func (m *MockTimelineRepository) ListEvents(patientID int, filter models.TimelineFilter) ([]models.TimelineEvent, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListEvents", patientID, filter)
+	ret0, _ := ret[0].([]models.TimelineEvent)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// ListEvents indicates an expected call of ListEvents.
+func (mr *MockTimelineRepositoryMockRecorder) ListEvents(patientID, filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListEvents", reflect.TypeOf((*MockTimelineRepository)(nil).ListEvents), patientID, filter)
+}
+
+// ListExams mocks base method.
+func (m *MockTimelineRepository) ListExams(ids []int) ([]models.Exam, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListExams", ids)
+	ret0, _ := ret[0].([]models.Exam)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListExams indicates an expected call of ListExams.
+func (mr *MockTimelineRepositoryMockRecorder) ListExams(ids interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListExams", reflect.TypeOf((*MockTimelineRepository)(nil).ListExams), ids)
+}
+
+// ListNotifications mocks base method.
+func (m *MockTimelineRepository) ListNotifications(ids []int) ([]models.WhatsAppNotification, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListNotifications", ids)
+	ret0, _ := ret[0].([]models.WhatsAppNotification)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListNotifications indicates an expected call of ListNotifications.
+func (mr *MockTimelineRepositoryMockRecorder) ListNotifications(ids interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListNotifications", reflect.TypeOf((*MockTimelineRepository)(nil).ListNotifications), ids)
+}
@@ -0,0 +1,152 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/repository/waitlist/repository.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	reflect "reflect"
+	models "software-backend/internal/models"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockWaitlistRepository is a mock of WaitlistRepository interface.
+type MockWaitlistRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockWaitlistRepositoryMockRecorder
+}
+
+// MockWaitlistRepositoryMockRecorder is the mock recorder for MockWaitlistRepository.
+type MockWaitlistRepositoryMockRecorder struct {
+	mock *MockWaitlistRepository
+}
+
+// NewMockWaitlistRepository creates a new mock instance.
+func NewMockWaitlistRepository(ctrl *gomock.Controller) *MockWaitlistRepository {
+	mock := &MockWaitlistRepository{ctrl: ctrl}
+	mock.recorder = &MockWaitlistRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockWaitlistRepository) EXPECT() *MockWaitlistRepositoryMockRecorder {
+	return m.recorder
+}
+
+// AcceptOffer mocks base method.
+func (m *MockWaitlistRepository) AcceptOffer(offerID, appointmentID int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AcceptOffer", offerID, appointmentID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AcceptOffer indicates an expected call of AcceptOffer.
+func (mr *MockWaitlistRepositoryMockRecorder) AcceptOffer(offerID, appointmentID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AcceptOffer", reflect.TypeOf((*MockWaitlistRepository)(nil).AcceptOffer), offerID, appointmentID)
+}
+
+// CreateEntry mocks base method.
+func (m *MockWaitlistRepository) CreateEntry(entry models.WaitlistEntry) (*models.WaitlistEntry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateEntry", entry)
+	ret0, _ := ret[0].(*models.WaitlistEntry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateEntry indicates an expected call of CreateEntry.
+func (mr *MockWaitlistRepositoryMockRecorder) CreateEntry(entry interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateEntry", reflect.TypeOf((*MockWaitlistRepository)(nil).CreateEntry), entry)
+}
+
+// CreateOffer mocks base method.
+func (m *MockWaitlistRepository) CreateOffer(offer models.WaitlistOffer) (*models.WaitlistOffer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateOffer", offer)
+	ret0, _ := ret[0].(*models.WaitlistOffer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateOffer indicates an expected call of CreateOffer.
+func (mr *MockWaitlistRepositoryMockRecorder) CreateOffer(offer interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateOffer", reflect.TypeOf((*MockWaitlistRepository)(nil).CreateOffer), offer)
+}
+
+// GetEntryByID mocks base method.
+func (m *MockWaitlistRepository) GetEntryByID(id int) (*models.WaitlistEntry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetEntryByID", id)
+	ret0, _ := ret[0].(*models.WaitlistEntry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetEntryByID indicates an expected call of GetEntryByID.
+func (mr *MockWaitlistRepositoryMockRecorder) GetEntryByID(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEntryByID", reflect.TypeOf((*MockWaitlistRepository)(nil).GetEntryByID), id)
+}
+
+// GetOfferByToken mocks base method.
+func (m *MockWaitlistRepository) GetOfferByToken(token string) (*models.WaitlistOffer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOfferByToken", token)
+	ret0, _ := ret[0].(*models.WaitlistOffer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOfferByToken indicates an expected call of GetOfferByToken.
+func (mr *MockWaitlistRepositoryMockRecorder) GetOfferByToken(token interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOfferByToken", reflect.TypeOf((*MockWaitlistRepository)(nil).GetOfferByToken), token)
+}
+
+// ListEntries mocks base method.
+func (m *MockWaitlistRepository) ListEntries(status string) ([]models.WaitlistEntry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListEntries", status)
+	ret0, _ := ret[0].([]models.WaitlistEntry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListEntries indicates an expected call of ListEntries.
+func (mr *MockWaitlistRepositoryMockRecorder) ListEntries(status interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListEntries", reflect.TypeOf((*MockWaitlistRepository)(nil).ListEntries), status)
+}
+
+// MarkOfferTaken mocks base method.
+func (m *MockWaitlistRepository) MarkOfferTaken(offerID int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkOfferTaken", offerID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkOfferTaken indicates an expected call of MarkOfferTaken.
+func (mr *MockWaitlistRepositoryMockRecorder) MarkOfferTaken(offerID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkOfferTaken", reflect.TypeOf((*MockWaitlistRepository)(nil).MarkOfferTaken), offerID)
+}
+
+// UpdateEntryStatus mocks base method.
+func (m *MockWaitlistRepository) UpdateEntryStatus(id int, status string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateEntryStatus", id, status)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateEntryStatus indicates an expected call of UpdateEntryStatus.
+func (mr *MockWaitlistRepositoryMockRecorder) UpdateEntryStatus(id, status interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateEntryStatus", reflect.TypeOf((*MockWaitlistRepository)(nil).UpdateEntryStatus), id, status)
+}
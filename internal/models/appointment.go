@@ -9,4 +9,171 @@ type Appointment struct {
 	Name      string        `json:"name"`
 	Start     time.Time     `json:"start"`
 	Duration  time.Duration `json:"duration"`
+	SeriesID  *int          `json:"series_id,omitempty"`
+
+	// Resources the appointment is booked with, appointments without either
+	// share a single clinic-wide schedule
+	ProviderID *int `json:"provider_id,omitempty"`
+	RoomID     *int `json:"room_id,omitempty"`
+
+	// Type of visit, its buffer is kept free after the appointment
+	AppointmentTypeID *int          `json:"appointment_type_id,omitempty"`
+	Buffer            time.Duration `json:"buffer,omitempty"`
+
+	// Lifecycle status, the timestamp & user refer to the latest status change
+	Status          AppointmentStatus `json:"status"`
+	StatusChangedAt *time.Time        `json:"status_changed_at,omitempty"`
+	StatusChangedBy *int              `json:"status_changed_by,omitempty"`
+
+	// Consultation started from the appointment, if any
+	ConsultationID *int `json:"consultation_id,omitempty"`
+
+	// Last change to the row, the sequence counts changes to its slot or status
+	UpdatedAt time.Time `json:"updated_at"`
+	Sequence  int       `json:"sequence"`
+
+	// Booking rules to override when booking or moving the appointment, not stored
+	Override *RuleOverrideRequest `json:"override,omitempty"`
+}
+
+// Status of an appointment through its lifecycle
+type AppointmentStatus string
+
+const (
+	StatusScheduled      AppointmentStatus = "scheduled"
+	StatusConfirmed      AppointmentStatus = "confirmed"
+	StatusCheckedIn      AppointmentStatus = "checked_in"
+	StatusInConsultation AppointmentStatus = "in_consultation"
+	StatusCompleted      AppointmentStatus = "completed"
+	StatusCancelled      AppointmentStatus = "cancelled"
+	StatusNoShow         AppointmentStatus = "no_show"
+)
+
+// Valid status transitions, terminal statuses have none
+var appointmentStatusTransitions = map[AppointmentStatus][]AppointmentStatus{
+	StatusScheduled:      {StatusConfirmed, StatusCheckedIn, StatusCancelled, StatusNoShow},
+	StatusConfirmed:      {StatusCheckedIn, StatusCancelled, StatusNoShow},
+	StatusCheckedIn:      {StatusInConsultation, StatusCancelled},
+	StatusInConsultation: {StatusCompleted},
+	StatusCompleted:      {},
+	StatusCancelled:      {},
+	StatusNoShow:         {},
+}
+
+// Returns true if the status is one of the known statuses
+func (s AppointmentStatus) IsValid() bool {
+	_, ok := appointmentStatusTransitions[s]
+	return ok
+}
+
+// Returns true if an appointment can move from this status to 'next'
+func (s AppointmentStatus) CanTransitionTo(next AppointmentStatus) bool {
+	for _, allowed := range appointmentStatusTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns true if the appointment no longer takes up its time slot
+func (s AppointmentStatus) ReleasesSlot() bool {
+	return s == StatusCancelled || s == StatusNoShow
+}
+
+// Returns true if no further status changes are possible
+func (s AppointmentStatus) IsTerminal() bool {
+	return len(appointmentStatusTransitions[s]) == 0
+}
+
+// A single recorded status change of an appointment
+type AppointmentStatusChange struct {
+	ID            int               `json:"id"`
+	AppointmentID int               `json:"appointment_id"`
+	From          AppointmentStatus `json:"from"`
+	To            AppointmentStatus `json:"to"`
+	ChangedAt     time.Time         `json:"changed_at"`
+	ChangedBy     *int              `json:"changed_by,omitempty"`
+}
+
+// Represents a recurring appointment series, the rule is a subset of
+// RFC 5545 RRULE (FREQ, INTERVAL, COUNT, UNTIL, BYDAY) and each occurrence
+// is expanded into its own appointment
+type AppointmentSeries struct {
+	ID         int           `json:"id"`
+	PatientID  int           `json:"patient_id"`
+	Name       string        `json:"name"`
+	Start      time.Time     `json:"start"`
+	Duration   time.Duration `json:"duration"`
+	RRule      string        `json:"rrule"`
+	Exceptions []string      `json:"exceptions,omitempty"` // Dates (YYYY-MM-DD) skipped by the series
+	ProviderID *int          `json:"provider_id,omitempty"`
+	RoomID     *int          `json:"room_id,omitempty"`
+
+	AppointmentTypeID *int `json:"appointment_type_id,omitempty"`
+}
+
+// An occurrence of a series that couldn't be booked & why
+type OccurrenceConflict struct {
+	Start  time.Time `json:"start"`
+	Reason string    `json:"reason"`
+}
+
+// Result of expanding a series into appointments
+type SeriesBookingResult struct {
+	Series    AppointmentSeries    `json:"series"`
+	Booked    []Appointment        `json:"booked"`
+	Conflicts []OccurrenceConflict `json:"conflicts"`
+}
+
+// A bookable time slot
+type TimeSlot struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+// An appointment left outside business hours & the slot proposed to move it to
+type RescheduleProposal struct {
+	Appointment   Appointment `json:"appointment"`
+	ProposedStart *time.Time  `json:"proposed_start,omitempty"`
+	ProposedEnd   *time.Time  `json:"proposed_end,omitempty"`
+	Reason        string      `json:"reason,omitempty"` // Why no slot was proposed
+}
+
+// Move of an appointment to a new start, part of a bulk reschedule
+type RescheduleMove struct {
+	AppointmentID int       `json:"appointment_id"`
+	NewStart      time.Time `json:"new_start"`
+}
+
+// Actions recorded in the audit trail of an appointment, deleting an appointment cancels it
+const (
+	AuditCreate       = "create"
+	AuditUpdate       = "update"
+	AuditDelete       = "delete"
+	AuditStatusChange = "status_change"
+)
+
+// A change made to an appointment, who made it & when
+type AppointmentAuditEntry struct {
+	ID            int                    `json:"id"`
+	AppointmentID int                    `json:"appointment_id"`
+	Action        string                 `json:"action"`
+	UserID        *int                   `json:"user_id,omitempty"`
+	Changes       map[string]FieldChange `json:"changes"` // Keyed by JSON field name
+	CreatedAt     time.Time              `json:"created_at"`
+}
+
+// Value of a field before & after a change, nil when there was none
+type FieldChange struct {
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// Attendance record of a patient, cancellations are late when made shortly before the appointment
+type AttendanceStats struct {
+	Kept          int `json:"kept"`
+	Cancelled     int `json:"cancelled"`
+	CancelledLate int `json:"cancelled_late"`
+	NoShows       int `json:"no_shows"`
 }
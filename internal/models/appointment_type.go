@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// Represents a kind of visit (first visit, follow-up, ...) & the defaults used when booking it
+type AppointmentType struct {
+	ID              int           `json:"id"`
+	Name            string        `json:"name"`
+	DefaultDuration time.Duration `json:"default_duration"`
+	Color           string        `json:"color"`  // Hex colour shown on the calendar, e.g. #1E88E5
+	Buffer          time.Duration `json:"buffer"` // Time kept free after the appointment, e.g. to clean up the room
+	QuestionnaireID *int          `json:"questionnaire_id,omitempty"`
+	Active          bool          `json:"active"`
+}
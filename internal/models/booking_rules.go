@@ -0,0 +1,48 @@
+package models
+
+import "time"
+
+// Limits checked when booking an appointment, a zero value disables the limit. Buffers
+// after a visit are set per appointment type
+type BookingRules struct {
+	MinLeadTime          time.Duration `json:"min_lead_time"`                        // How long in advance appointments have to be booked
+	MaxHorizon           time.Duration `json:"max_horizon"`                          // How far ahead appointments can be booked
+	MaxNewPatientsPerDay int           `json:"max_new_patients_per_day"`             // First visits per clinic day
+	MaxPerPatientPerDay  int           `json:"max_appointments_per_patient_per_day"` // Appointments of a patient per clinic day
+	MaxNoShows           int           `json:"max_no_shows"`                         // No-shows after which a patient's bookings need confirming through an override
+	UpdatedAt            time.Time     `json:"updated_at"`
+	UpdatedBy            *int          `json:"updated_by,omitempty"`
+}
+
+// Names of the booking rules, used to report violations & request overrides
+const (
+	RuleMinLeadTime          = "min_lead_time"
+	RuleMaxHorizon           = "max_horizon"
+	RuleMaxNewPatientsPerDay = "max_new_patients_per_day"
+	RuleMaxPerPatientPerDay  = "max_appointments_per_patient_per_day"
+	RuleMaxNoShows           = "max_no_shows"
+)
+
+// A booking rule an appointment breaks
+type RuleViolation struct {
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// Request to book an appointment despite the listed rules, only privileged users may
+// send one. The user is taken from the session, never from the payload
+type RuleOverrideRequest struct {
+	Rules  []string `json:"rules"`
+	Reason string   `json:"reason"`
+	UserID *int     `json:"-"`
+}
+
+// Record of an appointment booked despite a rule
+type BookingRuleOverride struct {
+	ID            int       `json:"id"`
+	AppointmentID int       `json:"appointment_id"`
+	Rule          string    `json:"rule"`
+	Reason        string    `json:"reason"`
+	UserID        *int      `json:"user_id,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+}
@@ -7,3 +7,49 @@ type BusinessHourInterval struct {
 	Start string `json:"start"`
 	End   string `json:"end"`
 }
+
+// A recurring weekly interval, weekday goes from 1 (Monday) to 7 (Sunday)
+// like horarios_laborales.dia_semana
+type WeeklyHourInterval struct {
+	Weekday int    `json:"weekday"`
+	Start   string `json:"start"`
+	End     string `json:"end"`
+}
+
+// Hours for a specific date replacing the weekly schedule, e.g. holidays. A closed
+// date has no intervals, the clinic doesn't open at all that day
+type SpecialHours struct {
+	Date      string                 `json:"date"`
+	Closed    bool                   `json:"closed"`
+	Intervals []BusinessHourInterval `json:"intervals"`
+}
+
+// A date read from a holiday calendar, closed or on reduced hours
+type HolidayImportEntry struct {
+	SpecialHours
+	Name string `json:"name,omitempty"`
+}
+
+// Outcome of a holiday import, nothing is saved on a dry run. Dates that already had
+// special hours are skipped & left as they were
+type HolidayImportReport struct {
+	DryRun               bool                 `json:"dry_run"`
+	Imported             []HolidayImportEntry `json:"imported"`
+	Skipped              []HolidayImportEntry `json:"skipped"`
+	AffectedAppointments []Appointment        `json:"affected_appointments"`
+}
+
+// Where the hours of a date come from
+const (
+	HoursSourceSpecial = "special"
+	HoursSourceWeekly  = "weekly"
+)
+
+// Effective hours of a date, from its special hours if it has any or else from the
+// weekly schedule. Closed when there are no intervals
+type DayHours struct {
+	Date      string                 `json:"date"`
+	Source    string                 `json:"source"`
+	Closed    bool                   `json:"closed"`
+	Intervals []BusinessHourInterval `json:"intervals"`
+}
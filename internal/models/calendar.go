@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// Token giving read-only access to a user's iCalendar feed, private feeds hide patient names
+type CalendarFeedToken struct {
+	ID         int        `json:"id"`
+	UserID     int        `json:"user_id"`
+	Token      string     `json:"token"`
+	Private    bool       `json:"private"`
+	ProviderID *int       `json:"provider_id,omitempty"` // Only this provider's appointments if set
+	CreatedAt  time.Time  `json:"created_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
@@ -0,0 +1,61 @@
+package models
+
+import "time"
+
+// Channels a patient can be contacted on, only WhatsApp messages are sent for now
+const (
+	ChannelWhatsApp = "whatsapp"
+	ChannelPhone    = "phone"
+	ChannelEmail    = "email"
+)
+
+// Consent statuses, the latest record of a channel is the one in force
+const (
+	ConsentOptIn  = "opt_in"
+	ConsentOptOut = "opt_out"
+)
+
+// Where a consent record came from, 'whatsapp_reply' is set by the webhook when a
+// patient answers STOP or START
+const (
+	ConsentSourceStaff         = "staff"
+	ConsentSourcePatient       = "patient"
+	ConsentSourceWhatsAppReply = "whatsapp_reply"
+)
+
+// A patient opting into or out of a channel, records are never changed
+type ConsentRecord struct {
+	ID        int       `json:"id"`
+	PatientID int       `json:"patient_id"`
+	Channel   string    `json:"channel"`
+	Status    string    `json:"status"`
+	Source    string    `json:"source"`
+	Note      string    `json:"note,omitempty"`
+	UserID    *int      `json:"user_id,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// How a patient wants to be contacted. Consents holds the record in force per channel &
+// is set by the backend
+type ContactPreferences struct {
+	PatientID        int                      `json:"patient_id"`
+	PreferredChannel string                   `json:"preferred_channel,omitempty"`
+	Language         string                   `json:"language,omitempty"` // e.g. "es" or "en_US"
+	DoNotContact     bool                     `json:"do_not_contact"`
+	Consents         map[string]ConsentRecord `json:"consents"`
+	UserID           *int                     `json:"user_id,omitempty"`
+	UpdatedAt        *time.Time               `json:"updated_at,omitempty"`
+}
+
+// Whether a patient can be messaged on a channel: never when they asked not to be
+// contacted, otherwise their latest consent for the channel decides. Without one only
+// their preferred channel, or any when they have none, is allowed
+func (p ContactPreferences) Allows(channel string) bool {
+	if p.DoNotContact {
+		return false
+	}
+	if consent, ok := p.Consents[channel]; ok {
+		return consent.Status == ConsentOptIn
+	}
+	return p.PreferredChannel == "" || p.PreferredChannel == channel
+}
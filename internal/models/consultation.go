@@ -6,6 +6,7 @@ type Consultation struct {
 	ID              int       `json:"id"`
 	PatientID       int       `json:"patient_id"`
 	QuestionnaireID *int      `json:"questionnaire_id,omitempty"`
+	AppointmentID   *int      `json:"appointment_id,omitempty"` // Appointment the visit was started from
 	Reason          string    `json:"reason"`
 	Date            time.Time `json:"date"`
 }
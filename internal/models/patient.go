@@ -10,14 +10,166 @@ type Patient struct {
 	Phone        string        `json:"phone"`         // Patient's phone number
 	Sex          string        `json:"sex"`           // Patient's sex
 	Antecedentes *Antecedentes `json:"antecedentes,omitempty"`
+
+	// Appointments kept, cancelled & missed, only filled in when getting a single patient
+	Attendance *AttendanceStats `json:"attendance,omitempty"`
+}
+
+// Values stored for a patient's sex
+const (
+	SexMale   = "M"
+	SexFemale = "F"
+	SexOther  = "O"
+)
+
+// Patients younger than this are minors, their notifications go to a guardian
+const AdultAge = 18
+
+// Whether the patient is a minor on the given day, patients without a birth date aren't
+func (p Patient) IsMinor(now time.Time) bool {
+	if p.DateOfBirth.IsZero() {
+		return false
+	}
+	return p.DateOfBirth.AddDate(AdultAge, 0, 0).After(now)
+}
+
+// Kinds of relationships between patients, the related patient is the patient's ...
+const (
+	RelationGuardian         = "guardian"
+	RelationParent           = "parent"
+	RelationSpouse           = "spouse"
+	RelationEmergencyContact = "emergency_contact"
+)
+
+// A patient related to another. When NotificationContact is set, notifications about
+// the patient go to the related patient
+type PatientRelationship struct {
+	ID                  int       `json:"id"`
+	PatientID           int       `json:"patient_id"`
+	RelatedPatientID    int       `json:"related_patient_id"`
+	Type                string    `json:"type"`
+	NotificationContact bool      `json:"notification_contact"`
+	RelatedPatient      *Patient  `json:"related_patient,omitempty"` // Set by the backend
+	CreatedAt           time.Time `json:"created_at"`
+}
+
+// A page of patients & how many there are in total
+type PatientList struct {
+	Patients []Patient `json:"patients"`
+	Total    int       `json:"total"`
+	Limit    int       `json:"limit"`
+	Offset   int       `json:"offset"`
+}
+
+// A stored phone rewritten to E.164
+type PhoneChange struct {
+	PatientID int    `json:"patient_id"`
+	Name      string `json:"name"`
+	Before    string `json:"before"`
+	After     string `json:"after"`
+}
+
+// A stored phone that couldn't be rewritten to E.164 & why
+type PhoneProblem struct {
+	PatientID int    `json:"patient_id"`
+	Name      string `json:"name"`
+	Phone     string `json:"phone"`
+	Error     string `json:"error"`
+}
+
+// What normalizing the stored phones did, or would do on a dry run
+type PhoneNormalizationReport struct {
+	DryRun      bool           `json:"dry_run"`
+	Checked     int            `json:"checked"`
+	Unchanged   int            `json:"unchanged"`
+	Changed     []PhoneChange  `json:"changed"`
+	Unparseable []PhoneProblem `json:"unparseable"`
 }
 
 // Antecedentes (Medical History) for a patient.
 // Defined here as it's tightly coupled with the Patient model.
+// Every change is kept as a new version, who wrote it & when are set by the backend
 type Antecedentes struct {
-	Medical string `json:"medical"`
-	Family  string `json:"family"`
-	Ocular  string `json:"ocular"`
-	Alergic string `json:"alergic"`
-	Other   string `json:"other"`
+	Medical   string    `json:"medical"`
+	Family    string    `json:"family"`
+	Ocular    string    `json:"ocular"`
+	Alergic   string    `json:"alergic"`
+	Other     string    `json:"other"`
+	Version   int       `json:"version,omitempty"`
+	UserID    *int      `json:"user_id,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Two versions of a patient's antecedentes & the fields changed between them, keyed
+// by JSON field name. From is nil when To is the first version
+type AntecedentesDiff struct {
+	PatientID int                    `json:"patient_id"`
+	From      *Antecedentes          `json:"from"`
+	To        Antecedentes           `json:"to"`
+	Changes   map[string]FieldChange `json:"changes"`
+}
+
+// Two patients that may be the same person, the score goes from 0 to 1 & the reasons
+// say what matched
+type DuplicateCandidate struct {
+	Patient   Patient  `json:"patient"`
+	Duplicate Patient  `json:"duplicate"`
+	Score     float64  `json:"score"`
+	Reasons   []string `json:"reasons"`
+}
+
+// Reasons two patients are duplicate candidates
+const (
+	DuplicateSimilarName = "similar_name"
+	DuplicateSameBirth   = "same_date_of_birth"
+	DuplicateSamePhone   = "same_phone"
+)
+
+// Record of a patient merged into another, the merged patient is kept as it was
+// before the merge & Moved counts the rows re-pointed per table
+type PatientMerge struct {
+	ID              int            `json:"id"`
+	PatientID       int            `json:"patient_id"`
+	MergedPatientID int            `json:"merged_patient_id"`
+	MergedPatient   Patient        `json:"merged_patient"`
+	Moved           map[string]int `json:"moved"`
+	UserID          *int           `json:"user_id,omitempty"`
+	CreatedAt       time.Time      `json:"created_at"`
+}
+
+// Kinds of events in a patient's timeline
+const (
+	TimelineAppointment  = "appointment"
+	TimelineConsultation = "consultation"
+	TimelineExam         = "exam"
+	TimelineNotification = "notification"
+)
+
+// Something that happened to a patient, only the field matching the type is set
+type TimelineEvent struct {
+	Type         string                   `json:"type"`
+	ID           int                      `json:"id"`
+	Date         time.Time                `json:"date"`
+	Appointment  *Appointment             `json:"appointment,omitempty"`
+	Consultation *ConsultationWithDetails `json:"consultation,omitempty"`
+	Exam         *Exam                    `json:"exam,omitempty"`
+	Notification *WhatsAppNotification    `json:"notification,omitempty"`
+}
+
+// Which events of a timeline to get, all types when none are given. From is included
+// & To isn't
+type TimelineFilter struct {
+	Types  []string
+	From   *time.Time
+	To     *time.Time
+	Limit  int
+	Offset int
+}
+
+// A page of a patient's timeline, latest events first
+type Timeline struct {
+	Events []TimelineEvent `json:"events"`
+	Total  int             `json:"total"`
+	Limit  int             `json:"limit"`
+	Offset int             `json:"offset"`
 }
@@ -0,0 +1,29 @@
+package models
+
+// Kinds of providers that can attend appointments
+const (
+	ProviderDoctor     = "doctor"
+	ProviderTechnician = "technician"
+)
+
+// Kinds of rooms, equipment is booked the same way as a room
+const (
+	RoomKindRoom      = "room"
+	RoomKindEquipment = "equipment"
+)
+
+// Represents a provider (doctor, technician) appointments are booked with
+type Provider struct {
+	ID     int    `json:"id"`
+	Name   string `json:"name"`
+	Kind   string `json:"kind"`
+	Active bool   `json:"active"`
+}
+
+// Represents a room or piece of equipment appointments are booked in
+type Room struct {
+	ID     int    `json:"id"`
+	Name   string `json:"name"`
+	Kind   string `json:"kind"`
+	Active bool   `json:"active"`
+}
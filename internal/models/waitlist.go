@@ -0,0 +1,50 @@
+package models
+
+import "time"
+
+// Waitlist entry statuses
+const (
+	WaitlistWaiting   = "waiting"
+	WaitlistBooked    = "booked"
+	WaitlistCancelled = "cancelled"
+)
+
+// Waitlist offer statuses, an offer is 'taken' when someone else accepted the slot first
+// or its entry got booked or cancelled
+const (
+	OfferPending  = "pending"
+	OfferAccepted = "accepted"
+	OfferTaken    = "taken"
+)
+
+// A patient waiting for a free slot, dates are YYYY-MM-DD & times of day "15:04",
+// times left empty mean any time of the day
+type WaitlistEntry struct {
+	ID         int           `json:"id"`
+	PatientID  int           `json:"patient_id"`
+	DateFrom   string        `json:"date_from"`
+	DateTo     string        `json:"date_to"`
+	TimeFrom   string        `json:"time_from,omitempty"`
+	TimeTo     string        `json:"time_to,omitempty"`
+	Duration   time.Duration `json:"duration"`
+	ProviderID *int          `json:"provider_id,omitempty"`
+	Notes      string        `json:"notes,omitempty"`
+	Status     string        `json:"status"`
+	CreatedAt  time.Time     `json:"created_at"`
+}
+
+// A freed slot offered to a waitlist entry, the first accepted offer books the slot
+type WaitlistOffer struct {
+	ID                    int           `json:"id"`
+	EntryID               int           `json:"entry_id"`
+	ReleasedAppointmentID int           `json:"released_appointment_id"`
+	Start                 time.Time     `json:"start"`
+	Duration              time.Duration `json:"duration"`
+	ProviderID            *int          `json:"provider_id,omitempty"`
+	RoomID                *int          `json:"room_id,omitempty"`
+	Token                 string        `json:"-"` // Sent to the patient only
+	ExpiresAt             time.Time     `json:"expires_at"`
+	Status                string        `json:"status"`
+	AppointmentID         *int          `json:"appointment_id,omitempty"`
+	CreatedAt             time.Time     `json:"created_at"`
+}
@@ -6,20 +6,22 @@ import (
 
 // WhatsAppConfig stores WhatsApp Business API credentials and configuration
 type WhatsAppConfig struct {
-	ID                   int       `json:"id" db:"id"`
-	PhoneNumberID        string    `json:"phone_number_id" db:"phone_number_id"` // WhatsApp Business Phone Number ID
-	AccessToken          string    `json:"-" db:"access_token"`                  // Bearer token (not exposed in JSON)
-	BusinessAccountID    string    `json:"business_account_id" db:"business_account_id"`
-	WebhookVerifyToken   string    `json:"-" db:"webhook_verify_token"` // Token for webhook verification
-	IsActive             bool      `json:"is_active" db:"is_active"`
-	ReminderEnabled      bool      `json:"reminder_enabled" db:"reminder_enabled"`
-	Reminder3DaysBefore  bool      `json:"reminder_3_days_before" db:"reminder_3_days_before"`
-	Reminder1DayBefore   bool      `json:"reminder_1_day_before" db:"reminder_1_day_before"`
-	Reminder2HoursBefore bool      `json:"reminder_2_hours_before" db:"reminder_2_hours_before"`
-	TemplateNameReminder string    `json:"template_name_reminder" db:"template_name_reminder"` // e.g., "recordatorio_cita"
-	TemplateLangCode     string    `json:"template_lang_code" db:"template_lang_code"`         // e.g., "es" or "es_MX"
-	CreatedAt            time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt            time.Time `json:"updated_at" db:"updated_at"`
+	ID                      int       `json:"id" db:"id"`
+	PhoneNumberID           string    `json:"phone_number_id" db:"phone_number_id"` // WhatsApp Business Phone Number ID
+	AccessToken             string    `json:"-" db:"access_token"`                  // Bearer token (not exposed in JSON)
+	BusinessAccountID       string    `json:"business_account_id" db:"business_account_id"`
+	WebhookVerifyToken      string    `json:"-" db:"webhook_verify_token"` // Token for webhook verification
+	IsActive                bool      `json:"is_active" db:"is_active"`
+	ReminderEnabled         bool      `json:"reminder_enabled" db:"reminder_enabled"`
+	Reminder3DaysBefore     bool      `json:"reminder_3_days_before" db:"reminder_3_days_before"`
+	Reminder1DayBefore      bool      `json:"reminder_1_day_before" db:"reminder_1_day_before"`
+	Reminder2HoursBefore    bool      `json:"reminder_2_hours_before" db:"reminder_2_hours_before"`
+	TemplateNameReminder    string    `json:"template_name_reminder" db:"template_name_reminder"`             // e.g., "recordatorio_cita"
+	TemplateLangCode        string    `json:"template_lang_code" db:"template_lang_code"`                     // e.g., "es" or "es_MX"
+	TemplateNameOffer       string    `json:"template_name_waitlist_offer" db:"template_name_waitlist_offer"` // e.g., "oferta_cita"
+	TemplateNameRescheduled string    `json:"template_name_rescheduled" db:"template_name_rescheduled"`       // e.g., "cita_reprogramada"
+	CreatedAt               time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt               time.Time `json:"updated_at" db:"updated_at"`
 }
 
 // WhatsAppNotification tracks sent WhatsApp reminders
@@ -35,6 +37,7 @@ type WhatsAppNotification struct {
 	SentAt        time.Time  `json:"sent_at" db:"sent_at"`
 	DeliveredAt   *time.Time `json:"delivered_at,omitempty" db:"delivered_at"`
 	ReadAt        *time.Time `json:"read_at,omitempty" db:"read_at"`
+	PreviousStart *time.Time `json:"previous_start,omitempty" db:"previous_start"` // Rescheduled notices only
 	CreatedAt     time.Time  `json:"created_at" db:"created_at"`
 	UpdatedAt     time.Time  `json:"updated_at" db:"updated_at"`
 }
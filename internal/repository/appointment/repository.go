@@ -2,18 +2,45 @@ package appointment
 
 import (
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"log"
+	"strings"
 	"time"
 
+	"software-backend/internal/clinic"
 	"software-backend/internal/models"
 
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 )
 
 // Custom errors, probably gonna be moved
-var ErrAppointmentNotFound = errors.New("appointment not found in repository")
+var (
+	ErrAppointmentNotFound = errors.New("appointment not found in repository")
+	ErrSeriesNotFound      = errors.New("appointment series not found in repository")
+	ErrStatusChanged       = errors.New("appointment status changed concurrently")
+	ErrAppointmentOverlap  = errors.New("appointment overlaps an existing one in repository")
+	ErrConsultationExists  = errors.New("appointment already has a consultation in repository")
+)
+
+// SQLSTATE raised when a row violates an exclusion constraint, see migrations/004
+const exclusionViolation = "23P01"
+
+// SQLSTATE raised when a row violates a unique constraint
+const uniqueViolation = "23505"
+
+// Returns true if the error is the database rejecting an overlapping appointment
+func isExclusionViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == exclusionViolation
+}
+
+// Returns true if the error is the database rejecting a duplicate value
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == uniqueViolation
+}
 
 // Interface for appointment data operations
 type AppointmentRepository interface {
@@ -22,12 +49,43 @@ type AppointmentRepository interface {
 	UpdateAppointment(appointment models.Appointment) error
 	DeleteAppointment(id int) error
 	ListAppointmentsInDateRange(startTime, endTime time.Time) ([]models.Appointment, error)
-	HasOverlappingAppointment(start, end time.Time, excludeID *int) (bool, error)
+	ListAppointmentsByIDs(ids []int) ([]models.Appointment, error)
+	ListProviderAppointmentsInDateRange(startTime, endTime time.Time, providerID int) ([]models.Appointment, error)
+	HasOverlappingAppointment(start, end time.Time, excludeID, providerID, roomID *int) (bool, error)
+	CountPatientAppointmentsInRange(patientID int, start, end time.Time, excludeID *int) (int, error)
+	CountNewPatientAppointmentsInRange(start, end time.Time, excludeID *int) (int, error)
+	HasCompletedAppointmentBefore(patientID int, before time.Time) (bool, error)
+	ListUncheckedAppointments(from, to time.Time) ([]models.Appointment, error)
+	GetAttendanceStats(patientID int, lateCancellation time.Duration) (*models.AttendanceStats, error)
+	CreateSeries(series models.AppointmentSeries) (*models.AppointmentSeries, error)
+	GetSeriesByID(id int) (*models.AppointmentSeries, error)
+	UpdateSeries(series models.AppointmentSeries) error
+	CancelSeriesAppointmentsFrom(seriesID int, from time.Time, userID *int) ([]models.Appointment, error)
+	UpdateAppointmentStatus(id int, from, to models.AppointmentStatus, userID *int) error
+	RescheduleAppointments(moves []models.RescheduleMove) error
+	CreateAuditEntry(entry models.AppointmentAuditEntry) error
+	CreateRuleOverride(override models.BookingRuleOverride) (*models.BookingRuleOverride, error)
+	CreateConsultation(consultation models.Consultation) (int, error)
+	GetAuditTrail(id int) ([]models.AppointmentAuditEntry, error)
+	GetStatusHistory(id int) ([]models.AppointmentStatusChange, error)
+	WithTransaction(fn func(repo AppointmentRepository) error) error
+}
+
+// Anything with a Scan method, sql.Row & sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// Queries run on the database, or on the transaction of a repository from WithTransaction
+type dbtx interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
 }
 
 // Struct to manage dependencies
 type appointmentRepository struct {
-	db *sql.DB
+	db dbtx
 }
 
 // Constructor to pass on dependencies
@@ -37,6 +95,38 @@ func NewAppointmentRepository(dbConn *sql.DB) AppointmentRepository {
 	}
 }
 
+// Run 'fn' with a repository whose queries all go through one transaction, committed
+// if 'fn' succeeds & rolled back otherwise. Nested calls join the outer transaction
+func (r *appointmentRepository) WithTransaction(fn func(repo AppointmentRepository) error) error {
+	return r.inTransaction(func(tx dbtx) error {
+		return fn(&appointmentRepository{db: tx})
+	})
+}
+
+// Run 'fn' in a transaction, the repository's own one if it already has one
+func (r *appointmentRepository) inTransaction(fn func(tx dbtx) error) error {
+	db, ok := r.db.(*sql.DB)
+	if !ok {
+		return fn(r.db)
+	}
+
+	// Start transaction
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("repository: failed to begin transaction: %w", err)
+	}
+	// Rollback if error
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("repository: failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
 // Get an appointment by ID
 func (r *appointmentRepository) GetAppointmentByID(id int) (*models.Appointment, error) {
 	// Build query
@@ -46,33 +136,73 @@ func (r *appointmentRepository) GetAppointmentByID(id int) (*models.Appointment,
             paciente_id,
             nombre,
             fecha,
-            duracion
+            duracion,
+            serie_id,
+            estado,
+            estado_actualizado,
+            estado_usuario_id,
+            proveedor_id,
+            sala_id,
+            tipo_cita_id,
+            margen,
+            actualizado,
+            secuencia,
+            (SELECT id FROM consultas WHERE consultas.cita_id = citas.id) AS consulta_id
         FROM
             citas
         WHERE
             id = $1
 	`
 
+	// Scan into model
+	appt, err := scanAppointment(r.db.QueryRow(query, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrAppointmentNotFound
+		}
+		return nil, fmt.Errorf("repository: failed to get appointment by ID %d: %w", id, err)
+	}
+
+	return appt, nil
+}
+
+// Scan a row with the appointment columns, in the order used by the SELECT queries
+func scanAppointment(row rowScanner) (*models.Appointment, error) {
 	// Create model
 	appt := &models.Appointment{}
 	var durationSeconds int64
 	var patientName sql.NullString
 	var patientID sql.NullInt64
+	var seriesID sql.NullInt64
+	var statusChangedAt sql.NullTime
+	var statusChangedBy sql.NullInt64
+	var providerID sql.NullInt64
+	var roomID sql.NullInt64
+	var typeID sql.NullInt64
+	var bufferSeconds int64
+	var consultationID sql.NullInt64
 	var fecha time.Time
 
-	// Scan into model
-	err := r.db.QueryRow(query, id).Scan(
+	err := row.Scan(
 		&appt.ID,
 		&patientID,
 		&patientName,
 		&fecha,
 		&durationSeconds,
+		&seriesID,
+		&appt.Status,
+		&statusChangedAt,
+		&statusChangedBy,
+		&providerID,
+		&roomID,
+		&typeID,
+		&bufferSeconds,
+		&appt.UpdatedAt,
+		&appt.Sequence,
+		&consultationID,
 	)
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return nil, ErrAppointmentNotFound
-		}
-		return nil, fmt.Errorf("repository: failed to get appointment by ID %d: %w", id, err)
+		return nil, err
 	}
 
 	// Null handling
@@ -86,20 +216,79 @@ func (r *appointmentRepository) GetAppointmentByID(id int) (*models.Appointment,
 	} else {
 		appt.Name = ""
 	}
+	if seriesID.Valid {
+		sID := int(seriesID.Int64)
+		appt.SeriesID = &sID
+	}
+	if statusChangedAt.Valid {
+		changedAt := clinic.In(statusChangedAt.Time)
+		appt.StatusChangedAt = &changedAt
+	}
+	if statusChangedBy.Valid {
+		userID := int(statusChangedBy.Int64)
+		appt.StatusChangedBy = &userID
+	}
+	appt.ProviderID = nullableInt(providerID)
+	appt.RoomID = nullableInt(roomID)
+	appt.AppointmentTypeID = nullableInt(typeID)
+	appt.ConsultationID = nullableInt(consultationID)
 
-	// Time - Interval management, Postgres is storing a BigInt in seconds
-	appt.Start = fecha
+	// Time - Interval management, Postgres is storing a BigInt in seconds. Times are
+	// returned in clinic time so they carry the clinic's offset
+	appt.Start = clinic.In(fecha)
 
 	appt.Duration = time.Duration(durationSeconds) * time.Second
+	appt.Buffer = time.Duration(bufferSeconds) * time.Second
 
 	return appt, nil
 }
 
+// Get several appointments at once, in no particular order. IDs not found are left out
+func (r *appointmentRepository) ListAppointmentsByIDs(ids []int) ([]models.Appointment, error) {
+	query := `
+		SELECT
+            id,
+            paciente_id,
+            nombre,
+            fecha,
+            duracion,
+            serie_id,
+            estado,
+            estado_actualizado,
+            estado_usuario_id,
+            proveedor_id,
+            sala_id,
+            tipo_cita_id,
+            margen,
+            actualizado,
+            secuencia,
+            (SELECT id FROM consultas WHERE consultas.cita_id = citas.id) AS consulta_id
+        FROM
+            citas
+        WHERE
+            id = ANY($1)
+	`
+	appointments, err := r.queryAppointments(query, pq.Array(ids))
+	if err != nil {
+		return nil, fmt.Errorf("repository: failed to list appointments by ID: %w", err)
+	}
+	return appointments, nil
+}
+
+// Convert a nullable integer column into an optional ID
+func nullableInt(value sql.NullInt64) *int {
+	if !value.Valid {
+		return nil
+	}
+	id := int(value.Int64)
+	return &id
+}
+
 // Create an appointment
 func (r *appointmentRepository) CreateAppointment(appointment models.Appointment) (*models.Appointment, error) {
 	// Build query
-	query := `INSERT INTO citas (paciente_id, nombre, fecha, duracion)
-			  VALUES ($1, $2, $3, $4)
+	query := `INSERT INTO citas (paciente_id, nombre, fecha, duracion, serie_id, estado, proveedor_id, sala_id, tipo_cita_id, margen)
+			  VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
 			  RETURNING id`
 
 	// Manage nullable patientID & create appointmentID
@@ -125,8 +314,18 @@ func (r *appointmentRepository) CreateAppointment(appointment models.Appointment
 		appointment.Name,
 		appointment.Start,
 		durationSeconds,
+		appointment.SeriesID,
+		appointment.Status,
+		appointment.ProviderID,
+		appointment.RoomID,
+		appointment.AppointmentTypeID,
+		int64(appointment.Buffer/time.Second),
 	).Scan(&appointmentID)
 	if err != nil {
+		// Another booking took the slot after it was checked
+		if isExclusionViolation(err) {
+			return nil, ErrAppointmentOverlap
+		}
 		return nil, fmt.Errorf("repository: failed to create appointment: %w", err)
 	}
 
@@ -142,8 +341,12 @@ func (r *appointmentRepository) UpdateAppointment(appointment models.Appointment
 				paciente_id = $1,
 				nombre = $2,
 				fecha = $3,
-				duracion = $4
-			  WHERE id = $5`
+				duracion = $4,
+				proveedor_id = $5,
+				sala_id = $6,
+				tipo_cita_id = $7,
+				margen = $8
+			  WHERE id = $9`
 
 	// PatientID null management
 	var patientIDValue interface{}
@@ -162,9 +365,16 @@ func (r *appointmentRepository) UpdateAppointment(appointment models.Appointment
 		appointment.Name,
 		appointment.Start,
 		durationSeconds,
+		appointment.ProviderID,
+		appointment.RoomID,
+		appointment.AppointmentTypeID,
+		int64(appointment.Buffer/time.Second),
 		appointment.ID,
 	)
 	if err != nil {
+		if isExclusionViolation(err) {
+			return ErrAppointmentOverlap
+		}
 		return fmt.Errorf("repository: failed to update appointment ID %d: %w", appointment.ID, err)
 	}
 	rowsAffected, err := result.RowsAffected()
@@ -204,7 +414,18 @@ func (r *appointmentRepository) ListAppointmentsInDateRange(startTime, endTime t
             paciente_id,
             nombre,
             fecha,
-            duracion
+            duracion,
+            serie_id,
+            estado,
+            estado_actualizado,
+            estado_usuario_id,
+            proveedor_id,
+            sala_id,
+            tipo_cita_id,
+            margen,
+            actualizado,
+            secuencia,
+            (SELECT id FROM consultas WHERE consultas.cita_id = citas.id) AS consulta_id
         FROM
             citas
         WHERE
@@ -220,11 +441,60 @@ func (r *appointmentRepository) ListAppointmentsInDateRange(startTime, endTime t
 	log.Printf("Repository: Parameter 2 ($2): Value=%v, Type=%T, Location=%v", queryEndTime, queryEndTime, queryEndTime.Location())
 
 	// Exec query
-	rows, err := r.db.Query(query, queryStartTime, queryEndTime)
+	appointments, err := r.queryAppointments(query, queryStartTime, queryEndTime)
 	if err != nil {
-		log.Printf("Repository: Error during db.Query: %v", err)
+		log.Printf("Repository: Error listing appointments: %v", err)
 		return nil, fmt.Errorf("repository: failed to list appointments in date range %v to %v: %w", startTime, endTime, err)
 	}
+
+	log.Printf("Repository: Successfully listed %d appointments.", len(appointments))
+
+	// Return resulting slice
+	return appointments, nil
+}
+
+// Get the appointments of a single provider within a date range
+func (r *appointmentRepository) ListProviderAppointmentsInDateRange(startTime, endTime time.Time, providerID int) ([]models.Appointment, error) {
+	// Build query
+	query := `
+		SELECT
+            id,
+            paciente_id,
+            nombre,
+            fecha,
+            duracion,
+            serie_id,
+            estado,
+            estado_actualizado,
+            estado_usuario_id,
+            proveedor_id,
+            sala_id,
+            tipo_cita_id,
+            margen,
+            actualizado,
+            secuencia,
+            (SELECT id FROM consultas WHERE consultas.cita_id = citas.id) AS consulta_id
+        FROM
+            citas
+        WHERE
+            fecha BETWEEN $1 AND $2
+            AND proveedor_id = $3
+        ORDER BY fecha
+	`
+
+	appointments, err := r.queryAppointments(query, startTime, endTime, providerID)
+	if err != nil {
+		return nil, fmt.Errorf("repository: failed to list appointments of provider %d in date range %v to %v: %w", providerID, startTime, endTime, err)
+	}
+	return appointments, nil
+}
+
+// Run a SELECT returning the appointment columns & scan every row
+func (r *appointmentRepository) queryAppointments(query string, args ...interface{}) ([]models.Appointment, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
 	defer rows.Close()
 
 	// Scan into appointment slice
@@ -232,69 +502,56 @@ func (r *appointmentRepository) ListAppointmentsInDateRange(startTime, endTime t
 	rowCount := 0
 	for rows.Next() {
 		rowCount++
-		var appt models.Appointment
-		var durationSeconds int64
-		var patientName sql.NullString
-		var patientID sql.NullInt64
-		var fecha time.Time
-
-		err := rows.Scan(
-			&appt.ID,
-			&patientID,
-			&patientName,
-			&fecha,
-			&durationSeconds,
-		)
+		appt, err := scanAppointment(rows)
 		if err != nil {
 			log.Printf("repository: error scanning row %d: %v", rowCount, err)
 			continue
 		}
 
-		if patientID.Valid {
-			appt.PatientID = int(patientID.Int64)
-		} else {
-			appt.PatientID = 0
-		}
-		if patientName.Valid {
-			appt.Name = patientName.String
-		} else {
-			appt.Name = ""
-		}
-
-		appt.Start = fecha
-
-		appt.Duration = time.Duration(durationSeconds) * time.Second // Convert seconds to Duration
-
-		appointments = append(appointments, appt)
+		appointments = append(appointments, *appt)
 	}
 
 	if err = rows.Err(); err != nil {
 		log.Printf("repository: error after iterating rows: %v", err)
-		return nil, fmt.Errorf("repository: error after iterating appointment rows in date range: %w", err)
+		return nil, fmt.Errorf("error after iterating appointment rows: %w", err)
 	}
-
-	log.Printf("Repository: Successfully listed %d appointments.", len(appointments))
-
-	// Return resulting slice
 	return appointments, nil
 }
 
-// Verify if an appointment is overlapping with another
-func (r *appointmentRepository) HasOverlappingAppointment(start, end time.Time, excludeID *int) (bool, error) {
+// Verify if an appointment is overlapping with another one sharing its provider or room,
+// appointments without either are only checked against others without either. The end
+// should include the appointment's buffer, the buffer of existing ones is accounted for here
+func (r *appointmentRepository) HasOverlappingAppointment(start, end time.Time, excludeID, providerID, roomID *int) (bool, error) {
 	// Build query
 	query := `
 		SELECT 1 FROM citas
 		WHERE NOT (
-			(fecha + make_interval(secs => duracion)) <= $1
+			(fecha + make_interval(secs => duracion + margen)) <= $1
 			OR fecha >= $2
 		)
+		AND estado NOT IN ('cancelled', 'no_show')
 	`
 	// Dynamically build args
 	args := []interface{}{start, end}
 	if excludeID != nil {
-		query += " AND id != $3"
 		args = append(args, *excludeID)
+		query += fmt.Sprintf(" AND id != $%d", len(args))
+	}
+
+	// Scope the check to the resources being booked
+	var scopes []string
+	if providerID != nil {
+		args = append(args, *providerID)
+		scopes = append(scopes, fmt.Sprintf("proveedor_id = $%d", len(args)))
 	}
+	if roomID != nil {
+		args = append(args, *roomID)
+		scopes = append(scopes, fmt.Sprintf("sala_id = $%d", len(args)))
+	}
+	if len(scopes) == 0 {
+		scopes = append(scopes, "(proveedor_id IS NULL AND sala_id IS NULL)")
+	}
+	query += " AND (" + strings.Join(scopes, " OR ") + ")"
 	query += " LIMIT 1"
 
 	row := r.db.QueryRow(query, args...)
@@ -308,3 +565,486 @@ func (r *appointmentRepository) HasOverlappingAppointment(start, end time.Time,
 	}
 	return true, nil // Overlap found
 }
+
+// Count a patient's appointments starting in a range, cancellations & no-shows don't count
+func (r *appointmentRepository) CountPatientAppointmentsInRange(patientID int, start, end time.Time, excludeID *int) (int, error) {
+	query := `
+		SELECT COUNT(*) FROM citas
+		WHERE paciente_id = $1
+		AND fecha >= $2 AND fecha < $3
+		AND estado NOT IN ('cancelled', 'no_show')
+		AND ($4::INT IS NULL OR id != $4)
+	`
+	var count int
+	if err := r.db.QueryRow(query, patientID, start, end, excludeID).Scan(&count); err != nil {
+		return 0, fmt.Errorf("repository: failed to count appointments of patient %d: %w", patientID, err)
+	}
+	return count, nil
+}
+
+// Count first visits starting in a range, appointments of unregistered patients or of
+// patients without an earlier completed appointment
+func (r *appointmentRepository) CountNewPatientAppointmentsInRange(start, end time.Time, excludeID *int) (int, error) {
+	query := `
+		SELECT COUNT(*) FROM citas c
+		WHERE c.fecha >= $1 AND c.fecha < $2
+		AND c.estado NOT IN ('cancelled', 'no_show')
+		AND ($3::INT IS NULL OR c.id != $3)
+		AND (c.paciente_id IS NULL OR NOT EXISTS (
+			SELECT 1 FROM citas p
+			WHERE p.paciente_id = c.paciente_id
+			AND p.estado = 'completed'
+			AND p.fecha < c.fecha
+		))
+	`
+	var count int
+	if err := r.db.QueryRow(query, start, end, excludeID).Scan(&count); err != nil {
+		return 0, fmt.Errorf("repository: failed to count new patient appointments: %w", err)
+	}
+	return count, nil
+}
+
+// Returns true if the patient completed an appointment before the given time
+func (r *appointmentRepository) HasCompletedAppointmentBefore(patientID int, before time.Time) (bool, error) {
+	query := `SELECT EXISTS (SELECT 1 FROM citas WHERE paciente_id = $1 AND estado = 'completed' AND fecha < $2)`
+	var exists bool
+	if err := r.db.QueryRow(query, patientID, before).Scan(&exists); err != nil {
+		return false, fmt.Errorf("repository: failed to check visits of patient %d: %w", patientID, err)
+	}
+	return exists, nil
+}
+
+// List the scheduled or confirmed appointments starting in a range, the ones nobody
+// checked in or cancelled
+func (r *appointmentRepository) ListUncheckedAppointments(from, to time.Time) ([]models.Appointment, error) {
+	query := `
+		SELECT
+            id,
+            paciente_id,
+            nombre,
+            fecha,
+            duracion,
+            serie_id,
+            estado,
+            estado_actualizado,
+            estado_usuario_id,
+            proveedor_id,
+            sala_id,
+            tipo_cita_id,
+            margen,
+            actualizado,
+            secuencia,
+            (SELECT id FROM consultas WHERE consultas.cita_id = citas.id) AS consulta_id
+        FROM
+            citas
+        WHERE
+            fecha >= $1 AND fecha < $2
+            AND estado IN ('scheduled', 'confirmed')
+        ORDER BY fecha
+	`
+	appointments, err := r.queryAppointments(query, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("repository: failed to list unchecked appointments: %w", err)
+	}
+	return appointments, nil
+}
+
+// Count a patient's kept, cancelled & missed appointments. Cancellations made less than
+// 'lateCancellation' before the appointment count as late
+func (r *appointmentRepository) GetAttendanceStats(patientID int, lateCancellation time.Duration) (*models.AttendanceStats, error) {
+	query := `
+		SELECT
+			COUNT(*) FILTER (WHERE estado = 'completed'),
+			COUNT(*) FILTER (WHERE estado = 'cancelled'),
+			COUNT(*) FILTER (WHERE estado = 'cancelled' AND estado_actualizado > fecha - make_interval(secs => $2)),
+			COUNT(*) FILTER (WHERE estado = 'no_show')
+		FROM citas
+		WHERE paciente_id = $1
+	`
+	stats := &models.AttendanceStats{}
+	err := r.db.QueryRow(query, patientID, lateCancellation.Seconds()).Scan(
+		&stats.Kept,
+		&stats.Cancelled,
+		&stats.CancelledLate,
+		&stats.NoShows,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("repository: failed to get attendance of patient %d: %w", patientID, err)
+	}
+	return stats, nil
+}
+
+// Create a recurring appointment series, occurrences are inserted separately
+func (r *appointmentRepository) CreateSeries(series models.AppointmentSeries) (*models.AppointmentSeries, error) {
+	// Build query
+	query := `INSERT INTO series_citas (paciente_id, nombre, fecha_inicio, duracion, regla, excepciones, proveedor_id, sala_id, tipo_cita_id)
+			  VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+			  RETURNING id`
+
+	// Manage nullable patientID
+	var patientIDValue interface{}
+	if series.PatientID == 0 {
+		patientIDValue = nil
+	} else {
+		patientIDValue = series.PatientID
+	}
+
+	// Exceptions are stored as a DATE[]
+	exceptions := series.Exceptions
+	if exceptions == nil {
+		exceptions = []string{}
+	}
+
+	err := r.db.QueryRow(query,
+		patientIDValue,
+		series.Name,
+		series.Start,
+		int64(series.Duration/time.Second),
+		series.RRule,
+		pq.Array(exceptions),
+		series.ProviderID,
+		series.RoomID,
+		series.AppointmentTypeID,
+	).Scan(&series.ID)
+	if err != nil {
+		return nil, fmt.Errorf("repository: failed to create appointment series: %w", err)
+	}
+
+	return &series, nil
+}
+
+// Get a recurring appointment series by ID
+func (r *appointmentRepository) GetSeriesByID(id int) (*models.AppointmentSeries, error) {
+	// Build query
+	query := `
+		SELECT
+            id,
+            paciente_id,
+            nombre,
+            fecha_inicio,
+            duracion,
+            regla,
+            excepciones,
+            proveedor_id,
+            sala_id,
+            tipo_cita_id
+        FROM
+            series_citas
+        WHERE
+            id = $1
+	`
+
+	// Create model
+	series := &models.AppointmentSeries{}
+	var durationSeconds int64
+	var patientName sql.NullString
+	var patientID sql.NullInt64
+	var exceptions pq.StringArray
+	var providerID sql.NullInt64
+	var roomID sql.NullInt64
+	var typeID sql.NullInt64
+
+	// Scan into model
+	err := r.db.QueryRow(query, id).Scan(
+		&series.ID,
+		&patientID,
+		&patientName,
+		&series.Start,
+		&durationSeconds,
+		&series.RRule,
+		&exceptions,
+		&providerID,
+		&roomID,
+		&typeID,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrSeriesNotFound
+		}
+		return nil, fmt.Errorf("repository: failed to get appointment series by ID %d: %w", id, err)
+	}
+
+	// Null handling
+	if patientID.Valid {
+		series.PatientID = int(patientID.Int64)
+	}
+	if patientName.Valid {
+		series.Name = patientName.String
+	}
+	series.Duration = time.Duration(durationSeconds) * time.Second
+	series.Exceptions = []string(exceptions)
+	series.ProviderID = nullableInt(providerID)
+	series.RoomID = nullableInt(roomID)
+	series.AppointmentTypeID = nullableInt(typeID)
+
+	return series, nil
+}
+
+// Update a recurring appointment series, doesn't touch its occurrences
+func (r *appointmentRepository) UpdateSeries(series models.AppointmentSeries) error {
+	// Build query
+	query := `UPDATE series_citas SET
+				paciente_id = $1,
+				nombre = $2,
+				fecha_inicio = $3,
+				duracion = $4,
+				regla = $5,
+				excepciones = $6,
+				proveedor_id = $7,
+				sala_id = $8,
+				tipo_cita_id = $9
+			  WHERE id = $10`
+
+	// PatientID null management
+	var patientIDValue interface{}
+	if series.PatientID == 0 {
+		patientIDValue = nil
+	} else {
+		patientIDValue = series.PatientID
+	}
+
+	exceptions := series.Exceptions
+	if exceptions == nil {
+		exceptions = []string{}
+	}
+
+	result, err := r.db.Exec(query,
+		patientIDValue,
+		series.Name,
+		series.Start,
+		int64(series.Duration/time.Second),
+		series.RRule,
+		pq.Array(exceptions),
+		series.ProviderID,
+		series.RoomID,
+		series.AppointmentTypeID,
+		series.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("repository: failed to update appointment series ID %d: %w", series.ID, err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("repository: failed to check rows affected for series update ID %d: %w", series.ID, err)
+	}
+	if rowsAffected == 0 {
+		return ErrSeriesNotFound
+	}
+	return nil
+}
+
+// Cancel the occurrences of a series starting at or after 'from' that haven't been
+// attended yet, recording the status change for each. Returns the cancelled occurrences
+// as they were before, the audit trail is up to the caller
+func (r *appointmentRepository) CancelSeriesAppointmentsFrom(seriesID int, from time.Time, userID *int) ([]models.Appointment, error) {
+	// Old values are read through the self-join so they can be stored in the history & returned
+	query := `
+		WITH cancelled AS (
+			UPDATE citas c
+			SET estado = 'cancelled', estado_actualizado = NOW(), estado_usuario_id = $3
+			FROM citas old
+			WHERE c.id = old.id
+			  AND c.serie_id = $1
+			  AND c.fecha >= $2
+			  AND c.estado IN ('scheduled', 'confirmed')
+			RETURNING old.id, old.paciente_id, old.nombre, old.fecha, old.duracion, old.serie_id,
+			          old.estado, old.estado_actualizado, old.estado_usuario_id, old.proveedor_id,
+			          old.sala_id, old.tipo_cita_id, old.margen, old.actualizado, old.secuencia,
+			          (SELECT id FROM consultas WHERE consultas.cita_id = old.id) AS consulta_id
+		), history AS (
+			INSERT INTO citas_estados (cita_id, estado_anterior, estado, fecha, usuario_id)
+			SELECT id, estado, 'cancelled', NOW(), $3 FROM cancelled
+		)
+		SELECT * FROM cancelled ORDER BY fecha, id
+	`
+	cancelled, err := r.queryAppointments(query, seriesID, from, userID)
+	if err != nil {
+		return nil, fmt.Errorf("repository: failed to cancel occurrences of series ID %d: %w", seriesID, err)
+	}
+	return cancelled, nil
+}
+
+// Move an appointment from one status to another & record the change, fails with
+// ErrStatusChanged if the appointment is no longer in the 'from' status
+func (r *appointmentRepository) UpdateAppointmentStatus(id int, from, to models.AppointmentStatus, userID *int) error {
+	return r.inTransaction(func(tx dbtx) error {
+		// Only update if the status is still the one the caller validated against
+		result, err := tx.Exec(`
+			UPDATE citas
+			SET estado = $1, estado_actualizado = NOW(), estado_usuario_id = $2
+			WHERE id = $3 AND estado = $4
+		`, to, userID, id, from)
+		if err != nil {
+			return fmt.Errorf("repository: failed to update status of appointment %d: %w", id, err)
+		}
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("repository: failed to check rows affected for status of appointment %d: %w", id, err)
+		}
+		if rowsAffected == 0 {
+			return ErrStatusChanged
+		}
+
+		// Record the change
+		_, err = tx.Exec(`
+			INSERT INTO citas_estados (cita_id, estado_anterior, estado, fecha, usuario_id)
+			VALUES ($1, $2, $3, NOW(), $4)
+		`, id, from, to, userID)
+		if err != nil {
+			return fmt.Errorf("repository: failed to record status change of appointment %d: %w", id, err)
+		}
+		return nil
+	})
+}
+
+// Get the status changes of an appointment, oldest first
+func (r *appointmentRepository) GetStatusHistory(id int) ([]models.AppointmentStatusChange, error) {
+	query := `
+		SELECT id, cita_id, estado_anterior, estado, fecha, usuario_id
+		FROM citas_estados
+		WHERE cita_id = $1
+		ORDER BY fecha, id
+	`
+	rows, err := r.db.Query(query, id)
+	if err != nil {
+		return nil, fmt.Errorf("repository: failed to get status history of appointment %d: %w", id, err)
+	}
+	defer rows.Close()
+
+	// Scan into history slice
+	history := []models.AppointmentStatusChange{}
+	for rows.Next() {
+		var change models.AppointmentStatusChange
+		var userID sql.NullInt64
+		if err := rows.Scan(&change.ID, &change.AppointmentID, &change.From, &change.To, &change.ChangedAt, &userID); err != nil {
+			return nil, fmt.Errorf("repository: failed to scan status change: %w", err)
+		}
+		if userID.Valid {
+			uID := int(userID.Int64)
+			change.ChangedBy = &uID
+		}
+		history = append(history, change)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("repository: error after iterating status history rows: %w", err)
+	}
+
+	return history, nil
+}
+
+// Move appointments to their new start all at once, only scheduled or confirmed ones can
+// be moved
+func (r *appointmentRepository) RescheduleAppointments(moves []models.RescheduleMove) error {
+	return r.inTransaction(func(tx dbtx) error {
+		for _, move := range moves {
+			result, err := tx.Exec(`
+				UPDATE citas SET fecha = $1
+				WHERE id = $2 AND estado IN ('scheduled', 'confirmed')
+			`, move.NewStart, move.AppointmentID)
+			if err != nil {
+				if isExclusionViolation(err) {
+					return ErrAppointmentOverlap
+				}
+				return fmt.Errorf("repository: failed to reschedule appointment ID %d: %w", move.AppointmentID, err)
+			}
+			rowsAffected, err := result.RowsAffected()
+			if err != nil {
+				return fmt.Errorf("repository: failed to check rows affected for reschedule ID %d: %w", move.AppointmentID, err)
+			}
+			// Gone or no longer movable since it was checked
+			if rowsAffected == 0 {
+				return ErrStatusChanged
+			}
+		}
+		return nil
+	})
+}
+
+// Record a change made to an appointment
+func (r *appointmentRepository) CreateAuditEntry(entry models.AppointmentAuditEntry) error {
+	changes, err := json.Marshal(entry.Changes)
+	if err != nil {
+		return fmt.Errorf("repository: failed to encode changes of appointment ID %d: %w", entry.AppointmentID, err)
+	}
+	query := `INSERT INTO citas_auditoria (cita_id, accion, usuario_id, cambios) VALUES ($1, $2, $3, $4)`
+	if _, err := r.db.Exec(query, entry.AppointmentID, entry.Action, entry.UserID, changes); err != nil {
+		return fmt.Errorf("repository: failed to record change of appointment ID %d: %w", entry.AppointmentID, err)
+	}
+	return nil
+}
+
+// Record an appointment booked despite a rule, next to the booking when in a transaction
+func (r *appointmentRepository) CreateRuleOverride(override models.BookingRuleOverride) (*models.BookingRuleOverride, error) {
+	query := `INSERT INTO excepciones_reglas (cita_id, regla, motivo, usuario_id)
+			  VALUES ($1, $2, $3, $4)
+			  RETURNING id, creado`
+
+	err := r.db.QueryRow(query,
+		override.AppointmentID,
+		override.Rule,
+		override.Reason,
+		override.UserID,
+	).Scan(&override.ID, &override.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("repository: failed to create rule override for appointment %d: %w", override.AppointmentID, err)
+	}
+	return &override, nil
+}
+
+// Create the consultation started from an appointment, next to its status change when
+// in a transaction. An appointment only ever has one consultation
+func (r *appointmentRepository) CreateConsultation(consultation models.Consultation) (int, error) {
+	query := `INSERT INTO consultas (paciente_id, cuestionario_id, motivo, fecha, cita_id)
+			  VALUES ($1, $2, $3, $4, $5)
+			  RETURNING id`
+
+	var id int
+	err := r.db.QueryRow(query,
+		consultation.PatientID,
+		consultation.QuestionnaireID,
+		consultation.Reason,
+		consultation.Date,
+		consultation.AppointmentID,
+	).Scan(&id)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return 0, ErrConsultationExists
+		}
+		return 0, fmt.Errorf("repository: failed to create consultation of appointment: %w", err)
+	}
+	return id, nil
+}
+
+// Get the changes made to an appointment, oldest first
+func (r *appointmentRepository) GetAuditTrail(id int) ([]models.AppointmentAuditEntry, error) {
+	query := `
+		SELECT id, cita_id, accion, usuario_id, cambios, fecha
+		FROM citas_auditoria
+		WHERE cita_id = $1
+		ORDER BY fecha, id
+	`
+	rows, err := r.db.Query(query, id)
+	if err != nil {
+		return nil, fmt.Errorf("repository: failed to get audit trail for appointment ID %d: %w", id, err)
+	}
+	defer rows.Close()
+
+	entries := []models.AppointmentAuditEntry{}
+	for rows.Next() {
+		var entry models.AppointmentAuditEntry
+		var userID sql.NullInt64
+		var changes []byte
+		if err := rows.Scan(&entry.ID, &entry.AppointmentID, &entry.Action, &userID, &changes, &entry.CreatedAt); err != nil {
+			return nil, fmt.Errorf("repository: failed to scan audit entry: %w", err)
+		}
+		entry.UserID = nullableInt(userID)
+		if err := json.Unmarshal(changes, &entry.Changes); err != nil {
+			return nil, fmt.Errorf("repository: failed to decode changes of audit entry %d: %w", entry.ID, err)
+		}
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("repository: error after iterating audit rows: %w", err)
+	}
+	return entries, nil
+}
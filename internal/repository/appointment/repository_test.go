@@ -6,7 +6,10 @@ import (
 	"testing"
 	"time"
 
+	"software-backend/internal/models"
+
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
 )
 
 func TestGetAppointmentByID_Found(t *testing.T) {
@@ -24,6 +27,7 @@ func TestGetAppointmentByID_Found(t *testing.T) {
 	expectedName := "John Doe"
 	expectedFecha := time.Now().Truncate(time.Second)
 	expectedDuration := int64(3600)
+	expectedSeriesID := int64(7)
 
 	// Set up mock
 	mock.ExpectQuery(regexp.QuoteMeta(`
@@ -32,7 +36,18 @@ func TestGetAppointmentByID_Found(t *testing.T) {
             paciente_id,
             nombre,
             fecha,
-            duracion
+            duracion,
+            serie_id,
+            estado,
+            estado_actualizado,
+            estado_usuario_id,
+            proveedor_id,
+            sala_id,
+            tipo_cita_id,
+            margen,
+            actualizado,
+            secuencia,
+            (SELECT id FROM consultas WHERE consultas.cita_id = citas.id) AS consulta_id
         FROM
             citas
         WHERE
@@ -40,13 +55,26 @@ func TestGetAppointmentByID_Found(t *testing.T) {
     `)).
 		WithArgs(expectedID).
 		WillReturnRows(sqlmock.NewRows([]string{
-			"id", "paciente_id", "nombre", "fecha", "duracion",
+			"id", "paciente_id", "nombre", "fecha", "duracion", "serie_id",
+			"estado", "estado_actualizado", "estado_usuario_id", "proveedor_id", "sala_id",
+			"tipo_cita_id", "margen", "actualizado", "secuencia", "consulta_id",
 		}).AddRow(
 			expectedID,
 			expectedPatientID,
 			expectedName,
 			expectedFecha,
 			expectedDuration,
+			expectedSeriesID,
+			"confirmed",
+			nil,
+			nil,
+			int64(3),
+			nil,
+			int64(2),
+			int64(300),
+			expectedFecha,
+			int64(1),
+			int64(8),
 		))
 
 	// Call method
@@ -59,7 +87,12 @@ func TestGetAppointmentByID_Found(t *testing.T) {
 		appt.PatientID != int(expectedPatientID) ||
 		appt.Name != expectedName ||
 		!appt.Start.Equal(expectedFecha) ||
-		appt.Duration != time.Duration(expectedDuration)*time.Second {
+		appt.Duration != time.Duration(expectedDuration)*time.Second ||
+		appt.SeriesID == nil || *appt.SeriesID != int(expectedSeriesID) ||
+		appt.Status != models.StatusConfirmed || appt.StatusChangedAt != nil ||
+		appt.ProviderID == nil || *appt.ProviderID != 3 || appt.RoomID != nil ||
+		appt.AppointmentTypeID == nil || *appt.AppointmentTypeID != 2 || appt.Buffer != 5*time.Minute ||
+		appt.ConsultationID == nil || *appt.ConsultationID != 8 {
 		t.Errorf("unexpected appointment: %+v", appt)
 	}
 
@@ -86,7 +119,18 @@ func TestGetAppointmentByID_NotFound(t *testing.T) {
             paciente_id,
             nombre,
             fecha,
-            duracion
+            duracion,
+            serie_id,
+            estado,
+            estado_actualizado,
+            estado_usuario_id,
+            proveedor_id,
+            sala_id,
+            tipo_cita_id,
+            margen,
+            actualizado,
+            secuencia,
+            (SELECT id FROM consultas WHERE consultas.cita_id = citas.id) AS consulta_id
         FROM
             citas
         WHERE
@@ -107,3 +151,173 @@ func TestGetAppointmentByID_NotFound(t *testing.T) {
 		t.Errorf("unmet sqlmock expectations: %v", err)
 	}
 }
+
+func TestUpdateAppointmentStatus_StaleStatus(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock database: %v", err)
+	}
+	defer db.Close()
+
+	repo := NewAppointmentRepository(db)
+	userID := 5
+
+	// Appointment is no longer in the expected status, nothing gets recorded
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE citas`)).
+		WithArgs(models.StatusCheckedIn, userID, 1, models.StatusScheduled).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	err = repo.UpdateAppointmentStatus(1, models.StatusScheduled, models.StatusCheckedIn, &userID)
+	if err != ErrStatusChanged {
+		t.Fatalf("expected ErrStatusChanged, got: %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet sqlmock expectations: %v", err)
+	}
+}
+
+func TestWithTransaction_NestedStatusChangeJoinsTransaction(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock database: %v", err)
+	}
+	defer db.Close()
+
+	repo := NewAppointmentRepository(db)
+
+	// A single transaction, rolled back as the status changed concurrently
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE citas`)).
+		WithArgs(models.StatusCancelled, nil, 1, models.StatusScheduled).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	err = repo.WithTransaction(func(tx AppointmentRepository) error {
+		return tx.UpdateAppointmentStatus(1, models.StatusScheduled, models.StatusCancelled, nil)
+	})
+	if err != ErrStatusChanged {
+		t.Fatalf("expected ErrStatusChanged, got: %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet sqlmock expectations: %v", err)
+	}
+}
+
+func TestHasOverlappingAppointment_ScopedToResources(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock database: %v", err)
+	}
+	defer db.Close()
+
+	repo := NewAppointmentRepository(db)
+	start := time.Date(2024, 7, 18, 10, 0, 0, 0, time.UTC)
+	end := start.Add(time.Hour)
+	excludeID, providerID, roomID := 9, 3, 5
+
+	// Only appointments sharing the provider or the room can overlap
+	mock.ExpectQuery(regexp.QuoteMeta(`AND id != $3 AND (proveedor_id = $4 OR sala_id = $5) LIMIT 1`)).
+		WithArgs(start, end, excludeID, providerID, roomID).
+		WillReturnError(sql.ErrNoRows)
+
+	overlap, err := repo.HasOverlappingAppointment(start, end, &excludeID, &providerID, &roomID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if overlap {
+		t.Errorf("expected no overlap")
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet sqlmock expectations: %v", err)
+	}
+}
+
+func TestHasOverlappingAppointment_UnassignedSharesClinicSchedule(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock database: %v", err)
+	}
+	defer db.Close()
+
+	repo := NewAppointmentRepository(db)
+	start := time.Date(2024, 7, 18, 10, 0, 0, 0, time.UTC)
+	end := start.Add(time.Hour)
+
+	mock.ExpectQuery(regexp.QuoteMeta(`AND ((proveedor_id IS NULL AND sala_id IS NULL)) LIMIT 1`)).
+		WithArgs(start, end).
+		WillReturnRows(sqlmock.NewRows([]string{"?column?"}).AddRow(1))
+
+	overlap, err := repo.HasOverlappingAppointment(start, end, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !overlap {
+		t.Errorf("expected overlap")
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet sqlmock expectations: %v", err)
+	}
+}
+
+func TestCreateAppointment_ExclusionViolation(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock database: %v", err)
+	}
+	defer db.Close()
+
+	repo := NewAppointmentRepository(db)
+
+	// Database rejects the insert because the slot was taken concurrently
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO citas`)).
+		WillReturnError(&pq.Error{Code: "23P01", Constraint: "citas_sin_solape_clinica"})
+
+	_, err = repo.CreateAppointment(models.Appointment{
+		PatientID: 1,
+		Start:     time.Date(2024, 7, 18, 10, 0, 0, 0, time.UTC),
+		Duration:  time.Hour,
+		Status:    models.StatusScheduled,
+	})
+	if err != ErrAppointmentOverlap {
+		t.Fatalf("expected ErrAppointmentOverlap, got: %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet sqlmock expectations: %v", err)
+	}
+}
+
+func TestCountNewPatientAppointmentsInRange(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock database: %v", err)
+	}
+	defer db.Close()
+
+	repo := NewAppointmentRepository(db)
+	start := time.Date(2024, 7, 18, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 0, 1)
+
+	// Unregistered patients & patients never seen before count as new
+	mock.ExpectQuery(regexp.QuoteMeta(`AND (c.paciente_id IS NULL OR NOT EXISTS (`)).
+		WithArgs(start, end, nil).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+
+	count, err := repo.CountNewPatientAppointmentsInRange(start, end, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if count != 3 {
+		t.Errorf("expected 3 new patient appointments, got %d", count)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet sqlmock expectations: %v", err)
+	}
+}
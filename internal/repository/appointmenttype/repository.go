@@ -0,0 +1,152 @@
+package appointmenttype
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"software-backend/internal/models"
+)
+
+// Custom errors, probably gonna be moved
+var ErrAppointmentTypeNotFound = errors.New("appointment type not found in repository")
+
+// Interface for appointment type data operations
+type AppointmentTypeRepository interface {
+	ListAppointmentTypes(onlyActive bool) ([]models.AppointmentType, error)
+	GetAppointmentTypeByID(id int) (*models.AppointmentType, error)
+	CreateAppointmentType(apptType models.AppointmentType) (*models.AppointmentType, error)
+	UpdateAppointmentType(apptType models.AppointmentType) error
+}
+
+// Struct to manage dependencies
+type appointmentTypeRepository struct {
+	db *sql.DB
+}
+
+// Constructor to pass on dependencies
+func NewAppointmentTypeRepository(db *sql.DB) AppointmentTypeRepository {
+	return &appointmentTypeRepository{db: db}
+}
+
+// List appointment types, optionally only the active ones
+func (r *appointmentTypeRepository) ListAppointmentTypes(onlyActive bool) ([]models.AppointmentType, error) {
+	query := `SELECT id, nombre, duracion, color, margen, cuestionario_id, activo FROM tipos_cita`
+	if onlyActive {
+		query += ` WHERE activo = TRUE`
+	}
+	query += ` ORDER BY nombre`
+
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("repository: failed to list appointment types: %w", err)
+	}
+	defer rows.Close()
+
+	types := []models.AppointmentType{}
+	for rows.Next() {
+		apptType, err := scanAppointmentType(rows)
+		if err != nil {
+			return nil, fmt.Errorf("repository: failed to scan appointment type: %w", err)
+		}
+		types = append(types, *apptType)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("repository: error after iterating appointment type rows: %w", err)
+	}
+	return types, nil
+}
+
+// Get an appointment type by ID
+func (r *appointmentTypeRepository) GetAppointmentTypeByID(id int) (*models.AppointmentType, error) {
+	query := `SELECT id, nombre, duracion, color, margen, cuestionario_id, activo FROM tipos_cita WHERE id = $1`
+	apptType, err := scanAppointmentType(r.db.QueryRow(query, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrAppointmentTypeNotFound
+		}
+		return nil, fmt.Errorf("repository: failed to get appointment type by ID %d: %w", id, err)
+	}
+	return apptType, nil
+}
+
+// Anything with a Scan method, sql.Row & sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// Scan a row with the appointment type columns, durations are stored in seconds
+func scanAppointmentType(row rowScanner) (*models.AppointmentType, error) {
+	apptType := &models.AppointmentType{}
+	var durationSeconds, bufferSeconds int64
+	var questionnaireID sql.NullInt64
+
+	err := row.Scan(
+		&apptType.ID,
+		&apptType.Name,
+		&durationSeconds,
+		&apptType.Color,
+		&bufferSeconds,
+		&questionnaireID,
+		&apptType.Active,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	apptType.DefaultDuration = time.Duration(durationSeconds) * time.Second
+	apptType.Buffer = time.Duration(bufferSeconds) * time.Second
+	if questionnaireID.Valid {
+		qID := int(questionnaireID.Int64)
+		apptType.QuestionnaireID = &qID
+	}
+	return apptType, nil
+}
+
+// Create an appointment type
+func (r *appointmentTypeRepository) CreateAppointmentType(apptType models.AppointmentType) (*models.AppointmentType, error) {
+	err := r.db.QueryRow(`
+		INSERT INTO tipos_cita (nombre, duracion, color, margen, cuestionario_id, activo)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id
+	`,
+		apptType.Name,
+		int64(apptType.DefaultDuration/time.Second),
+		apptType.Color,
+		int64(apptType.Buffer/time.Second),
+		apptType.QuestionnaireID,
+		apptType.Active,
+	).Scan(&apptType.ID)
+	if err != nil {
+		return nil, fmt.Errorf("repository: failed to create appointment type: %w", err)
+	}
+	return &apptType, nil
+}
+
+// Update an appointment type
+func (r *appointmentTypeRepository) UpdateAppointmentType(apptType models.AppointmentType) error {
+	result, err := r.db.Exec(`
+		UPDATE tipos_cita SET nombre = $1, duracion = $2, color = $3, margen = $4, cuestionario_id = $5, activo = $6
+		WHERE id = $7
+	`,
+		apptType.Name,
+		int64(apptType.DefaultDuration/time.Second),
+		apptType.Color,
+		int64(apptType.Buffer/time.Second),
+		apptType.QuestionnaireID,
+		apptType.Active,
+		apptType.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("repository: failed to update appointment type ID %d: %w", apptType.ID, err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("repository: failed to check rows affected for appointment type ID %d: %w", apptType.ID, err)
+	}
+	if rowsAffected == 0 {
+		return ErrAppointmentTypeNotFound
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package bookingrule
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"software-backend/internal/models"
+)
+
+// Interface for booking rule data operations
+type BookingRuleRepository interface {
+	GetRules() (*models.BookingRules, error)
+	UpdateRules(rules models.BookingRules) (*models.BookingRules, error)
+	ListOverrides(appointmentID int) ([]models.BookingRuleOverride, error)
+}
+
+// Struct to manage dependencies
+type bookingRuleRepository struct {
+	db *sql.DB
+}
+
+// Constructor to pass on dependencies
+func NewBookingRuleRepository(db *sql.DB) BookingRuleRepository {
+	return &bookingRuleRepository{db: db}
+}
+
+// Get the booking rules, no rules apply if the row is missing
+func (r *bookingRuleRepository) GetRules() (*models.BookingRules, error) {
+	query := `
+		SELECT antelacion_minima, horizonte_maximo, max_primera_vez_dia, max_citas_paciente_dia, max_inasistencias, actualizado, actualizado_por
+		FROM reglas_agenda
+		WHERE id
+	`
+	rules := &models.BookingRules{}
+	var leadSeconds, horizonSeconds int64
+	var updatedBy sql.NullInt64
+	err := r.db.QueryRow(query).Scan(
+		&leadSeconds,
+		&horizonSeconds,
+		&rules.MaxNewPatientsPerDay,
+		&rules.MaxPerPatientPerDay,
+		&rules.MaxNoShows,
+		&rules.UpdatedAt,
+		&updatedBy,
+	)
+	if err == sql.ErrNoRows {
+		return &models.BookingRules{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("repository: failed to get booking rules: %w", err)
+	}
+
+	rules.MinLeadTime = time.Duration(leadSeconds) * time.Second
+	rules.MaxHorizon = time.Duration(horizonSeconds) * time.Second
+	if updatedBy.Valid {
+		uID := int(updatedBy.Int64)
+		rules.UpdatedBy = &uID
+	}
+	return rules, nil
+}
+
+// Replace the booking rules
+func (r *bookingRuleRepository) UpdateRules(rules models.BookingRules) (*models.BookingRules, error) {
+	query := `
+		INSERT INTO reglas_agenda (id, antelacion_minima, horizonte_maximo, max_primera_vez_dia, max_citas_paciente_dia, max_inasistencias, actualizado, actualizado_por)
+		VALUES (TRUE, $1, $2, $3, $4, $5, NOW(), $6)
+		ON CONFLICT (id) DO UPDATE SET
+			antelacion_minima = EXCLUDED.antelacion_minima,
+			horizonte_maximo = EXCLUDED.horizonte_maximo,
+			max_primera_vez_dia = EXCLUDED.max_primera_vez_dia,
+			max_citas_paciente_dia = EXCLUDED.max_citas_paciente_dia,
+			max_inasistencias = EXCLUDED.max_inasistencias,
+			actualizado = EXCLUDED.actualizado,
+			actualizado_por = EXCLUDED.actualizado_por
+		RETURNING actualizado
+	`
+	err := r.db.QueryRow(query,
+		int64(rules.MinLeadTime/time.Second),
+		int64(rules.MaxHorizon/time.Second),
+		rules.MaxNewPatientsPerDay,
+		rules.MaxPerPatientPerDay,
+		rules.MaxNoShows,
+		rules.UpdatedBy,
+	).Scan(&rules.UpdatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("repository: failed to update booking rules: %w", err)
+	}
+	return &rules, nil
+}
+
+// List the rules overridden for an appointment, oldest first
+func (r *bookingRuleRepository) ListOverrides(appointmentID int) ([]models.BookingRuleOverride, error) {
+	query := `
+		SELECT id, cita_id, regla, motivo, usuario_id, creado
+		FROM excepciones_reglas
+		WHERE cita_id = $1
+		ORDER BY creado, id
+	`
+	rows, err := r.db.Query(query, appointmentID)
+	if err != nil {
+		return nil, fmt.Errorf("repository: failed to list rule overrides for appointment %d: %w", appointmentID, err)
+	}
+	defer rows.Close()
+
+	overrides := []models.BookingRuleOverride{}
+	for rows.Next() {
+		var override models.BookingRuleOverride
+		var userID sql.NullInt64
+		if err := rows.Scan(&override.ID, &override.AppointmentID, &override.Rule, &override.Reason, &userID, &override.CreatedAt); err != nil {
+			return nil, fmt.Errorf("repository: failed to scan rule override: %w", err)
+		}
+		if userID.Valid {
+			uID := int(userID.Int64)
+			override.UserID = &uID
+		}
+		overrides = append(overrides, override)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("repository: error after iterating rule override rows: %w", err)
+	}
+	return overrides, nil
+}
@@ -2,6 +2,7 @@ package businesshour
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	"time"
 
@@ -11,8 +12,19 @@ import (
 // Interface defines methods to interact with repository
 type BusinessHoursRepository interface {
 	GetBusinessHoursForDate(date time.Time) ([]models.BusinessHourInterval, error)
+	GetProviderWeeklyHours(providerID int) ([]models.WeeklyHourInterval, error)
+	ReplaceProviderWeeklyHours(providerID int, hours []models.WeeklyHourInterval) error
+	GetWeeklyHours() ([]models.WeeklyHourInterval, error)
+	ReplaceWeeklyHours(hours []models.WeeklyHourInterval) error
+	ListSpecialHours(from, to time.Time) ([]models.SpecialHours, error)
+	SetSpecialHours(special models.SpecialHours) error
+	CreateSpecialHours(specials []models.SpecialHours) ([]string, error)
+	DeleteSpecialHours(date time.Time) error
 }
 
+// Custom errors, probably gonna be moved
+var ErrSpecialHoursNotFound = errors.New("special hours not found")
+
 // Struct to manage dependencies
 type businessHoursRepository struct {
 	db *sql.DB
@@ -29,7 +41,7 @@ func (r *businessHoursRepository) GetBusinessHoursForDate(date time.Time) ([]mod
 
 	// Check for special hours (Holiday, etc.)
 	rows, err := r.db.Query(`
-        SELECT hora_apertura, hora_cierre
+        SELECT hora_apertura, hora_cierre, cerrado
         FROM horarios_especiales
         WHERE fecha = $1
     `, date.Format("2006-01-02"))
@@ -37,18 +49,27 @@ func (r *businessHoursRepository) GetBusinessHoursForDate(date time.Time) ([]mod
 		return nil, fmt.Errorf("query special hours: %w", err)
 	}
 	defer rows.Close()
+	found := false
 	for rows.Next() {
-		var start, end time.Time
-		if err := rows.Scan(&start, &end); err != nil {
+		var start, end sql.NullTime
+		var closed bool
+		if err := rows.Scan(&start, &end, &closed); err != nil {
 			return nil, fmt.Errorf("scan special hours: %w", err)
 		}
+		found = true
+		if closed || !start.Valid || !end.Valid {
+			continue
+		}
 		intervals = append(intervals, models.BusinessHourInterval{
-			Start: start.Format("15:04"),
-			End:   end.Format("15:04"),
+			Start: start.Time.Format("15:04"),
+			End:   end.Time.Format("15:04"),
 		})
 	}
-	// Return special working hours if found
-	if len(intervals) > 0 {
+	// Return special working hours if found, closed days have none
+	if found {
+		if intervals == nil {
+			intervals = []models.BusinessHourInterval{}
+		}
 		return intervals, nil
 	}
 
@@ -80,3 +101,287 @@ func (r *businessHoursRepository) GetBusinessHoursForDate(date time.Time) ([]mod
 	// Return regular hours
 	return intervals, nil
 }
+
+// Get the weekly hours of a provider, empty if the provider follows the clinic hours
+func (r *businessHoursRepository) GetProviderWeeklyHours(providerID int) ([]models.WeeklyHourInterval, error) {
+	rows, err := r.db.Query(`
+        SELECT dia_semana, hora_apertura, hora_cierre
+        FROM horarios_proveedores
+        WHERE proveedor_id = $1
+        ORDER BY dia_semana, hora_apertura
+    `, providerID)
+	if err != nil {
+		return nil, fmt.Errorf("query provider hours: %w", err)
+	}
+	defer rows.Close()
+
+	hours := []models.WeeklyHourInterval{}
+	for rows.Next() {
+		var weekday int
+		var start, end time.Time
+		if err := rows.Scan(&weekday, &start, &end); err != nil {
+			return nil, fmt.Errorf("scan provider hours: %w", err)
+		}
+		hours = append(hours, models.WeeklyHourInterval{
+			Weekday: weekday,
+			Start:   start.Format("15:04"),
+			End:     end.Format("15:04"),
+		})
+	}
+	return hours, rows.Err()
+}
+
+// Replace the weekly hours of a provider, an empty list makes it follow the clinic hours
+func (r *businessHoursRepository) ReplaceProviderWeeklyHours(providerID int, hours []models.WeeklyHourInterval) error {
+	// Start transaction
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("begin provider hours transaction: %w", err)
+	}
+	// Rollback if error
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM horarios_proveedores WHERE proveedor_id = $1`, providerID); err != nil {
+		return fmt.Errorf("delete provider hours: %w", err)
+	}
+	for _, h := range hours {
+		_, err := tx.Exec(`
+            INSERT INTO horarios_proveedores (proveedor_id, dia_semana, hora_apertura, hora_cierre)
+            VALUES ($1, $2, $3, $4)
+        `, providerID, h.Weekday, h.Start, h.End)
+		if err != nil {
+			return fmt.Errorf("insert provider hours: %w", err)
+		}
+	}
+
+	// Commit transaction if every query successful
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit provider hours: %w", err)
+	}
+	return nil
+}
+
+// Get the clinic's weekly schedule
+func (r *businessHoursRepository) GetWeeklyHours() ([]models.WeeklyHourInterval, error) {
+	rows, err := r.db.Query(`
+        SELECT dia_semana, hora_apertura, hora_cierre
+        FROM horarios_laborales
+        ORDER BY dia_semana, hora_apertura
+    `)
+	if err != nil {
+		return nil, fmt.Errorf("query regular hours: %w", err)
+	}
+	defer rows.Close()
+
+	hours := []models.WeeklyHourInterval{}
+	for rows.Next() {
+		var weekday int
+		var start, end time.Time
+		if err := rows.Scan(&weekday, &start, &end); err != nil {
+			return nil, fmt.Errorf("scan regular hours: %w", err)
+		}
+		hours = append(hours, models.WeeklyHourInterval{
+			Weekday: weekday,
+			Start:   start.Format("15:04"),
+			End:     end.Format("15:04"),
+		})
+	}
+	return hours, rows.Err()
+}
+
+// Replace the clinic's weekly schedule, weekdays without intervals are closed
+func (r *businessHoursRepository) ReplaceWeeklyHours(hours []models.WeeklyHourInterval) error {
+	// Start transaction
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("begin regular hours transaction: %w", err)
+	}
+	// Rollback if error
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM horarios_laborales`); err != nil {
+		return fmt.Errorf("delete regular hours: %w", err)
+	}
+	for _, h := range hours {
+		_, err := tx.Exec(`
+            INSERT INTO horarios_laborales (dia_semana, hora_apertura, hora_cierre)
+            VALUES ($1, $2, $3)
+        `, h.Weekday, h.Start, h.End)
+		if err != nil {
+			return fmt.Errorf("insert regular hours: %w", err)
+		}
+	}
+
+	// Commit transaction if every query successful
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit regular hours: %w", err)
+	}
+	return nil
+}
+
+// List the special hours between two dates, both included, ordered by date
+func (r *businessHoursRepository) ListSpecialHours(from, to time.Time) ([]models.SpecialHours, error) {
+	rows, err := r.db.Query(`
+        SELECT fecha, hora_apertura, hora_cierre, cerrado
+        FROM horarios_especiales
+        WHERE fecha BETWEEN $1 AND $2
+        ORDER BY fecha, hora_apertura
+    `, from.Format("2006-01-02"), to.Format("2006-01-02"))
+	if err != nil {
+		return nil, fmt.Errorf("query special hours: %w", err)
+	}
+	defer rows.Close()
+
+	// Rows come ordered, a date's intervals are next to each other
+	specials := []models.SpecialHours{}
+	for rows.Next() {
+		var date time.Time
+		var start, end sql.NullTime
+		var closed bool
+		if err := rows.Scan(&date, &start, &end, &closed); err != nil {
+			return nil, fmt.Errorf("scan special hours: %w", err)
+		}
+		day := date.Format("2006-01-02")
+		if len(specials) == 0 || specials[len(specials)-1].Date != day {
+			specials = append(specials, models.SpecialHours{Date: day, Intervals: []models.BusinessHourInterval{}})
+		}
+		special := &specials[len(specials)-1]
+		if closed || !start.Valid || !end.Valid {
+			special.Closed = true
+			continue
+		}
+		special.Intervals = append(special.Intervals, models.BusinessHourInterval{
+			Start: start.Time.Format("15:04"),
+			End:   end.Time.Format("15:04"),
+		})
+	}
+	return specials, rows.Err()
+}
+
+// Set the special hours of a date, replacing what it had
+func (r *businessHoursRepository) SetSpecialHours(special models.SpecialHours) error {
+	// Start transaction
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("begin special hours transaction: %w", err)
+	}
+	// Rollback if error
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM horarios_especiales WHERE fecha = $1`, special.Date); err != nil {
+		return fmt.Errorf("delete special hours: %w", err)
+	}
+	if err := insertSpecialHours(tx, special); err != nil {
+		return err
+	}
+
+	// Commit transaction if every query successful
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit special hours: %w", err)
+	}
+	return nil
+}
+
+// Add the special hours of several dates at once, either all are saved or none. Dates
+// that already have special hours are left as they are & returned as skipped
+func (r *businessHoursRepository) CreateSpecialHours(specials []models.SpecialHours) ([]string, error) {
+	// Start transaction
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("begin special hours transaction: %w", err)
+	}
+	// Rollback if error
+	defer tx.Rollback()
+
+	// Dates have no unique constraint, concurrent imports wait for each other instead
+	if _, err := tx.Exec(`LOCK TABLE horarios_especiales IN SHARE ROW EXCLUSIVE MODE`); err != nil {
+		return nil, fmt.Errorf("lock special hours: %w", err)
+	}
+	skipped := []string{}
+	for _, special := range specials {
+		inserted, err := insertNewSpecialHours(tx, special)
+		if err != nil {
+			return nil, err
+		}
+		if !inserted {
+			skipped = append(skipped, special.Date)
+		}
+	}
+
+	// Commit transaction if every query successful
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit special hours: %w", err)
+	}
+	return skipped, nil
+}
+
+// Insert a date's special hours unless it already has some, reports whether they were
+func insertNewSpecialHours(tx *sql.Tx, special models.SpecialHours) (bool, error) {
+	// The first row only goes in if the date has none, the other intervals follow it
+	var start, end sql.NullString
+	if !special.Closed && len(special.Intervals) > 0 {
+		start = sql.NullString{String: special.Intervals[0].Start, Valid: true}
+		end = sql.NullString{String: special.Intervals[0].End, Valid: true}
+	}
+	result, err := tx.Exec(`
+        INSERT INTO horarios_especiales (fecha, hora_apertura, hora_cierre, cerrado)
+        SELECT $1::date, $2::time, $3::time, $4::boolean
+        WHERE NOT EXISTS (SELECT 1 FROM horarios_especiales WHERE fecha = $1::date)
+    `, special.Date, start, end, special.Closed)
+	if err != nil {
+		return false, fmt.Errorf("insert special hours: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("insert special hours: %w", err)
+	}
+	if rows == 0 {
+		return false, nil
+	}
+	if special.Closed || len(special.Intervals) < 2 {
+		return true, nil
+	}
+	if err := insertSpecialHours(tx, models.SpecialHours{Date: special.Date, Intervals: special.Intervals[1:]}); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Insert the rows of a date's special hours, closed dates are a single row without hours
+func insertSpecialHours(tx *sql.Tx, special models.SpecialHours) error {
+	if special.Closed {
+		_, err := tx.Exec(`
+            INSERT INTO horarios_especiales (fecha, cerrado)
+            VALUES ($1, TRUE)
+        `, special.Date)
+		if err != nil {
+			return fmt.Errorf("insert special hours: %w", err)
+		}
+	}
+	for _, h := range special.Intervals {
+		_, err := tx.Exec(`
+            INSERT INTO horarios_especiales (fecha, hora_apertura, hora_cierre)
+            VALUES ($1, $2, $3)
+        `, special.Date, h.Start, h.End)
+		if err != nil {
+			return fmt.Errorf("insert special hours: %w", err)
+		}
+	}
+	return nil
+}
+
+// Delete the special hours of a date, it goes back to the weekly schedule
+func (r *businessHoursRepository) DeleteSpecialHours(date time.Time) error {
+	result, err := r.db.Exec(`DELETE FROM horarios_especiales WHERE fecha = $1`, date.Format("2006-01-02"))
+	if err != nil {
+		return fmt.Errorf("delete special hours: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("delete special hours: %w", err)
+	}
+	if rowsAffected == 0 {
+		return ErrSpecialHoursNotFound
+	}
+	return nil
+}
@@ -6,6 +6,8 @@ import (
 	"testing"
 	"time"
 
+	"software-backend/internal/models"
+
 	"github.com/DATA-DOG/go-sqlmock"
 )
 
@@ -21,15 +23,16 @@ func TestGetBusinessHoursForDate_SpecialHoursFound(t *testing.T) {
 
 	// Mock special hours query
 	mock.ExpectQuery(regexp.QuoteMeta(`
-        SELECT hora_apertura, hora_cierre
+        SELECT hora_apertura, hora_cierre, cerrado
         FROM horarios_especiales
         WHERE fecha = $1
     `)).
 		WithArgs(testDate.Format("2006-01-02")).
-		WillReturnRows(sqlmock.NewRows([]string{"hora_apertura", "hora_cierre"}).
+		WillReturnRows(sqlmock.NewRows([]string{"hora_apertura", "hora_cierre", "cerrado"}).
 			AddRow(
 				time.Date(0, 1, 1, 9, 0, 0, 0, time.UTC),
 				time.Date(0, 1, 1, 17, 0, 0, 0, time.UTC),
+				false,
 			),
 		)
 
@@ -58,12 +61,12 @@ func TestGetBusinessHoursForDate_RegularHoursFound(t *testing.T) {
 
 	// Mock special hours query (no rows)
 	mock.ExpectQuery(regexp.QuoteMeta(`
-        SELECT hora_apertura, hora_cierre
+        SELECT hora_apertura, hora_cierre, cerrado
         FROM horarios_especiales
         WHERE fecha = $1
     `)).
 		WithArgs(testDate.Format("2006-01-02")).
-		WillReturnRows(sqlmock.NewRows([]string{"hora_apertura", "hora_cierre"}))
+		WillReturnRows(sqlmock.NewRows([]string{"hora_apertura", "hora_cierre", "cerrado"}))
 
 	// Mock regular hours query
 	weekday := int(testDate.Weekday())
@@ -108,12 +111,12 @@ func TestGetBusinessHoursForDate_NoHoursFound(t *testing.T) {
 
 	// Mock special hours query (no rows)
 	mock.ExpectQuery(regexp.QuoteMeta(`
-        SELECT hora_apertura, hora_cierre
+        SELECT hora_apertura, hora_cierre, cerrado
         FROM horarios_especiales
         WHERE fecha = $1
     `)).
 		WithArgs(testDate.Format("2006-01-02")).
-		WillReturnRows(sqlmock.NewRows([]string{"hora_apertura", "hora_cierre"}))
+		WillReturnRows(sqlmock.NewRows([]string{"hora_apertura", "hora_cierre", "cerrado"}))
 
 	// Mock regular hours query (no rows)
 	weekday := int(testDate.Weekday())
@@ -153,7 +156,7 @@ func TestGetBusinessHoursForDate_SpecialHoursQueryError(t *testing.T) {
 
 	// Mock special hours query error
 	mock.ExpectQuery(regexp.QuoteMeta(`
-        SELECT hora_apertura, hora_cierre
+        SELECT hora_apertura, hora_cierre, cerrado
         FROM horarios_especiales
         WHERE fecha = $1
     `)).
@@ -169,3 +172,116 @@ func TestGetBusinessHoursForDate_SpecialHoursQueryError(t *testing.T) {
 		t.Errorf("unmet sqlmock expectations: %v", err)
 	}
 }
+
+func TestGetBusinessHoursForDate_ClosedAllDay(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock database: %v", err)
+	}
+	defer db.Close()
+
+	repo := NewBusinessHoursRepository(db)
+	testDate := time.Date(2024, 12, 25, 0, 0, 0, 0, time.UTC)
+
+	// Closed dates don't fall back to the regular hours
+	mock.ExpectQuery(regexp.QuoteMeta(`
+        SELECT hora_apertura, hora_cierre, cerrado
+        FROM horarios_especiales
+        WHERE fecha = $1
+    `)).
+		WithArgs(testDate.Format("2006-01-02")).
+		WillReturnRows(sqlmock.NewRows([]string{"hora_apertura", "hora_cierre", "cerrado"}).
+			AddRow(nil, nil, true),
+		)
+
+	intervals, err := repo.GetBusinessHoursForDate(testDate)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if intervals == nil || len(intervals) != 0 {
+		t.Errorf("expected no intervals, got: %+v", intervals)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet sqlmock expectations: %v", err)
+	}
+}
+
+func TestListSpecialHours_GroupsByDate(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock database: %v", err)
+	}
+	defer db.Close()
+
+	repo := NewBusinessHoursRepository(db)
+	from := time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery(regexp.QuoteMeta(`FROM horarios_especiales`)).
+		WithArgs("2024-12-01", "2024-12-31").
+		WillReturnRows(sqlmock.NewRows([]string{"fecha", "hora_apertura", "hora_cierre", "cerrado"}).
+			AddRow(time.Date(2024, 12, 24, 0, 0, 0, 0, time.UTC), time.Date(0, 1, 1, 8, 0, 0, 0, time.UTC), time.Date(0, 1, 1, 10, 0, 0, 0, time.UTC), false).
+			AddRow(time.Date(2024, 12, 24, 0, 0, 0, 0, time.UTC), time.Date(0, 1, 1, 11, 0, 0, 0, time.UTC), time.Date(0, 1, 1, 12, 0, 0, 0, time.UTC), false).
+			AddRow(time.Date(2024, 12, 25, 0, 0, 0, 0, time.UTC), nil, nil, true),
+		)
+
+	specials, err := repo.ListSpecialHours(from, to)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(specials) != 2 {
+		t.Fatalf("expected 2 dates, got: %+v", specials)
+	}
+	if specials[0].Date != "2024-12-24" || specials[0].Closed || len(specials[0].Intervals) != 2 {
+		t.Errorf("unexpected first date: %+v", specials[0])
+	}
+	if specials[1].Date != "2024-12-25" || !specials[1].Closed || len(specials[1].Intervals) != 0 {
+		t.Errorf("unexpected second date: %+v", specials[1])
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet sqlmock expectations: %v", err)
+	}
+}
+
+func TestCreateSpecialHours_SkipsDatesWithHours(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock database: %v", err)
+	}
+	defer db.Close()
+
+	repo := NewBusinessHoursRepository(db)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`LOCK TABLE horarios_especiales`)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	// The 24th got special hours since they were listed
+	mock.ExpectExec(regexp.QuoteMeta(`WHERE NOT EXISTS`)).
+		WithArgs("2024-12-24", sql.NullString{String: "08:00", Valid: true}, sql.NullString{String: "10:00", Valid: true}, false).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(regexp.QuoteMeta(`WHERE NOT EXISTS`)).
+		WithArgs("2024-12-31", sql.NullString{String: "08:00", Valid: true}, sql.NullString{String: "10:00", Valid: true}, false).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO horarios_especiales (fecha, hora_apertura, hora_cierre)`)).
+		WithArgs("2024-12-31", "11:00", "12:00").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	intervals := []models.BusinessHourInterval{{Start: "08:00", End: "10:00"}, {Start: "11:00", End: "12:00"}}
+	skipped, err := repo.CreateSpecialHours([]models.SpecialHours{
+		{Date: "2024-12-24", Intervals: intervals},
+		{Date: "2024-12-31", Intervals: intervals},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(skipped) != 1 || skipped[0] != "2024-12-24" {
+		t.Errorf("unexpected skipped dates: %v", skipped)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet sqlmock expectations: %v", err)
+	}
+}
@@ -0,0 +1,140 @@
+package calendar
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"software-backend/internal/models"
+)
+
+// Custom errors, probably gonna be moved
+var ErrFeedTokenNotFound = errors.New("calendar feed token not found in repository")
+
+// Interface for calendar feed token data operations
+type CalendarRepository interface {
+	CreateFeedToken(token models.CalendarFeedToken) (*models.CalendarFeedToken, error)
+	ListFeedTokens(userID int) ([]models.CalendarFeedToken, error)
+	GetActiveFeedToken(token string) (*models.CalendarFeedToken, error)
+	RevokeFeedToken(id, userID int) error
+}
+
+// Struct to manage dependencies
+type calendarRepository struct {
+	db *sql.DB
+}
+
+// Constructor to pass on dependencies
+func NewCalendarRepository(db *sql.DB) CalendarRepository {
+	return &calendarRepository{db: db}
+}
+
+// Create a feed token
+func (r *calendarRepository) CreateFeedToken(token models.CalendarFeedToken) (*models.CalendarFeedToken, error) {
+	err := r.db.QueryRow(`
+		INSERT INTO tokens_calendario (usuario_id, token, privado, proveedor_id)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, creado
+	`, token.UserID, token.Token, token.Private, token.ProviderID).Scan(&token.ID, &token.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("repository: failed to create calendar feed token: %w", err)
+	}
+	return &token, nil
+}
+
+// List the feed tokens of a user, revoked ones included, newest first
+func (r *calendarRepository) ListFeedTokens(userID int) ([]models.CalendarFeedToken, error) {
+	rows, err := r.db.Query(`
+		SELECT id, usuario_id, token, privado, proveedor_id, creado, revocado
+		FROM tokens_calendario
+		WHERE usuario_id = $1
+		ORDER BY creado DESC, id DESC
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("repository: failed to list calendar feed tokens of user %d: %w", userID, err)
+	}
+	defer rows.Close()
+
+	tokens := []models.CalendarFeedToken{}
+	for rows.Next() {
+		token, err := scanFeedToken(rows)
+		if err != nil {
+			return nil, fmt.Errorf("repository: failed to scan calendar feed token: %w", err)
+		}
+		tokens = append(tokens, *token)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("repository: error after iterating calendar feed token rows: %w", err)
+	}
+	return tokens, nil
+}
+
+// Get a feed token that hasn't been revoked
+func (r *calendarRepository) GetActiveFeedToken(token string) (*models.CalendarFeedToken, error) {
+	feedToken, err := scanFeedToken(r.db.QueryRow(`
+		SELECT id, usuario_id, token, privado, proveedor_id, creado, revocado
+		FROM tokens_calendario
+		WHERE token = $1 AND revocado IS NULL
+	`, token))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrFeedTokenNotFound
+		}
+		return nil, fmt.Errorf("repository: failed to get calendar feed token: %w", err)
+	}
+	return feedToken, nil
+}
+
+// Anything with a Scan method, sql.Row & sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// Scan a row with the feed token columns
+func scanFeedToken(row rowScanner) (*models.CalendarFeedToken, error) {
+	token := &models.CalendarFeedToken{}
+	var providerID sql.NullInt64
+	var revokedAt sql.NullTime
+
+	err := row.Scan(
+		&token.ID,
+		&token.UserID,
+		&token.Token,
+		&token.Private,
+		&providerID,
+		&token.CreatedAt,
+		&revokedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// Null handling
+	if providerID.Valid {
+		pID := int(providerID.Int64)
+		token.ProviderID = &pID
+	}
+	if revokedAt.Valid {
+		token.RevokedAt = &revokedAt.Time
+	}
+	return token, nil
+}
+
+// Revoke one of a user's feed tokens, tokens of other users or already revoked are not found
+func (r *calendarRepository) RevokeFeedToken(id, userID int) error {
+	result, err := r.db.Exec(`
+		UPDATE tokens_calendario SET revocado = NOW()
+		WHERE id = $1 AND usuario_id = $2 AND revocado IS NULL
+	`, id, userID)
+	if err != nil {
+		return fmt.Errorf("repository: failed to revoke calendar feed token ID %d: %w", id, err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("repository: failed to check rows affected for calendar feed token ID %d: %w", id, err)
+	}
+	if rowsAffected == 0 {
+		return ErrFeedTokenNotFound
+	}
+	return nil
+}
@@ -0,0 +1,200 @@
+package consent
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"software-backend/internal/models"
+)
+
+// Interface for interaction with repository
+type ConsentRepository interface {
+	GetContactPreferences(patientID int) (*models.ContactPreferences, error)
+	SetContactPreferences(preferences models.ContactPreferences) error
+	CreateConsent(record models.ConsentRecord) (*models.ConsentRecord, error)
+	ListConsents(patientID int) ([]models.ConsentRecord, error)
+	CreateConsentByPhone(phone string, record models.ConsentRecord) ([]int, error)
+}
+
+// Struct to manage dependencies
+type consentRepository struct {
+	db *sql.DB
+}
+
+// Constructor to pass on dependencies
+func NewConsentRepository(db *sql.DB) ConsentRepository {
+	return &consentRepository{db: db}
+}
+
+// Get a patient's contact preferences & the consent in force per channel, patients
+// without preferences get the defaults
+func (r *consentRepository) GetContactPreferences(patientID int) (*models.ContactPreferences, error) {
+	preferences := &models.ContactPreferences{PatientID: patientID, Consents: map[string]models.ConsentRecord{}}
+
+	var channel, language sql.NullString
+	var userID sql.NullInt64
+	var updatedAt time.Time
+	err := r.db.QueryRow(`
+        SELECT canal_preferido, idioma, no_contactar, usuario_id, fecha_actualizacion
+        FROM preferencias_contacto
+        WHERE paciente_id = $1
+    `, patientID).Scan(&channel, &language, &preferences.DoNotContact, &userID, &updatedAt)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("repository: failed to get contact preferences of patient %d: %w", patientID, err)
+	}
+	if err == nil {
+		preferences.PreferredChannel = channel.String
+		preferences.Language = language.String
+		preferences.UpdatedAt = &updatedAt
+		if userID.Valid {
+			id := int(userID.Int64)
+			preferences.UserID = &id
+		}
+	}
+
+	// Latest record of each channel
+	records, err := r.queryConsents(`
+        SELECT DISTINCT ON (canal) id, paciente_id, canal, estado, origen, nota, usuario_id, fecha
+        FROM consentimientos_contacto
+        WHERE paciente_id = $1
+        ORDER BY canal, fecha DESC, id DESC
+    `, patientID)
+	if err != nil {
+		return nil, err
+	}
+	for _, record := range records {
+		preferences.Consents[record.Channel] = record
+	}
+	return preferences, nil
+}
+
+// Create or replace a patient's contact preferences
+func (r *consentRepository) SetContactPreferences(preferences models.ContactPreferences) error {
+	_, err := r.db.Exec(`
+        INSERT INTO preferencias_contacto (paciente_id, canal_preferido, idioma, no_contactar, usuario_id, fecha_actualizacion)
+        VALUES ($1, $2, $3, $4, $5, NOW())
+        ON CONFLICT (paciente_id) DO UPDATE SET
+            canal_preferido = EXCLUDED.canal_preferido,
+            idioma = EXCLUDED.idioma,
+            no_contactar = EXCLUDED.no_contactar,
+            usuario_id = EXCLUDED.usuario_id,
+            fecha_actualizacion = EXCLUDED.fecha_actualizacion
+    `,
+		preferences.PatientID,
+		sql.NullString{String: preferences.PreferredChannel, Valid: preferences.PreferredChannel != ""},
+		sql.NullString{String: preferences.Language, Valid: preferences.Language != ""},
+		preferences.DoNotContact,
+		preferences.UserID,
+	)
+	if err != nil {
+		return fmt.Errorf("repository: failed to set contact preferences of patient %d: %w", preferences.PatientID, err)
+	}
+	return nil
+}
+
+// Record a patient opting into or out of a channel
+func (r *consentRepository) CreateConsent(record models.ConsentRecord) (*models.ConsentRecord, error) {
+	err := r.db.QueryRow(`
+        INSERT INTO consentimientos_contacto (paciente_id, canal, estado, origen, nota, usuario_id)
+        VALUES ($1, $2, $3, $4, $5, $6)
+        RETURNING id, fecha
+    `,
+		record.PatientID,
+		record.Channel,
+		record.Status,
+		record.Source,
+		sql.NullString{String: record.Note, Valid: record.Note != ""},
+		record.UserID,
+	).Scan(&record.ID, &record.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("repository: failed to record consent of patient %d: %w", record.PatientID, err)
+	}
+	return &record, nil
+}
+
+// List every consent record of a patient, latest first
+func (r *consentRepository) ListConsents(patientID int) ([]models.ConsentRecord, error) {
+	return r.queryConsents(`
+        SELECT id, paciente_id, canal, estado, origen, nota, usuario_id, fecha
+        FROM consentimientos_contacto
+        WHERE paciente_id = $1
+        ORDER BY fecha DESC, id DESC
+    `, patientID)
+}
+
+// Record the same consent for every patient with a phone number, given in E.164 like
+// phones are stored. Only exact matches count, so other patients sharing the last digits
+// aren't touched. Returns the IDs of the patients it was recorded for
+func (r *consentRepository) CreateConsentByPhone(phone string, record models.ConsentRecord) ([]int, error) {
+	rows, err := r.db.Query(`
+        INSERT INTO consentimientos_contacto (paciente_id, canal, estado, origen, nota, usuario_id)
+        SELECT id, $2, $3, $4, $5, NULL
+        FROM pacientes
+        WHERE telefono = $1
+        RETURNING paciente_id
+    `,
+		phone,
+		record.Channel,
+		record.Status,
+		record.Source,
+		sql.NullString{String: record.Note, Valid: record.Note != ""},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("repository: failed to record consent for phone %s: %w", phone, err)
+	}
+	defer rows.Close()
+
+	patientIDs := []int{}
+	for rows.Next() {
+		var patientID int
+		if err := rows.Scan(&patientID); err != nil {
+			return nil, fmt.Errorf("repository: failed to scan patient of consent: %w", err)
+		}
+		patientIDs = append(patientIDs, patientID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("repository: error after iterating consents by phone: %w", err)
+	}
+	return patientIDs, nil
+}
+
+// Run a query of consent records
+func (r *consentRepository) queryConsents(query string, args ...interface{}) ([]models.ConsentRecord, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("repository: failed to list consent records: %w", err)
+	}
+	defer rows.Close()
+
+	records := []models.ConsentRecord{}
+	for rows.Next() {
+		var record models.ConsentRecord
+		var note sql.NullString
+		var userID sql.NullInt64
+		err := rows.Scan(
+			&record.ID,
+			&record.PatientID,
+			&record.Channel,
+			&record.Status,
+			&record.Source,
+			&note,
+			&userID,
+			&record.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("repository: failed to scan consent record: %w", err)
+		}
+		record.Note = note.String
+		if userID.Valid {
+			id := int(userID.Int64)
+			record.UserID = &id
+		}
+		records = append(records, record)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("repository: error after iterating consent records: %w", err)
+	}
+	return records, nil
+}
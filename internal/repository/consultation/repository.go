@@ -12,6 +12,7 @@ type ConsultationRepository interface {
 	Create(consultation models.Consultation) (int, error)
 	GetByID(id int) (*models.Consultation, error)
 	GetByPatientID(patientID int) ([]models.Consultation, error)
+	GetByAppointmentID(appointmentID int) (*models.Consultation, error)
 	Update(id int, consultation models.Consultation) error
 	Delete(id int) error
 	GetComplete(id int) (*models.CompleteConsultation, error)
@@ -27,8 +28,8 @@ func NewConsultationRepository(db *sql.DB) ConsultationRepository {
 
 func (r *consultationRepository) Create(consultation models.Consultation) (int, error) {
 	query := `
-		INSERT INTO consultas (paciente_id, cuestionario_id, motivo, fecha)
-		VALUES ($1, $2, $3, $4)
+		INSERT INTO consultas (paciente_id, cuestionario_id, motivo, fecha, cita_id)
+		VALUES ($1, $2, $3, $4, $5)
 		RETURNING id`
 
 	var id int
@@ -38,6 +39,7 @@ func (r *consultationRepository) Create(consultation models.Consultation) (int,
 		consultation.QuestionnaireID,
 		consultation.Reason,
 		consultation.Date,
+		consultation.AppointmentID,
 	).Scan(&id)
 
 	return id, err
@@ -45,12 +47,13 @@ func (r *consultationRepository) Create(consultation models.Consultation) (int,
 
 func (r *consultationRepository) GetByID(id int) (*models.Consultation, error) {
 	query := `
-		SELECT id, paciente_id, cuestionario_id, motivo, fecha 
+		SELECT id, paciente_id, cuestionario_id, motivo, fecha, cita_id
 		FROM consultas 
 		WHERE id = $1`
 
 	var c models.Consultation
 	var questionnaireID sql.NullInt64
+	var appointmentID sql.NullInt64
 
 	err := r.db.QueryRow(query, id).Scan(
 		&c.ID,
@@ -58,6 +61,7 @@ func (r *consultationRepository) GetByID(id int) (*models.Consultation, error) {
 		&questionnaireID,
 		&c.Reason,
 		&c.Date,
+		&appointmentID,
 	)
 	if err != nil {
 		return nil, err
@@ -67,13 +71,17 @@ func (r *consultationRepository) GetByID(id int) (*models.Consultation, error) {
 		qID := int(questionnaireID.Int64)
 		c.QuestionnaireID = &qID
 	}
+	if appointmentID.Valid {
+		aID := int(appointmentID.Int64)
+		c.AppointmentID = &aID
+	}
 
 	return &c, nil
 }
 
 func (r *consultationRepository) GetByPatientID(patientID int) ([]models.Consultation, error) {
 	query := `
-		SELECT id, paciente_id, cuestionario_id, motivo, fecha 
+		SELECT id, paciente_id, cuestionario_id, motivo, fecha, cita_id
 		FROM consultas 
 		WHERE paciente_id = $1 
 		ORDER BY fecha DESC`
@@ -88,6 +96,7 @@ func (r *consultationRepository) GetByPatientID(patientID int) ([]models.Consult
 	for rows.Next() {
 		var c models.Consultation
 		var questionnaireID sql.NullInt64
+		var appointmentID sql.NullInt64
 
 		err := rows.Scan(
 			&c.ID,
@@ -95,6 +104,7 @@ func (r *consultationRepository) GetByPatientID(patientID int) ([]models.Consult
 			&questionnaireID,
 			&c.Reason,
 			&c.Date,
+			&appointmentID,
 		)
 		if err != nil {
 			return nil, err
@@ -104,12 +114,26 @@ func (r *consultationRepository) GetByPatientID(patientID int) ([]models.Consult
 			qID := int(questionnaireID.Int64)
 			c.QuestionnaireID = &qID
 		}
+		if appointmentID.Valid {
+			aID := int(appointmentID.Int64)
+			c.AppointmentID = &aID
+		}
 
 		consultations = append(consultations, c)
 	}
 	return consultations, nil
 }
 
+// Get the consultation started from an appointment, sql.ErrNoRows if there's none
+func (r *consultationRepository) GetByAppointmentID(appointmentID int) (*models.Consultation, error) {
+	var id int
+	err := r.db.QueryRow(`SELECT id FROM consultas WHERE cita_id = $1`, appointmentID).Scan(&id)
+	if err != nil {
+		return nil, err
+	}
+	return r.GetByID(id)
+}
+
 func (r *consultationRepository) Update(id int, consultation models.Consultation) error {
 	query := `
 		UPDATE consultas 
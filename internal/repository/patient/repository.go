@@ -2,18 +2,31 @@ package patient
 
 import (
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"log"
+	"strings"
 	"time"
 
 	"software-backend/internal/models"
 
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 )
 
 // Custom errors, like others is probably going to be moved
-var ErrPatientNotFound = errors.New("patient not found in repository")
+var (
+	ErrPatientNotFound      = errors.New("patient not found in repository")
+	ErrPatientInUse         = errors.New("patient still has appointments or records in repository")
+	ErrRelationshipNotFound = errors.New("patient relationship not found in repository")
+	ErrRelationshipExists   = errors.New("patient relationship already exists in repository")
+)
+
+// SQLSTATEs raised when a row is still referenced by another table & on duplicates
+const (
+	foreignKeyViolation = "23503"
+	uniqueViolation     = "23505"
+)
 
 // Interface for interaction with repository
 type PatientRepository interface {
@@ -21,8 +34,21 @@ type PatientRepository interface {
 	CreatePatient(patient models.Patient) (*models.Patient, error)
 	UpdatePatient(patient models.Patient) error
 	DeletePatient(id int) error
-	ListPatients() ([]models.Patient, error)
-	SearchPatients(query string, limit int) ([]models.Patient, error)
+	ListPatients(limit, offset int) ([]models.Patient, int, error)
+	SearchPatientsByName(words []string, limit int) ([]models.Patient, error)
+	SearchPatientsByPhone(digits string, limit int) ([]models.Patient, error)
+	SearchPatientsByBirthDate(date time.Time, limit int) ([]models.Patient, error)
+	ListDuplicateCandidates(afterID, afterDuplicateID, limit int) ([]models.DuplicateCandidate, error)
+	MergePatients(merge models.PatientMerge) (*models.PatientMerge, error)
+	ListMerges(patientID int) ([]models.PatientMerge, error)
+	ListAntecedentes(patientID int) ([]models.Antecedentes, error)
+	ListPatientPhones() ([]models.Patient, error)
+	UpdatePatientPhones(changes []models.PhoneChange) (int, error)
+	ListRelationships(patientID int) ([]models.PatientRelationship, error)
+	CreateRelationship(relationship models.PatientRelationship) (*models.PatientRelationship, error)
+	DeleteRelationship(patientID, relationshipID int) error
+	SetNotificationContact(patientID int, relationshipID *int) error
+	GetNotificationContact(patientID int) (*models.PatientRelationship, error)
 }
 
 // Struct to pass on dependencies
@@ -37,7 +63,7 @@ func NewPatientRepository(dbConn *sql.DB) PatientRepository {
 	return &sqlPatientRepository{db: dbConn}
 }
 
-// Get a patient & their record via ID
+// Get a patient & their record via ID, with the current version of their antecedentes
 func (r *sqlPatientRepository) GetPatientByID(id int) (*models.Patient, error) {
 	// Build query
 	query := `
@@ -51,11 +77,18 @@ func (r *sqlPatientRepository) GetPatientByID(id int) (*models.Patient, error) {
             a.familiares,
             a.oculares,
             a.alergicos,
-            a.otros
+            a.otros,
+            a.version,
+            a.usuario_id,
+            a.fecha
         FROM
             pacientes p
-        LEFT JOIN
-            antecedentes a ON p.id = a.paciente_id
+        LEFT JOIN LATERAL (
+            SELECT * FROM antecedentes
+            WHERE paciente_id = p.id
+            ORDER BY version DESC
+            LIMIT 1
+        ) a ON TRUE
         WHERE
             p.id = $1
 	`
@@ -69,6 +102,9 @@ func (r *sqlPatientRepository) GetPatientByID(id int) (*models.Patient, error) {
 	var antecedenteOcular sql.NullString
 	var antecedenteAlergic sql.NullString
 	var antecedenteOther sql.NullString
+	var antecedenteVersion sql.NullInt64
+	var antecedenteUserID sql.NullInt64
+	var antecedenteDate sql.NullTime
 
 	// Scan into patient
 	err := r.db.QueryRow(query, id).Scan(
@@ -82,6 +118,9 @@ func (r *sqlPatientRepository) GetPatientByID(id int) (*models.Patient, error) {
 		&antecedenteOcular,
 		&antecedenteAlergic,
 		&antecedenteOther,
+		&antecedenteVersion,
+		&antecedenteUserID,
+		&antecedenteDate,
 	)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
@@ -97,14 +136,20 @@ func (r *sqlPatientRepository) GetPatientByID(id int) (*models.Patient, error) {
 		patient.Phone = ""
 	}
 
-	// Handle antecedentes
-	if antecedenteMedical.Valid || antecedenteFamily.Valid || antecedenteOcular.Valid || antecedenteAlergic.Valid || antecedenteOther.Valid {
+	// Handle antecedentes, a version exists when there's one
+	if antecedenteVersion.Valid {
 		patient.Antecedentes = &models.Antecedentes{
-			Medical: antecedenteMedical.String,
-			Family:  antecedenteFamily.String,
-			Ocular:  antecedenteOcular.String,
-			Alergic: antecedenteAlergic.String,
-			Other:   antecedenteOther.String,
+			Medical:   antecedenteMedical.String,
+			Family:    antecedenteFamily.String,
+			Ocular:    antecedenteOcular.String,
+			Alergic:   antecedenteAlergic.String,
+			Other:     antecedenteOther.String,
+			Version:   int(antecedenteVersion.Int64),
+			CreatedAt: antecedenteDate.Time,
+		}
+		if antecedenteUserID.Valid {
+			userID := int(antecedenteUserID.Int64)
+			patient.Antecedentes.UserID = &userID
 		}
 	} else {
 		patient.Antecedentes = nil
@@ -142,20 +187,24 @@ func (r *sqlPatientRepository) CreatePatient(patient models.Patient) (*models.Pa
 	// Update the patient model with the generated ID
 	patient.ID = patientID
 
-	// Insert related antecedentes if provided
+	// Insert related antecedentes if provided, as their first version
 	if patient.Antecedentes != nil {
+		antecedentes := *patient.Antecedentes
 		antecedentesQuery := `
-			INSERT INTO antecedentes (paciente_id, medicos, familares, oculares, alergicos, otros)
-			VALUES ($1, $2, $3, $4, $5, $6)
+			INSERT INTO antecedentes (paciente_id, version, medicos, familiares, oculares, alergicos, otros, usuario_id)
+			VALUES ($1, 1, $2, $3, $4, $5, $6, $7)
+			RETURNING version, fecha
 		`
-		_, err := tx.Exec(antecedentesQuery,
+		err := tx.QueryRow(antecedentesQuery,
 			patient.ID,
-			patient.Antecedentes.Medical,
-			patient.Antecedentes.Family,
-			patient.Antecedentes.Ocular,
-			patient.Antecedentes.Alergic,
-			patient.Antecedentes.Other,
-		)
+			antecedentes.Medical,
+			antecedentes.Family,
+			antecedentes.Ocular,
+			antecedentes.Alergic,
+			antecedentes.Other,
+			antecedentes.UserID,
+		).Scan(&antecedentes.Version, &antecedentes.CreatedAt)
+		patient.Antecedentes = &antecedentes
 		if err != nil {
 			log.Printf("repository: failed to create antecedentes for patient %d: %v", patient.ID, err)
 			return nil, fmt.Errorf("repository: failed to create antecedentes for patient %d: %w", patient.ID, err)
@@ -201,60 +250,11 @@ func (r *sqlPatientRepository) UpdatePatient(patient models.Patient) error {
 		return ErrPatientNotFound
 	}
 
-	// Handle antecedentes updates
+	// Antecedentes are never overwritten, a change is added as a new version
 	if patient.Antecedentes != nil {
-		// Check if Antecedentes already exist for this patient
-		var existingAntecedentesID int
-		checkQuery := `SELECT id FROM antecedentes WHERE paciente_id = $1`
-		err := tx.QueryRow(checkQuery, patient.ID).Scan(&existingAntecedentesID)
-
-		if err != nil {
-			if errors.Is(err, sql.ErrNoRows) {
-				// Antecedentes do not exist, INSERT
-				antecedentesInsertQuery := `
-					INSERT INTO antecedentes (paciente_id, medicos, familiares, oculares, alergicos, otros)
-					VALUES ($1, $2, $3, $4, $5, $6)
-				`
-				_, err := tx.Exec(antecedentesInsertQuery,
-					patient.ID,
-					patient.Antecedentes.Medical,
-					patient.Antecedentes.Family,
-					patient.Antecedentes.Ocular,
-					patient.Antecedentes.Alergic,
-					patient.Antecedentes.Other,
-				)
-				if err != nil {
-					log.Printf("repository: failed to insert antecedentes for patient %d: %v", patient.ID, err)
-					return fmt.Errorf("repository: failed to insert antecedentes for patient %d: %w", patient.ID, err)
-				}
-			} else {
-				// Some other error checking for existing Antecedentes
-				log.Printf("repository: failed to check for existing antecedentes for patient %d: %v", patient.ID, err)
-				return fmt.Errorf("repository: failed to check for existing antecedentes for patient %d: %w", patient.ID, err)
-			}
-		} else {
-			// Antecedentes exist, UPDATE
-			antecedentesUpdateQuery := `
-				UPDATE antecedentes
-				SET medicos = $1, familiares = $2, oculares = $3, alergicos = $4, otros = $5
-				WHERE paciente_id = $6
-			`
-			_, err := tx.Exec(antecedentesUpdateQuery,
-				patient.Antecedentes.Medical,
-				patient.Antecedentes.Family,
-				patient.Antecedentes.Ocular,
-				patient.Antecedentes.Alergic,
-				patient.Antecedentes.Other,
-				patient.ID,
-			)
-			if err != nil {
-				log.Printf("repository: failed to update antecedentes for patient %d: %v", patient.ID, err)
-				return fmt.Errorf("repository: failed to update antecedentes for patient %d: %w", patient.ID, err)
-			}
+		if err := addAntecedentesVersion(tx, patient.ID, *patient.Antecedentes); err != nil {
+			return err
 		}
-	} else {
-		// TODO pending implementation, has to do with patient creation / update flow. Leaving for when that
-		// module is implemented
 	}
 
 	// Commit transaction if queries successful
@@ -265,6 +265,47 @@ func (r *sqlPatientRepository) UpdatePatient(patient models.Patient) error {
 	return nil
 }
 
+// Add a version of a patient's antecedentes on top of the current one, nothing is added
+// when they're the same. The patient's row must already be locked by the transaction
+func addAntecedentesVersion(tx *sql.Tx, patientID int, antecedentes models.Antecedentes) error {
+	var current models.Antecedentes
+	err := tx.QueryRow(`
+        SELECT version, COALESCE(medicos, ''), COALESCE(familiares, ''), COALESCE(oculares, ''),
+               COALESCE(alergicos, ''), COALESCE(otros, '')
+        FROM antecedentes
+        WHERE paciente_id = $1
+        ORDER BY version DESC
+        LIMIT 1
+    `, patientID).Scan(&current.Version, &current.Medical, &current.Family, &current.Ocular, &current.Alergic, &current.Other)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		log.Printf("repository: failed to get current antecedentes for patient %d: %v", patientID, err)
+		return fmt.Errorf("repository: failed to get current antecedentes for patient %d: %w", patientID, err)
+	}
+	if err == nil && current.Medical == antecedentes.Medical && current.Family == antecedentes.Family &&
+		current.Ocular == antecedentes.Ocular && current.Alergic == antecedentes.Alergic && current.Other == antecedentes.Other {
+		return nil
+	}
+
+	_, err = tx.Exec(`
+        INSERT INTO antecedentes (paciente_id, version, medicos, familiares, oculares, alergicos, otros, usuario_id)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+    `,
+		patientID,
+		current.Version+1,
+		antecedentes.Medical,
+		antecedentes.Family,
+		antecedentes.Ocular,
+		antecedentes.Alergic,
+		antecedentes.Other,
+		antecedentes.UserID,
+	)
+	if err != nil {
+		log.Printf("repository: failed to add antecedentes version for patient %d: %v", patientID, err)
+		return fmt.Errorf("repository: failed to add antecedentes version for patient %d: %w", patientID, err)
+	}
+	return nil
+}
+
 // Delete a patient based on ID
 func (r *sqlPatientRepository) DeletePatient(id int) error {
 	// Start transaction
@@ -279,13 +320,17 @@ func (r *sqlPatientRepository) DeletePatient(id int) error {
 	antecedentesDeleteQuery := `DELETE FROM antecedentes WHERE paciente_id = $1`
 	_, err = tx.Exec(antecedentesDeleteQuery, id)
 	if err != nil {
-		log.Printf("repository: failed to delete antecedentes for patient %d: %v", id, err)
+		return fmt.Errorf("repository: failed to delete antecedentes for patient %d: %w", id, err)
 	}
 
 	// Delete paciente
 	patientDeleteQuery := `DELETE FROM pacientes WHERE id = $1`
 	result, err := tx.Exec(patientDeleteQuery, id)
 	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == foreignKeyViolation {
+			return ErrPatientInUse
+		}
 		return fmt.Errorf("repository: failed to delete patient from pacientes table (ID %d): %w", id, err)
 	}
 	rowsAffected, err := result.RowsAffected()
@@ -304,8 +349,14 @@ func (r *sqlPatientRepository) DeletePatient(id int) error {
 	return nil
 }
 
-// Lists patients
-func (r *sqlPatientRepository) ListPatients() ([]models.Patient, error) {
+// Lists a page of patients along with how many patients there are in total
+func (r *sqlPatientRepository) ListPatients(limit, offset int) ([]models.Patient, int, error) {
+	// Total for the pages
+	var total int
+	if err := r.db.QueryRow(`SELECT COUNT(*) FROM pacientes`).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("repository: failed to count patients: %w", err)
+	}
+
 	// Query for patient details only
 	query := `
 		SELECT
@@ -316,13 +367,13 @@ func (r *sqlPatientRepository) ListPatients() ([]models.Patient, error) {
             sexo
         FROM
             pacientes
-		ORDER BY nombre -- Order alphabetically by name
+		ORDER BY nombre, id -- Order alphabetically by name, ID keeps pages stable
+		LIMIT $1 OFFSET $2
 	`
 
-	// Exec query w/o params, not needed
-	rows, err := r.db.Query(query)
+	rows, err := r.db.Query(query, limit, offset)
 	if err != nil {
-		return nil, fmt.Errorf("repository: failed to list patients: %w", err)
+		return nil, 0, fmt.Errorf("repository: failed to list patients: %w", err)
 	}
 	defer rows.Close()
 
@@ -356,54 +407,657 @@ func (r *sqlPatientRepository) ListPatients() ([]models.Patient, error) {
 	}
 
 	if err = rows.Err(); err != nil {
-		return nil, fmt.Errorf("repository: error after iterating patient rows: %w", err)
+		return nil, 0, fmt.Errorf("repository: error after iterating patient rows: %w", err)
 	}
 
-	return patients, nil
+	return patients, total, nil
 }
 
-// Get a list of patients of names fuzzy-match
-func (r *sqlPatientRepository) SearchPatients(query string, limit int) ([]models.Patient, error) {
-	// Search by name (case-insensitive, partial match)
-	sqlQuery := `
+// Search patients by name ignoring case & accents, best matches first. Names with every
+// word in any order come first, then names close enough to catch typos. Backed by the
+// trigram index on lower(f_unaccent(nombre)), see migrations/014
+func (r *sqlPatientRepository) SearchPatientsByName(words []string, limit int) ([]models.Patient, error) {
+	if len(words) == 0 {
+		return []models.Patient{}, nil
+	}
+
+	// One LIKE per word, $1 is the whole query & $2 the limit
+	args := []interface{}{strings.Join(words, " "), limit}
+	conditions := make([]string, len(words))
+	for i, word := range words {
+		args = append(args, "%"+escapeLike(word)+"%")
+		conditions[i] = fmt.Sprintf("lower(f_unaccent(nombre)) LIKE lower(f_unaccent($%d))", i+3)
+	}
+	allWords := strings.Join(conditions, " AND ")
+
+	query := fmt.Sprintf(`
         SELECT id, nombre, fecha_nacimiento, telefono, sexo
         FROM pacientes
-        WHERE nombre ILIKE $1
+        WHERE (%[1]s)
+           OR lower(f_unaccent($1)) <%% lower(f_unaccent(nombre))
+        ORDER BY (%[1]s) DESC,
+                 word_similarity(lower(f_unaccent($1)), lower(f_unaccent(nombre))) DESC,
+                 similarity(lower(f_unaccent($1)), lower(f_unaccent(nombre))) DESC,
+                 nombre
+        LIMIT $2
+    `, allWords)
+	return r.searchPatients(query, args...)
+}
+
+// Search patients by phone digits anywhere in the number, numbers ending in them first
+func (r *sqlPatientRepository) SearchPatientsByPhone(digits string, limit int) ([]models.Patient, error) {
+	return r.searchPatients(`
+        SELECT id, nombre, fecha_nacimiento, telefono, sexo
+        FROM pacientes
+        WHERE regexp_replace(telefono, '[^0-9]', '', 'g') LIKE $1
+        ORDER BY (regexp_replace(telefono, '[^0-9]', '', 'g') LIKE $2) DESC, nombre
+        LIMIT $3
+    `, "%"+digits+"%", "%"+digits, limit)
+}
+
+// Search patients born on a date
+func (r *sqlPatientRepository) SearchPatientsByBirthDate(date time.Time, limit int) ([]models.Patient, error) {
+	return r.searchPatients(`
+        SELECT id, nombre, fecha_nacimiento, telefono, sexo
+        FROM pacientes
+        WHERE fecha_nacimiento = $1
         ORDER BY nombre
         LIMIT $2
-    `
-	// Exec query
-	rows, err := r.db.Query(sqlQuery, "%"+query+"%", limit)
+    `, date.Format("2006-01-02"), limit)
+}
+
+// Run a patient search & scan the results in order
+func (r *sqlPatientRepository) searchPatients(query string, args ...interface{}) ([]models.Patient, error) {
+	rows, err := r.db.Query(query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("repository: failed to search patients: %w", err)
 	}
 	defer rows.Close()
 
 	// Scan into patient list
-	var patients []models.Patient
+	patients := []models.Patient{}
 	for rows.Next() {
 		var patient models.Patient
-		var dateOfBirth time.Time
 		var phone sql.NullString
+		if err := rows.Scan(&patient.ID, &patient.Name, &patient.DateOfBirth, &phone, &patient.Sex); err != nil {
+			return nil, fmt.Errorf("repository: failed to scan patient: %w", err)
+		}
+		patient.Phone = phone.String
+		patients = append(patients, patient)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("repository: error after iterating patient rows: %w", err)
+	}
+	return patients, nil
+}
+
+// Escape the LIKE wildcards in user input
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(value)
+}
+
+// Pairs of patients sharing their birth date, phone or name, the first of each pair
+// has the lower ID. Pairs are ordered by their IDs & start after the given pair, so
+// they can be paged through starting from 0, 0. Scoring them is up to the caller
+func (r *sqlPatientRepository) ListDuplicateCandidates(afterID, afterDuplicateID, limit int) ([]models.DuplicateCandidate, error) {
+	rows, err := r.db.Query(`
+        SELECT a.id, a.nombre, a.fecha_nacimiento, a.telefono, a.sexo,
+               b.id, b.nombre, b.fecha_nacimiento, b.telefono, b.sexo
+        FROM pacientes a
+        JOIN pacientes b ON a.id < b.id AND (
+            a.fecha_nacimiento = b.fecha_nacimiento
+            OR (a.telefono <> '' AND a.telefono = b.telefono)
+            OR LOWER(a.nombre) = LOWER(b.nombre)
+        )
+        WHERE (a.id, b.id) > ($1, $2)
+        ORDER BY a.id, b.id
+        LIMIT $3
+    `, afterID, afterDuplicateID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("repository: failed to list duplicate candidates: %w", err)
+	}
+	defer rows.Close()
 
+	candidates := []models.DuplicateCandidate{}
+	for rows.Next() {
+		var candidate models.DuplicateCandidate
+		var phone, duplicatePhone sql.NullString
 		err := rows.Scan(
-			&patient.ID,
-			&patient.Name,
-			&dateOfBirth,
-			&phone,
-			&patient.Sex,
+			&candidate.Patient.ID, &candidate.Patient.Name, &candidate.Patient.DateOfBirth, &phone, &candidate.Patient.Sex,
+			&candidate.Duplicate.ID, &candidate.Duplicate.Name, &candidate.Duplicate.DateOfBirth, &duplicatePhone, &candidate.Duplicate.Sex,
 		)
 		if err != nil {
-			continue
+			return nil, fmt.Errorf("repository: failed to scan duplicate candidate: %w", err)
 		}
-		patient.DateOfBirth = dateOfBirth
-		if phone.Valid {
-			patient.Phone = phone.String
-		} else {
-			patient.Phone = ""
+		candidate.Patient.Phone = phone.String
+		candidate.Duplicate.Phone = duplicatePhone.String
+		candidates = append(candidates, candidate)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("repository: error after iterating duplicate candidates: %w", err)
+	}
+	return candidates, nil
+}
+
+// Tables pointing at a patient that are re-pointed on a merge, antecedentes is merged
+// apart as a patient only has one
+var mergedTables = []struct {
+	name  string
+	query string
+}{
+	{"citas", `UPDATE citas SET paciente_id = $1 WHERE paciente_id = $2`},
+	{"series_citas", `UPDATE series_citas SET paciente_id = $1 WHERE paciente_id = $2`},
+	{"consultas", `UPDATE consultas SET paciente_id = $1 WHERE paciente_id = $2`},
+	{"examenes", `UPDATE examenes SET paciente_id = $1 WHERE paciente_id = $2`},
+	{"lista_espera", `UPDATE lista_espera SET paciente_id = $1 WHERE paciente_id = $2`},
+	{"whatsapp_notifications", `UPDATE whatsapp_notifications SET patient_id = $1 WHERE patient_id = $2`},
+	{"consentimientos_contacto", `UPDATE consentimientos_contacto SET paciente_id = $1 WHERE paciente_id = $2`},
+	{"fusiones_pacientes", `UPDATE fusiones_pacientes SET paciente_id = $1 WHERE paciente_id = $2`},
+}
+
+// Merge a patient into another in one transaction: everything pointing at the merged
+// patient is moved to the surviving one, the merged patient is deleted & the merge is
+// recorded. Both antecedentes are kept, joined field by field
+func (r *sqlPatientRepository) MergePatients(merge models.PatientMerge) (*models.PatientMerge, error) {
+	// Start transaction
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("repository: failed to begin transaction for merging patients: %w", err)
+	}
+	// Rollback if error
+	defer tx.Rollback()
+
+	// Lock both so nothing is added to the merged patient meanwhile
+	var locked int
+	err = tx.QueryRow(`
+        SELECT COUNT(*) FROM (
+            SELECT id FROM pacientes WHERE id IN ($1, $2) FOR UPDATE
+        ) p
+    `, merge.PatientID, merge.MergedPatientID).Scan(&locked)
+	if err != nil {
+		return nil, fmt.Errorf("repository: failed to lock patients for merge: %w", err)
+	}
+	if locked != 2 {
+		return nil, ErrPatientNotFound
+	}
+
+	merge.Moved = map[string]int{}
+	for _, table := range mergedTables {
+		result, err := tx.Exec(table.query, merge.PatientID, merge.MergedPatientID)
+		if err != nil {
+			return nil, fmt.Errorf("repository: failed to move %s of patient %d: %w", table.name, merge.MergedPatientID, err)
+		}
+		moved, err := result.RowsAffected()
+		if err != nil {
+			return nil, fmt.Errorf("repository: failed to check rows moved in %s: %w", table.name, err)
+		}
+		merge.Moved[table.name] = int(moved)
+	}
+
+	// Antecedentes, the current versions of both are joined into a new version of the
+	// surviving patient's. The merged patient's versions are kept with the merge record
+	result, err := tx.Exec(`
+        WITH s AS (
+            SELECT * FROM antecedentes WHERE paciente_id = $1 ORDER BY version DESC LIMIT 1
+        ), d AS (
+            SELECT * FROM antecedentes WHERE paciente_id = $2 ORDER BY version DESC LIMIT 1
+        )
+        INSERT INTO antecedentes (paciente_id, version, medicos, familiares, oculares, alergicos, otros, usuario_id)
+        SELECT
+            $1,
+            COALESCE(s.version, 0) + 1,
+            CASE WHEN COALESCE(d.medicos, '') IN ('', COALESCE(s.medicos, '')) THEN s.medicos
+                 WHEN COALESCE(s.medicos, '') = '' THEN d.medicos
+                 ELSE s.medicos || E'\n' || d.medicos END,
+            CASE WHEN COALESCE(d.familiares, '') IN ('', COALESCE(s.familiares, '')) THEN s.familiares
+                 WHEN COALESCE(s.familiares, '') = '' THEN d.familiares
+                 ELSE s.familiares || E'\n' || d.familiares END,
+            CASE WHEN COALESCE(d.oculares, '') IN ('', COALESCE(s.oculares, '')) THEN s.oculares
+                 WHEN COALESCE(s.oculares, '') = '' THEN d.oculares
+                 ELSE s.oculares || E'\n' || d.oculares END,
+            CASE WHEN COALESCE(d.alergicos, '') IN ('', COALESCE(s.alergicos, '')) THEN s.alergicos
+                 WHEN COALESCE(s.alergicos, '') = '' THEN d.alergicos
+                 ELSE s.alergicos || E'\n' || d.alergicos END,
+            CASE WHEN COALESCE(d.otros, '') IN ('', COALESCE(s.otros, '')) THEN s.otros
+                 WHEN COALESCE(s.otros, '') = '' THEN d.otros
+                 ELSE s.otros || E'\n' || d.otros END,
+            $3
+        FROM d LEFT JOIN s ON TRUE
+    `, merge.PatientID, merge.MergedPatientID, merge.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("repository: failed to merge antecedentes of patient %d: %w", merge.MergedPatientID, err)
+	}
+	joined, err := result.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("repository: failed to check merged antecedentes: %w", err)
+	}
+	merge.Moved["antecedentes"] = int(joined)
+
+	// Relationships, dropping the ones between both patients & the ones the surviving
+	// patient already has. The surviving patient's notification contact is kept
+	_, err = tx.Exec(`
+        DELETE FROM relaciones_pacientes r
+        WHERE (r.paciente_id IN ($1, $2) AND r.relacionado_id IN ($1, $2))
+           OR (r.paciente_id = $2 AND EXISTS (
+                SELECT 1 FROM relaciones_pacientes s
+                WHERE s.paciente_id = $1 AND s.relacionado_id = r.relacionado_id AND s.tipo = r.tipo))
+           OR (r.relacionado_id = $2 AND EXISTS (
+                SELECT 1 FROM relaciones_pacientes s
+                WHERE s.relacionado_id = $1 AND s.paciente_id = r.paciente_id AND s.tipo = r.tipo))
+    `, merge.PatientID, merge.MergedPatientID)
+	if err != nil {
+		return nil, fmt.Errorf("repository: failed to drop duplicate relationships of patient %d: %w", merge.MergedPatientID, err)
+	}
+	_, err = tx.Exec(`
+        UPDATE relaciones_pacientes SET contacto_notificaciones = FALSE
+        WHERE paciente_id = $2 AND contacto_notificaciones
+          AND EXISTS (SELECT 1 FROM relaciones_pacientes WHERE paciente_id = $1 AND contacto_notificaciones)
+    `, merge.PatientID, merge.MergedPatientID)
+	if err != nil {
+		return nil, fmt.Errorf("repository: failed to clear notification contact of patient %d: %w", merge.MergedPatientID, err)
+	}
+	relationships := 0
+	for _, query := range []string{
+		`UPDATE relaciones_pacientes SET paciente_id = $1 WHERE paciente_id = $2`,
+		`UPDATE relaciones_pacientes SET relacionado_id = $1 WHERE relacionado_id = $2`,
+	} {
+		result, err := tx.Exec(query, merge.PatientID, merge.MergedPatientID)
+		if err != nil {
+			return nil, fmt.Errorf("repository: failed to move relationships of patient %d: %w", merge.MergedPatientID, err)
+		}
+		moved, err := result.RowsAffected()
+		if err != nil {
+			return nil, fmt.Errorf("repository: failed to check moved relationships: %w", err)
+		}
+		relationships += int(moved)
+	}
+	merge.Moved["relaciones_pacientes"] = relationships
+
+	// Contact preferences, not contacting either patient wins. The surviving patient's
+	// channel & language are kept unless they're not set
+	result, err = tx.Exec(`
+        INSERT INTO preferencias_contacto (paciente_id, canal_preferido, idioma, no_contactar, usuario_id, fecha_actualizacion)
+        SELECT $1, d.canal_preferido, d.idioma, d.no_contactar, $3, NOW()
+        FROM preferencias_contacto d
+        WHERE d.paciente_id = $2
+        ON CONFLICT (paciente_id) DO UPDATE SET
+            canal_preferido = COALESCE(NULLIF(preferencias_contacto.canal_preferido, ''), EXCLUDED.canal_preferido),
+            idioma = COALESCE(NULLIF(preferencias_contacto.idioma, ''), EXCLUDED.idioma),
+            no_contactar = preferencias_contacto.no_contactar OR EXCLUDED.no_contactar,
+            usuario_id = EXCLUDED.usuario_id,
+            fecha_actualizacion = EXCLUDED.fecha_actualizacion
+    `, merge.PatientID, merge.MergedPatientID, merge.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("repository: failed to merge contact preferences of patient %d: %w", merge.MergedPatientID, err)
+	}
+	preferences, err := result.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("repository: failed to check merged contact preferences: %w", err)
+	}
+	merge.Moved["preferencias_contacto"] = int(preferences)
+
+	// Record the merge
+	mergedPatient, err := json.Marshal(merge.MergedPatient)
+	if err != nil {
+		return nil, fmt.Errorf("repository: failed to encode merged patient: %w", err)
+	}
+	moves, err := json.Marshal(merge.Moved)
+	if err != nil {
+		return nil, fmt.Errorf("repository: failed to encode merge moves: %w", err)
+	}
+	err = tx.QueryRow(`
+        INSERT INTO fusiones_pacientes (paciente_id, paciente_fusionado_id, datos_fusionado, movidos, usuario_id)
+        VALUES ($1, $2, $3, $4, $5)
+        RETURNING id, fecha
+    `, merge.PatientID, merge.MergedPatientID, mergedPatient, moves, merge.UserID).Scan(&merge.ID, &merge.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("repository: failed to record patient merge: %w", err)
+	}
+
+	// Keep the merged patient's antecedentes versions, they can't stay in antecedentes
+	// without their patient
+	_, err = tx.Exec(`
+        INSERT INTO antecedentes_fusionados (fusion_id, version, medicos, familiares, oculares, alergicos, otros, usuario_id, fecha)
+        SELECT $1, version, medicos, familiares, oculares, alergicos, otros, usuario_id, fecha
+        FROM antecedentes
+        WHERE paciente_id = $2
+    `, merge.ID, merge.MergedPatientID)
+	if err != nil {
+		return nil, fmt.Errorf("repository: failed to keep antecedentes versions of patient %d: %w", merge.MergedPatientID, err)
+	}
+	if _, err := tx.Exec(`DELETE FROM antecedentes WHERE paciente_id = $1`, merge.MergedPatientID); err != nil {
+		return nil, fmt.Errorf("repository: failed to delete merged antecedentes of patient %d: %w", merge.MergedPatientID, err)
+	}
+
+	// Nothing should point at the merged patient anymore
+	if _, err := tx.Exec(`DELETE FROM pacientes WHERE id = $1`, merge.MergedPatientID); err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == foreignKeyViolation {
+			return nil, fmt.Errorf("%w: %s", ErrPatientInUse, pqErr.Table)
+		}
+		return nil, fmt.Errorf("repository: failed to delete merged patient %d: %w", merge.MergedPatientID, err)
+	}
+
+	// Commit transaction if every query successful
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("repository: failed to commit transaction for merging patients: %w", err)
+	}
+	return &merge, nil
+}
+
+// List the patients merged into a patient, latest first
+func (r *sqlPatientRepository) ListMerges(patientID int) ([]models.PatientMerge, error) {
+	rows, err := r.db.Query(`
+        SELECT id, paciente_id, paciente_fusionado_id, datos_fusionado, movidos, usuario_id, fecha
+        FROM fusiones_pacientes
+        WHERE paciente_id = $1
+        ORDER BY fecha DESC, id DESC
+    `, patientID)
+	if err != nil {
+		return nil, fmt.Errorf("repository: failed to list merges of patient %d: %w", patientID, err)
+	}
+	defer rows.Close()
+
+	merges := []models.PatientMerge{}
+	for rows.Next() {
+		var merge models.PatientMerge
+		var mergedPatient, moves []byte
+		var userID sql.NullInt64
+		if err := rows.Scan(&merge.ID, &merge.PatientID, &merge.MergedPatientID, &mergedPatient, &moves, &userID, &merge.CreatedAt); err != nil {
+			return nil, fmt.Errorf("repository: failed to scan patient merge: %w", err)
+		}
+		if err := json.Unmarshal(mergedPatient, &merge.MergedPatient); err != nil {
+			return nil, fmt.Errorf("repository: failed to decode merged patient: %w", err)
+		}
+		if err := json.Unmarshal(moves, &merge.Moved); err != nil {
+			return nil, fmt.Errorf("repository: failed to decode merge moves: %w", err)
+		}
+		if userID.Valid {
+			id := int(userID.Int64)
+			merge.UserID = &id
+		}
+		merges = append(merges, merge)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("repository: error after iterating patient merges: %w", err)
+	}
+	return merges, nil
+}
+
+// List every version of a patient's antecedentes, latest first
+func (r *sqlPatientRepository) ListAntecedentes(patientID int) ([]models.Antecedentes, error) {
+	rows, err := r.db.Query(`
+        SELECT version, COALESCE(medicos, ''), COALESCE(familiares, ''), COALESCE(oculares, ''),
+               COALESCE(alergicos, ''), COALESCE(otros, ''), usuario_id, fecha
+        FROM antecedentes
+        WHERE paciente_id = $1
+        ORDER BY version DESC
+    `, patientID)
+	if err != nil {
+		return nil, fmt.Errorf("repository: failed to list antecedentes of patient %d: %w", patientID, err)
+	}
+	defer rows.Close()
+
+	versions := []models.Antecedentes{}
+	for rows.Next() {
+		var antecedentes models.Antecedentes
+		var userID sql.NullInt64
+		err := rows.Scan(
+			&antecedentes.Version,
+			&antecedentes.Medical,
+			&antecedentes.Family,
+			&antecedentes.Ocular,
+			&antecedentes.Alergic,
+			&antecedentes.Other,
+			&userID,
+			&antecedentes.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("repository: failed to scan antecedentes version: %w", err)
+		}
+		if userID.Valid {
+			id := int(userID.Int64)
+			antecedentes.UserID = &id
+		}
+		versions = append(versions, antecedentes)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("repository: error after iterating antecedentes versions: %w", err)
+	}
+	return versions, nil
+}
+
+// List the ID, name & phone of every patient with a phone
+func (r *sqlPatientRepository) ListPatientPhones() ([]models.Patient, error) {
+	rows, err := r.db.Query(`
+        SELECT id, nombre, telefono
+        FROM pacientes
+        WHERE COALESCE(telefono, '') <> ''
+        ORDER BY id
+    `)
+	if err != nil {
+		return nil, fmt.Errorf("repository: failed to list patient phones: %w", err)
+	}
+	defer rows.Close()
+
+	patients := []models.Patient{}
+	for rows.Next() {
+		var patient models.Patient
+		if err := rows.Scan(&patient.ID, &patient.Name, &patient.Phone); err != nil {
+			return nil, fmt.Errorf("repository: failed to scan patient phone: %w", err)
 		}
 		patients = append(patients, patient)
 	}
-	// Return resulting list
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("repository: error after iterating patient phones: %w", err)
+	}
 	return patients, nil
 }
+
+// Rewrite stored phones in one transaction. A phone changed since it was read is left
+// alone, returns how many were rewritten
+func (r *sqlPatientRepository) UpdatePatientPhones(changes []models.PhoneChange) (int, error) {
+	// Start transaction
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("repository: failed to begin transaction for updating phones: %w", err)
+	}
+	// Rollback if error
+	defer tx.Rollback()
+
+	updated := 0
+	for _, change := range changes {
+		result, err := tx.Exec(`UPDATE pacientes SET telefono = $1 WHERE id = $2 AND telefono = $3`, change.After, change.PatientID, change.Before)
+		if err != nil {
+			return 0, fmt.Errorf("repository: failed to update phone of patient %d: %w", change.PatientID, err)
+		}
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return 0, fmt.Errorf("repository: failed to check rows affected for phone of patient %d: %w", change.PatientID, err)
+		}
+		updated += int(rowsAffected)
+	}
+
+	// Commit transaction if every update successful
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("repository: failed to commit transaction for updating phones: %w", err)
+	}
+	return updated, nil
+}
+
+// Relationships with the related patient's basic data
+const relationshipSelect = `
+    SELECT r.id, r.paciente_id, r.relacionado_id, r.tipo, r.contacto_notificaciones, r.fecha,
+           p.nombre, p.fecha_nacimiento, p.telefono, p.sexo
+    FROM relaciones_pacientes r
+    JOIN pacientes p ON p.id = r.relacionado_id
+`
+
+// List the patients related to a patient, the notification contact first
+func (r *sqlPatientRepository) ListRelationships(patientID int) ([]models.PatientRelationship, error) {
+	rows, err := r.db.Query(relationshipSelect+`
+        WHERE r.paciente_id = $1
+        ORDER BY r.contacto_notificaciones DESC, r.fecha, r.id
+    `, patientID)
+	if err != nil {
+		return nil, fmt.Errorf("repository: failed to list relationships of patient %d: %w", patientID, err)
+	}
+	defer rows.Close()
+
+	relationships := []models.PatientRelationship{}
+	for rows.Next() {
+		relationship, err := scanRelationship(rows)
+		if err != nil {
+			return nil, err
+		}
+		relationships = append(relationships, *relationship)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("repository: error after iterating relationships: %w", err)
+	}
+	return relationships, nil
+}
+
+// Relate a patient to another, becoming their only notification contact if flagged
+func (r *sqlPatientRepository) CreateRelationship(relationship models.PatientRelationship) (*models.PatientRelationship, error) {
+	// Start transaction
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("repository: failed to begin transaction for creating relationship: %w", err)
+	}
+	// Rollback if error
+	defer tx.Rollback()
+
+	if relationship.NotificationContact {
+		if _, err := tx.Exec(`UPDATE relaciones_pacientes SET contacto_notificaciones = FALSE WHERE paciente_id = $1`, relationship.PatientID); err != nil {
+			return nil, fmt.Errorf("repository: failed to clear notification contact of patient %d: %w", relationship.PatientID, err)
+		}
+	}
+	err = tx.QueryRow(`
+        INSERT INTO relaciones_pacientes (paciente_id, relacionado_id, tipo, contacto_notificaciones)
+        VALUES ($1, $2, $3, $4)
+        RETURNING id, fecha
+    `, relationship.PatientID, relationship.RelatedPatientID, relationship.Type, relationship.NotificationContact).
+		Scan(&relationship.ID, &relationship.CreatedAt)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) {
+			switch pqErr.Code {
+			case foreignKeyViolation:
+				return nil, ErrPatientNotFound
+			case uniqueViolation:
+				return nil, ErrRelationshipExists
+			}
+		}
+		return nil, fmt.Errorf("repository: failed to create relationship of patient %d: %w", relationship.PatientID, err)
+	}
+
+	// Commit transaction if both queries successful
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("repository: failed to commit transaction for creating relationship: %w", err)
+	}
+	return &relationship, nil
+}
+
+// Delete one of a patient's relationships
+func (r *sqlPatientRepository) DeleteRelationship(patientID, relationshipID int) error {
+	result, err := r.db.Exec(`DELETE FROM relaciones_pacientes WHERE id = $1 AND paciente_id = $2`, relationshipID, patientID)
+	if err != nil {
+		return fmt.Errorf("repository: failed to delete relationship %d: %w", relationshipID, err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("repository: failed to check rows affected for relationship delete (ID %d): %w", relationshipID, err)
+	}
+	if rowsAffected == 0 {
+		return ErrRelationshipNotFound
+	}
+	return nil
+}
+
+// Choose which relationship is a patient's notification contact, nil for none
+func (r *sqlPatientRepository) SetNotificationContact(patientID int, relationshipID *int) error {
+	// Start transaction
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("repository: failed to begin transaction for setting notification contact: %w", err)
+	}
+	// Rollback if error
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`UPDATE relaciones_pacientes SET contacto_notificaciones = FALSE WHERE paciente_id = $1`, patientID); err != nil {
+		return fmt.Errorf("repository: failed to clear notification contact of patient %d: %w", patientID, err)
+	}
+	if relationshipID != nil {
+		result, err := tx.Exec(`
+            UPDATE relaciones_pacientes SET contacto_notificaciones = TRUE
+            WHERE id = $1 AND paciente_id = $2
+        `, *relationshipID, patientID)
+		if err != nil {
+			return fmt.Errorf("repository: failed to set notification contact of patient %d: %w", patientID, err)
+		}
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("repository: failed to check rows affected for notification contact: %w", err)
+		}
+		if rowsAffected == 0 {
+			return ErrRelationshipNotFound
+		}
+	}
+
+	// Commit transaction if both queries successful
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("repository: failed to commit transaction for setting notification contact: %w", err)
+	}
+	return nil
+}
+
+// Get who notifications about a patient can go to: the chosen notification contact,
+// else a guardian or parent. Contacts with a phone come first, so a chosen contact
+// without one falls back to the next guardian or parent. Nil when there's none
+func (r *sqlPatientRepository) GetNotificationContact(patientID int) (*models.PatientRelationship, error) {
+	rows, err := r.db.Query(relationshipSelect+`
+        WHERE r.paciente_id = $1 AND (r.contacto_notificaciones OR r.tipo IN ('guardian', 'parent'))
+        ORDER BY COALESCE(p.telefono, '') = '',
+                 r.contacto_notificaciones DESC,
+                 CASE r.tipo WHEN 'guardian' THEN 0 ELSE 1 END,
+                 r.fecha, r.id
+        LIMIT 1
+    `, patientID)
+	if err != nil {
+		return nil, fmt.Errorf("repository: failed to get notification contact of patient %d: %w", patientID, err)
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return nil, fmt.Errorf("repository: error getting notification contact: %w", err)
+		}
+		return nil, nil
+	}
+	return scanRelationship(rows)
+}
+
+// Scan a row of relationshipSelect
+func scanRelationship(rows *sql.Rows) (*models.PatientRelationship, error) {
+	relationship := &models.PatientRelationship{RelatedPatient: &models.Patient{}}
+	var phone sql.NullString
+	err := rows.Scan(
+		&relationship.ID,
+		&relationship.PatientID,
+		&relationship.RelatedPatientID,
+		&relationship.Type,
+		&relationship.NotificationContact,
+		&relationship.CreatedAt,
+		&relationship.RelatedPatient.Name,
+		&relationship.RelatedPatient.DateOfBirth,
+		&phone,
+		&relationship.RelatedPatient.Sex,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("repository: failed to scan relationship: %w", err)
+	}
+	relationship.RelatedPatient.ID = relationship.RelatedPatientID
+	relationship.RelatedPatient.Phone = phone.String
+	return relationship, nil
+}
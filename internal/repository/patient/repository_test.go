@@ -0,0 +1,309 @@
+package patient
+
+import (
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"software-backend/internal/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+)
+
+func TestCreatePatient_WithAntecedentes(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock database: %v", err)
+	}
+	defer db.Close()
+
+	repo := NewPatientRepository(db)
+	patient := models.Patient{
+		Name:         "Ana López",
+		DateOfBirth:  time.Date(1990, 5, 1, 0, 0, 0, 0, time.UTC),
+		Phone:        "50212345678",
+		Sex:          models.SexFemale,
+		Antecedentes: &models.Antecedentes{Family: "Glaucoma"},
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO pacientes (nombre, fecha_nacimiento, telefono, sexo)`)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+	// The column is familiares, like everywhere else
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO antecedentes (paciente_id, version, medicos, familiares, oculares, alergicos, otros, usuario_id)`)).
+		WithArgs(7, "", "Glaucoma", "", "", "", nil).
+		WillReturnRows(sqlmock.NewRows([]string{"version", "fecha"}).AddRow(1, time.Now()))
+	mock.ExpectCommit()
+
+	created, err := repo.CreatePatient(patient)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if created.ID != 7 {
+		t.Errorf("expected ID 7, got %d", created.ID)
+	}
+	if created.Antecedentes.Version != 1 {
+		t.Errorf("expected the first antecedentes version, got %d", created.Antecedentes.Version)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet sqlmock expectations: %v", err)
+	}
+}
+
+func TestUpdatePatient_AddsAntecedentesVersion(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock database: %v", err)
+	}
+	defer db.Close()
+
+	repo := NewPatientRepository(db)
+	userID := 3
+	patient := models.Patient{
+		ID:           7,
+		Name:         "Ana López",
+		DateOfBirth:  time.Date(1990, 5, 1, 0, 0, 0, 0, time.UTC),
+		Sex:          models.SexFemale,
+		Antecedentes: &models.Antecedentes{Family: "Glaucoma", Alergic: "Penicilina", UserID: &userID},
+	}
+	current := sqlmock.NewRows([]string{"version", "medicos", "familiares", "oculares", "alergicos", "otros"})
+
+	// A change is added on top of the current version
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE pacientes`)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(regexp.QuoteMeta(`FROM antecedentes`)).
+		WithArgs(7).
+		WillReturnRows(current.AddRow(2, "", "Glaucoma", "", "", ""))
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO antecedentes (paciente_id, version, medicos, familiares, oculares, alergicos, otros, usuario_id)`)).
+		WithArgs(7, 3, "", "Glaucoma", "", "Penicilina", "", &userID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	if err := repo.UpdatePatient(patient); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Nothing is added when they're the same
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE pacientes`)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(regexp.QuoteMeta(`FROM antecedentes`)).
+		WithArgs(7).
+		WillReturnRows(sqlmock.NewRows([]string{"version", "medicos", "familiares", "oculares", "alergicos", "otros"}).
+			AddRow(3, "", "Glaucoma", "", "Penicilina", ""))
+	mock.ExpectCommit()
+
+	if err := repo.UpdatePatient(patient); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet sqlmock expectations: %v", err)
+	}
+}
+
+func TestDeletePatient_StillReferenced(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock database: %v", err)
+	}
+	defer db.Close()
+
+	repo := NewPatientRepository(db)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM antecedentes WHERE paciente_id = $1`)).
+		WithArgs(7).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM pacientes WHERE id = $1`)).
+		WithArgs(7).
+		WillReturnError(&pq.Error{Code: foreignKeyViolation})
+	mock.ExpectRollback()
+
+	if err := repo.DeletePatient(7); !errors.Is(err, ErrPatientInUse) {
+		t.Errorf("expected ErrPatientInUse, got %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet sqlmock expectations: %v", err)
+	}
+}
+
+func TestDeletePatient_AntecedentesFail(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock database: %v", err)
+	}
+	defer db.Close()
+
+	repo := NewPatientRepository(db)
+
+	// The patient is kept when its antecedentes can't be removed
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM antecedentes WHERE paciente_id = $1`)).
+		WithArgs(7).
+		WillReturnError(errors.New("db error"))
+	mock.ExpectRollback()
+
+	if err := repo.DeletePatient(7); err == nil {
+		t.Error("expected an error")
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet sqlmock expectations: %v", err)
+	}
+}
+
+func TestMergePatients_MovesRecordsAndDeletesDuplicate(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock database: %v", err)
+	}
+	defer db.Close()
+
+	repo := NewPatientRepository(db)
+	userID := 3
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`FOR UPDATE`)).
+		WithArgs(1, 2).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+	for i, table := range mergedTables {
+		mock.ExpectExec(regexp.QuoteMeta(table.query)).
+			WithArgs(1, 2).
+			WillReturnResult(sqlmock.NewResult(0, int64(i)))
+	}
+	// Antecedentes are joined into a new version, never updated
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO antecedentes`)).
+		WithArgs(1, 2, &userID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	// Relationships between both go away, the rest are moved
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM relaciones_pacientes r`)).
+		WithArgs(1, 2).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE relaciones_pacientes SET contacto_notificaciones = FALSE`)).
+		WithArgs(1, 2).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE relaciones_pacientes SET paciente_id = $1 WHERE paciente_id = $2`)).
+		WithArgs(1, 2).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE relaciones_pacientes SET relacionado_id = $1 WHERE relacionado_id = $2`)).
+		WithArgs(1, 2).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	// Preferences are joined into the surviving patient's
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO preferencias_contacto`)).
+		WithArgs(1, 2, &userID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO fusiones_pacientes`)).
+		WithArgs(1, 2, sqlmock.AnyArg(), sqlmock.AnyArg(), &userID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "fecha"}).AddRow(5, time.Now()))
+	// The merged patient's versions are kept with the merge before they're deleted
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO antecedentes_fusionados`)).
+		WithArgs(5, 2).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM antecedentes WHERE paciente_id = $1`)).
+		WithArgs(2).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM pacientes WHERE id = $1`)).
+		WithArgs(2).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	merge, err := repo.MergePatients(models.PatientMerge{PatientID: 1, MergedPatientID: 2, MergedPatient: models.Patient{ID: 2}, UserID: &userID})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if merge.ID != 5 || merge.Moved["citas"] != 0 || merge.Moved["consultas"] != 2 || merge.Moved["antecedentes"] != 1 || merge.Moved["relaciones_pacientes"] != 3 || merge.Moved["preferencias_contacto"] != 1 {
+		t.Errorf("unexpected merge: %+v", merge)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet sqlmock expectations: %v", err)
+	}
+}
+
+func TestMergePatients_MissingPatient(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock database: %v", err)
+	}
+	defer db.Close()
+
+	repo := NewPatientRepository(db)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`FOR UPDATE`)).
+		WithArgs(1, 2).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectRollback()
+
+	if _, err := repo.MergePatients(models.PatientMerge{PatientID: 1, MergedPatientID: 2}); !errors.Is(err, ErrPatientNotFound) {
+		t.Errorf("expected ErrPatientNotFound, got %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet sqlmock expectations: %v", err)
+	}
+}
+
+func TestSearchPatientsByName_MatchesEveryWord(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock database: %v", err)
+	}
+	defer db.Close()
+
+	repo := NewPatientRepository(db)
+
+	// Each word is a LIKE on the indexed expression, wildcards typed are escaped
+	mock.ExpectQuery(regexp.QuoteMeta(`lower(f_unaccent(nombre)) LIKE lower(f_unaccent($3)) AND lower(f_unaccent(nombre)) LIKE lower(f_unaccent($4))`)).
+		WithArgs("Perez 50%", 10, "%Perez%", `%50\%%`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "nombre", "fecha_nacimiento", "telefono", "sexo"}).
+			AddRow(1, "Juan Pérez", time.Date(1990, 5, 1, 0, 0, 0, 0, time.UTC), nil, models.SexMale),
+		)
+
+	patients, err := repo.SearchPatientsByName([]string{"Perez", "50%"}, 10)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(patients) != 1 || patients[0].Name != "Juan Pérez" || patients[0].Phone != "" {
+		t.Errorf("unexpected patients: %+v", patients)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet sqlmock expectations: %v", err)
+	}
+}
+
+func TestGetNotificationContact_PrefersContactsWithAPhone(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock database: %v", err)
+	}
+	defer db.Close()
+
+	repo := NewPatientRepository(db)
+
+	// Having a phone outranks being the chosen contact, so a guardian with one wins
+	columns := []string{"id", "paciente_id", "relacionado_id", "tipo", "contacto_notificaciones", "fecha", "nombre", "fecha_nacimiento", "telefono", "sexo"}
+	mock.ExpectQuery(`ORDER BY COALESCE\(p\.telefono, ''\) = '',\s+r\.contacto_notificaciones DESC`).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow(4, 1, 3, models.RelationGuardian, false, time.Now(), "Luis López", time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC), "+50255551234", models.SexMale))
+
+	contact, err := repo.GetNotificationContact(1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if contact.RelatedPatient.ID != 3 || contact.RelatedPatient.Phone != "+50255551234" {
+		t.Errorf("unexpected notification contact: %+v", contact.RelatedPatient)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet sqlmock expectations: %v", err)
+	}
+}
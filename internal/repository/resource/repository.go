@@ -0,0 +1,185 @@
+package resource
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"software-backend/internal/models"
+)
+
+// Custom errors, probably gonna be moved
+var (
+	ErrProviderNotFound = errors.New("provider not found in repository")
+	ErrRoomNotFound     = errors.New("room not found in repository")
+)
+
+// Interface for provider & room data operations
+type ResourceRepository interface {
+	ListProviders(onlyActive bool) ([]models.Provider, error)
+	GetProviderByID(id int) (*models.Provider, error)
+	CreateProvider(provider models.Provider) (*models.Provider, error)
+	UpdateProvider(provider models.Provider) error
+	ListRooms(onlyActive bool) ([]models.Room, error)
+	GetRoomByID(id int) (*models.Room, error)
+	CreateRoom(room models.Room) (*models.Room, error)
+	UpdateRoom(room models.Room) error
+}
+
+// Struct to manage dependencies
+type resourceRepository struct {
+	db *sql.DB
+}
+
+// Constructor to pass on dependencies
+func NewResourceRepository(db *sql.DB) ResourceRepository {
+	return &resourceRepository{db: db}
+}
+
+// List providers, optionally only the active ones
+func (r *resourceRepository) ListProviders(onlyActive bool) ([]models.Provider, error) {
+	query := `SELECT id, nombre, tipo, activo FROM proveedores`
+	if onlyActive {
+		query += ` WHERE activo = TRUE`
+	}
+	query += ` ORDER BY nombre`
+
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("repository: failed to list providers: %w", err)
+	}
+	defer rows.Close()
+
+	providers := []models.Provider{}
+	for rows.Next() {
+		var p models.Provider
+		if err := rows.Scan(&p.ID, &p.Name, &p.Kind, &p.Active); err != nil {
+			return nil, fmt.Errorf("repository: failed to scan provider: %w", err)
+		}
+		providers = append(providers, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("repository: error after iterating provider rows: %w", err)
+	}
+	return providers, nil
+}
+
+// Get a provider by ID
+func (r *resourceRepository) GetProviderByID(id int) (*models.Provider, error) {
+	var p models.Provider
+	err := r.db.QueryRow(`SELECT id, nombre, tipo, activo FROM proveedores WHERE id = $1`, id).
+		Scan(&p.ID, &p.Name, &p.Kind, &p.Active)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrProviderNotFound
+		}
+		return nil, fmt.Errorf("repository: failed to get provider by ID %d: %w", id, err)
+	}
+	return &p, nil
+}
+
+// Create a provider
+func (r *resourceRepository) CreateProvider(provider models.Provider) (*models.Provider, error) {
+	err := r.db.QueryRow(`
+		INSERT INTO proveedores (nombre, tipo, activo)
+		VALUES ($1, $2, $3)
+		RETURNING id
+	`, provider.Name, provider.Kind, provider.Active).Scan(&provider.ID)
+	if err != nil {
+		return nil, fmt.Errorf("repository: failed to create provider: %w", err)
+	}
+	return &provider, nil
+}
+
+// Update a provider
+func (r *resourceRepository) UpdateProvider(provider models.Provider) error {
+	result, err := r.db.Exec(`
+		UPDATE proveedores SET nombre = $1, tipo = $2, activo = $3
+		WHERE id = $4
+	`, provider.Name, provider.Kind, provider.Active, provider.ID)
+	if err != nil {
+		return fmt.Errorf("repository: failed to update provider ID %d: %w", provider.ID, err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("repository: failed to check rows affected for provider ID %d: %w", provider.ID, err)
+	}
+	if rowsAffected == 0 {
+		return ErrProviderNotFound
+	}
+	return nil
+}
+
+// List rooms, optionally only the active ones
+func (r *resourceRepository) ListRooms(onlyActive bool) ([]models.Room, error) {
+	query := `SELECT id, nombre, tipo, activo FROM salas`
+	if onlyActive {
+		query += ` WHERE activo = TRUE`
+	}
+	query += ` ORDER BY nombre`
+
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("repository: failed to list rooms: %w", err)
+	}
+	defer rows.Close()
+
+	rooms := []models.Room{}
+	for rows.Next() {
+		var room models.Room
+		if err := rows.Scan(&room.ID, &room.Name, &room.Kind, &room.Active); err != nil {
+			return nil, fmt.Errorf("repository: failed to scan room: %w", err)
+		}
+		rooms = append(rooms, room)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("repository: error after iterating room rows: %w", err)
+	}
+	return rooms, nil
+}
+
+// Get a room by ID
+func (r *resourceRepository) GetRoomByID(id int) (*models.Room, error) {
+	var room models.Room
+	err := r.db.QueryRow(`SELECT id, nombre, tipo, activo FROM salas WHERE id = $1`, id).
+		Scan(&room.ID, &room.Name, &room.Kind, &room.Active)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrRoomNotFound
+		}
+		return nil, fmt.Errorf("repository: failed to get room by ID %d: %w", id, err)
+	}
+	return &room, nil
+}
+
+// Create a room
+func (r *resourceRepository) CreateRoom(room models.Room) (*models.Room, error) {
+	err := r.db.QueryRow(`
+		INSERT INTO salas (nombre, tipo, activo)
+		VALUES ($1, $2, $3)
+		RETURNING id
+	`, room.Name, room.Kind, room.Active).Scan(&room.ID)
+	if err != nil {
+		return nil, fmt.Errorf("repository: failed to create room: %w", err)
+	}
+	return &room, nil
+}
+
+// Update a room
+func (r *resourceRepository) UpdateRoom(room models.Room) error {
+	result, err := r.db.Exec(`
+		UPDATE salas SET nombre = $1, tipo = $2, activo = $3
+		WHERE id = $4
+	`, room.Name, room.Kind, room.Active, room.ID)
+	if err != nil {
+		return fmt.Errorf("repository: failed to update room ID %d: %w", room.ID, err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("repository: failed to check rows affected for room ID %d: %w", room.ID, err)
+	}
+	if rowsAffected == 0 {
+		return ErrRoomNotFound
+	}
+	return nil
+}
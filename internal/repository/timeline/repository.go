@@ -0,0 +1,236 @@
+package timeline
+
+import (
+	"database/sql"
+	"fmt"
+
+	"software-backend/internal/models"
+
+	"github.com/lib/pq"
+)
+
+// Interface for the timeline queries, appointments come from their own repository
+type TimelineRepository interface {
+	ListEvents(patientID int, filter models.TimelineFilter) ([]models.TimelineEvent, int, error)
+	ListConsultations(ids []int) ([]models.ConsultationWithDetails, error)
+	ListExams(ids []int) ([]models.Exam, error)
+	ListNotifications(ids []int) ([]models.WhatsAppNotification, error)
+}
+
+// Struct to manage dependencies
+type timelineRepository struct {
+	db *sql.DB
+}
+
+// Constructor to pass on dependencies
+func NewTimelineRepository(db *sql.DB) TimelineRepository {
+	return &timelineRepository{db: db}
+}
+
+// Every event of a patient with its type, ID & date. $1 is the patient, $2 the types
+// (NULL for all), $3 & $4 the optional date range
+const timelineEvents = `
+    SELECT tipo, id, fecha FROM (
+        SELECT 'appointment' AS tipo, id, fecha::timestamptz AS fecha FROM citas WHERE paciente_id = $1
+        UNION ALL
+        SELECT 'consultation', id, fecha::timestamptz FROM consultas WHERE paciente_id = $1
+        UNION ALL
+        SELECT 'exam', id, fecha::timestamptz FROM examenes WHERE paciente_id = $1
+        UNION ALL
+        SELECT 'notification', id, created_at::timestamptz FROM whatsapp_notifications WHERE patient_id = $1
+    ) e
+    WHERE ($2::text[] IS NULL OR tipo = ANY($2::text[]))
+      AND ($3::timestamptz IS NULL OR fecha >= $3)
+      AND ($4::timestamptz IS NULL OR fecha < $4)
+`
+
+// Get a page of a patient's events, latest first, & how many there are in total. Only
+// type, ID & date are filled in
+func (r *timelineRepository) ListEvents(patientID int, filter models.TimelineFilter) ([]models.TimelineEvent, int, error) {
+	var types interface{}
+	if len(filter.Types) > 0 {
+		types = pq.Array(filter.Types)
+	}
+	args := []interface{}{patientID, types, filter.From, filter.To}
+
+	var total int
+	if err := r.db.QueryRow(`SELECT COUNT(*) FROM (`+timelineEvents+`) t`, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("repository: failed to count timeline of patient %d: %w", patientID, err)
+	}
+
+	rows, err := r.db.Query(timelineEvents+`
+    ORDER BY fecha DESC, tipo, id DESC
+    LIMIT $5 OFFSET $6
+    `, append(args, filter.Limit, filter.Offset)...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("repository: failed to list timeline of patient %d: %w", patientID, err)
+	}
+	defer rows.Close()
+
+	events := []models.TimelineEvent{}
+	for rows.Next() {
+		var event models.TimelineEvent
+		if err := rows.Scan(&event.Type, &event.ID, &event.Date); err != nil {
+			return nil, 0, fmt.Errorf("repository: failed to scan timeline event: %w", err)
+		}
+		events = append(events, event)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("repository: error after iterating timeline events: %w", err)
+	}
+	return events, total, nil
+}
+
+// Get several consultations with their diagnoses & treatments
+func (r *timelineRepository) ListConsultations(ids []int) ([]models.ConsultationWithDetails, error) {
+	rows, err := r.db.Query(`
+        SELECT id, paciente_id, cuestionario_id, motivo, fecha, cita_id
+        FROM consultas
+        WHERE id = ANY($1)
+    `, pq.Array(ids))
+	if err != nil {
+		return nil, fmt.Errorf("repository: failed to list consultations: %w", err)
+	}
+	defer rows.Close()
+
+	consultations := []models.ConsultationWithDetails{}
+	byID := map[int]int{}
+	for rows.Next() {
+		var c models.ConsultationWithDetails
+		var questionnaireID, appointmentID sql.NullInt64
+		if err := rows.Scan(&c.ID, &c.PatientID, &questionnaireID, &c.Reason, &c.Date, &appointmentID); err != nil {
+			return nil, fmt.Errorf("repository: failed to scan consultation: %w", err)
+		}
+		c.QuestionnaireID = nullableInt(questionnaireID)
+		c.AppointmentID = nullableInt(appointmentID)
+		c.Diagnoses = []models.Diagnostic{}
+		byID[c.ID] = len(consultations)
+		consultations = append(consultations, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("repository: error after iterating consultations: %w", err)
+	}
+	if len(consultations) == 0 {
+		return consultations, nil
+	}
+
+	// Diagnoses & their treatments, one row per treatment
+	rows, err = r.db.Query(`
+        SELECT d.consulta_id, d.id, d.nombre, d.recomendacion,
+               t.id, t.componente_activo, t.presentacion, t.dosificacion, t.frecuencia, t.tiempo
+        FROM diagnosticos d
+        LEFT JOIN tratamientos t ON d.id = t.diagnostico_id
+        WHERE d.consulta_id = ANY($1)
+        ORDER BY d.consulta_id, d.id, t.id
+    `, pq.Array(ids))
+	if err != nil {
+		return nil, fmt.Errorf("repository: failed to list diagnoses: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var diag models.Diagnostic
+		var treatID sql.NullInt64
+		var activeComponent, presentation, dosage, frequency, duration sql.NullString
+		err := rows.Scan(
+			&diag.ConsultationID, &diag.ID, &diag.Name, &diag.Recommendation,
+			&treatID, &activeComponent, &presentation, &dosage, &frequency, &duration,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("repository: failed to scan diagnosis: %w", err)
+		}
+
+		// Rows come ordered, a diagnosis' treatments are next to each other
+		consultation := &consultations[byID[diag.ConsultationID]]
+		if n := len(consultation.Diagnoses); n == 0 || consultation.Diagnoses[n-1].ID != diag.ID {
+			diag.Treatments = []models.Treatment{}
+			consultation.Diagnoses = append(consultation.Diagnoses, diag)
+		}
+		if treatID.Valid {
+			last := &consultation.Diagnoses[len(consultation.Diagnoses)-1]
+			last.Treatments = append(last.Treatments, models.Treatment{
+				ID:              int(treatID.Int64),
+				DiagnosticID:    diag.ID,
+				ActiveComponent: activeComponent.String,
+				Presentation:    presentation.String,
+				Dosage:          dosage.String,
+				Frequency:       frequency.String,
+				Duration:        duration.String,
+			})
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("repository: error after iterating diagnoses: %w", err)
+	}
+	return consultations, nil
+}
+
+// Get several exams
+func (r *timelineRepository) ListExams(ids []int) ([]models.Exam, error) {
+	rows, err := r.db.Query(`
+        SELECT id, paciente_id, consulta_id, tipo, fecha, s3_key, file_size, mime_type
+        FROM examenes
+        WHERE id = ANY($1)
+    `, pq.Array(ids))
+	if err != nil {
+		return nil, fmt.Errorf("repository: failed to list exams: %w", err)
+	}
+	defer rows.Close()
+
+	exams := []models.Exam{}
+	for rows.Next() {
+		var exam models.Exam
+		err := rows.Scan(&exam.ID, &exam.PatientID, &exam.ConsultaID, &exam.Type, &exam.Date, &exam.S3Key, &exam.FileSize, &exam.MimeType)
+		if err != nil {
+			return nil, fmt.Errorf("repository: failed to scan exam: %w", err)
+		}
+		exam.SetHasFile()
+		exams = append(exams, exam)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("repository: error after iterating exams: %w", err)
+	}
+	return exams, nil
+}
+
+// Get several WhatsApp notifications
+func (r *timelineRepository) ListNotifications(ids []int) ([]models.WhatsAppNotification, error) {
+	rows, err := r.db.Query(`
+        SELECT id, appointment_id, patient_id, phone_number, message_type,
+               status, whatsapp_msg_id, error_message, sent_at,
+               delivered_at, read_at, created_at, updated_at
+        FROM whatsapp_notifications
+        WHERE id = ANY($1)
+    `, pq.Array(ids))
+	if err != nil {
+		return nil, fmt.Errorf("repository: failed to list notifications: %w", err)
+	}
+	defer rows.Close()
+
+	notifications := []models.WhatsAppNotification{}
+	for rows.Next() {
+		var n models.WhatsAppNotification
+		err := rows.Scan(
+			&n.ID, &n.AppointmentID, &n.PatientID, &n.PhoneNumber, &n.MessageType,
+			&n.Status, &n.WhatsAppMsgID, &n.ErrorMessage, &n.SentAt,
+			&n.DeliveredAt, &n.ReadAt, &n.CreatedAt, &n.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("repository: failed to scan notification: %w", err)
+		}
+		notifications = append(notifications, n)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("repository: error after iterating notifications: %w", err)
+	}
+	return notifications, nil
+}
+
+// Convert a nullable integer column into an optional ID
+func nullableInt(value sql.NullInt64) *int {
+	if !value.Valid {
+		return nil
+	}
+	id := int(value.Int64)
+	return &id
+}
@@ -0,0 +1,337 @@
+package waitlist
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"software-backend/internal/models"
+)
+
+// Custom errors, probably gonna be moved
+var (
+	ErrEntryNotFound = errors.New("waitlist entry not found in repository")
+	ErrOfferNotFound = errors.New("waitlist offer not found in repository")
+	ErrOfferClosed   = errors.New("waitlist offer is no longer pending")
+)
+
+// Interface for waitlist data operations
+type WaitlistRepository interface {
+	CreateEntry(entry models.WaitlistEntry) (*models.WaitlistEntry, error)
+	GetEntryByID(id int) (*models.WaitlistEntry, error)
+	ListEntries(status string) ([]models.WaitlistEntry, error)
+	UpdateEntryStatus(id int, status string) error
+	CreateOffer(offer models.WaitlistOffer) (*models.WaitlistOffer, error)
+	GetOfferByToken(token string) (*models.WaitlistOffer, error)
+	AcceptOffer(offerID, appointmentID int) error
+	MarkOfferTaken(offerID int) error
+}
+
+// Struct to manage dependencies
+type waitlistRepository struct {
+	db *sql.DB
+}
+
+// Constructor to pass on dependencies
+func NewWaitlistRepository(db *sql.DB) WaitlistRepository {
+	return &waitlistRepository{db: db}
+}
+
+// Create a waitlist entry
+func (r *waitlistRepository) CreateEntry(entry models.WaitlistEntry) (*models.WaitlistEntry, error) {
+	query := `INSERT INTO lista_espera (paciente_id, fecha_desde, fecha_hasta, hora_desde, hora_hasta, duracion, proveedor_id, notas, estado)
+			  VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+			  RETURNING id, creado`
+
+	err := r.db.QueryRow(query,
+		entry.PatientID,
+		entry.DateFrom,
+		entry.DateTo,
+		nullableString(entry.TimeFrom),
+		nullableString(entry.TimeTo),
+		int64(entry.Duration/time.Second),
+		entry.ProviderID,
+		entry.Notes,
+		entry.Status,
+	).Scan(&entry.ID, &entry.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("repository: failed to create waitlist entry: %w", err)
+	}
+	return &entry, nil
+}
+
+// Get a waitlist entry by ID
+func (r *waitlistRepository) GetEntryByID(id int) (*models.WaitlistEntry, error) {
+	query := `
+		SELECT id, paciente_id, fecha_desde, fecha_hasta, hora_desde, hora_hasta, duracion, proveedor_id, notas, estado, creado
+		FROM lista_espera
+		WHERE id = $1
+	`
+	entry, err := scanEntry(r.db.QueryRow(query, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrEntryNotFound
+		}
+		return nil, fmt.Errorf("repository: failed to get waitlist entry by ID %d: %w", id, err)
+	}
+	return entry, nil
+}
+
+// List waitlist entries oldest first, every entry if status is empty
+func (r *waitlistRepository) ListEntries(status string) ([]models.WaitlistEntry, error) {
+	query := `
+		SELECT id, paciente_id, fecha_desde, fecha_hasta, hora_desde, hora_hasta, duracion, proveedor_id, notas, estado, creado
+		FROM lista_espera
+	`
+	var args []interface{}
+	if status != "" {
+		query += ` WHERE estado = $1`
+		args = append(args, status)
+	}
+	query += ` ORDER BY creado, id`
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("repository: failed to list waitlist entries: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []models.WaitlistEntry{}
+	for rows.Next() {
+		entry, err := scanEntry(rows)
+		if err != nil {
+			return nil, fmt.Errorf("repository: failed to scan waitlist entry: %w", err)
+		}
+		entries = append(entries, *entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("repository: error after iterating waitlist rows: %w", err)
+	}
+	return entries, nil
+}
+
+// Anything with a Scan method, sql.Row & sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// Scan a row with the waitlist entry columns
+func scanEntry(row rowScanner) (*models.WaitlistEntry, error) {
+	entry := &models.WaitlistEntry{}
+	var dateFrom, dateTo time.Time
+	var timeFrom, timeTo sql.NullTime
+	var durationSeconds int64
+	var providerID sql.NullInt64
+
+	err := row.Scan(
+		&entry.ID,
+		&entry.PatientID,
+		&dateFrom,
+		&dateTo,
+		&timeFrom,
+		&timeTo,
+		&durationSeconds,
+		&providerID,
+		&entry.Notes,
+		&entry.Status,
+		&entry.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// Dates & times of day are handled as strings by the service
+	entry.DateFrom = dateFrom.Format("2006-01-02")
+	entry.DateTo = dateTo.Format("2006-01-02")
+	if timeFrom.Valid {
+		entry.TimeFrom = timeFrom.Time.Format("15:04")
+	}
+	if timeTo.Valid {
+		entry.TimeTo = timeTo.Time.Format("15:04")
+	}
+	entry.Duration = time.Duration(durationSeconds) * time.Second
+	if providerID.Valid {
+		pID := int(providerID.Int64)
+		entry.ProviderID = &pID
+	}
+	return entry, nil
+}
+
+// Update the status of a waitlist entry, its pending offers are closed once it's no
+// longer waiting
+func (r *waitlistRepository) UpdateEntryStatus(id int, status string) error {
+	// Start transaction
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("repository: failed to begin transaction for waitlist entry ID %d: %w", id, err)
+	}
+	// Rollback if error
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`UPDATE lista_espera SET estado = $1 WHERE id = $2`, status, id)
+	if err != nil {
+		return fmt.Errorf("repository: failed to update waitlist entry ID %d: %w", id, err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("repository: failed to check rows affected for waitlist entry ID %d: %w", id, err)
+	}
+	if rowsAffected == 0 {
+		return ErrEntryNotFound
+	}
+
+	if status != models.WaitlistWaiting {
+		_, err = tx.Exec(`UPDATE ofertas_lista_espera SET estado = 'taken' WHERE entrada_id = $1 AND estado = 'pending'`, id)
+		if err != nil {
+			return fmt.Errorf("repository: failed to close offers of waitlist entry ID %d: %w", id, err)
+		}
+	}
+
+	// Commit transaction if every query successful
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("repository: failed to commit waitlist entry ID %d: %w", id, err)
+	}
+	return nil
+}
+
+// Create an offer of a freed slot
+func (r *waitlistRepository) CreateOffer(offer models.WaitlistOffer) (*models.WaitlistOffer, error) {
+	query := `INSERT INTO ofertas_lista_espera (entrada_id, cita_liberada_id, fecha, duracion, proveedor_id, sala_id, token, expira, estado)
+			  VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+			  RETURNING id, creado`
+
+	err := r.db.QueryRow(query,
+		offer.EntryID,
+		offer.ReleasedAppointmentID,
+		offer.Start,
+		int64(offer.Duration/time.Second),
+		offer.ProviderID,
+		offer.RoomID,
+		offer.Token,
+		offer.ExpiresAt,
+		offer.Status,
+	).Scan(&offer.ID, &offer.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("repository: failed to create waitlist offer: %w", err)
+	}
+	return &offer, nil
+}
+
+// Get an offer by the token sent to the patient
+func (r *waitlistRepository) GetOfferByToken(token string) (*models.WaitlistOffer, error) {
+	query := `
+		SELECT id, entrada_id, cita_liberada_id, fecha, duracion, proveedor_id, sala_id, token, expira, estado, cita_id, creado
+		FROM ofertas_lista_espera
+		WHERE token = $1
+	`
+	offer := &models.WaitlistOffer{}
+	var durationSeconds int64
+	var providerID, roomID, appointmentID sql.NullInt64
+	err := r.db.QueryRow(query, token).Scan(
+		&offer.ID,
+		&offer.EntryID,
+		&offer.ReleasedAppointmentID,
+		&offer.Start,
+		&durationSeconds,
+		&providerID,
+		&roomID,
+		&offer.Token,
+		&offer.ExpiresAt,
+		&offer.Status,
+		&appointmentID,
+		&offer.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrOfferNotFound
+		}
+		return nil, fmt.Errorf("repository: failed to get waitlist offer: %w", err)
+	}
+
+	// Null handling
+	offer.Duration = time.Duration(durationSeconds) * time.Second
+	if providerID.Valid {
+		pID := int(providerID.Int64)
+		offer.ProviderID = &pID
+	}
+	if roomID.Valid {
+		rID := int(roomID.Int64)
+		offer.RoomID = &rID
+	}
+	if appointmentID.Valid {
+		aID := int(appointmentID.Int64)
+		offer.AppointmentID = &aID
+	}
+	return offer, nil
+}
+
+// Mark an offer accepted with the appointment it booked, its entry as booked & every
+// other pending offer for the same slot or entry as taken. Fails with ErrOfferClosed if
+// the offer or its entry was closed in the meantime
+func (r *waitlistRepository) AcceptOffer(offerID, appointmentID int) error {
+	// Start transaction
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("repository: failed to begin transaction for offer %d: %w", offerID, err)
+	}
+	// Rollback if error
+	defer tx.Rollback()
+
+	var entryID, releasedID int
+	err = tx.QueryRow(`
+		UPDATE ofertas_lista_espera
+		SET estado = 'accepted', cita_id = $2
+		WHERE id = $1 AND estado = 'pending'
+		RETURNING entrada_id, cita_liberada_id
+	`, offerID, appointmentID).Scan(&entryID, &releasedID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrOfferClosed
+		}
+		return fmt.Errorf("repository: failed to accept offer %d: %w", offerID, err)
+	}
+
+	result, err := tx.Exec(`UPDATE lista_espera SET estado = 'booked' WHERE id = $1 AND estado = 'waiting'`, entryID)
+	if err != nil {
+		return fmt.Errorf("repository: failed to book waitlist entry %d: %w", entryID, err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("repository: failed to check rows affected for waitlist entry %d: %w", entryID, err)
+	}
+	if rowsAffected == 0 {
+		return ErrOfferClosed
+	}
+	_, err = tx.Exec(`
+		UPDATE ofertas_lista_espera
+		SET estado = 'taken'
+		WHERE (cita_liberada_id = $1 OR entrada_id = $2) AND estado = 'pending'
+	`, releasedID, entryID)
+	if err != nil {
+		return fmt.Errorf("repository: failed to close other offers for appointment %d: %w", releasedID, err)
+	}
+
+	// Commit transaction if every query successful
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("repository: failed to commit acceptance of offer %d: %w", offerID, err)
+	}
+	return nil
+}
+
+// Mark an offer as taken by someone else
+func (r *waitlistRepository) MarkOfferTaken(offerID int) error {
+	_, err := r.db.Exec(`UPDATE ofertas_lista_espera SET estado = 'taken' WHERE id = $1 AND estado = 'pending'`, offerID)
+	if err != nil {
+		return fmt.Errorf("repository: failed to mark offer %d as taken: %w", offerID, err)
+	}
+	return nil
+}
+
+// Store empty strings as NULL
+func nullableString(value string) interface{} {
+	if value == "" {
+		return nil
+	}
+	return value
+}
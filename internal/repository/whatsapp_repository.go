@@ -14,6 +14,7 @@ type WhatsAppRepository interface {
 	CreateNotification(ctx context.Context, notification *models.WhatsAppNotification) error
 	UpdateNotificationStatus(ctx context.Context, msgID string, status string, deliveredAt, readAt *sql.NullTime) error
 	GetPendingNotifications(ctx context.Context, limit int) ([]*models.WhatsAppNotification, error)
+	GetPendingNotificationsByType(ctx context.Context, messageType string, limit int) ([]*models.WhatsAppNotification, error)
 	LogWebhook(ctx context.Context, eventType string, payload string) error
 	GetNotificationsByAppointment(ctx context.Context, appointmentID int) ([]*models.WhatsAppNotification, error)
 }
@@ -31,7 +32,8 @@ func (r *whatsAppRepository) GetConfig(ctx context.Context) (*models.WhatsAppCon
 		SELECT id, phone_number_id, access_token, business_account_id, 
 		       webhook_verify_token, is_active, reminder_enabled,
 		       reminder_3_days_before, reminder_1_day_before, reminder_2_hours_before,
-		       template_name_reminder, template_lang_code, created_at, updated_at
+		       template_name_reminder, template_lang_code, template_name_waitlist_offer,
+		       template_name_rescheduled, created_at, updated_at
 		FROM whatsapp_config
 		ORDER BY id DESC
 		LIMIT 1
@@ -51,6 +53,8 @@ func (r *whatsAppRepository) GetConfig(ctx context.Context) (*models.WhatsAppCon
 		&config.Reminder2HoursBefore,
 		&config.TemplateNameReminder,
 		&config.TemplateLangCode,
+		&config.TemplateNameOffer,
+		&config.TemplateNameRescheduled,
 		&config.CreatedAt,
 		&config.UpdatedAt,
 	)
@@ -79,8 +83,10 @@ func (r *whatsAppRepository) UpdateConfig(ctx context.Context, config *models.Wh
 		    reminder_2_hours_before = $9,
 		    template_name_reminder = $10,
 		    template_lang_code = $11,
+		    template_name_waitlist_offer = $12,
+		    template_name_rescheduled = $13,
 		    updated_at = CURRENT_TIMESTAMP
-		WHERE id = $12
+		WHERE id = $14
 	`
 
 	_, err := r.db.ExecContext(ctx, query,
@@ -95,6 +101,8 @@ func (r *whatsAppRepository) UpdateConfig(ctx context.Context, config *models.Wh
 		config.Reminder2HoursBefore,
 		config.TemplateNameReminder,
 		config.TemplateLangCode,
+		config.TemplateNameOffer,
+		config.TemplateNameRescheduled,
 		config.ID,
 	)
 
@@ -109,13 +117,15 @@ func (r *whatsAppRepository) CreateNotification(ctx context.Context, notificatio
 	query := `
 		INSERT INTO whatsapp_notifications (
 			appointment_id, patient_id, phone_number, message_type, 
-			status, whatsapp_msg_id, error_message, sent_at
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+			status, whatsapp_msg_id, error_message, sent_at, previous_start
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
 		ON CONFLICT (appointment_id, message_type) DO UPDATE
-		SET status = EXCLUDED.status,
+		SET phone_number = EXCLUDED.phone_number,
+		    status = EXCLUDED.status,
 		    whatsapp_msg_id = EXCLUDED.whatsapp_msg_id,
 		    error_message = EXCLUDED.error_message,
 		    sent_at = EXCLUDED.sent_at,
+		    previous_start = EXCLUDED.previous_start,
 		    updated_at = CURRENT_TIMESTAMP
 		RETURNING id, created_at, updated_at
 	`
@@ -129,6 +139,7 @@ func (r *whatsAppRepository) CreateNotification(ctx context.Context, notificatio
 		notification.WhatsAppMsgID,
 		notification.ErrorMessage,
 		notification.SentAt,
+		notification.PreviousStart,
 	).Scan(&notification.ID, &notification.CreatedAt, &notification.UpdatedAt)
 
 	if err != nil {
@@ -158,45 +169,34 @@ func (r *whatsAppRepository) UpdateNotificationStatus(ctx context.Context, msgID
 
 func (r *whatsAppRepository) GetPendingNotifications(ctx context.Context, limit int) ([]*models.WhatsAppNotification, error) {
 	query := `
-		SELECT id, appointment_id, patient_id, phone_number, message_type, 
-		       status, whatsapp_msg_id, error_message, sent_at, 
-		       delivered_at, read_at, created_at, updated_at
+		SELECT ` + notificationColumns + `
 		FROM whatsapp_notifications
 		WHERE status = 'pending'
 		ORDER BY created_at ASC
 		LIMIT $1
 	`
 
-	rows, err := r.db.QueryContext(ctx, query, limit)
+	notifications, err := r.queryNotifications(ctx, query, limit)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get pending notifications: %w", err)
 	}
-	defer rows.Close()
+	return notifications, nil
+}
 
-	var notifications []*models.WhatsAppNotification
-	for rows.Next() {
-		var n models.WhatsAppNotification
-		err := rows.Scan(
-			&n.ID,
-			&n.AppointmentID,
-			&n.PatientID,
-			&n.PhoneNumber,
-			&n.MessageType,
-			&n.Status,
-			&n.WhatsAppMsgID,
-			&n.ErrorMessage,
-			&n.SentAt,
-			&n.DeliveredAt,
-			&n.ReadAt,
-			&n.CreatedAt,
-			&n.UpdatedAt,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan notification: %w", err)
-		}
-		notifications = append(notifications, &n)
-	}
+// Oldest pending notifications of a single type, e.g. the reschedule notices left to send
+func (r *whatsAppRepository) GetPendingNotificationsByType(ctx context.Context, messageType string, limit int) ([]*models.WhatsAppNotification, error) {
+	query := `
+		SELECT ` + notificationColumns + `
+		FROM whatsapp_notifications
+		WHERE status = 'pending' AND message_type = $1
+		ORDER BY updated_at ASC
+		LIMIT $2
+	`
 
+	notifications, err := r.queryNotifications(ctx, query, messageType, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pending %s notifications: %w", messageType, err)
+	}
 	return notifications, nil
 }
 
@@ -216,18 +216,30 @@ func (r *whatsAppRepository) LogWebhook(ctx context.Context, eventType string, p
 
 func (r *whatsAppRepository) GetNotificationsByAppointment(ctx context.Context, appointmentID int) ([]*models.WhatsAppNotification, error) {
 	query := `
-		SELECT id, appointment_id, patient_id, phone_number, message_type, 
-		       status, whatsapp_msg_id, error_message, sent_at, 
-		       delivered_at, read_at, created_at, updated_at
+		SELECT ` + notificationColumns + `
 		FROM whatsapp_notifications
 		WHERE appointment_id = $1
 		ORDER BY created_at DESC
 	`
 
-	rows, err := r.db.QueryContext(ctx, query, appointmentID)
+	notifications, err := r.queryNotifications(ctx, query, appointmentID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get notifications: %w", err)
 	}
+	return notifications, nil
+}
+
+// Columns read into a WhatsAppNotification, in the order queryNotifications scans them
+const notificationColumns = `id, appointment_id, patient_id, phone_number, message_type,
+		       status, whatsapp_msg_id, error_message, sent_at,
+		       delivered_at, read_at, previous_start, created_at, updated_at`
+
+// Run a query selecting notificationColumns & scan its rows
+func (r *whatsAppRepository) queryNotifications(ctx context.Context, query string, args ...interface{}) ([]*models.WhatsAppNotification, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
 	defer rows.Close()
 
 	var notifications []*models.WhatsAppNotification
@@ -245,6 +257,7 @@ func (r *whatsAppRepository) GetNotificationsByAppointment(ctx context.Context,
 			&n.SentAt,
 			&n.DeliveredAt,
 			&n.ReadAt,
+			&n.PreviousStart,
 			&n.CreatedAt,
 			&n.UpdatedAt,
 		)
@@ -254,5 +267,5 @@ func (r *whatsAppRepository) GetNotificationsByAppointment(ctx context.Context,
 		notifications = append(notifications, &n)
 	}
 
-	return notifications, nil
+	return notifications, rows.Err()
 }
@@ -0,0 +1,61 @@
+package scheduler
+
+import (
+	"log"
+	"time"
+
+	"software-backend/internal/service/appointment"
+)
+
+// NoShowScheduler periodically flags appointments nobody checked in as no-shows
+type NoShowScheduler struct {
+	appointmentService appointment.AppointmentService
+	interval           time.Duration
+	stopChan           chan struct{}
+}
+
+// NewNoShowScheduler creates a new no-show scheduler
+func NewNoShowScheduler(appointmentService appointment.AppointmentService, interval time.Duration) *NoShowScheduler {
+	return &NoShowScheduler{
+		appointmentService: appointmentService,
+		interval:           interval,
+		stopChan:           make(chan struct{}),
+	}
+}
+
+// Start begins the scheduler
+func (s *NoShowScheduler) Start() {
+	log.Println("Starting no-show scheduler...")
+	ticker := time.NewTicker(s.interval)
+
+	// Run immediately on start
+	s.runCheck()
+
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				s.runCheck()
+			case <-s.stopChan:
+				ticker.Stop()
+				log.Println("No-show scheduler stopped")
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops the scheduler
+func (s *NoShowScheduler) Stop() {
+	close(s.stopChan)
+}
+
+func (s *NoShowScheduler) runCheck() {
+	marked, err := s.appointmentService.MarkNoShows()
+	if err != nil {
+		log.Printf("Error flagging no-shows: %v", err)
+	}
+	if marked > 0 {
+		log.Printf("Flagged %d appointment(s) as no-show", marked)
+	}
+}
@@ -0,0 +1,66 @@
+package scheduler
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"software-backend/internal/service"
+)
+
+// RescheduleNoticeScheduler periodically sends the pending reschedule notices, notices
+// that failed to send are retried on the next run
+type RescheduleNoticeScheduler struct {
+	notifier *service.WhatsAppRescheduleNotifier
+	interval time.Duration
+	stopChan chan struct{}
+}
+
+// NewRescheduleNoticeScheduler creates a new reschedule notice scheduler
+func NewRescheduleNoticeScheduler(notifier *service.WhatsAppRescheduleNotifier, interval time.Duration) *RescheduleNoticeScheduler {
+	return &RescheduleNoticeScheduler{
+		notifier: notifier,
+		interval: interval,
+		stopChan: make(chan struct{}),
+	}
+}
+
+// Start begins the scheduler
+func (s *RescheduleNoticeScheduler) Start() {
+	log.Println("Starting reschedule notice scheduler...")
+	ticker := time.NewTicker(s.interval)
+
+	// Run immediately on start
+	s.runCheck()
+
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				s.runCheck()
+			case <-s.stopChan:
+				ticker.Stop()
+				log.Println("Reschedule notice scheduler stopped")
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops the scheduler
+func (s *RescheduleNoticeScheduler) Stop() {
+	close(s.stopChan)
+}
+
+func (s *RescheduleNoticeScheduler) runCheck() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	sent, err := s.notifier.SendPendingRescheduled(ctx)
+	if err != nil {
+		log.Printf("Error sending reschedule notices: %v", err)
+	}
+	if sent > 0 {
+		log.Printf("Sent %d reschedule notice(s)", sent)
+	}
+}
@@ -0,0 +1,49 @@
+package appointment
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"software-backend/internal/models"
+	"software-backend/internal/repository/appointment"
+)
+
+// Attendance settings
+const (
+	// Time after an appointment starts before it's a no-show if nobody checked in
+	NoShowGracePeriod = 30 * time.Minute
+	// Cancellations made closer than this to the appointment count as late
+	LateCancellationWindow = 24 * time.Hour
+	// How far back the no-show job looks, older appointments are left as they are
+	noShowLookback = 48 * time.Hour
+)
+
+// Flag the appointments nobody checked in within the grace period as no-shows, returns
+// how many were flagged. Meant to run periodically
+func (s *appointmentService) MarkNoShows() (int, error) {
+	cutoff := s.now().Add(-NoShowGracePeriod)
+	appointments, err := s.apptRepo.ListUncheckedAppointments(cutoff.Add(-noShowLookback), cutoff)
+	if err != nil {
+		return 0, fmt.Errorf("service: failed to list unchecked appointments: %w", err)
+	}
+
+	marked := 0
+	for _, appt := range appointments {
+		// No user, the change is made by the system
+		if _, err := s.ChangeAppointmentStatus(appt.ID, models.StatusNoShow, nil); err != nil {
+			// Checked in or cancelled since it was listed
+			if errors.Is(err, appointment.ErrStatusChanged) || errors.Is(err, ErrInvalidTransition) {
+				continue
+			}
+			return marked, err
+		}
+		marked++
+	}
+	return marked, nil
+}
+
+// Get how many appointments a patient kept, cancelled & missed
+func (s *appointmentService) GetAttendanceStats(patientID int) (*models.AttendanceStats, error) {
+	return s.apptRepo.GetAttendanceStats(patientID, LateCancellationWindow)
+}
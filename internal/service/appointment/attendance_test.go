@@ -0,0 +1,43 @@
+package appointment
+
+import (
+	"testing"
+	"time"
+
+	"software-backend/internal/mocks"
+	"software-backend/internal/models"
+	"software-backend/internal/repository/appointment"
+
+	"github.com/golang/mock/gomock"
+)
+
+func TestMarkNoShows(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockAppointmentRepository(ctrl)
+	expectTransactions(mockRepo)
+	svc := NewAppointmentService(mockRepo, mocks.NewMockAppointmentTypeRepository(ctrl), &mockBookingRuleRepository{}, &mockBusinessHoursService{}).(*appointmentService)
+	svc.now = func() time.Time { return time.Date(2024, 7, 18, 12, 0, 0, 0, time.UTC) }
+
+	// Only appointments started before the grace period are looked at
+	cutoff := time.Date(2024, 7, 18, 11, 30, 0, 0, time.UTC)
+	mockRepo.EXPECT().
+		ListUncheckedAppointments(cutoff.Add(-noShowLookback), cutoff).
+		Return([]models.Appointment{{ID: 1, Status: models.StatusScheduled}, {ID: 2, Status: models.StatusConfirmed}}, nil)
+	mockRepo.EXPECT().GetAppointmentByID(1).Return(&models.Appointment{ID: 1, Status: models.StatusScheduled}, nil)
+	mockRepo.EXPECT().UpdateAppointmentStatus(1, models.StatusScheduled, models.StatusNoShow, nil).Return(nil)
+
+	// Checked in while the job was running
+	mockRepo.EXPECT().GetAppointmentByID(2).Return(&models.Appointment{ID: 2, Status: models.StatusConfirmed}, nil)
+	mockRepo.EXPECT().UpdateAppointmentStatus(2, models.StatusConfirmed, models.StatusNoShow, nil).Return(appointment.ErrStatusChanged)
+	mockRepo.EXPECT().CreateAuditEntry(gomock.Any()).Return(nil)
+
+	marked, err := svc.MarkNoShows()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if marked != 1 {
+		t.Errorf("expected 1 appointment flagged, got %d", marked)
+	}
+}
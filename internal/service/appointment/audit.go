@@ -0,0 +1,115 @@
+package appointment
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"software-backend/internal/models"
+)
+
+// Fields left out of the audit trail, they follow from other changes or aren't stored
+var unauditedFields = map[string]bool{
+	"id":                true,
+	"updated_at":        true,
+	"sequence":          true,
+	"status_changed_at": true,
+	"status_changed_by": true,
+	"consultation_id":   true,
+	"override":          true,
+}
+
+// Record a change made to an appointment, 'before' is nil for a new one. Meant to run in
+// the change's transaction so no change goes unrecorded
+func (s *appointmentService) audit(action string, before, after *models.Appointment, userID *int) error {
+	changes, err := diffAppointments(before, after)
+	if err != nil {
+		return fmt.Errorf("service: failed to diff appointment %d for the audit trail: %w", after.ID, err)
+	}
+	// Nothing to record for an update that didn't change anything
+	if len(changes) == 0 && action == models.AuditUpdate {
+		return nil
+	}
+
+	return s.apptRepo.CreateAuditEntry(models.AppointmentAuditEntry{
+		AppointmentID: after.ID,
+		Action:        action,
+		UserID:        userID,
+		Changes:       changes,
+	})
+}
+
+// Get the fields that differ between two versions of an appointment, by JSON field name
+func diffAppointments(before, after *models.Appointment) (map[string]models.FieldChange, error) {
+	beforeFields, err := appointmentFields(before)
+	if err != nil {
+		return nil, err
+	}
+	afterFields, err := appointmentFields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := map[string]models.FieldChange{}
+	for name, value := range afterFields {
+		if unauditedFields[name] || reflect.DeepEqual(beforeFields[name], value) {
+			continue
+		}
+		// A field going from missing to empty didn't really change
+		if isEmptyField(beforeFields[name]) && isEmptyField(value) {
+			continue
+		}
+		changes[name] = models.FieldChange{Before: beforeFields[name], After: value}
+	}
+	// Fields cleared by the change
+	for name, value := range beforeFields {
+		if _, ok := afterFields[name]; !ok && !unauditedFields[name] && !isEmptyField(value) {
+			changes[name] = models.FieldChange{Before: value, After: nil}
+		}
+	}
+	return changes, nil
+}
+
+// Returns true for a missing or zero JSON value
+func isEmptyField(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case string:
+		return v == ""
+	case float64:
+		return v == 0
+	case bool:
+		return !v
+	}
+	return false
+}
+
+// Fields of an appointment as they're sent by the API, none for a nil one
+func appointmentFields(appt *models.Appointment) (map[string]interface{}, error) {
+	fields := map[string]interface{}{}
+	if appt == nil {
+		return fields, nil
+	}
+	// The same instant reads the same whatever time zone it came in
+	normalized := *appt
+	normalized.Start = normalized.Start.UTC()
+
+	data, err := json.Marshal(normalized)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+// Get the changes made to an appointment, oldest first
+func (s *appointmentService) GetAuditTrail(id int) ([]models.AppointmentAuditEntry, error) {
+	// Make sure the appointment exists so a missing one isn't an empty trail
+	if _, err := s.apptRepo.GetAppointmentByID(id); err != nil {
+		return nil, err
+	}
+	return s.apptRepo.GetAuditTrail(id)
+}
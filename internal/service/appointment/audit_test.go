@@ -0,0 +1,86 @@
+package appointment
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"software-backend/internal/mocks"
+	"software-backend/internal/models"
+
+	"github.com/golang/mock/gomock"
+)
+
+func TestUpdateAppointment_RecordsChange(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockAppointmentRepository(ctrl)
+	expectTransactions(mockRepo)
+	bhService := &mockBusinessHoursService{
+		GetBusinessHoursForDateFunc: func(date time.Time) ([]models.BusinessHourInterval, error) {
+			return []models.BusinessHourInterval{{Start: "09:00", End: "17:00"}}, nil
+		},
+	}
+	svc := NewAppointmentService(mockRepo, mocks.NewMockAppointmentTypeRepository(ctrl), &mockBookingRuleRepository{}, bhService)
+
+	existing := &models.Appointment{ID: 7, PatientID: 1, Name: "Ana", Start: time.Date(2024, 7, 18, 10, 0, 0, 0, time.UTC), Duration: time.Hour, Status: models.StatusConfirmed, Sequence: 2}
+	mockRepo.EXPECT().GetAppointmentByID(7).Return(existing, nil)
+	mockRepo.EXPECT().HasOverlappingAppointment(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Nil(), gomock.Nil()).Return(false, nil)
+	mockRepo.EXPECT().UpdateAppointment(gomock.Any()).Return(nil)
+
+	userID := 5
+	mockRepo.EXPECT().
+		CreateAuditEntry(gomock.Any()).
+		DoAndReturn(func(entry models.AppointmentAuditEntry) error {
+			if entry.AppointmentID != 7 || entry.Action != models.AuditUpdate || entry.UserID == nil || *entry.UserID != userID {
+				t.Errorf("unexpected audit entry: %+v", entry)
+			}
+			// Only the start moved, the status & sequence aren't part of the update
+			if len(entry.Changes) != 1 {
+				t.Fatalf("expected only the start to change, got %+v", entry.Changes)
+			}
+			change := entry.Changes["start"]
+			if change.Before != "2024-07-18T10:00:00Z" || change.After != "2024-07-18T11:00:00Z" {
+				t.Errorf("unexpected start change: %+v", change)
+			}
+			return nil
+		})
+
+	moved := models.Appointment{ID: 7, PatientID: 1, Name: "Ana", Start: time.Date(2024, 7, 18, 11, 0, 0, 0, time.UTC), Duration: time.Hour}
+	if err := svc.UpdateAppointment(moved, &userID); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestChangeAppointmentStatus_AuditFailureFails(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockAppointmentRepository(ctrl)
+	expectTransactions(mockRepo)
+	svc := NewAppointmentService(mockRepo, mocks.NewMockAppointmentTypeRepository(ctrl), &mockBookingRuleRepository{}, &mockBusinessHoursService{})
+
+	mockRepo.EXPECT().GetAppointmentByID(7).Return(&models.Appointment{ID: 7, Status: models.StatusScheduled}, nil)
+	mockRepo.EXPECT().UpdateAppointmentStatus(7, models.StatusScheduled, models.StatusConfirmed, gomock.Nil()).Return(nil)
+	mockRepo.EXPECT().CreateAuditEntry(gomock.Any()).Return(errors.New("connection reset"))
+
+	// The status change is rolled back with its transaction, so the request fails
+	if _, err := svc.ChangeAppointmentStatus(7, models.StatusConfirmed, nil); err == nil {
+		t.Errorf("expected the status change to fail without its audit entry")
+	}
+}
+
+func TestDiffAppointments_SameInstantInOtherZone(t *testing.T) {
+	zone := time.FixedZone("UTC-6", -6*60*60)
+	before := &models.Appointment{ID: 1, Start: time.Date(2024, 7, 18, 10, 0, 0, 0, time.UTC), Duration: time.Hour}
+	after := &models.Appointment{ID: 1, Start: time.Date(2024, 7, 18, 4, 0, 0, 0, zone), Duration: time.Hour}
+
+	changes, err := diffAppointments(before, after)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(changes) != 0 {
+		t.Errorf("expected no changes, got %+v", changes)
+	}
+}
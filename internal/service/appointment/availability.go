@@ -0,0 +1,219 @@
+package appointment
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"software-backend/internal/clinic"
+	"software-backend/internal/models"
+)
+
+// Availability defaults & limits
+const (
+	DefaultSlotGranularity = 15 * time.Minute
+	MaxAvailabilityRange   = 31 * 24 * time.Hour
+	nextSlotSearchDays     = 30
+)
+
+// Returned when nothing is free within the search window
+var ErrNoAvailableSlot = errors.New("no available slot found")
+
+// Error for an appointment whose slot is taken, with the next slot it fits in when there's
+// one within the search window. Matches ErrAppointmentConflict
+type SlotConflictError struct {
+	NextAvailable *models.TimeSlot
+}
+
+func (e *SlotConflictError) Error() string {
+	return ErrAppointmentConflict.Error()
+}
+
+func (e *SlotConflictError) Is(target error) bool {
+	return target == ErrAppointmentConflict
+}
+
+// Get every bookable slot of the given duration between two dates (inclusive), slots
+// start every 'granularity' inside business hours & skip booked appointments. With a
+// provider the provider's hours & bookings are used, otherwise the clinic-wide ones
+func (s *appointmentService) GetAvailableSlots(from, to time.Time, duration, granularity time.Duration, providerID *int) ([]models.TimeSlot, error) {
+	return s.availableSlots(from, to, duration, granularity, providerID, nil)
+}
+
+// Like GetAvailableSlots, with moves planned but not yet stored taken into account
+func (s *appointmentService) availableSlots(from, to time.Time, duration, granularity time.Duration, providerID *int, pending *plannedMoves) ([]models.TimeSlot, error) {
+	// Basic input validation
+	if duration <= 0 {
+		return nil, fmt.Errorf("%w: duration must be positive", ErrInvalidAppointment)
+	}
+	if granularity <= 0 {
+		granularity = DefaultSlotGranularity
+	}
+	if to.Before(from) {
+		return nil, fmt.Errorf("%w: 'from' must not be after 'to'", ErrInvalidAppointment)
+	}
+	if to.Sub(from) > MaxAvailabilityRange {
+		return nil, fmt.Errorf("%w: range can't exceed %d days", ErrInvalidAppointment, int(MaxAvailabilityRange.Hours()/24))
+	}
+
+	// Whole clinic days in the range
+	firstDay := clinic.StartOfDay(from)
+	lastDay := clinic.StartOfDay(to)
+	rangeEnd := lastDay.AddDate(0, 0, 1)
+
+	// Load bookings once, starting a day early to catch appointments running into the range
+	booked, err := s.listAppointments(firstDay.AddDate(0, 0, -1), rangeEnd, providerID)
+	if err != nil {
+		return nil, fmt.Errorf("service: failed to fetch appointments for availability: %w", err)
+	}
+	booked = pending.apply(booked, providerID)
+	if providerID == nil {
+		booked = unassignedAppointments(booked)
+	}
+	sort.SliceStable(booked, func(i, j int) bool {
+		return booked[i].Start.Before(booked[j].Start)
+	})
+
+	now := s.now()
+	slots := []models.TimeSlot{}
+	for day := firstDay; day.Before(rangeEnd); day = day.AddDate(0, 0, 1) {
+		intervals, err := s.hoursForDate(day, providerID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get business hours: %w", err)
+		}
+		daySlots, err := freeSlotsForDay(day, intervals, booked, duration, granularity, now)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse business hours: %w", err)
+		}
+		slots = append(slots, daySlots...)
+	}
+
+	return slots, nil
+}
+
+// Find the first bookable slot of the given duration at or after 'after'
+func (s *appointmentService) FindNextAvailableSlot(after time.Time, duration time.Duration, providerID *int) (*models.TimeSlot, error) {
+	return s.nextAvailableSlot(after, duration, providerID, nil)
+}
+
+// Like FindNextAvailableSlot, with moves planned but not yet stored taken into account
+func (s *appointmentService) nextAvailableSlot(after time.Time, duration time.Duration, providerID *int, pending *plannedMoves) (*models.TimeSlot, error) {
+	// Search a week at a time so the common case only needs one lookup
+	for offset := 0; offset < nextSlotSearchDays; offset += 7 {
+		from := after.AddDate(0, 0, offset)
+		to := after.AddDate(0, 0, offset+6)
+		slots, err := s.availableSlots(from, to, duration, DefaultSlotGranularity, providerID, pending)
+		if err != nil {
+			return nil, err
+		}
+		for _, slot := range slots {
+			if !slot.Start.Before(after) {
+				return &slot, nil
+			}
+		}
+	}
+	return nil, ErrNoAvailableSlot
+}
+
+// Turn a conflict booking an appointment into a SlotConflictError suggesting the next slot
+// it fits in, other errors are returned as they are
+func (s *appointmentService) withNextSlot(appt models.Appointment, err error) error {
+	if !errors.Is(err, ErrAppointmentConflict) {
+		return err
+	}
+	// The suggestion is a courtesy, the conflict is reported either way
+	next, _ := s.nextSlotFor(appt)
+	return &SlotConflictError{NextAvailable: next}
+}
+
+// Find the first slot at or after the appointment's start where it fits with its buffer,
+// within its provider's hours & clear of everything sharing its provider or room
+func (s *appointmentService) nextSlotFor(appt models.Appointment) (*models.TimeSlot, error) {
+	length := appt.Duration + appt.Buffer
+	if appt.Duration <= 0 {
+		return nil, fmt.Errorf("%w: duration must be positive", ErrInvalidAppointment)
+	}
+
+	now := s.now()
+	for offset := 0; offset < nextSlotSearchDays; offset += 7 {
+		firstDay := clinic.StartOfDay(appt.Start.AddDate(0, 0, offset))
+		rangeEnd := firstDay.AddDate(0, 0, 7)
+
+		// Every booking of the week, starting a day early to catch appointments running into it
+		all, err := s.apptRepo.ListAppointmentsInDateRange(firstDay.AddDate(0, 0, -1), rangeEnd)
+		if err != nil {
+			return nil, fmt.Errorf("service: failed to fetch appointments for availability: %w", err)
+		}
+		booked := sharingSchedule(appt, all)
+
+		for day := firstDay; day.Before(rangeEnd); day = day.AddDate(0, 0, 1) {
+			intervals, err := s.hoursForDate(day, appt.ProviderID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get business hours: %w", err)
+			}
+			slots, err := freeSlotsForDay(day, intervals, booked, length, DefaultSlotGranularity, now)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse business hours: %w", err)
+			}
+			for _, slot := range slots {
+				if !slot.Start.Before(appt.Start) {
+					// The buffer is kept free but isn't part of the appointment
+					slot.End = slot.Start.Add(appt.Duration)
+					return &slot, nil
+				}
+			}
+		}
+	}
+	return nil, ErrNoAvailableSlot
+}
+
+// Generate the free slots within a single day's business intervals
+func freeSlotsForDay(day time.Time, intervals []models.BusinessHourInterval, booked []models.Appointment, duration, granularity time.Duration, now time.Time) ([]models.TimeSlot, error) {
+	var slots []models.TimeSlot
+	for _, interval := range intervals {
+		intervalStart, intervalEnd, err := intervalOnDate(day, interval)
+		if err != nil {
+			return nil, err
+		}
+
+		for start := intervalStart; !start.Add(duration).After(intervalEnd); start = start.Add(granularity) {
+			end := start.Add(duration)
+			// Slots in the past can't be booked
+			if start.Before(now) {
+				continue
+			}
+			if overlapsAny(start, end, booked) {
+				continue
+			}
+			slots = append(slots, models.TimeSlot{Start: start, End: end})
+		}
+	}
+	return slots, nil
+}
+
+// Returns true if [start, end) overlaps any of the appointments still holding their slot,
+// including the buffer kept free after each of them
+func overlapsAny(start, end time.Time, appointments []models.Appointment) bool {
+	for _, appt := range appointments {
+		if appt.Status.ReleasesSlot() {
+			continue
+		}
+		apptEnd := appt.Start.Add(appt.Duration + appt.Buffer)
+		if appt.Start.Before(end) && apptEnd.After(start) {
+			return true
+		}
+	}
+	return false
+}
+
+// Keep only the appointments booked without provider or room, the ones sharing the clinic-wide schedule
+func unassignedAppointments(appointments []models.Appointment) []models.Appointment {
+	var result []models.Appointment
+	for _, appt := range appointments {
+		if appt.ProviderID == nil && appt.RoomID == nil {
+			result = append(result, appt)
+		}
+	}
+	return result
+}
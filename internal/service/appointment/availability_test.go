@@ -0,0 +1,86 @@
+package appointment
+
+import (
+	"testing"
+	"time"
+
+	"software-backend/internal/mocks"
+	"software-backend/internal/models"
+
+	"github.com/golang/mock/gomock"
+)
+
+func TestGetAvailableSlots_SkipsBookedAndBreaks(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockAppointmentRepository(ctrl)
+	bhService := &mockBusinessHoursService{
+		GetBusinessHoursForDateFunc: func(date time.Time) ([]models.BusinessHourInterval, error) {
+			// Lunch break between intervals
+			return []models.BusinessHourInterval{{Start: "09:00", End: "10:30"}, {Start: "11:00", End: "12:00"}}, nil
+		},
+	}
+
+	day := time.Date(2024, 7, 18, 0, 0, 0, 0, time.UTC)
+	mockRepo.EXPECT().
+		ListAppointmentsInDateRange(gomock.Any(), gomock.Any()).
+		Return([]models.Appointment{
+			{ID: 1, Start: day.Add(9*time.Hour + 30*time.Minute), Duration: 30 * time.Minute, Status: models.StatusScheduled},
+			// Cancelled appointments free their slot
+			{ID: 2, Start: day.Add(10 * time.Hour), Duration: 30 * time.Minute, Status: models.StatusCancelled},
+		}, nil)
+
+	svc := NewAppointmentService(mockRepo, mocks.NewMockAppointmentTypeRepository(ctrl), &mockBookingRuleRepository{}, bhService).(*appointmentService)
+	svc.now = func() time.Time { return day }
+
+	slots, err := svc.GetAvailableSlots(day, day, 30*time.Minute, 30*time.Minute, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []time.Time{
+		day.Add(9 * time.Hour),
+		day.Add(10 * time.Hour),
+		day.Add(11 * time.Hour),
+		day.Add(11*time.Hour + 30*time.Minute),
+	}
+	if len(slots) != len(want) {
+		t.Fatalf("expected %d slots, got %d: %+v", len(want), len(slots), slots)
+	}
+	for i, start := range want {
+		if !slots[i].Start.Equal(start) || !slots[i].End.Equal(start.Add(30*time.Minute)) {
+			t.Errorf("slot %d: expected start %v, got %+v", i, start, slots[i])
+		}
+	}
+}
+
+func TestFindNextAvailableSlot_SkipsPastSlots(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockAppointmentRepository(ctrl)
+	bhService := &mockBusinessHoursService{
+		GetBusinessHoursForDateFunc: func(date time.Time) ([]models.BusinessHourInterval, error) {
+			return []models.BusinessHourInterval{{Start: "09:00", End: "17:00"}}, nil
+		},
+	}
+
+	day := time.Date(2024, 7, 18, 0, 0, 0, 0, time.UTC)
+	mockRepo.EXPECT().
+		ListAppointmentsInDateRange(gomock.Any(), gomock.Any()).
+		Return([]models.Appointment{
+			{ID: 1, Start: day.Add(10 * time.Hour), Duration: time.Hour},
+		}, nil)
+
+	svc := NewAppointmentService(mockRepo, mocks.NewMockAppointmentTypeRepository(ctrl), &mockBookingRuleRepository{}, bhService).(*appointmentService)
+	svc.now = func() time.Time { return day }
+
+	next, err := svc.FindNextAvailableSlot(day.Add(10*time.Hour), time.Hour, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !next.Start.Equal(day.Add(11 * time.Hour)) {
+		t.Errorf("expected next slot at 11:00, got %+v", next)
+	}
+}
@@ -0,0 +1,201 @@
+package appointment
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"software-backend/internal/clinic"
+	"software-backend/internal/models"
+)
+
+// Most appointments a single bulk reschedule can move
+const maxRescheduleMoves = 200
+
+// Moves planned but not stored yet, so several appointments can be placed at once
+// without being proposed the same slot
+type plannedMoves struct {
+	moving  map[int]bool
+	planned []models.Appointment
+}
+
+// Replace the current position of the appointments being moved with their planned one,
+// only planned appointments sharing the schedule being looked at are added
+func (p *plannedMoves) apply(booked []models.Appointment, providerID *int) []models.Appointment {
+	if p == nil {
+		return booked
+	}
+	var result []models.Appointment
+	for _, appt := range booked {
+		if !p.moving[appt.ID] {
+			result = append(result, appt)
+		}
+	}
+	for _, appt := range p.planned {
+		if providerID == nil || (appt.ProviderID != nil && *appt.ProviderID == *providerID) {
+			result = append(result, appt)
+		}
+	}
+	return result
+}
+
+// List the scheduled or confirmed appointments between two dates (inclusive) that are
+// outside the business hours of their day, e.g. after special hours closed the clinic,
+// along with the next free slot for each of them
+func (s *appointmentService) PreviewReschedule(from, to time.Time) ([]models.RescheduleProposal, error) {
+	// Basic input validation
+	if to.Before(from) {
+		return nil, fmt.Errorf("%w: 'from' must not be after 'to'", ErrInvalidAppointment)
+	}
+	if to.Sub(from) > MaxAvailabilityRange {
+		return nil, fmt.Errorf("%w: range can't exceed %d days", ErrInvalidAppointment, int(MaxAvailabilityRange.Hours()/24))
+	}
+
+	appointments, err := s.listAppointments(clinic.StartOfDay(from), clinic.StartOfDay(to).AddDate(0, 0, 1), nil)
+	if err != nil {
+		return nil, fmt.Errorf("service: failed to fetch appointments to reschedule: %w", err)
+	}
+	sort.SliceStable(appointments, func(i, j int) bool {
+		return appointments[i].Start.Before(appointments[j].Start)
+	})
+
+	// Find the appointments left outside business hours
+	var affected []models.Appointment
+	pending := &plannedMoves{moving: map[int]bool{}}
+	for _, appt := range appointments {
+		if appt.Status != models.StatusScheduled && appt.Status != models.StatusConfirmed {
+			continue
+		}
+		intervals, err := s.hoursForDate(appt.Start, appt.ProviderID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get business hours: %w", err)
+		}
+		within, err := isWithinBusinessHours(appt.Start, appt.Start.Add(appt.Duration), intervals)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse business hours: %w", err)
+		}
+		if !within {
+			affected = append(affected, appt)
+			pending.moving[appt.ID] = true
+		}
+	}
+
+	// Propose the first free slot after each appointment, slots already proposed are
+	// taken into account so no two appointments get the same one
+	proposals := []models.RescheduleProposal{}
+	now := s.now()
+	for _, appt := range affected {
+		proposal := models.RescheduleProposal{Appointment: appt}
+		after := appt.Start
+		if after.Before(now) {
+			after = now
+		}
+		slot, err := s.nextAvailableSlot(after, appt.Duration, appt.ProviderID, pending)
+		switch {
+		case errors.Is(err, ErrNoAvailableSlot):
+			proposal.Reason = err.Error()
+		case err != nil:
+			return nil, err
+		default:
+			proposal.ProposedStart = &slot.Start
+			proposal.ProposedEnd = &slot.End
+			moved := appt
+			moved.Start = slot.Start
+			pending.planned = append(pending.planned, moved)
+		}
+		proposals = append(proposals, proposal)
+	}
+	return proposals, nil
+}
+
+// Move several appointments at once, e.g. the ones proposed by PreviewReschedule. Every
+// move is checked first & they're stored in a single transaction, so either all of them
+// are moved or none is. Patients are told their new time through the rescheduled hooks
+func (s *appointmentService) ConfirmReschedule(moves []models.RescheduleMove, userID *int) ([]models.Appointment, error) {
+	// Basic input validation
+	if len(moves) == 0 {
+		return nil, fmt.Errorf("%w: no appointments to move", ErrInvalidAppointment)
+	}
+	if len(moves) > maxRescheduleMoves {
+		return nil, fmt.Errorf("%w: can't move more than %d appointments at once", ErrInvalidAppointment, maxRescheduleMoves)
+	}
+
+	// Check every move against business hours, stored appointments & the other moves
+	moved := make([]models.Appointment, 0, len(moves))
+	previous := make([]models.Appointment, 0, len(moves))
+	pending := &plannedMoves{moving: map[int]bool{}}
+	for _, move := range moves {
+		if pending.moving[move.AppointmentID] {
+			return nil, fmt.Errorf("%w: appointment %d is moved more than once", ErrInvalidAppointment, move.AppointmentID)
+		}
+		pending.moving[move.AppointmentID] = true
+
+		appt, err := s.apptRepo.GetAppointmentByID(move.AppointmentID)
+		if err != nil {
+			return nil, err
+		}
+		if appt.Status != models.StatusScheduled && appt.Status != models.StatusConfirmed {
+			return nil, fmt.Errorf("%w: appointment %d is %s", ErrInvalidTransition, appt.ID, appt.Status)
+		}
+		previous = append(previous, *appt)
+		appt.Start = move.NewStart
+		if err := s.validateSlot(*appt, &appt.ID); err != nil {
+			return nil, fmt.Errorf("appointment %d: %w", appt.ID, err)
+		}
+		if overlapsAny(appt.Start, appt.Start.Add(appt.Duration+appt.Buffer), sharingSchedule(*appt, pending.planned)) {
+			return nil, fmt.Errorf("appointment %d: %w", appt.ID, ErrAppointmentConflict)
+		}
+		pending.planned = append(pending.planned, *appt)
+		moved = append(moved, *appt)
+	}
+
+	err := s.inTransaction(func(tx *appointmentService) error {
+		if err := tx.apptRepo.RescheduleAppointments(moves); err != nil {
+			return mapOverlapError(err)
+		}
+		for i := range moved {
+			if err := tx.audit(models.AuditUpdate, &previous[i], &moved[i], userID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	// The old times are free unless another moved appointment took them
+	s.releaseSlots(previous, moved)
+	for i := range moved {
+		s.rescheduled(moved[i], previous[i].Start)
+	}
+	return moved, nil
+}
+
+// Register a hook run whenever an appointment is moved by a reschedule, e.g. to queue a
+// notice to the patient. Hooks run before the reschedule returns so they should be quick
+func (s *appointmentService) OnRescheduled(hook RescheduledHook) {
+	s.rescheduledHooks = append(s.rescheduledHooks, hook)
+}
+
+// Run the rescheduled hooks once the moves are stored
+func (s *appointmentService) rescheduled(moved models.Appointment, previousStart time.Time) {
+	for _, hook := range s.rescheduledHooks {
+		hook(moved, previousStart)
+	}
+}
+
+// Keep the appointments that can't overlap with 'appt', the ones sharing its provider or
+// room or, without either, the clinic-wide schedule. Same scopes as the overlap check
+func sharingSchedule(appt models.Appointment, others []models.Appointment) []models.Appointment {
+	var result []models.Appointment
+	for _, other := range others {
+		sameProvider := appt.ProviderID != nil && other.ProviderID != nil && *appt.ProviderID == *other.ProviderID
+		sameRoom := appt.RoomID != nil && other.RoomID != nil && *appt.RoomID == *other.RoomID
+		bothUnassigned := appt.ProviderID == nil && appt.RoomID == nil && other.ProviderID == nil && other.RoomID == nil
+		if sameProvider || sameRoom || bothUnassigned {
+			result = append(result, other)
+		}
+	}
+	return result
+}
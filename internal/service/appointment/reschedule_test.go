@@ -0,0 +1,160 @@
+package appointment
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"software-backend/internal/mocks"
+	"software-backend/internal/models"
+
+	"github.com/golang/mock/gomock"
+)
+
+// Clinic open 09:00-17:00 except on 2024-07-18, closed by special hours
+func closedOnJuly18() *mockBusinessHoursService {
+	return &mockBusinessHoursService{
+		GetBusinessHoursForDateFunc: func(date time.Time) ([]models.BusinessHourInterval, error) {
+			if date.Format("2006-01-02") == "2024-07-18" {
+				return nil, nil
+			}
+			return []models.BusinessHourInterval{{Start: "09:00", End: "17:00"}}, nil
+		},
+	}
+}
+
+func TestPreviewReschedule_ProposesDistinctSlots(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockAppointmentRepository(ctrl)
+	svc := NewAppointmentService(mockRepo, mocks.NewMockAppointmentTypeRepository(ctrl), &mockBookingRuleRepository{}, closedOnJuly18()).(*appointmentService)
+	svc.now = func() time.Time { return time.Date(2024, 7, 17, 8, 0, 0, 0, time.UTC) }
+
+	booked := []models.Appointment{
+		{ID: 1, PatientID: 1, Start: time.Date(2024, 7, 18, 10, 0, 0, 0, time.UTC), Duration: 30 * time.Minute, Status: models.StatusScheduled},
+		{ID: 2, PatientID: 2, Start: time.Date(2024, 7, 18, 10, 30, 0, 0, time.UTC), Duration: 30 * time.Minute, Status: models.StatusConfirmed},
+		// Already cancelled, nothing to move
+		{ID: 3, PatientID: 3, Start: time.Date(2024, 7, 18, 11, 0, 0, 0, time.UTC), Duration: 30 * time.Minute, Status: models.StatusCancelled},
+	}
+	mockRepo.EXPECT().
+		ListAppointmentsInDateRange(gomock.Any(), gomock.Any()).
+		Return(booked, nil).
+		AnyTimes()
+
+	day := time.Date(2024, 7, 18, 0, 0, 0, 0, time.UTC)
+	proposals, err := svc.PreviewReschedule(day, day)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(proposals) != 2 {
+		t.Fatalf("expected 2 proposals, got %+v", proposals)
+	}
+
+	// Both move to the next open day, one after the other
+	expected := []time.Time{
+		time.Date(2024, 7, 19, 9, 0, 0, 0, time.UTC),
+		time.Date(2024, 7, 19, 9, 30, 0, 0, time.UTC),
+	}
+	for i, proposal := range proposals {
+		if proposal.ProposedStart == nil || !proposal.ProposedStart.Equal(expected[i]) {
+			t.Errorf("proposal %d: expected %v, got %v", i, expected[i], proposal.ProposedStart)
+		}
+	}
+}
+
+func TestConfirmReschedule_MovesInOneGo(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockAppointmentRepository(ctrl)
+	expectTransactions(mockRepo)
+	mockRepo.EXPECT().CreateAuditEntry(gomock.Any()).Return(nil).AnyTimes()
+	svc := NewAppointmentService(mockRepo, mocks.NewMockAppointmentTypeRepository(ctrl), &mockBookingRuleRepository{}, closedOnJuly18())
+
+	mockRepo.EXPECT().
+		GetAppointmentByID(1).
+		Return(&models.Appointment{ID: 1, Start: time.Date(2024, 7, 18, 10, 0, 0, 0, time.UTC), Duration: 30 * time.Minute, Status: models.StatusScheduled}, nil)
+	mockRepo.EXPECT().
+		GetAppointmentByID(2).
+		Return(&models.Appointment{ID: 2, Start: time.Date(2024, 7, 18, 10, 30, 0, 0, time.UTC), Duration: 30 * time.Minute, Status: models.StatusScheduled}, nil)
+	mockRepo.EXPECT().
+		HasOverlappingAppointment(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Nil(), gomock.Nil()).
+		Return(false, nil).
+		Times(2)
+
+	moves := []models.RescheduleMove{
+		{AppointmentID: 1, NewStart: time.Date(2024, 7, 19, 9, 0, 0, 0, time.UTC)},
+		{AppointmentID: 2, NewStart: time.Date(2024, 7, 19, 9, 30, 0, 0, time.UTC)},
+	}
+	mockRepo.EXPECT().RescheduleAppointments(moves).Return(nil)
+	released := make(chan models.Appointment, 2)
+	svc.OnSlotReleased(func(appt models.Appointment) {
+		released <- appt
+	})
+	notified := make(chan time.Time, 2)
+	svc.OnRescheduled(func(moved models.Appointment, previousStart time.Time) {
+		notified <- previousStart
+	})
+
+	moved, err := svc.ConfirmReschedule(moves, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(moved) != 2 || !moved[1].Start.Equal(moves[1].NewStart) {
+		t.Errorf("unexpected moved appointments: %+v", moved)
+	}
+
+	// Both old slots on the 18th are free again
+	for i := 0; i < 2; i++ {
+		select {
+		case appt := <-released:
+			if appt.Start.Day() != 18 {
+				t.Errorf("expected the old slot to be released, got %v", appt.Start)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("expected 2 released slots, got %d", i)
+		}
+	}
+
+	// Patients are told about the move along with their previous time
+	for i := 0; i < 2; i++ {
+		select {
+		case previousStart := <-notified:
+			if previousStart.Day() != 18 {
+				t.Errorf("expected the previous start on the 18th, got %v", previousStart)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("expected 2 reschedule notifications, got %d", i)
+		}
+	}
+}
+
+func TestConfirmReschedule_MovesCollide(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockAppointmentRepository(ctrl)
+	svc := NewAppointmentService(mockRepo, mocks.NewMockAppointmentTypeRepository(ctrl), &mockBookingRuleRepository{}, closedOnJuly18())
+
+	mockRepo.EXPECT().
+		GetAppointmentByID(gomock.Any()).
+		DoAndReturn(func(id int) (*models.Appointment, error) {
+			return &models.Appointment{ID: id, Start: time.Date(2024, 7, 18, 10, 0, 0, 0, time.UTC), Duration: 30 * time.Minute, Status: models.StatusScheduled}, nil
+		}).
+		Times(2)
+	mockRepo.EXPECT().
+		HasOverlappingAppointment(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Nil(), gomock.Nil()).
+		Return(false, nil).
+		Times(2)
+
+	// Both moved to the same slot, nothing is stored
+	newStart := time.Date(2024, 7, 19, 9, 0, 0, 0, time.UTC)
+	_, err := svc.ConfirmReschedule([]models.RescheduleMove{
+		{AppointmentID: 1, NewStart: newStart},
+		{AppointmentID: 2, NewStart: newStart.Add(15 * time.Minute)},
+	}, nil)
+	if !errors.Is(err, ErrAppointmentConflict) {
+		t.Errorf("expected conflict error, got %v", err)
+	}
+}
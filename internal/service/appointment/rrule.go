@@ -0,0 +1,245 @@
+package appointment
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"software-backend/internal/clinic"
+)
+
+// Upper bound on occurrences a single series can expand into
+const maxSeriesOccurrences = 200
+
+// Errors for recurrence rules
+var ErrInvalidRecurrenceRule = errors.New("invalid recurrence rule")
+
+// Supported RRULE frequencies
+const (
+	freqDaily   = "DAILY"
+	freqWeekly  = "WEEKLY"
+	freqMonthly = "MONTHLY"
+)
+
+// Weekday codes as used in BYDAY
+var rruleWeekdays = map[string]time.Weekday{
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+	"SU": time.Sunday,
+}
+
+// Parsed subset of an RFC 5545 RRULE
+type recurrenceRule struct {
+	freq     string
+	interval int
+	count    int
+	until    *time.Time
+	byDay    []time.Weekday
+}
+
+// Parse an RRULE string such as "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,TH;COUNT=10",
+// the "RRULE:" prefix is optional
+func parseRRule(rule string) (*recurrenceRule, error) {
+	rule = strings.TrimPrefix(strings.TrimSpace(rule), "RRULE:")
+	if rule == "" {
+		return nil, fmt.Errorf("%w: empty rule", ErrInvalidRecurrenceRule)
+	}
+
+	r := &recurrenceRule{interval: 1}
+	for _, part := range strings.Split(rule, ";") {
+		key, value, found := strings.Cut(part, "=")
+		if !found {
+			return nil, fmt.Errorf("%w: malformed part %q", ErrInvalidRecurrenceRule, part)
+		}
+		switch strings.ToUpper(key) {
+		case "FREQ":
+			r.freq = strings.ToUpper(value)
+		case "INTERVAL":
+			interval, err := strconv.Atoi(value)
+			if err != nil || interval < 1 {
+				return nil, fmt.Errorf("%w: invalid INTERVAL %q", ErrInvalidRecurrenceRule, value)
+			}
+			r.interval = interval
+		case "COUNT":
+			count, err := strconv.Atoi(value)
+			if err != nil || count < 1 {
+				return nil, fmt.Errorf("%w: invalid COUNT %q", ErrInvalidRecurrenceRule, value)
+			}
+			if count > maxSeriesOccurrences {
+				return nil, fmt.Errorf("%w: COUNT can't be over %d", ErrInvalidRecurrenceRule, maxSeriesOccurrences)
+			}
+			r.count = count
+		case "UNTIL":
+			until, err := parseRRuleTime(value)
+			if err != nil {
+				return nil, fmt.Errorf("%w: invalid UNTIL %q", ErrInvalidRecurrenceRule, value)
+			}
+			r.until = &until
+		case "BYDAY":
+			for _, code := range strings.Split(value, ",") {
+				weekday, ok := rruleWeekdays[strings.ToUpper(code)]
+				if !ok {
+					return nil, fmt.Errorf("%w: invalid BYDAY %q", ErrInvalidRecurrenceRule, code)
+				}
+				r.byDay = append(r.byDay, weekday)
+			}
+		case "WKST":
+			// Weeks always start on Monday here, which is also the RFC default
+		default:
+			return nil, fmt.Errorf("%w: unsupported part %q", ErrInvalidRecurrenceRule, key)
+		}
+	}
+
+	// Validate combination of parts
+	switch r.freq {
+	case freqDaily, freqWeekly, freqMonthly:
+	case "":
+		return nil, fmt.Errorf("%w: FREQ is required", ErrInvalidRecurrenceRule)
+	default:
+		return nil, fmt.Errorf("%w: unsupported FREQ %q", ErrInvalidRecurrenceRule, r.freq)
+	}
+	if r.count > 0 && r.until != nil {
+		return nil, fmt.Errorf("%w: COUNT and UNTIL are mutually exclusive", ErrInvalidRecurrenceRule)
+	}
+	if r.count == 0 && r.until == nil {
+		return nil, fmt.Errorf("%w: either COUNT or UNTIL is required", ErrInvalidRecurrenceRule)
+	}
+	if len(r.byDay) > 0 && r.freq != freqWeekly {
+		return nil, fmt.Errorf("%w: BYDAY is only supported with FREQ=WEEKLY", ErrInvalidRecurrenceRule)
+	}
+
+	return r, nil
+}
+
+// Parse an UNTIL value, either a date or a date-time (UTC if suffixed with Z, clinic time otherwise)
+func parseRRuleTime(value string) (time.Time, error) {
+	for _, layout := range []string{"20060102T150405Z", "20060102T150405", "20060102"} {
+		loc := clinic.Location()
+		if strings.HasSuffix(layout, "Z") {
+			loc = time.UTC
+		}
+		if t, err := time.ParseInLocation(layout, value, loc); err == nil {
+			// A plain date includes the whole day
+			if layout == "20060102" {
+				t = t.Add(24*time.Hour - time.Second)
+			}
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognized time %q", value)
+}
+
+// Expand the rule into occurrence start times beginning at dtstart, dates
+// in exceptions (YYYY-MM-DD) are skipped after COUNT is applied like EXDATE. Rules
+// running past maxSeriesOccurrences are refused rather than cut short
+func (r *recurrenceRule) occurrences(dtstart time.Time, exceptions []string) ([]time.Time, error) {
+	excluded := make(map[string]bool, len(exceptions))
+	for _, date := range exceptions {
+		excluded[date] = true
+	}
+
+	var result []time.Time
+	generated := 0
+	truncated := false
+	// Returns false once the rule is exhausted
+	emit := func(t time.Time) bool {
+		if r.until != nil && t.After(*r.until) {
+			return false
+		}
+		if r.count > 0 && generated >= r.count {
+			return false
+		}
+		if generated >= maxSeriesOccurrences {
+			truncated = true
+			return false
+		}
+		generated++
+		if !excluded[t.Format("2006-01-02")] {
+			result = append(result, t)
+		}
+		return true
+	}
+
+	loc := dtstart.Location()
+	hour, minute, second := dtstart.Clock()
+	switch r.freq {
+	case freqDaily:
+		for i := 0; ; i++ {
+			t := time.Date(dtstart.Year(), dtstart.Month(), dtstart.Day()+i*r.interval, hour, minute, second, 0, loc)
+			if !emit(t) {
+				break
+			}
+		}
+	case freqWeekly:
+		days := r.byDay
+		if len(days) == 0 {
+			days = []time.Weekday{dtstart.Weekday()}
+		}
+		// Order days Monday first so each week is emitted chronologically
+		sorted := append([]time.Weekday(nil), days...)
+		sort.Slice(sorted, func(i, j int) bool {
+			return mondayIndex(sorted[i]) < mondayIndex(sorted[j])
+		})
+		weekStart := dtstart.Day() - mondayIndex(dtstart.Weekday())
+	weeks:
+		for i := 0; ; i++ {
+			for _, day := range sorted {
+				t := time.Date(dtstart.Year(), dtstart.Month(), weekStart+i*7*r.interval+mondayIndex(day), hour, minute, second, 0, loc)
+				if t.Before(dtstart) {
+					continue
+				}
+				if !emit(t) {
+					break weeks
+				}
+			}
+		}
+	case freqMonthly:
+		for i := 0; ; i++ {
+			t := time.Date(dtstart.Year(), dtstart.Month()+time.Month(i*r.interval), dtstart.Day(), hour, minute, second, 0, loc)
+			// Months without that day (e.g. the 31st) are skipped as per RFC 5545
+			if t.Day() != dtstart.Day() {
+				if i > maxSeriesOccurrences {
+					truncated = true
+					break
+				}
+				continue
+			}
+			if !emit(t) {
+				break
+			}
+		}
+	}
+
+	if truncated {
+		return nil, fmt.Errorf("%w: rule has more than %d occurrences", ErrInvalidRecurrenceRule, maxSeriesOccurrences)
+	}
+	return result, nil
+}
+
+// Days since Monday for a weekday
+func mondayIndex(day time.Weekday) int {
+	return (int(day) + 6) % 7
+}
+
+// Return the rule with its end replaced by UNTIL, used to split a series
+func ruleEndingAt(rule string, until time.Time) string {
+	rule = strings.TrimPrefix(strings.TrimSpace(rule), "RRULE:")
+	var parts []string
+	for _, part := range strings.Split(rule, ";") {
+		key, _, _ := strings.Cut(part, "=")
+		switch strings.ToUpper(key) {
+		case "COUNT", "UNTIL":
+			continue
+		}
+		parts = append(parts, part)
+	}
+	parts = append(parts, "UNTIL="+until.UTC().Format("20060102T150405Z"))
+	return strings.Join(parts, ";")
+}
@@ -0,0 +1,136 @@
+package appointment
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseRRule_Invalid(t *testing.T) {
+	rules := []string{
+		"",
+		"FREQ=YEARLY;COUNT=3",
+		"FREQ=DAILY",
+		"FREQ=DAILY;COUNT=3;UNTIL=20240801",
+		"FREQ=DAILY;BYDAY=MO;COUNT=3",
+		"FREQ=WEEKLY;BYDAY=XX;COUNT=3",
+		"FREQ=WEEKLY;INTERVAL=0;COUNT=3",
+		"COUNT=3",
+		"FREQ=DAILY;COUNT=300",
+	}
+	for _, rule := range rules {
+		if _, err := parseRRule(rule); !errors.Is(err, ErrInvalidRecurrenceRule) {
+			t.Errorf("rule %q: expected invalid rule error, got %v", rule, err)
+		}
+	}
+}
+
+func TestOccurrences_DailyCount(t *testing.T) {
+	rule, err := parseRRule("RRULE:FREQ=DAILY;INTERVAL=2;COUNT=3")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	start := time.Date(2024, 7, 18, 10, 0, 0, 0, time.UTC)
+
+	got, err := rule.occurrences(start, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []time.Time{
+		start,
+		start.AddDate(0, 0, 2),
+		start.AddDate(0, 0, 4),
+	}
+	assertOccurrences(t, got, want)
+}
+
+func TestOccurrences_WeeklyByDayUntil(t *testing.T) {
+	rule, err := parseRRule("FREQ=WEEKLY;BYDAY=TH,MO;UNTIL=20240730")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// Thursday
+	start := time.Date(2024, 7, 18, 9, 30, 0, 0, time.UTC)
+
+	got, err := rule.occurrences(start, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []time.Time{
+		time.Date(2024, 7, 18, 9, 30, 0, 0, time.UTC),
+		time.Date(2024, 7, 22, 9, 30, 0, 0, time.UTC),
+		time.Date(2024, 7, 25, 9, 30, 0, 0, time.UTC),
+		time.Date(2024, 7, 29, 9, 30, 0, 0, time.UTC),
+	}
+	assertOccurrences(t, got, want)
+}
+
+func TestOccurrences_MonthlySkipsShortMonths(t *testing.T) {
+	rule, err := parseRRule("FREQ=MONTHLY;COUNT=3")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	start := time.Date(2024, 1, 31, 8, 0, 0, 0, time.UTC)
+
+	got, err := rule.occurrences(start, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []time.Time{
+		time.Date(2024, 1, 31, 8, 0, 0, 0, time.UTC),
+		time.Date(2024, 3, 31, 8, 0, 0, 0, time.UTC),
+		time.Date(2024, 5, 31, 8, 0, 0, 0, time.UTC),
+	}
+	assertOccurrences(t, got, want)
+}
+
+func TestOccurrences_ExceptionsCountTowardsCount(t *testing.T) {
+	rule, err := parseRRule("FREQ=DAILY;COUNT=3")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	start := time.Date(2024, 7, 18, 10, 0, 0, 0, time.UTC)
+
+	got, err := rule.occurrences(start, []string{"2024-07-19"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []time.Time{
+		time.Date(2024, 7, 18, 10, 0, 0, 0, time.UTC),
+		time.Date(2024, 7, 20, 10, 0, 0, 0, time.UTC),
+	}
+	assertOccurrences(t, got, want)
+}
+
+func TestOccurrences_TooManyRefused(t *testing.T) {
+	// Daily for two years runs well past the cap
+	rule, err := parseRRule("FREQ=DAILY;UNTIL=20260718")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	start := time.Date(2024, 7, 18, 10, 0, 0, 0, time.UTC)
+
+	if _, err := rule.occurrences(start, nil); !errors.Is(err, ErrInvalidRecurrenceRule) {
+		t.Errorf("expected invalid rule error, got %v", err)
+	}
+}
+
+func TestRuleEndingAt(t *testing.T) {
+	until := time.Date(2024, 7, 25, 9, 59, 59, 0, time.UTC)
+	got := ruleEndingAt("FREQ=WEEKLY;COUNT=10;BYDAY=MO", until)
+	if got != "FREQ=WEEKLY;BYDAY=MO;UNTIL=20240725T095959Z" {
+		t.Errorf("unexpected rule: %s", got)
+	}
+}
+
+func assertOccurrences(t *testing.T, got, want []time.Time) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("expected %d occurrences, got %d: %v", len(want), len(got), got)
+	}
+	for i := range want {
+		if !got[i].Equal(want[i]) {
+			t.Errorf("occurrence %d: expected %v, got %v", i, want[i], got[i])
+		}
+	}
+}
@@ -0,0 +1,179 @@
+package appointment
+
+import (
+	"fmt"
+	"strings"
+
+	"software-backend/internal/clinic"
+	"software-backend/internal/models"
+)
+
+// Error listing the booking rules an appointment breaks, matches ErrBookingRuleViolated
+type BookingRuleError struct {
+	Violations []models.RuleViolation
+}
+
+func (e *BookingRuleError) Error() string {
+	messages := make([]string, len(e.Violations))
+	for i, violation := range e.Violations {
+		messages[i] = violation.Message
+	}
+	return fmt.Sprintf("%s: %s", ErrBookingRuleViolated, strings.Join(messages, "; "))
+}
+
+func (e *BookingRuleError) Is(target error) bool {
+	return target == ErrBookingRuleViolated
+}
+
+// Known rules, overrides can only name these
+var bookingRuleNames = map[string]bool{
+	models.RuleMinLeadTime:          true,
+	models.RuleMaxHorizon:           true,
+	models.RuleMaxNewPatientsPerDay: true,
+	models.RuleMaxPerPatientPerDay:  true,
+	models.RuleMaxNoShows:           true,
+}
+
+// Check an appointment against the booking rules, the appointment with 'excludeID' isn't
+// counted against the daily limits. Broken rules must all be covered by the appointment's
+// override, the ones that were are returned so they can be recorded
+func (s *appointmentService) checkBookingRules(appointment models.Appointment, excludeID *int) ([]models.RuleViolation, error) {
+	// Validate the override before looking at the rules
+	override := appointment.Override
+	if override != nil {
+		if override.UserID == nil {
+			return nil, fmt.Errorf("%w: overriding booking rules requires a logged in user", ErrInvalidAppointment)
+		}
+		for _, rule := range override.Rules {
+			if !bookingRuleNames[rule] {
+				return nil, fmt.Errorf("%w: unknown booking rule %q", ErrInvalidAppointment, rule)
+			}
+		}
+	}
+
+	rules, err := s.ruleRepo.GetRules()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get booking rules: %w", err)
+	}
+	violations, err := s.evaluateBookingRules(appointment, *rules, excludeID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Split what was overridden from what still blocks the booking
+	var overridden, remaining []models.RuleViolation
+	for _, violation := range violations {
+		if override != nil && containsString(override.Rules, violation.Rule) {
+			overridden = append(overridden, violation)
+		} else {
+			remaining = append(remaining, violation)
+		}
+	}
+	if len(remaining) > 0 {
+		return nil, &BookingRuleError{Violations: remaining}
+	}
+	return overridden, nil
+}
+
+// List the booking rules an appointment breaks, limits per day are per clinic day
+func (s *appointmentService) evaluateBookingRules(appointment models.Appointment, rules models.BookingRules, excludeID *int) ([]models.RuleViolation, error) {
+	var violations []models.RuleViolation
+	now := s.now()
+
+	// Lead time & horizon
+	if rules.MinLeadTime > 0 && appointment.Start.Before(now.Add(rules.MinLeadTime)) {
+		violations = append(violations, models.RuleViolation{
+			Rule:    models.RuleMinLeadTime,
+			Message: fmt.Sprintf("appointments must be booked at least %v in advance", rules.MinLeadTime),
+		})
+	}
+	if rules.MaxHorizon > 0 && appointment.Start.After(now.Add(rules.MaxHorizon)) {
+		violations = append(violations, models.RuleViolation{
+			Rule:    models.RuleMaxHorizon,
+			Message: fmt.Sprintf("appointments can't be booked more than %v ahead", rules.MaxHorizon),
+		})
+	}
+
+	startOfDay := clinic.StartOfDay(appointment.Start)
+	startOfNextDay := startOfDay.AddDate(0, 0, 1)
+
+	// Appointments of the same patient that day, unregistered patients can't be told apart
+	if rules.MaxPerPatientPerDay > 0 && appointment.PatientID != 0 {
+		count, err := s.apptRepo.CountPatientAppointmentsInRange(appointment.PatientID, startOfDay, startOfNextDay, excludeID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to count appointments of patient: %w", err)
+		}
+		if count >= rules.MaxPerPatientPerDay {
+			violations = append(violations, models.RuleViolation{
+				Rule:    models.RuleMaxPerPatientPerDay,
+				Message: fmt.Sprintf("patient already has %d appointment(s) that day", count),
+			})
+		}
+	}
+
+	// Patients who keep missing appointments need their bookings confirmed
+	if rules.MaxNoShows > 0 && appointment.PatientID != 0 {
+		stats, err := s.GetAttendanceStats(appointment.PatientID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get attendance of patient: %w", err)
+		}
+		if stats.NoShows >= rules.MaxNoShows {
+			violations = append(violations, models.RuleViolation{
+				Rule:    models.RuleMaxNoShows,
+				Message: fmt.Sprintf("patient missed %d appointment(s), the booking needs to be confirmed", stats.NoShows),
+			})
+		}
+	}
+
+	// First visits that day, the appointment is one if the patient was never seen before it
+	if rules.MaxNewPatientsPerDay > 0 {
+		isNew := appointment.PatientID == 0
+		if !isNew {
+			seen, err := s.apptRepo.HasCompletedAppointmentBefore(appointment.PatientID, appointment.Start)
+			if err != nil {
+				return nil, fmt.Errorf("failed to check earlier visits of patient: %w", err)
+			}
+			isNew = !seen
+		}
+		if isNew {
+			count, err := s.apptRepo.CountNewPatientAppointmentsInRange(startOfDay, startOfNextDay, excludeID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to count new patient appointments: %w", err)
+			}
+			if count >= rules.MaxNewPatientsPerDay {
+				violations = append(violations, models.RuleViolation{
+					Rule:    models.RuleMaxNewPatientsPerDay,
+					Message: fmt.Sprintf("the day already has %d new patient visit(s)", count),
+				})
+			}
+		}
+	}
+	return violations, nil
+}
+
+// Record the rules overridden to book an appointment, meant to run in the booking's
+// transaction so there's no booking without its overrides
+func (s *appointmentService) recordOverrides(appointmentID int, overridden []models.RuleViolation, override *models.RuleOverrideRequest) error {
+	for _, violation := range overridden {
+		_, err := s.apptRepo.CreateRuleOverride(models.BookingRuleOverride{
+			AppointmentID: appointmentID,
+			Rule:          violation.Rule,
+			Reason:        override.Reason,
+			UserID:        override.UserID,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Returns true if the slice contains the value
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
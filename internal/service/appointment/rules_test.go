@@ -0,0 +1,224 @@
+package appointment
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"software-backend/internal/mocks"
+	"software-backend/internal/models"
+
+	"github.com/golang/mock/gomock"
+)
+
+// Service with a free 09:00-17:00 schedule & the given booking rules, 'now' is 2024-07-18 08:00
+func newRulesTestService(ctrl *gomock.Controller, mockRepo *mocks.MockAppointmentRepository, ruleRepo *mockBookingRuleRepository) *appointmentService {
+	bhService := &mockBusinessHoursService{
+		GetBusinessHoursForDateFunc: func(date time.Time) ([]models.BusinessHourInterval, error) {
+			return []models.BusinessHourInterval{{Start: "09:00", End: "17:00"}}, nil
+		},
+	}
+	mockRepo.EXPECT().
+		HasOverlappingAppointment(gomock.Any(), gomock.Any(), gomock.Nil(), gomock.Nil(), gomock.Nil()).
+		Return(false, nil).
+		AnyTimes()
+	mockRepo.EXPECT().CreateAuditEntry(gomock.Any()).Return(nil).AnyTimes()
+	expectTransactions(mockRepo)
+	svc := NewAppointmentService(mockRepo, mocks.NewMockAppointmentTypeRepository(ctrl), ruleRepo, bhService).(*appointmentService)
+	svc.now = func() time.Time { return time.Date(2024, 7, 18, 8, 0, 0, 0, time.UTC) }
+	return svc
+}
+
+func TestCreateAppointment_LeadTimeAndHorizon(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockAppointmentRepository(ctrl)
+	ruleRepo := &mockBookingRuleRepository{rules: models.BookingRules{MinLeadTime: 2 * time.Hour, MaxHorizon: 30 * 24 * time.Hour}}
+	svc := newRulesTestService(ctrl, mockRepo, ruleRepo)
+
+	// 09:00 is only an hour away
+	_, err := svc.CreateAppointment(models.Appointment{PatientID: 1, Start: time.Date(2024, 7, 18, 9, 0, 0, 0, time.UTC), Duration: time.Hour}, nil)
+	var ruleErr *BookingRuleError
+	if !errors.As(err, &ruleErr) || len(ruleErr.Violations) != 1 || ruleErr.Violations[0].Rule != models.RuleMinLeadTime {
+		t.Fatalf("expected lead time violation, got %v", err)
+	}
+	if !errors.Is(err, ErrBookingRuleViolated) {
+		t.Errorf("expected error to match ErrBookingRuleViolated")
+	}
+
+	// Too far ahead
+	_, err = svc.CreateAppointment(models.Appointment{PatientID: 1, Start: time.Date(2024, 9, 2, 10, 0, 0, 0, time.UTC), Duration: time.Hour}, nil)
+	if !errors.As(err, &ruleErr) || ruleErr.Violations[0].Rule != models.RuleMaxHorizon {
+		t.Errorf("expected horizon violation, got %v", err)
+	}
+}
+
+func TestCreateAppointment_PatientDailyLimitOverridden(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockAppointmentRepository(ctrl)
+	ruleRepo := &mockBookingRuleRepository{rules: models.BookingRules{MaxPerPatientPerDay: 1}}
+	svc := newRulesTestService(ctrl, mockRepo, ruleRepo)
+
+	mockRepo.EXPECT().
+		CountPatientAppointmentsInRange(1, time.Date(2024, 7, 18, 0, 0, 0, 0, time.UTC), time.Date(2024, 7, 19, 0, 0, 0, 0, time.UTC), gomock.Nil()).
+		Return(1, nil).
+		Times(2)
+	mockRepo.EXPECT().
+		CreateAppointment(gomock.Any()).
+		DoAndReturn(func(appt models.Appointment) (*models.Appointment, error) {
+			appt.ID = 7
+			return &appt, nil
+		})
+
+	appt := models.Appointment{PatientID: 1, Start: time.Date(2024, 7, 18, 15, 0, 0, 0, time.UTC), Duration: time.Hour}
+
+	// Without an override the second appointment that day is refused
+	if _, err := svc.CreateAppointment(appt, nil); !errors.Is(err, ErrBookingRuleViolated) {
+		t.Fatalf("expected booking rule violation, got %v", err)
+	}
+
+	// The override is let through & recorded
+	var overrides []models.BookingRuleOverride
+	mockRepo.EXPECT().
+		CreateRuleOverride(gomock.Any()).
+		DoAndReturn(func(override models.BookingRuleOverride) (*models.BookingRuleOverride, error) {
+			overrides = append(overrides, override)
+			return &override, nil
+		})
+	userID := 3
+	appt.Override = &models.RuleOverrideRequest{Rules: []string{models.RuleMaxPerPatientPerDay}, Reason: "Urgent", UserID: &userID}
+	created, err := svc.CreateAppointment(appt, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if created.Override != nil {
+		t.Errorf("expected override not to be echoed back")
+	}
+	if len(overrides) != 1 {
+		t.Fatalf("expected a single override recorded, got %+v", overrides)
+	}
+	recorded := overrides[0]
+	if recorded.AppointmentID != 7 || recorded.Rule != models.RuleMaxPerPatientPerDay || recorded.Reason != "Urgent" || *recorded.UserID != 3 {
+		t.Errorf("unexpected override recorded: %+v", recorded)
+	}
+}
+
+func TestCreateAppointment_NewPatientLimit(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockAppointmentRepository(ctrl)
+	ruleRepo := &mockBookingRuleRepository{rules: models.BookingRules{MaxNewPatientsPerDay: 2}}
+	svc := newRulesTestService(ctrl, mockRepo, ruleRepo)
+	start := time.Date(2024, 7, 18, 15, 0, 0, 0, time.UTC)
+
+	// Patient 1 was never seen & the day is full of first visits
+	mockRepo.EXPECT().HasCompletedAppointmentBefore(1, start).Return(false, nil)
+	mockRepo.EXPECT().CountNewPatientAppointmentsInRange(gomock.Any(), gomock.Any(), gomock.Nil()).Return(2, nil)
+	_, err := svc.CreateAppointment(models.Appointment{PatientID: 1, Start: start, Duration: time.Hour}, nil)
+	var ruleErr *BookingRuleError
+	if !errors.As(err, &ruleErr) || ruleErr.Violations[0].Rule != models.RuleMaxNewPatientsPerDay {
+		t.Fatalf("expected new patient limit violation, got %v", err)
+	}
+
+	// Patient 2 is a returning patient, the limit doesn't apply
+	mockRepo.EXPECT().HasCompletedAppointmentBefore(2, start).Return(true, nil)
+	mockRepo.EXPECT().CreateAppointment(gomock.Any()).DoAndReturn(func(appt models.Appointment) (*models.Appointment, error) {
+		return &appt, nil
+	})
+	if _, err := svc.CreateAppointment(models.Appointment{PatientID: 2, Start: start, Duration: time.Hour}, nil); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestCreateAppointment_OverrideRequiresUser(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockAppointmentRepository(ctrl)
+	svc := newRulesTestService(ctrl, mockRepo, &mockBookingRuleRepository{})
+
+	appt := models.Appointment{
+		PatientID: 1,
+		Start:     time.Date(2024, 7, 18, 15, 0, 0, 0, time.UTC),
+		Duration:  time.Hour,
+		Override:  &models.RuleOverrideRequest{Rules: []string{models.RuleMinLeadTime}},
+	}
+	if _, err := svc.CreateAppointment(appt, nil); !errors.Is(err, ErrInvalidAppointment) {
+		t.Errorf("expected invalid appointment error, got %v", err)
+	}
+}
+
+func TestCreateAppointment_RepeatNoShowsNeedConfirmation(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockAppointmentRepository(ctrl)
+	ruleRepo := &mockBookingRuleRepository{rules: models.BookingRules{MaxNoShows: 2}}
+	svc := newRulesTestService(ctrl, mockRepo, ruleRepo)
+
+	mockRepo.EXPECT().
+		GetAttendanceStats(1, LateCancellationWindow).
+		Return(&models.AttendanceStats{Kept: 4, NoShows: 2}, nil).
+		Times(2)
+	mockRepo.EXPECT().
+		CreateAppointment(gomock.Any()).
+		DoAndReturn(func(appt models.Appointment) (*models.Appointment, error) {
+			appt.ID = 9
+			return &appt, nil
+		})
+
+	appt := models.Appointment{PatientID: 1, Start: time.Date(2024, 7, 18, 15, 0, 0, 0, time.UTC), Duration: time.Hour}
+	_, err := svc.CreateAppointment(appt, nil)
+	var ruleErr *BookingRuleError
+	if !errors.As(err, &ruleErr) || ruleErr.Violations[0].Rule != models.RuleMaxNoShows {
+		t.Fatalf("expected no-show violation, got %v", err)
+	}
+
+	// Confirmed by staff
+	mockRepo.EXPECT().
+		CreateRuleOverride(gomock.Any()).
+		DoAndReturn(func(override models.BookingRuleOverride) (*models.BookingRuleOverride, error) {
+			if override.AppointmentID != 9 || override.Rule != models.RuleMaxNoShows {
+				t.Errorf("expected the confirmation to be recorded, got %+v", override)
+			}
+			return &override, nil
+		})
+	userID := 3
+	appt.Override = &models.RuleOverrideRequest{Rules: []string{models.RuleMaxNoShows}, Reason: "Called patient", UserID: &userID}
+	if _, err := svc.CreateAppointment(appt, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestCreateAppointment_OverrideNotRecordedFails(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockAppointmentRepository(ctrl)
+	ruleRepo := &mockBookingRuleRepository{rules: models.BookingRules{MinLeadTime: 2 * time.Hour}}
+	svc := newRulesTestService(ctrl, mockRepo, ruleRepo)
+
+	mockRepo.EXPECT().
+		CreateAppointment(gomock.Any()).
+		DoAndReturn(func(appt models.Appointment) (*models.Appointment, error) {
+			appt.ID = 7
+			return &appt, nil
+		})
+	mockRepo.EXPECT().CreateRuleOverride(gomock.Any()).Return(nil, errors.New("connection reset"))
+
+	// The booking is rolled back with its transaction, so the request fails
+	userID := 3
+	appt := models.Appointment{
+		PatientID: 1,
+		Start:     time.Date(2024, 7, 18, 9, 0, 0, 0, time.UTC),
+		Duration:  time.Hour,
+		Override:  &models.RuleOverrideRequest{Rules: []string{models.RuleMinLeadTime}, Reason: "Walk-in", UserID: &userID},
+	}
+	if _, err := svc.CreateAppointment(appt, nil); err == nil {
+		t.Errorf("expected the booking to fail without its override")
+	}
+}
@@ -6,107 +6,294 @@ import (
 	"sort"
 	"time"
 
+	"software-backend/internal/clinic"
 	"software-backend/internal/models"
 	"software-backend/internal/repository/appointment"
+	"software-backend/internal/repository/appointmenttype"
+	"software-backend/internal/repository/bookingrule"
 	"software-backend/internal/service/businesshour"
 )
 
 // Custom errors, probably moved onto separate file in the future
 var (
-	ErrAppointmentConflict = errors.New("appointment time slot conflict")
-	ErrInvalidAppointment  = errors.New("invalid appointment data")
+	ErrAppointmentConflict  = errors.New("appointment time slot conflict")
+	ErrInvalidAppointment   = errors.New("invalid appointment data")
+	ErrOutsideBusinessHours = errors.New("appointment outside working hours")
+	ErrNotInSeries          = errors.New("appointment does not belong to a series")
+	ErrInvalidStatus        = errors.New("invalid appointment status")
+	ErrInvalidTransition    = errors.New("invalid appointment status transition")
+	ErrBookingRuleViolated  = errors.New("appointment breaks booking rules")
 )
 
+// Rolls back a series booking that couldn't book any occurrence
+var errNothingBooked = errors.New("no occurrence could be booked")
+
 // Interface defines methods expected from the service
 type AppointmentService interface {
-	GetAppointmentsInDateRangeAndGroupedByDay(startTime, endTime time.Time) (map[string][]models.Appointment, error)
+	GetAppointment(id int) (*models.Appointment, error)
+	GetAppointmentsInDateRangeAndGroupedByDay(startTime, endTime time.Time, providerID *int) (map[string][]models.Appointment, error)
 	GetTodaysAppointments() (map[string][]models.Appointment, error)
-	GetAppointmentsForMonth(year int, month time.Month) (map[string][]models.Appointment, error)
-	GetAppointmentsForDate(date time.Time) ([]models.Appointment, error)
-	DeleteAppointment(id int) error
-	CreateAppointment(appointment models.Appointment) (*models.Appointment, error)
-	UpdateAppointment(appointment models.Appointment) error
+	GetAppointmentsForMonth(year int, month time.Month, providerID *int) (map[string][]models.Appointment, error)
+	GetAppointmentsForDate(date time.Time, providerID *int) ([]models.Appointment, error)
+	CancelAppointment(id int, userID *int) (*models.Appointment, error)
+	ChangeAppointmentStatus(id int, status models.AppointmentStatus, userID *int) (*models.Appointment, error)
+	StartConsultation(consultation models.Consultation, userID *int) (int, error)
+	GetStatusHistory(id int) ([]models.AppointmentStatusChange, error)
+	GetAuditTrail(id int) ([]models.AppointmentAuditEntry, error)
+	GetAttendanceStats(patientID int) (*models.AttendanceStats, error)
+	MarkNoShows() (int, error)
+	CreateAppointment(appointment models.Appointment, userID *int) (*models.Appointment, error)
+	UpdateAppointment(appointment models.Appointment, userID *int) error
+	CreateAppointmentSeries(series models.AppointmentSeries, userID *int) (*models.SeriesBookingResult, error)
+	UpdateFollowingOccurrences(appointmentID int, series models.AppointmentSeries, userID *int) (*models.SeriesBookingResult, error)
+	CancelFollowingOccurrences(appointmentID int, userID *int) (int, error)
+	GetAvailableSlots(from, to time.Time, duration, granularity time.Duration, providerID *int) ([]models.TimeSlot, error)
+	FindNextAvailableSlot(after time.Time, duration time.Duration, providerID *int) (*models.TimeSlot, error)
+	PreviewReschedule(from, to time.Time) ([]models.RescheduleProposal, error)
+	ConfirmReschedule(moves []models.RescheduleMove, userID *int) ([]models.Appointment, error)
+	OnSlotReleased(hook SlotReleasedHook)
+	OnRescheduled(hook RescheduledHook)
 }
 
+// Called with a cancelled or moved appointment, as it was before, when its slot becomes free again
+type SlotReleasedHook func(released models.Appointment)
+
+// Called with an appointment moved by a reschedule & its start before the move
+type RescheduledHook func(moved models.Appointment, previousStart time.Time)
+
 // Struct to manage dependencies
 type appointmentService struct {
 	apptRepo             appointment.AppointmentRepository
+	typeRepo             appointmenttype.AppointmentTypeRepository
+	ruleRepo             bookingrule.BookingRuleRepository
 	businessHoursService businesshour.BusinessHoursService
+	now                  func() time.Time
+	slotReleasedHooks    []SlotReleasedHook
+	rescheduledHooks     []RescheduledHook
 }
 
 // Constructor to pass on dependencies
-func NewAppointmentService(apptRepo appointment.AppointmentRepository, bhService businesshour.BusinessHoursService) AppointmentService {
+func NewAppointmentService(apptRepo appointment.AppointmentRepository, typeRepo appointmenttype.AppointmentTypeRepository, ruleRepo bookingrule.BookingRuleRepository, bhService businesshour.BusinessHoursService) AppointmentService {
 	return &appointmentService{
 		apptRepo:             apptRepo,
+		typeRepo:             typeRepo,
+		ruleRepo:             ruleRepo,
 		businessHoursService: bhService,
+		now:                  time.Now,
 	}
 }
 
-// Create a new appointment
-func (s *appointmentService) CreateAppointment(appointment models.Appointment) (*models.Appointment, error) {
+// Run 'fn' in a transaction with a copy of the service whose repository queries go
+// through it, everything 'fn' writes is rolled back if it fails
+func (s *appointmentService) inTransaction(fn func(tx *appointmentService) error) error {
+	return s.apptRepo.WithTransaction(func(repo appointment.AppointmentRepository) error {
+		tx := *s
+		tx.apptRepo = repo
+		return fn(&tx)
+	})
+}
+
+// Get an appointment by ID
+func (s *appointmentService) GetAppointment(id int) (*models.Appointment, error) {
+	return s.apptRepo.GetAppointmentByID(id)
+}
+
+// Create a new appointment, 'userID' is the user booking it if known
+func (s *appointmentService) CreateAppointment(appointment models.Appointment, userID *int) (*models.Appointment, error) {
 	// Basic input validation
 	if appointment.PatientID == 0 && appointment.Name == "" {
 		return nil, fmt.Errorf("either patient ID or name must be provided")
 	}
 
-	// Get business hours for validation
-	intervals, err := s.businessHoursService.GetBusinessHoursForDate(appointment.Start)
+	// Defaults from the type of visit
+	if err := s.applyAppointmentType(&appointment); err != nil {
+		return nil, err
+	}
+
+	// Check business hours & overlap vs other scheduled appointments
+	if err := s.validateSlot(appointment, nil); err != nil {
+		return nil, s.withNextSlot(appointment, err)
+	}
+
+	// Check booking rules, unless overridden
+	overridden, err := s.checkBookingRules(appointment, nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get business hours: %w", err)
+		return nil, err
 	}
 
-	// Check overlap vs other scheduled appointments
+	// New appointments always start their lifecycle as scheduled
+	appointment.Status = models.StatusScheduled
+	var created *models.Appointment
+	err = s.inTransaction(func(tx *appointmentService) error {
+		created, err = tx.apptRepo.CreateAppointment(appointment)
+		if err != nil {
+			return mapOverlapError(err)
+		}
+		if err := tx.recordOverrides(created.ID, overridden, appointment.Override); err != nil {
+			return err
+		}
+		created.Override = nil
+		return tx.audit(models.AuditCreate, nil, created, userID)
+	})
+	if err != nil {
+		return nil, s.withNextSlot(appointment, err)
+	}
+	return created, nil
+}
+
+// The checks above can race with a concurrent booking, the database constraint
+// has the final say & is reported like any other conflict
+func mapOverlapError(err error) error {
+	if errors.Is(err, appointment.ErrAppointmentOverlap) {
+		return ErrAppointmentConflict
+	}
+	return err
+}
+
+// Fill in the duration of an appointment from its type if none was given, the buffer
+// always comes from the type so it can't be set on its own
+func (s *appointmentService) applyAppointmentType(appointment *models.Appointment) error {
+	appointment.Buffer = 0
+	if appointment.AppointmentTypeID == nil {
+		return nil
+	}
+	apptType, err := s.getAppointmentType(*appointment.AppointmentTypeID)
+	if err != nil {
+		return err
+	}
+	if appointment.Duration == 0 {
+		appointment.Duration = apptType.DefaultDuration
+	}
+	appointment.Buffer = apptType.Buffer
+	return nil
+}
+
+// Get an appointment type, an unknown type is reported as invalid input
+func (s *appointmentService) getAppointmentType(id int) (*models.AppointmentType, error) {
+	apptType, err := s.typeRepo.GetAppointmentTypeByID(id)
+	if err != nil {
+		if errors.Is(err, appointmenttype.ErrAppointmentTypeNotFound) {
+			return nil, fmt.Errorf("%w: appointment type %d not found", ErrInvalidAppointment, id)
+		}
+		return nil, err
+	}
+	return apptType, nil
+}
+
+// Check that the appointment's slot is free for its provider & room and within business
+// hours, returns ErrAppointmentConflict or ErrOutsideBusinessHours when it can't be booked.
+// The buffer only has to be free, it may run past closing time
+func (s *appointmentService) validateSlot(appointment models.Appointment, excludeID *int) error {
 	start := appointment.Start
 	end := appointment.Start.Add(appointment.Duration)
-	overlap, err := s.apptRepo.HasOverlappingAppointment(start, end, nil)
+
+	// Get business hours for validation
+	intervals, err := s.hoursForDate(start, appointment.ProviderID)
+	if err != nil {
+		return fmt.Errorf("failed to get business hours: %w", err)
+	}
+
+	// Check overlap vs other scheduled appointments
+	overlap, err := s.apptRepo.HasOverlappingAppointment(start, end.Add(appointment.Buffer), excludeID, appointment.ProviderID, appointment.RoomID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to check for overlapping appointments: %w", err)
+		return fmt.Errorf("failed to check for overlapping appointments: %w", err)
 	}
 	// Return conflict on conflict
 	if overlap {
-		return nil, ErrAppointmentConflict
+		return ErrAppointmentConflict
 	}
 
 	// Check if interval within business hours
 	within, err := isWithinBusinessHours(start, end, intervals)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse business hours: %w", err)
+		return fmt.Errorf("failed to parse business hours: %w", err)
 	}
 	// Return error on appointment outside business hours
 	if !within {
-		return nil, errors.New("appointment outside working hours")
+		return ErrOutsideBusinessHours
 	}
-	return s.apptRepo.CreateAppointment(appointment)
+	return nil
 }
 
-// Update an appointment given the new values including ID
-func (s *appointmentService) UpdateAppointment(appointment models.Appointment) error {
-	// Check against overlapping appointment
-	start := appointment.Start
-	end := appointment.Start.Add(appointment.Duration)
-	overlap, err := s.apptRepo.HasOverlappingAppointment(start, end, nil)
+// Get the bookable hours of a day, the provider's own hours when booking with one. The
+// day is the one the date falls on in the clinic
+func (s *appointmentService) hoursForDate(date time.Time, providerID *int) ([]models.BusinessHourInterval, error) {
+	date = clinic.In(date)
+	if providerID != nil {
+		return s.businessHoursService.GetProviderHoursForDate(*providerID, date)
+	}
+	return s.businessHoursService.GetBusinessHoursForDate(date)
+}
+
+// Update an appointment given the new values including ID, 'userID' is the user making the change if known
+func (s *appointmentService) UpdateAppointment(appointment models.Appointment, userID *int) error {
+	// Finished or cancelled appointments can't be edited
+	existing, err := s.apptRepo.GetAppointmentByID(appointment.ID)
 	if err != nil {
-		return fmt.Errorf("failed to check for overlapping appointments: %w", err)
+		return err
 	}
-	if overlap {
-		// Return conflict on conflict
-		return ErrAppointmentConflict
+	if existing.Status.IsTerminal() {
+		return fmt.Errorf("%w: appointment is %s", ErrInvalidTransition, existing.Status)
+	}
+	if err := s.applyAppointmentType(&appointment); err != nil {
+		return err
 	}
-	return s.apptRepo.UpdateAppointment(appointment)
+
+	// Check business hours & overlap, the appointment can't collide with itself
+	if err := s.validateSlot(appointment, &appointment.ID); err != nil {
+		return err
+	}
+
+	// Booking rules only apply again when moving the appointment or changing its patient
+	var overridden []models.RuleViolation
+	if !appointment.Start.Equal(existing.Start) || appointment.PatientID != existing.PatientID {
+		overridden, err = s.checkBookingRules(appointment, &appointment.ID)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Only the editable fields change, the rest stays as it was
+	updated := *existing
+	updated.PatientID = appointment.PatientID
+	updated.Name = appointment.Name
+	updated.Start = appointment.Start
+	updated.Duration = appointment.Duration
+	updated.ProviderID = appointment.ProviderID
+	updated.RoomID = appointment.RoomID
+	updated.AppointmentTypeID = appointment.AppointmentTypeID
+	updated.Buffer = appointment.Buffer
+
+	err = s.inTransaction(func(tx *appointmentService) error {
+		if err := tx.apptRepo.UpdateAppointment(updated); err != nil {
+			return mapOverlapError(err)
+		}
+		if err := tx.recordOverrides(appointment.ID, overridden, appointment.Override); err != nil {
+			return err
+		}
+		return tx.audit(models.AuditUpdate, existing, &updated, userID)
+	})
+	if err != nil {
+		return err
+	}
+
+	// Moving an appointment frees whatever part of its old slot it no longer takes
+	s.releaseSlots([]models.Appointment{*existing}, []models.Appointment{updated})
+	return nil
 }
 
-// Get appointments in a date range, grouping them by day
-func (s *appointmentService) GetAppointmentsInDateRangeAndGroupedByDay(startTime, endTime time.Time) (map[string][]models.Appointment, error) {
+// Get appointments in a date range, grouping them by day, optionally only the given provider's
+func (s *appointmentService) GetAppointmentsInDateRangeAndGroupedByDay(startTime, endTime time.Time, providerID *int) (map[string][]models.Appointment, error) {
 	// Get appointments from repository
-	appointments, err := s.apptRepo.ListAppointmentsInDateRange(startTime, endTime)
+	appointments, err := s.listAppointments(startTime, endTime, providerID)
 	if err != nil {
 		return nil, fmt.Errorf("service: failed to fetch appointments from repository: %w", err)
 	}
 
-	// Group by day in a map
+	// Group by clinic day in a map
 	grouped := make(map[string][]models.Appointment)
 	for _, appt := range appointments {
-		dateKey := appt.Start.Format("2006-01-02")
+		dateKey := clinic.In(appt.Start).Format("2006-01-02")
 		grouped[dateKey] = append(grouped[dateKey], appt)
 	}
 	for dateStr, appts := range grouped {
@@ -120,27 +307,24 @@ func (s *appointmentService) GetAppointmentsInDateRangeAndGroupedByDay(startTime
 	return grouped, nil
 }
 
-// Get appointments for current day using time.Now()
+// Get appointments for the current clinic day
 func (s *appointmentService) GetTodaysAppointments() (map[string][]models.Appointment, error) {
-	// Get current time
-	now := time.Now()
-
-	// Generate start of day at 00:00 & end of day at 23:59
-	startOfDay := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
-	endOfDay := startOfDay.Add(24 * time.Hour).Add(-time.Nanosecond)
+	// Generate start of day at 00:00 & end of day at 23:59, days aren't always 24h long
+	startOfDay := clinic.StartOfDay(s.now())
+	endOfDay := startOfDay.AddDate(0, 0, 1).Add(-time.Nanosecond)
 
 	// Get appointments from repository & return them
-	return s.GetAppointmentsInDateRangeAndGroupedByDay(startOfDay, endOfDay)
+	return s.GetAppointmentsInDateRangeAndGroupedByDay(startOfDay, endOfDay, nil)
 }
 
-// Get appointments for a specific date
-func (s *appointmentService) GetAppointmentsForDate(date time.Time) ([]models.Appointment, error) {
-	// Generate start & end of day as 'time'
-	startOfDay := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
-	endOfDay := startOfDay.Add(24 * time.Hour).Add(-time.Nanosecond)
+// Get appointments for a specific date, optionally only the given provider's
+func (s *appointmentService) GetAppointmentsForDate(date time.Time, providerID *int) ([]models.Appointment, error) {
+	// Generate start & end of the clinic day as 'time'
+	startOfDay := clinic.StartOfDay(date)
+	endOfDay := startOfDay.AddDate(0, 0, 1).Add(-time.Nanosecond)
 
 	// Get appointments within interval from repository
-	appointments, err := s.apptRepo.ListAppointmentsInDateRange(startOfDay, endOfDay)
+	appointments, err := s.listAppointments(startOfDay, endOfDay, providerID)
 	if err != nil {
 		return nil, fmt.Errorf("service: failed to fetch appointments for date: %w", err)
 	}
@@ -152,40 +336,142 @@ func (s *appointmentService) GetAppointmentsForDate(date time.Time) ([]models.Ap
 	return appointments, nil
 }
 
-// Get appointments for a specific month (year-month format)
-func (s *appointmentService) GetAppointmentsForMonth(year int, month time.Month) (map[string][]models.Appointment, error) {
+// Get appointments for a specific month (year-month format), optionally only the given provider's
+func (s *appointmentService) GetAppointmentsForMonth(year int, month time.Month, providerID *int) (map[string][]models.Appointment, error) {
 	// Generate start / end times for month interval
-	startOfMonth := time.Date(year, month, 1, 0, 0, 0, 0, time.Now().Location())
+	startOfMonth := time.Date(year, month, 1, 0, 0, 0, 0, clinic.Location())
 	startOfNextMonth := startOfMonth.AddDate(0, 1, 0)
 	endOfMonth := startOfNextMonth.Add(-time.Nanosecond)
 
 	// Get appointments from repository & return them
-	return s.GetAppointmentsInDateRangeAndGroupedByDay(startOfMonth, endOfMonth)
+	return s.GetAppointmentsInDateRangeAndGroupedByDay(startOfMonth, endOfMonth, providerID)
+}
+
+// List appointments in a range, only the provider's if one is given
+func (s *appointmentService) listAppointments(startTime, endTime time.Time, providerID *int) ([]models.Appointment, error) {
+	if providerID != nil {
+		return s.apptRepo.ListProviderAppointmentsInDateRange(startTime, endTime, *providerID)
+	}
+	return s.apptRepo.ListAppointmentsInDateRange(startTime, endTime)
+}
+
+// Cancel an appointment, the row is kept so cancellations stay on record
+func (s *appointmentService) CancelAppointment(id int, userID *int) (*models.Appointment, error) {
+	return s.changeStatus(id, models.StatusCancelled, userID, models.AuditDelete, nil)
+}
+
+// Move an appointment to a new status if the transition is allowed
+func (s *appointmentService) ChangeAppointmentStatus(id int, status models.AppointmentStatus, userID *int) (*models.Appointment, error) {
+	return s.changeStatus(id, status, userID, models.AuditStatusChange, nil)
+}
+
+// Move the appointment a consultation is started from to in consultation & create the
+// consultation in the same transaction, so neither is left without the other. Returns
+// the consultation's ID
+func (s *appointmentService) StartConsultation(consultation models.Consultation, userID *int) (int, error) {
+	if consultation.AppointmentID == nil {
+		return 0, fmt.Errorf("%w: consultation isn't linked to an appointment", ErrInvalidAppointment)
+	}
+	var id int
+	_, err := s.changeStatus(*consultation.AppointmentID, models.StatusInConsultation, userID, models.AuditStatusChange,
+		func(tx *appointmentService) error {
+			var err error
+			id, err = tx.apptRepo.CreateConsultation(consultation)
+			return err
+		})
+	if err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+// Move an appointment to a new status, recorded in the audit trail as 'action'. 'also'
+// runs in the same transaction when given
+func (s *appointmentService) changeStatus(id int, status models.AppointmentStatus, userID *int, action string, also func(tx *appointmentService) error) (*models.Appointment, error) {
+	// Basic input validation
+	if !status.IsValid() {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidStatus, status)
+	}
+
+	// Validate transition against the current status
+	appt, err := s.apptRepo.GetAppointmentByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if !appt.Status.CanTransitionTo(status) {
+		return nil, fmt.Errorf("%w: %s to %s", ErrInvalidTransition, appt.Status, status)
+	}
+
+	// Reflect the change on the returned model
+	before := *appt
+	now := s.now()
+	appt.Status = status
+	appt.StatusChangedAt = &now
+	appt.StatusChangedBy = userID
+
+	// Update status via repository, together with its audit entry
+	err = s.inTransaction(func(tx *appointmentService) error {
+		if err := tx.apptRepo.UpdateAppointmentStatus(id, before.Status, status, userID); err != nil {
+			return err
+		}
+		if also != nil {
+			if err := also(tx); err != nil {
+				return err
+			}
+		}
+		return tx.audit(action, &before, appt, userID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Let others know the slot can be booked again, no-shows are already in the past
+	if status == models.StatusCancelled {
+		s.releaseSlot(*appt)
+	}
+	return appt, nil
+}
+
+// Register a hook run whenever an appointment is cancelled, e.g. to offer the slot to the waitlist
+func (s *appointmentService) OnSlotReleased(hook SlotReleasedHook) {
+	s.slotReleasedHooks = append(s.slotReleasedHooks, hook)
 }
 
-// Delete an appointment
-func (s *appointmentService) DeleteAppointment(id int) error {
-	// Delete appointment via repository
-	return s.apptRepo.DeleteAppointment(id)
+// Run the slot released hooks in the background so they don't hold up the cancellation
+func (s *appointmentService) releaseSlot(released models.Appointment) {
+	for _, hook := range s.slotReleasedHooks {
+		go hook(released)
+	}
+}
+
+// Release the slots of appointments that were cancelled or moved away, 'released' as they
+// were before. Slots taken right away by one of the 'taken' appointments aren't released
+func (s *appointmentService) releaseSlots(released, taken []models.Appointment) {
+	for _, appt := range released {
+		if overlapsAny(appt.Start, appt.Start.Add(appt.Duration+appt.Buffer), sharingSchedule(appt, taken)) {
+			continue
+		}
+		s.releaseSlot(appt)
+	}
+}
+
+// Get the status changes of an appointment
+func (s *appointmentService) GetStatusHistory(id int) ([]models.AppointmentStatusChange, error) {
+	// Make sure the appointment exists so a missing one isn't an empty history
+	if _, err := s.apptRepo.GetAppointmentByID(id); err != nil {
+		return nil, err
+	}
+	return s.apptRepo.GetStatusHistory(id)
 }
 
 // Returns true if the appointment is fully within any business interval
 func isWithinBusinessHours(appointmentStart, appointmentEnd time.Time, intervals []models.BusinessHourInterval) (bool, error) {
 	// Parse interval, as a day can have multiple intervals to indicate lunch break for example
 	for _, interval := range intervals {
-		intervalStart, err := time.ParseInLocation("15:04", interval.Start, appointmentStart.Location())
-		if err != nil {
-			return false, err
-		}
-		intervalEnd, err := time.ParseInLocation("15:04", interval.End, appointmentStart.Location())
+		intervalStart, intervalEnd, err := intervalOnDate(appointmentStart, interval)
 		if err != nil {
 			return false, err
 		}
-		// Set the date to match appointmentStart
-		intervalStart = time.Date(appointmentStart.Year(), appointmentStart.Month(), appointmentStart.Day(),
-			intervalStart.Hour(), intervalStart.Minute(), 0, 0, appointmentStart.Location())
-		intervalEnd = time.Date(appointmentStart.Year(), appointmentStart.Month(), appointmentStart.Day(),
-			intervalEnd.Hour(), intervalEnd.Minute(), 0, 0, appointmentStart.Location())
 
 		if !appointmentStart.Before(intervalStart) && !appointmentEnd.After(intervalEnd) {
 			return true, nil
@@ -193,3 +479,275 @@ func isWithinBusinessHours(appointmentStart, appointmentEnd time.Time, intervals
 	}
 	return false, nil
 }
+
+// Convert a "15:04" business hour interval into times on the clinic day 'date' falls on,
+// business hours are always clinic time
+func intervalOnDate(date time.Time, interval models.BusinessHourInterval) (time.Time, time.Time, error) {
+	date = clinic.In(date)
+	intervalStart, err := time.Parse("15:04", interval.Start)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	intervalEnd, err := time.Parse("15:04", interval.End)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	// Set the date to match the given date
+	intervalStart = time.Date(date.Year(), date.Month(), date.Day(),
+		intervalStart.Hour(), intervalStart.Minute(), 0, 0, clinic.Location())
+	intervalEnd = time.Date(date.Year(), date.Month(), date.Day(),
+		intervalEnd.Hour(), intervalEnd.Minute(), 0, 0, clinic.Location())
+	return intervalStart, intervalEnd, nil
+}
+
+// Create a recurring series & book every occurrence that fits, occurrences that
+// overlap or fall outside business hours are reported instead of failing the series.
+// Nothing is stored unless at least one occurrence can be booked
+func (s *appointmentService) CreateAppointmentSeries(series models.AppointmentSeries, userID *int) (*models.SeriesBookingResult, error) {
+	series, occurrences, buffer, err := s.expandSeries(series)
+	if err != nil {
+		return nil, err
+	}
+
+	var result *models.SeriesBookingResult
+	err = s.inTransaction(func(tx *appointmentService) error {
+		result, err = tx.bookSeries(series, occurrences, buffer, userID)
+		return err
+	})
+	if err != nil && !errors.Is(err, errNothingBooked) {
+		return nil, err
+	}
+	return result, nil
+}
+
+// Validate a series & expand it into the start of each occurrence, along with the
+// buffer the occurrences get from the series' type
+func (s *appointmentService) expandSeries(series models.AppointmentSeries) (models.AppointmentSeries, []time.Time, time.Duration, error) {
+	// Basic input validation
+	if series.PatientID == 0 && series.Name == "" {
+		return series, nil, 0, fmt.Errorf("either patient ID or name must be provided")
+	}
+	// Occurrences get the duration & buffer of the series' type
+	var buffer time.Duration
+	if series.AppointmentTypeID != nil {
+		apptType, err := s.getAppointmentType(*series.AppointmentTypeID)
+		if err != nil {
+			return series, nil, 0, err
+		}
+		if series.Duration == 0 {
+			series.Duration = apptType.DefaultDuration
+		}
+		buffer = apptType.Buffer
+	}
+	if series.Duration <= 0 {
+		return series, nil, 0, fmt.Errorf("%w: duration must be positive", ErrInvalidAppointment)
+	}
+	for _, date := range series.Exceptions {
+		if _, err := time.Parse("2006-01-02", date); err != nil {
+			return series, nil, 0, fmt.Errorf("%w: invalid exception date %q", ErrInvalidAppointment, date)
+		}
+	}
+	rule, err := parseRRule(series.RRule)
+	if err != nil {
+		return series, nil, 0, err
+	}
+	// Expand in the clinic time zone so occurrences keep their wall clock time across DST
+	series.Start = clinic.In(series.Start)
+	occurrences, err := rule.occurrences(series.Start, series.Exceptions)
+	if err != nil {
+		return series, nil, 0, err
+	}
+	if len(occurrences) == 0 {
+		return series, nil, 0, fmt.Errorf("%w: rule produces no occurrences", ErrInvalidRecurrenceRule)
+	}
+	return series, occurrences, buffer, nil
+}
+
+// Check every occurrence of a series & store the series with the ones that fit, meant
+// to run in a transaction. Occurrences are booked one at a time so the ones already
+// booked count against the next one's booking rules. Returns errNothingBooked along with
+// the conflicts when no occurrence fits so the transaction is rolled back
+func (s *appointmentService) bookSeries(series models.AppointmentSeries, occurrences []time.Time, buffer time.Duration, userID *int) (*models.SeriesBookingResult, error) {
+	result := &models.SeriesBookingResult{
+		Series:    series,
+		Booked:    []models.Appointment{},
+		Conflicts: []models.OccurrenceConflict{},
+	}
+
+	var created *models.AppointmentSeries
+	for _, start := range occurrences {
+		occurrence := models.Appointment{
+			PatientID:         series.PatientID,
+			Name:              series.Name,
+			Start:             start,
+			Duration:          series.Duration,
+			ProviderID:        series.ProviderID,
+			RoomID:            series.RoomID,
+			Status:            models.StatusScheduled,
+			AppointmentTypeID: series.AppointmentTypeID,
+			Buffer:            buffer,
+		}
+		// Occurrences that conflict are collected & left out
+		if err := s.validateSlot(occurrence, nil); err != nil {
+			if errors.Is(err, ErrAppointmentConflict) || errors.Is(err, ErrOutsideBusinessHours) {
+				result.Conflicts = append(result.Conflicts, models.OccurrenceConflict{Start: start, Reason: err.Error()})
+				continue
+			}
+			return nil, err
+		}
+		// Series can't override booking rules, occurrences breaking them are left out
+		if _, err := s.checkBookingRules(occurrence, nil); err != nil {
+			if errors.Is(err, ErrBookingRuleViolated) {
+				result.Conflicts = append(result.Conflicts, models.OccurrenceConflict{Start: start, Reason: err.Error()})
+				continue
+			}
+			return nil, err
+		}
+
+		// Store the series itself along with its first occurrence that fits
+		if created == nil {
+			var err error
+			created, err = s.apptRepo.CreateSeries(series)
+			if err != nil {
+				return nil, fmt.Errorf("service: failed to create appointment series: %w", err)
+			}
+			result.Series = *created
+		}
+		occurrence.SeriesID = &created.ID
+		appt, err := s.apptRepo.CreateAppointment(occurrence)
+		if err != nil {
+			return nil, fmt.Errorf("service: failed to book occurrence at %v: %w", occurrence.Start, mapOverlapError(err))
+		}
+		if err := s.audit(models.AuditCreate, nil, appt, userID); err != nil {
+			return nil, err
+		}
+		result.Booked = append(result.Booked, *appt)
+	}
+	if created == nil {
+		return result, errNothingBooked
+	}
+	return result, nil
+}
+
+// Replace an occurrence & every later one in its series with a new series definition,
+// the original series is cut so it ends right before the given occurrence. Fields left
+// empty in the new definition are taken from the original series. The original series
+// is left untouched unless the new one books at least one occurrence
+func (s *appointmentService) UpdateFollowingOccurrences(appointmentID int, series models.AppointmentSeries, userID *int) (*models.SeriesBookingResult, error) {
+	appt, original, err := s.getOccurrenceAndSeries(appointmentID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Fill in defaults from the original series
+	if series.PatientID == 0 && series.Name == "" {
+		series.PatientID = original.PatientID
+		series.Name = original.Name
+	}
+	if series.Start.IsZero() {
+		series.Start = appt.Start
+	}
+	if series.Duration == 0 {
+		series.Duration = original.Duration
+	}
+	if series.RRule == "" {
+		series.RRule = original.RRule
+	}
+	if series.ProviderID == nil {
+		series.ProviderID = original.ProviderID
+	}
+	if series.RoomID == nil {
+		series.RoomID = original.RoomID
+	}
+	if series.AppointmentTypeID == nil {
+		series.AppointmentTypeID = original.AppointmentTypeID
+	}
+
+	// Validate the new series before touching existing occurrences
+	series, occurrences, buffer, err := s.expandSeries(series)
+	if err != nil {
+		return nil, err
+	}
+
+	// The cancelled occurrences free their slots for the new ones within the transaction
+	var result *models.SeriesBookingResult
+	var cancelled []models.Appointment
+	err = s.inTransaction(func(tx *appointmentService) error {
+		cancelled, err = tx.cutSeriesAt(appt, original, userID)
+		if err != nil {
+			return err
+		}
+		result, err = tx.bookSeries(series, occurrences, buffer, userID)
+		return err
+	})
+	if errors.Is(err, errNothingBooked) {
+		return result, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	s.releaseSlots(cancelled, result.Booked)
+	return result, nil
+}
+
+// Cancel an occurrence & every later one in its series, returns how many were cancelled
+func (s *appointmentService) CancelFollowingOccurrences(appointmentID int, userID *int) (int, error) {
+	appt, series, err := s.getOccurrenceAndSeries(appointmentID)
+	if err != nil {
+		return 0, err
+	}
+	var cancelled []models.Appointment
+	err = s.inTransaction(func(tx *appointmentService) error {
+		cancelled, err = tx.cutSeriesAt(appt, series, userID)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	s.releaseSlots(cancelled, nil)
+	return len(cancelled), nil
+}
+
+// Get an appointment along with the series it belongs to
+func (s *appointmentService) getOccurrenceAndSeries(appointmentID int) (*models.Appointment, *models.AppointmentSeries, error) {
+	appt, err := s.apptRepo.GetAppointmentByID(appointmentID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if appt.SeriesID == nil {
+		return nil, nil, ErrNotInSeries
+	}
+	series, err := s.apptRepo.GetSeriesByID(*appt.SeriesID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return appt, series, nil
+}
+
+// Cancel the occurrences of a series from the given appointment onwards & end the
+// series right before it, the series is kept as a record of the cancelled occurrences.
+// Returns the cancelled occurrences
+func (s *appointmentService) cutSeriesAt(appt *models.Appointment, series *models.AppointmentSeries, userID *int) ([]models.Appointment, error) {
+	cancelled, err := s.apptRepo.CancelSeriesAppointmentsFrom(series.ID, appt.Start, userID)
+	if err != nil {
+		return nil, fmt.Errorf("service: failed to cancel following occurrences: %w", err)
+	}
+
+	// Audited like single cancellations
+	now := s.now()
+	for _, before := range cancelled {
+		after := before
+		after.Status = models.StatusCancelled
+		after.StatusChangedAt = &now
+		after.StatusChangedBy = userID
+		if err := s.audit(models.AuditDelete, &before, &after, userID); err != nil {
+			return nil, err
+		}
+	}
+
+	series.RRule = ruleEndingAt(series.RRule, appt.Start.Add(-time.Second))
+	if err := s.apptRepo.UpdateSeries(*series); err != nil {
+		return nil, fmt.Errorf("service: failed to end appointment series: %w", err)
+	}
+	return cancelled, nil
+}
@@ -5,8 +5,12 @@ import (
 	"testing"
 	"time"
 
+	"software-backend/internal/clinic"
 	"software-backend/internal/mocks"
 	"software-backend/internal/models"
+	"software-backend/internal/repository/appointment"
+	"software-backend/internal/repository/appointmenttype"
+	"software-backend/internal/repository/bookingrule"
 
 	"github.com/golang/mock/gomock"
 )
@@ -14,12 +18,74 @@ import (
 // Hand-written mock for BusinessHoursService
 type mockBusinessHoursService struct {
 	GetBusinessHoursForDateFunc func(date time.Time) ([]models.BusinessHourInterval, error)
+	GetProviderHoursForDateFunc func(providerID int, date time.Time) ([]models.BusinessHourInterval, error)
 }
 
 func (m *mockBusinessHoursService) GetBusinessHoursForDate(date time.Time) ([]models.BusinessHourInterval, error) {
 	return m.GetBusinessHoursForDateFunc(date)
 }
 
+func (m *mockBusinessHoursService) GetProviderHoursForDate(providerID int, date time.Time) ([]models.BusinessHourInterval, error) {
+	return m.GetProviderHoursForDateFunc(providerID, date)
+}
+
+func (m *mockBusinessHoursService) GetBusinessHoursForRange(from, to time.Time, providerID *int) ([]models.DayHours, error) {
+	return nil, nil
+}
+
+func (m *mockBusinessHoursService) GetProviderWeeklyHours(providerID int) ([]models.WeeklyHourInterval, error) {
+	return nil, nil
+}
+
+func (m *mockBusinessHoursService) SetProviderWeeklyHours(providerID int, hours []models.WeeklyHourInterval) error {
+	return nil
+}
+
+func (m *mockBusinessHoursService) GetWeeklyHours() ([]models.WeeklyHourInterval, error) {
+	return nil, nil
+}
+
+func (m *mockBusinessHoursService) SetWeeklyHours(hours []models.WeeklyHourInterval) error {
+	return nil
+}
+
+func (m *mockBusinessHoursService) ListSpecialHours(from, to time.Time) ([]models.SpecialHours, error) {
+	return nil, nil
+}
+
+func (m *mockBusinessHoursService) SetSpecialHours(special models.SpecialHours) error {
+	return nil
+}
+
+func (m *mockBusinessHoursService) DeleteSpecialHours(date time.Time) error {
+	return nil
+}
+
+func (m *mockBusinessHoursService) ImportHolidays(data []byte, format string, dryRun bool) (*models.HolidayImportReport, error) {
+	return nil, nil
+}
+
+// Hand-written mock for BookingRuleRepository, no rules apply unless set
+type mockBookingRuleRepository struct {
+	bookingrule.BookingRuleRepository
+	rules models.BookingRules
+}
+
+func (m *mockBookingRuleRepository) GetRules() (*models.BookingRules, error) {
+	rules := m.rules
+	return &rules, nil
+}
+
+// Let transactions run straight on the mocked repository
+func expectTransactions(mockRepo *mocks.MockAppointmentRepository) {
+	mockRepo.EXPECT().
+		WithTransaction(gomock.Any()).
+		DoAndReturn(func(fn func(repo appointment.AppointmentRepository) error) error {
+			return fn(mockRepo)
+		}).
+		AnyTimes()
+}
+
 func TestCreateAppointment_Conflict(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -32,21 +98,43 @@ func TestCreateAppointment_Conflict(t *testing.T) {
 	}
 
 	// Set up expectations
+	roomID, otherRoomID := 2, 3
+	day := time.Date(2024, 7, 18, 0, 0, 0, 0, time.UTC)
 	mockRepo.EXPECT().
-		HasOverlappingAppointment(gomock.Any(), gomock.Any(), gomock.Nil()).
+		HasOverlappingAppointment(gomock.Any(), gomock.Any(), gomock.Nil(), gomock.Nil(), &roomID).
 		Return(true, nil)
+	mockRepo.EXPECT().
+		ListAppointmentsInDateRange(gomock.Any(), gomock.Any()).
+		Return([]models.Appointment{
+			{ID: 1, Start: day.Add(10 * time.Hour), Duration: time.Hour, Buffer: 15 * time.Minute, RoomID: &roomID, Status: models.StatusScheduled},
+			// Another room doesn't get in the way
+			{ID: 2, Start: day.Add(11*time.Hour + 15*time.Minute), Duration: time.Hour, RoomID: &otherRoomID, Status: models.StatusScheduled},
+		}, nil)
 
-	svc := NewAppointmentService(mockRepo, bhService)
+	svc := NewAppointmentService(mockRepo, mocks.NewMockAppointmentTypeRepository(ctrl), &mockBookingRuleRepository{}, bhService).(*appointmentService)
+	svc.now = func() time.Time { return day.Add(8 * time.Hour) }
 
 	appt := models.Appointment{
 		PatientID: 1,
 		Name:      "Test",
-		Start:     time.Date(2024, 7, 18, 10, 0, 0, 0, time.UTC),
-		Duration:  time.Hour,
+		Start:     day.Add(10 * time.Hour),
+		Duration:  30 * time.Minute,
+		Buffer:    15 * time.Minute,
+		RoomID:    &roomID,
 	}
-	_, err := svc.CreateAppointment(appt)
+	_, err := svc.CreateAppointment(appt, nil)
 	if !errors.Is(err, ErrAppointmentConflict) {
-		t.Errorf("expected conflict error, got %v", err)
+		t.Fatalf("expected conflict error, got %v", err)
+	}
+
+	// The suggestion starts once the booking & its buffer are over
+	var conflictErr *SlotConflictError
+	if !errors.As(err, &conflictErr) || conflictErr.NextAvailable == nil {
+		t.Fatalf("expected a suggested slot, got %v", err)
+	}
+	next := conflictErr.NextAvailable
+	if !next.Start.Equal(day.Add(11*time.Hour+15*time.Minute)) || !next.End.Equal(next.Start.Add(30*time.Minute)) {
+		t.Errorf("unexpected suggested slot: %+v", next)
 	}
 }
 
@@ -63,10 +151,10 @@ func TestCreateAppointment_OutsideBusinessHours(t *testing.T) {
 
 	// No overlap
 	mockRepo.EXPECT().
-		HasOverlappingAppointment(gomock.Any(), gomock.Any(), gomock.Nil()).
+		HasOverlappingAppointment(gomock.Any(), gomock.Any(), gomock.Nil(), gomock.Nil(), gomock.Nil()).
 		Return(false, nil)
 
-	svc := NewAppointmentService(mockRepo, bhService)
+	svc := NewAppointmentService(mockRepo, mocks.NewMockAppointmentTypeRepository(ctrl), &mockBookingRuleRepository{}, bhService)
 
 	appt := models.Appointment{
 		PatientID: 1,
@@ -74,17 +162,120 @@ func TestCreateAppointment_OutsideBusinessHours(t *testing.T) {
 		Start:     time.Date(2024, 7, 18, 18, 0, 0, 0, time.UTC), // 6pm, outside business hours
 		Duration:  time.Hour,
 	}
-	_, err := svc.CreateAppointment(appt)
+	_, err := svc.CreateAppointment(appt, nil)
 	if err == nil || err.Error() != "appointment outside working hours" {
 		t.Errorf("expected outside working hours error, got %v", err)
 	}
 }
 
+func TestCreateAppointment_WithProviderUsesProviderHours(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockAppointmentRepository(ctrl)
+	bhService := &mockBusinessHoursService{
+		GetProviderHoursForDateFunc: func(providerID int, date time.Time) ([]models.BusinessHourInterval, error) {
+			if providerID != 3 {
+				t.Errorf("expected provider 3, got %d", providerID)
+			}
+			// Provider only works mornings
+			return []models.BusinessHourInterval{{Start: "09:00", End: "12:00"}}, nil
+		},
+	}
+
+	providerID, roomID := 3, 5
+	// Overlap is only checked against the provider & room being booked
+	mockRepo.EXPECT().
+		HasOverlappingAppointment(gomock.Any(), gomock.Any(), gomock.Nil(), &providerID, &roomID).
+		Return(false, nil)
+
+	svc := NewAppointmentService(mockRepo, mocks.NewMockAppointmentTypeRepository(ctrl), &mockBookingRuleRepository{}, bhService)
+
+	appt := models.Appointment{
+		PatientID:  1,
+		Name:       "Test",
+		Start:      time.Date(2024, 7, 18, 14, 0, 0, 0, time.UTC),
+		Duration:   time.Hour,
+		ProviderID: &providerID,
+		RoomID:     &roomID,
+	}
+	_, err := svc.CreateAppointment(appt, nil)
+	if !errors.Is(err, ErrOutsideBusinessHours) {
+		t.Errorf("expected outside working hours error, got %v", err)
+	}
+}
+
+func TestCreateAppointment_TypeDefaultsDurationAndBuffer(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockAppointmentRepository(ctrl)
+	expectTransactions(mockRepo)
+	mockRepo.EXPECT().CreateAuditEntry(gomock.Any()).Return(nil).AnyTimes()
+	mockTypeRepo := mocks.NewMockAppointmentTypeRepository(ctrl)
+	bhService := &mockBusinessHoursService{
+		GetBusinessHoursForDateFunc: func(date time.Time) ([]models.BusinessHourInterval, error) {
+			return []models.BusinessHourInterval{{Start: "09:00", End: "17:00"}}, nil
+		},
+	}
+
+	typeID := 2
+	start := time.Date(2024, 7, 18, 10, 0, 0, 0, time.UTC)
+	mockTypeRepo.EXPECT().
+		GetAppointmentTypeByID(typeID).
+		Return(&models.AppointmentType{ID: typeID, DefaultDuration: 45 * time.Minute, Buffer: 5 * time.Minute}, nil)
+	// The buffer is kept free after the appointment
+	mockRepo.EXPECT().
+		HasOverlappingAppointment(start, start.Add(50*time.Minute), gomock.Nil(), gomock.Nil(), gomock.Nil()).
+		Return(false, nil)
+	mockRepo.EXPECT().
+		CreateAppointment(gomock.Any()).
+		DoAndReturn(func(appt models.Appointment) (*models.Appointment, error) {
+			appt.ID = 1
+			return &appt, nil
+		})
+
+	svc := NewAppointmentService(mockRepo, mockTypeRepo, &mockBookingRuleRepository{}, bhService)
+
+	created, err := svc.CreateAppointment(models.Appointment{
+		PatientID:         1,
+		Start:             start,
+		AppointmentTypeID: &typeID,
+		Buffer:            time.Hour, // Ignored, it comes from the type
+	}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if created.Duration != 45*time.Minute || created.Buffer != 5*time.Minute {
+		t.Errorf("expected the type's duration & buffer, got %v & %v", created.Duration, created.Buffer)
+	}
+}
+
+func TestCreateAppointment_UnknownType(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockTypeRepo := mocks.NewMockAppointmentTypeRepository(ctrl)
+	mockTypeRepo.EXPECT().
+		GetAppointmentTypeByID(9).
+		Return(nil, appointmenttype.ErrAppointmentTypeNotFound)
+
+	svc := NewAppointmentService(mocks.NewMockAppointmentRepository(ctrl), mockTypeRepo, &mockBookingRuleRepository{}, &mockBusinessHoursService{})
+
+	typeID := 9
+	_, err := svc.CreateAppointment(models.Appointment{PatientID: 1, Start: time.Now(), AppointmentTypeID: &typeID}, nil)
+	if !errors.Is(err, ErrInvalidAppointment) {
+		t.Errorf("expected invalid appointment error, got %v", err)
+	}
+}
+
 func TestCreateAppointment_Success(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
 	mockRepo := mocks.NewMockAppointmentRepository(ctrl)
+	expectTransactions(mockRepo)
+	mockRepo.EXPECT().CreateAuditEntry(gomock.Any()).Return(nil).AnyTimes()
 	bhService := &mockBusinessHoursService{
 		GetBusinessHoursForDateFunc: func(date time.Time) ([]models.BusinessHourInterval, error) {
 			return []models.BusinessHourInterval{{Start: "09:00", End: "17:00"}}, nil
@@ -93,7 +284,7 @@ func TestCreateAppointment_Success(t *testing.T) {
 
 	// No overlap
 	mockRepo.EXPECT().
-		HasOverlappingAppointment(gomock.Any(), gomock.Any(), gomock.Nil()).
+		HasOverlappingAppointment(gomock.Any(), gomock.Any(), gomock.Nil(), gomock.Nil(), gomock.Nil()).
 		Return(false, nil)
 	// CreateAppointment returns the appointment with ID set
 	mockRepo.EXPECT().
@@ -103,7 +294,7 @@ func TestCreateAppointment_Success(t *testing.T) {
 			return &appt, nil
 		})
 
-	svc := NewAppointmentService(mockRepo, bhService)
+	svc := NewAppointmentService(mockRepo, mocks.NewMockAppointmentTypeRepository(ctrl), &mockBookingRuleRepository{}, bhService)
 
 	appt := models.Appointment{
 		PatientID: 1,
@@ -111,7 +302,7 @@ func TestCreateAppointment_Success(t *testing.T) {
 		Start:     time.Date(2024, 7, 18, 10, 0, 0, 0, time.UTC),
 		Duration:  time.Hour,
 	}
-	created, err := svc.CreateAppointment(appt)
+	created, err := svc.CreateAppointment(appt, nil)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -120,6 +311,104 @@ func TestCreateAppointment_Success(t *testing.T) {
 	}
 }
 
+func TestCreateAppointment_ConcurrentBookingIsConflict(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockAppointmentRepository(ctrl)
+	expectTransactions(mockRepo)
+	bhService := &mockBusinessHoursService{
+		GetBusinessHoursForDateFunc: func(date time.Time) ([]models.BusinessHourInterval, error) {
+			return []models.BusinessHourInterval{{Start: "09:00", End: "17:00"}}, nil
+		},
+	}
+
+	// Slot looked free but another booking won the race on insert
+	mockRepo.EXPECT().
+		HasOverlappingAppointment(gomock.Any(), gomock.Any(), gomock.Nil(), gomock.Nil(), gomock.Nil()).
+		Return(false, nil)
+	mockRepo.EXPECT().
+		CreateAppointment(gomock.Any()).
+		Return(nil, appointment.ErrAppointmentOverlap)
+	mockRepo.EXPECT().ListAppointmentsInDateRange(gomock.Any(), gomock.Any()).Return(nil, nil).AnyTimes()
+
+	svc := NewAppointmentService(mockRepo, mocks.NewMockAppointmentTypeRepository(ctrl), &mockBookingRuleRepository{}, bhService)
+
+	_, err := svc.CreateAppointment(models.Appointment{
+		PatientID: 1,
+		Start:     time.Date(2024, 7, 18, 10, 0, 0, 0, time.UTC),
+		Duration:  time.Hour,
+	}, nil)
+	if !errors.Is(err, ErrAppointmentConflict) {
+		t.Errorf("expected conflict error, got %v", err)
+	}
+}
+
+func TestUpdateAppointment_ExcludesItself(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockAppointmentRepository(ctrl)
+	expectTransactions(mockRepo)
+	mockRepo.EXPECT().CreateAuditEntry(gomock.Any()).Return(nil).AnyTimes()
+	bhService := &mockBusinessHoursService{
+		GetBusinessHoursForDateFunc: func(date time.Time) ([]models.BusinessHourInterval, error) {
+			return []models.BusinessHourInterval{{Start: "09:00", End: "17:00"}}, nil
+		},
+	}
+	svc := NewAppointmentService(mockRepo, mocks.NewMockAppointmentTypeRepository(ctrl), &mockBookingRuleRepository{}, bhService)
+
+	appt := models.Appointment{
+		ID:        7,
+		PatientID: 1,
+		Start:     time.Date(2024, 7, 18, 10, 30, 0, 0, time.UTC),
+		Duration:  time.Hour,
+	}
+	ownID := 7
+	mockRepo.EXPECT().
+		GetAppointmentByID(7).
+		Return(&models.Appointment{ID: 7, Status: models.StatusScheduled}, nil)
+	mockRepo.EXPECT().
+		HasOverlappingAppointment(appt.Start, appt.Start.Add(time.Hour), &ownID, gomock.Nil(), gomock.Nil()).
+		Return(false, nil)
+	// What's saved is what's audited, the stored appointment with the edits applied
+	saved := appt
+	saved.Status = models.StatusScheduled
+	mockRepo.EXPECT().
+		UpdateAppointment(saved).
+		Return(nil)
+
+	if err := svc.UpdateAppointment(appt, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestUpdateAppointment_OutsideBusinessHours(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockAppointmentRepository(ctrl)
+	bhService := &mockBusinessHoursService{
+		GetBusinessHoursForDateFunc: func(date time.Time) ([]models.BusinessHourInterval, error) {
+			return []models.BusinessHourInterval{{Start: "09:00", End: "17:00"}}, nil
+		},
+	}
+	svc := NewAppointmentService(mockRepo, mocks.NewMockAppointmentTypeRepository(ctrl), &mockBookingRuleRepository{}, bhService)
+
+	mockRepo.EXPECT().
+		GetAppointmentByID(7).
+		Return(&models.Appointment{ID: 7, Status: models.StatusScheduled}, nil)
+	mockRepo.EXPECT().
+		HasOverlappingAppointment(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Nil(), gomock.Nil()).
+		Return(false, nil)
+
+	// Moved to the evening, nothing is saved
+	appt := models.Appointment{ID: 7, PatientID: 1, Start: time.Date(2024, 7, 18, 18, 0, 0, 0, time.UTC), Duration: time.Hour}
+	if err := svc.UpdateAppointment(appt, nil); !errors.Is(err, ErrOutsideBusinessHours) {
+		t.Errorf("expected outside business hours error, got %v", err)
+	}
+}
+
 func TestCreateAppointment_InvalidInput(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -127,7 +416,7 @@ func TestCreateAppointment_InvalidInput(t *testing.T) {
 	mockRepo := mocks.NewMockAppointmentRepository(ctrl)
 	bhService := &mockBusinessHoursService{}
 
-	svc := NewAppointmentService(mockRepo, bhService)
+	svc := NewAppointmentService(mockRepo, mocks.NewMockAppointmentTypeRepository(ctrl), &mockBookingRuleRepository{}, bhService)
 
 	appt := models.Appointment{
 		PatientID: 0,
@@ -135,7 +424,7 @@ func TestCreateAppointment_InvalidInput(t *testing.T) {
 		Start:     time.Now(),
 		Duration:  time.Hour,
 	}
-	_, err := svc.CreateAppointment(appt)
+	_, err := svc.CreateAppointment(appt, nil)
 	if err == nil || err.Error() != "either patient ID or name must be provided" {
 		t.Errorf("expected input validation error, got %v", err)
 	}
@@ -152,7 +441,7 @@ func TestCreateAppointment_BusinessHoursError(t *testing.T) {
 		},
 	}
 
-	svc := NewAppointmentService(mockRepo, bhService)
+	svc := NewAppointmentService(mockRepo, mocks.NewMockAppointmentTypeRepository(ctrl), &mockBookingRuleRepository{}, bhService)
 
 	appt := models.Appointment{
 		PatientID: 1,
@@ -160,9 +449,416 @@ func TestCreateAppointment_BusinessHoursError(t *testing.T) {
 		Start:     time.Now(),
 		Duration:  time.Hour,
 	}
-	_, err := svc.CreateAppointment(appt)
+	_, err := svc.CreateAppointment(appt, nil)
 	if err == nil || err.Error() != "failed to get business hours: db error" {
 		t.Errorf("expected business hours error, got %v", err)
 	}
 }
 
+func TestCreateAppointmentSeries_ReportsConflicts(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockAppointmentRepository(ctrl)
+	mockRepo.EXPECT().CreateAuditEntry(gomock.Any()).Return(nil).AnyTimes()
+	expectTransactions(mockRepo)
+	bhService := &mockBusinessHoursService{
+		GetBusinessHoursForDateFunc: func(date time.Time) ([]models.BusinessHourInterval, error) {
+			return []models.BusinessHourInterval{{Start: "09:00", End: "17:00"}}, nil
+		},
+	}
+
+	mockRepo.EXPECT().
+		CreateSeries(gomock.Any()).
+		DoAndReturn(func(series models.AppointmentSeries) (*models.AppointmentSeries, error) {
+			series.ID = 3
+			return &series, nil
+		})
+	// Second occurrence collides with an existing appointment
+	gomock.InOrder(
+		mockRepo.EXPECT().HasOverlappingAppointment(gomock.Any(), gomock.Any(), gomock.Nil(), gomock.Nil(), gomock.Nil()).Return(false, nil),
+		mockRepo.EXPECT().HasOverlappingAppointment(gomock.Any(), gomock.Any(), gomock.Nil(), gomock.Nil(), gomock.Nil()).Return(true, nil),
+		mockRepo.EXPECT().HasOverlappingAppointment(gomock.Any(), gomock.Any(), gomock.Nil(), gomock.Nil(), gomock.Nil()).Return(false, nil),
+	)
+	mockRepo.EXPECT().
+		CreateAppointment(gomock.Any()).
+		Times(2).
+		DoAndReturn(func(appt models.Appointment) (*models.Appointment, error) {
+			if appt.SeriesID == nil || *appt.SeriesID != 3 {
+				t.Errorf("expected occurrence linked to series 3, got %v", appt.SeriesID)
+			}
+			return &appt, nil
+		})
+
+	svc := NewAppointmentService(mockRepo, mocks.NewMockAppointmentTypeRepository(ctrl), &mockBookingRuleRepository{}, bhService)
+
+	start := time.Date(2024, 7, 18, 10, 0, 0, 0, time.UTC)
+	result, err := svc.CreateAppointmentSeries(models.AppointmentSeries{
+		PatientID: 1,
+		Start:     start,
+		Duration:  30 * time.Minute,
+		RRule:     "FREQ=WEEKLY;COUNT=3",
+	}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.Booked) != 2 {
+		t.Errorf("expected 2 booked occurrences, got %d", len(result.Booked))
+	}
+	if len(result.Conflicts) != 1 || !result.Conflicts[0].Start.Equal(start.AddDate(0, 0, 7)) {
+		t.Errorf("unexpected conflicts: %+v", result.Conflicts)
+	}
+}
+
+func TestCreateAppointmentSeries_NothingBooked(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockAppointmentRepository(ctrl)
+	expectTransactions(mockRepo)
+	bhService := &mockBusinessHoursService{
+		GetBusinessHoursForDateFunc: func(date time.Time) ([]models.BusinessHourInterval, error) {
+			return []models.BusinessHourInterval{{Start: "09:00", End: "17:00"}}, nil
+		},
+	}
+
+	// Every occurrence collides, so neither the series nor any appointment is stored
+	mockRepo.EXPECT().
+		HasOverlappingAppointment(gomock.Any(), gomock.Any(), gomock.Nil(), gomock.Nil(), gomock.Nil()).
+		Return(true, nil).
+		Times(2)
+
+	svc := NewAppointmentService(mockRepo, mocks.NewMockAppointmentTypeRepository(ctrl), &mockBookingRuleRepository{}, bhService)
+
+	result, err := svc.CreateAppointmentSeries(models.AppointmentSeries{
+		PatientID: 1,
+		Start:     time.Date(2024, 7, 18, 10, 0, 0, 0, time.UTC),
+		Duration:  30 * time.Minute,
+		RRule:     "FREQ=WEEKLY;COUNT=2",
+	}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.Booked) != 0 || len(result.Conflicts) != 2 || result.Series.ID != 0 {
+		t.Errorf("unexpected result: %+v", result)
+	}
+}
+
+func TestCreateAppointmentSeries_RulesSeeEarlierOccurrences(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockAppointmentRepository(ctrl)
+	mockRepo.EXPECT().CreateAuditEntry(gomock.Any()).Return(nil).AnyTimes()
+	expectTransactions(mockRepo)
+	mockRepo.EXPECT().
+		HasOverlappingAppointment(gomock.Any(), gomock.Any(), gomock.Nil(), gomock.Nil(), gomock.Nil()).
+		Return(false, nil).
+		AnyTimes()
+	bhService := &mockBusinessHoursService{
+		GetBusinessHoursForDateFunc: func(date time.Time) ([]models.BusinessHourInterval, error) {
+			return []models.BusinessHourInterval{{Start: "09:00", End: "17:00"}}, nil
+		},
+	}
+	ruleRepo := &mockBookingRuleRepository{rules: models.BookingRules{MaxPerPatientPerDay: 1}}
+
+	// Each occurrence is stored before the next one is counted, so the count sees it
+	createAppointment := func(appt models.Appointment) (*models.Appointment, error) {
+		return &appt, nil
+	}
+	gomock.InOrder(
+		mockRepo.EXPECT().CountPatientAppointmentsInRange(1, gomock.Any(), gomock.Any(), gomock.Nil()).Return(0, nil),
+		mockRepo.EXPECT().CreateSeries(gomock.Any()).DoAndReturn(func(series models.AppointmentSeries) (*models.AppointmentSeries, error) {
+			series.ID = 3
+			return &series, nil
+		}),
+		mockRepo.EXPECT().CreateAppointment(gomock.Any()).DoAndReturn(createAppointment),
+		mockRepo.EXPECT().CountPatientAppointmentsInRange(1, gomock.Any(), gomock.Any(), gomock.Nil()).Return(0, nil),
+		mockRepo.EXPECT().CreateAppointment(gomock.Any()).DoAndReturn(createAppointment),
+	)
+
+	svc := NewAppointmentService(mockRepo, mocks.NewMockAppointmentTypeRepository(ctrl), ruleRepo, bhService)
+
+	result, err := svc.CreateAppointmentSeries(models.AppointmentSeries{
+		PatientID: 1,
+		Start:     time.Date(2024, 7, 18, 10, 0, 0, 0, time.UTC),
+		Duration:  30 * time.Minute,
+		RRule:     "FREQ=DAILY;COUNT=2",
+	}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.Booked) != 2 || len(result.Conflicts) != 0 {
+		t.Errorf("unexpected result: %+v", result)
+	}
+}
+
+func TestUpdateFollowingOccurrences_InvalidSeriesKeepsOriginal(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockAppointmentRepository(ctrl)
+	seriesID := 3
+	occurrenceStart := time.Date(2024, 7, 18, 10, 0, 0, 0, time.UTC)
+
+	// Nothing is cancelled when the new definition can't be expanded
+	mockRepo.EXPECT().
+		GetAppointmentByID(10).
+		Return(&models.Appointment{ID: 10, Start: occurrenceStart, SeriesID: &seriesID}, nil).
+		AnyTimes()
+	mockRepo.EXPECT().
+		GetSeriesByID(seriesID).
+		Return(&models.AppointmentSeries{ID: seriesID, PatientID: 1, Start: occurrenceStart, Duration: time.Hour, RRule: "FREQ=WEEKLY;COUNT=5"}, nil).
+		AnyTimes()
+
+	svc := NewAppointmentService(mockRepo, mocks.NewMockAppointmentTypeRepository(ctrl), &mockBookingRuleRepository{}, &mockBusinessHoursService{})
+
+	invalid := map[string]models.AppointmentSeries{
+		"negative duration": {Duration: -time.Hour},
+		"bad exception":     {Exceptions: []string{"18/07/2024"}},
+		"no occurrences":    {RRule: "FREQ=WEEKLY;COUNT=1", Exceptions: []string{"2024-07-18"}},
+	}
+	for name, series := range invalid {
+		if _, err := svc.UpdateFollowingOccurrences(10, series, nil); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestCancelFollowingOccurrences_EndsSeries(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockAppointmentRepository(ctrl)
+	expectTransactions(mockRepo)
+	bhService := &mockBusinessHoursService{}
+
+	seriesID := 3
+	seriesStart := time.Date(2024, 7, 4, 10, 0, 0, 0, time.UTC)
+	occurrenceStart := time.Date(2024, 7, 18, 10, 0, 0, 0, time.UTC)
+
+	mockRepo.EXPECT().
+		GetAppointmentByID(10).
+		Return(&models.Appointment{ID: 10, Start: occurrenceStart, SeriesID: &seriesID}, nil)
+	mockRepo.EXPECT().
+		GetSeriesByID(seriesID).
+		Return(&models.AppointmentSeries{ID: seriesID, Start: seriesStart, RRule: "FREQ=WEEKLY;COUNT=5"}, nil)
+	mockRepo.EXPECT().
+		CancelSeriesAppointmentsFrom(seriesID, occurrenceStart, gomock.Nil()).
+		Return([]models.Appointment{
+			{ID: 10, Start: occurrenceStart, Duration: time.Hour, Status: models.StatusConfirmed},
+			{ID: 11, Start: occurrenceStart.AddDate(0, 0, 7), Duration: time.Hour, Status: models.StatusScheduled},
+			{ID: 12, Start: occurrenceStart.AddDate(0, 0, 14), Duration: time.Hour, Status: models.StatusScheduled},
+		}, nil)
+	// Each one is audited like a single cancellation
+	mockRepo.EXPECT().
+		CreateAuditEntry(gomock.Any()).
+		DoAndReturn(func(entry models.AppointmentAuditEntry) error {
+			if entry.Action != models.AuditDelete || entry.Changes["status"].After != string(models.StatusCancelled) {
+				t.Errorf("unexpected audit entry: %+v", entry)
+			}
+			return nil
+		}).
+		Times(3)
+	mockRepo.EXPECT().
+		UpdateSeries(gomock.Any()).
+		DoAndReturn(func(series models.AppointmentSeries) error {
+			if series.RRule != "FREQ=WEEKLY;UNTIL=20240718T095959Z" {
+				t.Errorf("unexpected truncated rule: %s", series.RRule)
+			}
+			return nil
+		})
+
+	svc := NewAppointmentService(mockRepo, mocks.NewMockAppointmentTypeRepository(ctrl), &mockBookingRuleRepository{}, bhService)
+	released := make(chan models.Appointment, 3)
+	svc.OnSlotReleased(func(appt models.Appointment) {
+		released <- appt
+	})
+
+	cancelled, err := svc.CancelFollowingOccurrences(10, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cancelled != 3 {
+		t.Errorf("expected 3 cancelled occurrences, got %d", cancelled)
+	}
+
+	// Every cancelled occurrence frees its slot
+	for i := 0; i < 3; i++ {
+		select {
+		case <-released:
+		case <-time.After(time.Second):
+			t.Fatalf("expected 3 released slots, got %d", i)
+		}
+	}
+}
+
+func TestChangeAppointmentStatus_ValidTransition(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockAppointmentRepository(ctrl)
+	expectTransactions(mockRepo)
+	mockRepo.EXPECT().CreateAuditEntry(gomock.Any()).Return(nil).AnyTimes()
+	bhService := &mockBusinessHoursService{}
+	userID := 4
+
+	mockRepo.EXPECT().
+		GetAppointmentByID(1).
+		Return(&models.Appointment{ID: 1, Status: models.StatusConfirmed}, nil)
+	mockRepo.EXPECT().
+		UpdateAppointmentStatus(1, models.StatusConfirmed, models.StatusCheckedIn, &userID).
+		Return(nil)
+
+	svc := NewAppointmentService(mockRepo, mocks.NewMockAppointmentTypeRepository(ctrl), &mockBookingRuleRepository{}, bhService)
+
+	appt, err := svc.ChangeAppointmentStatus(1, models.StatusCheckedIn, &userID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if appt.Status != models.StatusCheckedIn || appt.StatusChangedBy == nil || *appt.StatusChangedBy != userID {
+		t.Errorf("unexpected appointment after status change: %+v", appt)
+	}
+}
+
+func TestStartConsultation_WithStatusChange(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockAppointmentRepository(ctrl)
+	mockRepo.EXPECT().CreateAuditEntry(gomock.Any()).Return(nil).AnyTimes()
+	svc := NewAppointmentService(mockRepo, mocks.NewMockAppointmentTypeRepository(ctrl), &mockBookingRuleRepository{}, &mockBusinessHoursService{})
+
+	// The consultation is created in the transaction of the status change
+	appointmentID := 1
+	consultation := models.Consultation{PatientID: 4, AppointmentID: &appointmentID, Reason: "Control"}
+	mockRepo.EXPECT().
+		GetAppointmentByID(1).
+		Return(&models.Appointment{ID: 1, Status: models.StatusCheckedIn}, nil)
+	mockRepo.EXPECT().
+		WithTransaction(gomock.Any()).
+		DoAndReturn(func(fn func(repo appointment.AppointmentRepository) error) error {
+			mockRepo.EXPECT().UpdateAppointmentStatus(1, models.StatusCheckedIn, models.StatusInConsultation, gomock.Nil()).Return(nil)
+			mockRepo.EXPECT().CreateConsultation(consultation).Return(0, appointment.ErrConsultationExists)
+			return fn(mockRepo)
+		})
+
+	if _, err := svc.StartConsultation(consultation, nil); !errors.Is(err, appointment.ErrConsultationExists) {
+		t.Errorf("expected consultation exists error, got %v", err)
+	}
+}
+
+func TestCancelAppointment_ReleasesSlot(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockAppointmentRepository(ctrl)
+	expectTransactions(mockRepo)
+	mockRepo.EXPECT().CreateAuditEntry(gomock.Any()).Return(nil).AnyTimes()
+	svc := NewAppointmentService(mockRepo, mocks.NewMockAppointmentTypeRepository(ctrl), &mockBookingRuleRepository{}, &mockBusinessHoursService{})
+
+	released := make(chan models.Appointment, 1)
+	svc.OnSlotReleased(func(appt models.Appointment) {
+		released <- appt
+	})
+
+	mockRepo.EXPECT().
+		GetAppointmentByID(7).
+		Return(&models.Appointment{ID: 7, Status: models.StatusConfirmed}, nil)
+	mockRepo.EXPECT().
+		UpdateAppointmentStatus(7, models.StatusConfirmed, models.StatusCancelled, gomock.Nil()).
+		Return(nil)
+
+	if _, err := svc.CancelAppointment(7, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	select {
+	case appt := <-released:
+		if appt.ID != 7 || appt.Status != models.StatusCancelled {
+			t.Errorf("unexpected released appointment: %+v", appt)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("slot released hook was not called")
+	}
+}
+
+func TestChangeAppointmentStatus_InvalidTransition(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockAppointmentRepository(ctrl)
+	bhService := &mockBusinessHoursService{}
+
+	// Cancelled is terminal
+	mockRepo.EXPECT().
+		GetAppointmentByID(1).
+		Return(&models.Appointment{ID: 1, Status: models.StatusCancelled}, nil)
+
+	svc := NewAppointmentService(mockRepo, mocks.NewMockAppointmentTypeRepository(ctrl), &mockBookingRuleRepository{}, bhService)
+
+	_, err := svc.ChangeAppointmentStatus(1, models.StatusConfirmed, nil)
+	if !errors.Is(err, ErrInvalidTransition) {
+		t.Errorf("expected invalid transition error, got %v", err)
+	}
+}
+
+func TestChangeAppointmentStatus_UnknownStatus(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockAppointmentRepository(ctrl)
+	svc := NewAppointmentService(mockRepo, mocks.NewMockAppointmentTypeRepository(ctrl), &mockBookingRuleRepository{}, &mockBusinessHoursService{})
+
+	_, err := svc.ChangeAppointmentStatus(1, "archived", nil)
+	if !errors.Is(err, ErrInvalidStatus) {
+		t.Errorf("expected invalid status error, got %v", err)
+	}
+}
+
+func TestCreateAppointment_BusinessHoursInClinicTimezone(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	if err := clinic.SetTimezone("America/Guatemala"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer clinic.SetTimezone("UTC")
+
+	mockRepo := mocks.NewMockAppointmentRepository(ctrl)
+	expectTransactions(mockRepo)
+	mockRepo.EXPECT().CreateAuditEntry(gomock.Any()).Return(nil).AnyTimes()
+	bhService := &mockBusinessHoursService{
+		GetBusinessHoursForDateFunc: func(date time.Time) ([]models.BusinessHourInterval, error) {
+			// Hours are looked up for the clinic day
+			if date.Format("2006-01-02") != "2024-07-18" {
+				t.Errorf("expected the clinic day 2024-07-18, got %s", date.Format("2006-01-02"))
+			}
+			return []models.BusinessHourInterval{{Start: "09:00", End: "17:00"}}, nil
+		},
+	}
+	mockRepo.EXPECT().
+		HasOverlappingAppointment(gomock.Any(), gomock.Any(), gomock.Nil(), gomock.Nil(), gomock.Nil()).
+		Return(false, nil).
+		Times(2)
+	mockRepo.EXPECT().
+		CreateAppointment(gomock.Any()).
+		DoAndReturn(func(appt models.Appointment) (*models.Appointment, error) {
+			appt.ID = 1
+			return &appt, nil
+		})
+
+	svc := NewAppointmentService(mockRepo, mocks.NewMockAppointmentTypeRepository(ctrl), &mockBookingRuleRepository{}, bhService)
+
+	// 15:30 UTC is 09:30 at the clinic (UTC-6)
+	_, err := svc.CreateAppointment(models.Appointment{PatientID: 1, Start: time.Date(2024, 7, 18, 15, 30, 0, 0, time.UTC), Duration: time.Hour}, nil)
+	if err != nil {
+		t.Errorf("expected booking within clinic hours, got %v", err)
+	}
+
+	// 23:30 UTC is 17:30 at the clinic, after closing even though it's the same UTC day
+	_, err = svc.CreateAppointment(models.Appointment{PatientID: 1, Start: time.Date(2024, 7, 18, 23, 30, 0, 0, time.UTC), Duration: time.Hour}, nil)
+	if !errors.Is(err, ErrOutsideBusinessHours) {
+		t.Errorf("expected outside working hours error, got %v", err)
+	}
+}
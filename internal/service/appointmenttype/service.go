@@ -0,0 +1,96 @@
+package appointmenttype
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"software-backend/internal/models"
+	"software-backend/internal/repository/appointmenttype"
+	questionnaire "software-backend/internal/service/questionnaire"
+)
+
+// Colour used when none is given
+const defaultColor = "#9E9E9E"
+
+// Custom errors, probably moved onto separate file in the future
+var ErrInvalidAppointmentType = errors.New("invalid appointment type data")
+
+// Calendar colours are given as #RRGGBB
+var colorPattern = regexp.MustCompile(`^#[0-9A-Fa-f]{6}$`)
+
+// Interface defines methods expected from the service
+type AppointmentTypeService interface {
+	ListAppointmentTypes(onlyActive bool) ([]models.AppointmentType, error)
+	GetAppointmentType(id int) (*models.AppointmentType, error)
+	CreateAppointmentType(apptType models.AppointmentType) (*models.AppointmentType, error)
+	UpdateAppointmentType(apptType models.AppointmentType) error
+}
+
+// Struct to manage dependencies
+type appointmentTypeService struct {
+	repo                 appointmenttype.AppointmentTypeRepository
+	questionnaireService questionnaire.QuestionnaireService
+}
+
+// Constructor to pass on dependencies
+func NewAppointmentTypeService(repo appointmenttype.AppointmentTypeRepository, questionnaireService questionnaire.QuestionnaireService) AppointmentTypeService {
+	return &appointmentTypeService{
+		repo:                 repo,
+		questionnaireService: questionnaireService,
+	}
+}
+
+// List appointment types, optionally only the active ones
+func (s *appointmentTypeService) ListAppointmentTypes(onlyActive bool) ([]models.AppointmentType, error) {
+	return s.repo.ListAppointmentTypes(onlyActive)
+}
+
+// Get an appointment type by ID
+func (s *appointmentTypeService) GetAppointmentType(id int) (*models.AppointmentType, error) {
+	return s.repo.GetAppointmentTypeByID(id)
+}
+
+// Create an appointment type, new types are always active
+func (s *appointmentTypeService) CreateAppointmentType(apptType models.AppointmentType) (*models.AppointmentType, error) {
+	if err := s.validate(&apptType); err != nil {
+		return nil, err
+	}
+	apptType.Active = true
+	return s.repo.CreateAppointmentType(apptType)
+}
+
+// Update an appointment type given the new values including ID
+func (s *appointmentTypeService) UpdateAppointmentType(apptType models.AppointmentType) error {
+	if err := s.validate(&apptType); err != nil {
+		return err
+	}
+	return s.repo.UpdateAppointmentType(apptType)
+}
+
+// Basic input validation, the colour defaults to grey
+func (s *appointmentTypeService) validate(apptType *models.AppointmentType) error {
+	apptType.Name = strings.TrimSpace(apptType.Name)
+	if apptType.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidAppointmentType)
+	}
+	if apptType.DefaultDuration <= 0 {
+		return fmt.Errorf("%w: default duration must be positive", ErrInvalidAppointmentType)
+	}
+	if apptType.Buffer < 0 {
+		return fmt.Errorf("%w: buffer can't be negative", ErrInvalidAppointmentType)
+	}
+	if apptType.Color == "" {
+		apptType.Color = defaultColor
+	}
+	if !colorPattern.MatchString(apptType.Color) {
+		return fmt.Errorf("%w: color must be given as #RRGGBB", ErrInvalidAppointmentType)
+	}
+	if apptType.QuestionnaireID != nil {
+		if err := s.questionnaireService.ValidateQuestionnaireExists(*apptType.QuestionnaireID); err != nil {
+			return fmt.Errorf("%w: questionnaire %d not found", ErrInvalidAppointmentType, *apptType.QuestionnaireID)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package bookingrule
+
+import (
+	"errors"
+	"fmt"
+
+	"software-backend/internal/models"
+	"software-backend/internal/repository/bookingrule"
+)
+
+// Custom errors, probably moved onto separate file in the future
+var ErrInvalidBookingRules = errors.New("invalid booking rules")
+
+// Interface defines methods expected from the service
+type BookingRuleService interface {
+	GetRules() (*models.BookingRules, error)
+	UpdateRules(rules models.BookingRules, userID *int) (*models.BookingRules, error)
+	ListOverrides(appointmentID int) ([]models.BookingRuleOverride, error)
+}
+
+// Struct to manage dependencies
+type bookingRuleService struct {
+	repo bookingrule.BookingRuleRepository
+}
+
+// Constructor to pass on dependencies
+func NewBookingRuleService(repo bookingrule.BookingRuleRepository) BookingRuleService {
+	return &bookingRuleService{repo: repo}
+}
+
+// Get the booking rules
+func (s *bookingRuleService) GetRules() (*models.BookingRules, error) {
+	return s.repo.GetRules()
+}
+
+// Replace the booking rules, zero disables a limit
+func (s *bookingRuleService) UpdateRules(rules models.BookingRules, userID *int) (*models.BookingRules, error) {
+	// Basic input validation
+	if rules.MinLeadTime < 0 || rules.MaxHorizon < 0 || rules.MaxNewPatientsPerDay < 0 || rules.MaxPerPatientPerDay < 0 || rules.MaxNoShows < 0 {
+		return nil, fmt.Errorf("%w: limits can't be negative", ErrInvalidBookingRules)
+	}
+	if rules.MaxHorizon > 0 && rules.MaxHorizon <= rules.MinLeadTime {
+		return nil, fmt.Errorf("%w: max_horizon must be longer than min_lead_time", ErrInvalidBookingRules)
+	}
+	rules.UpdatedBy = userID
+	return s.repo.UpdateRules(rules)
+}
+
+// List the rules overridden for an appointment
+func (s *bookingRuleService) ListOverrides(appointmentID int) ([]models.BookingRuleOverride, error) {
+	return s.repo.ListOverrides(appointmentID)
+}
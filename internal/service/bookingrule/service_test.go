@@ -0,0 +1,51 @@
+package bookingrule
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"software-backend/internal/mocks"
+	"software-backend/internal/models"
+
+	"github.com/golang/mock/gomock"
+)
+
+func TestUpdateRules_RecordsUser(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockBookingRuleRepository(ctrl)
+	svc := NewBookingRuleService(mockRepo)
+	userID := 3
+
+	mockRepo.EXPECT().
+		UpdateRules(gomock.Any()).
+		DoAndReturn(func(rules models.BookingRules) (*models.BookingRules, error) {
+			if rules.UpdatedBy == nil || *rules.UpdatedBy != userID {
+				t.Errorf("expected rules updated by user %d, got %v", userID, rules.UpdatedBy)
+			}
+			return &rules, nil
+		})
+
+	_, err := svc.UpdateRules(models.BookingRules{MinLeadTime: time.Hour, MaxHorizon: 90 * 24 * time.Hour, MaxPerPatientPerDay: 1}, &userID)
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestUpdateRules_Invalid(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	svc := NewBookingRuleService(mocks.NewMockBookingRuleRepository(ctrl))
+
+	for _, rules := range []models.BookingRules{
+		{MaxNewPatientsPerDay: -1},
+		{MinLeadTime: 48 * time.Hour, MaxHorizon: 24 * time.Hour},
+	} {
+		if _, err := svc.UpdateRules(rules, nil); !errors.Is(err, ErrInvalidBookingRules) {
+			t.Errorf("expected invalid rules error for %+v, got %v", rules, err)
+		}
+	}
+}
@@ -0,0 +1,361 @@
+package businesshour
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"software-backend/internal/clinic"
+	"software-backend/internal/models"
+)
+
+// Holiday file formats
+const (
+	HolidayFormatICS = "ics"
+	HolidayFormatCSV = "csv"
+)
+
+// Longest all-day event expanded into dates, guards against a mistyped DTEND
+const maxHolidayDays = 31
+
+// Import a holiday calendar as special hours & report the booked appointments on the
+// imported dates. Dates that already have special hours are skipped, a dry run reports
+// the same without saving anything
+func (s *businessHoursService) ImportHolidays(data []byte, format string, dryRun bool) (*models.HolidayImportReport, error) {
+	var entries []models.HolidayImportEntry
+	var err error
+	switch format {
+	case HolidayFormatICS:
+		entries, err = parseICSHolidays(data)
+	case HolidayFormatCSV:
+		entries, err = parseCSVHolidays(data)
+	default:
+		return nil, fmt.Errorf("%w: unknown format %q, expected ics or csv", ErrInvalidHolidayFile, format)
+	}
+	if err != nil {
+		return nil, err
+	}
+	entries, err = mergeHolidayEntries(entries)
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("%w: no dates found", ErrInvalidHolidayFile)
+	}
+	for _, entry := range entries {
+		if err := validateIntervals(entry.Intervals); err != nil {
+			return nil, fmt.Errorf("%s: %w", entry.Date, err)
+		}
+	}
+
+	// Entries are sorted by date, first & last give the range
+	from, _ := clinic.ParseDate(entries[0].Date)
+	to, _ := clinic.ParseDate(entries[len(entries)-1].Date)
+	existing, err := s.repo.ListSpecialHours(from, to)
+	if err != nil {
+		return nil, err
+	}
+	existingDates := map[string]bool{}
+	for _, special := range existing {
+		existingDates[special.Date] = true
+	}
+
+	report := &models.HolidayImportReport{
+		DryRun:               dryRun,
+		Imported:             []models.HolidayImportEntry{},
+		Skipped:              []models.HolidayImportEntry{},
+		AffectedAppointments: []models.Appointment{},
+	}
+	importedDates := map[string]bool{}
+	var specials []models.SpecialHours
+	for _, entry := range entries {
+		if existingDates[entry.Date] {
+			report.Skipped = append(report.Skipped, entry)
+			continue
+		}
+		report.Imported = append(report.Imported, entry)
+		importedDates[entry.Date] = true
+		specials = append(specials, entry.SpecialHours)
+	}
+	if len(specials) == 0 {
+		return report, nil
+	}
+
+	// Booked appointments on the imported dates, they may need to be moved
+	appointments, err := s.apptRepo.ListUncheckedAppointments(from, to.AddDate(0, 0, 1))
+	if err != nil {
+		return nil, err
+	}
+	for _, appt := range appointments {
+		if importedDates[clinic.In(appt.Start).Format("2006-01-02")] {
+			report.AffectedAppointments = append(report.AffectedAppointments, appt)
+		}
+	}
+
+	if dryRun {
+		return report, nil
+	}
+	skipped, err := s.repo.CreateSpecialHours(specials)
+	if err != nil {
+		return nil, err
+	}
+	// Dates given special hours meanwhile were kept as they are
+	if len(skipped) > 0 {
+		skipReportDates(report, skipped)
+	}
+	return report, nil
+}
+
+// Move dates of a report from imported to skipped, their appointments aren't affected
+func skipReportDates(report *models.HolidayImportReport, dates []string) {
+	skipped := map[string]bool{}
+	for _, date := range dates {
+		skipped[date] = true
+	}
+	imported := []models.HolidayImportEntry{}
+	for _, entry := range report.Imported {
+		if skipped[entry.Date] {
+			report.Skipped = append(report.Skipped, entry)
+		} else {
+			imported = append(imported, entry)
+		}
+	}
+	report.Imported = imported
+	sort.Slice(report.Skipped, func(i, j int) bool { return report.Skipped[i].Date < report.Skipped[j].Date })
+
+	affected := []models.Appointment{}
+	for _, appt := range report.AffectedAppointments {
+		if !skipped[clinic.In(appt.Start).Format("2006-01-02")] {
+			affected = append(affected, appt)
+		}
+	}
+	report.AffectedAppointments = affected
+}
+
+// Combine the entries of the same date & sort them by date. A date can't be both
+// closed & open on some intervals
+func mergeHolidayEntries(entries []models.HolidayImportEntry) ([]models.HolidayImportEntry, error) {
+	byDate := map[string]int{}
+	var merged []models.HolidayImportEntry
+	for _, entry := range entries {
+		i, ok := byDate[entry.Date]
+		if !ok {
+			byDate[entry.Date] = len(merged)
+			merged = append(merged, entry)
+			continue
+		}
+		if merged[i].Closed != entry.Closed {
+			return nil, fmt.Errorf("%w: %s is both closed & open on reduced hours", ErrInvalidHolidayFile, entry.Date)
+		}
+		merged[i].Intervals = append(merged[i].Intervals, entry.Intervals...)
+	}
+	sort.Slice(merged, func(i, j int) bool {
+		return merged[i].Date < merged[j].Date
+	})
+	return merged, nil
+}
+
+// Read a CSV of "date,name,start,end" rows, dates as YYYY-MM-DD & hours as HH:MM. Rows
+// without start & end close the clinic that day, a header row is allowed
+func parseCSVHolidays(data []byte) ([]models.HolidayImportEntry, error) {
+	reader := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, []byte("\ufeff"))))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidHolidayFile, err)
+	}
+
+	var entries []models.HolidayImportEntry
+	for i, record := range records {
+		field := func(n int) string {
+			if n < len(record) {
+				return strings.TrimSpace(record[n])
+			}
+			return ""
+		}
+		date, err := clinic.ParseDate(field(0))
+		if err != nil {
+			// Header
+			if i == 0 {
+				continue
+			}
+			return nil, fmt.Errorf("%w: line %d: invalid date %q, expected YYYY-MM-DD", ErrInvalidHolidayFile, i+1, field(0))
+		}
+
+		entry := models.HolidayImportEntry{
+			SpecialHours: models.SpecialHours{Date: date.Format("2006-01-02"), Closed: true},
+			Name:         field(1),
+		}
+		start, end := field(2), field(3)
+		if start != "" || end != "" {
+			if start == "" || end == "" {
+				return nil, fmt.Errorf("%w: line %d: reduced hours need both start & end", ErrInvalidHolidayFile, i+1)
+			}
+			entry.Closed = false
+			entry.Intervals = []models.BusinessHourInterval{{Start: start, End: end}}
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// A content line of an iCalendar file, e.g. "DTSTART;VALUE=DATE:20241225"
+type icsProperty struct {
+	params map[string]string
+	value  string
+}
+
+// Read the events of an iCalendar file. All-day events close the clinic on each of
+// their dates, timed events open it only while they last, e.g. half days
+func parseICSHolidays(data []byte) ([]models.HolidayImportEntry, error) {
+	var entries []models.HolidayImportEntry
+	var event map[string]icsProperty
+	for _, line := range unfoldICS(data) {
+		name, prop := parseICSLine(line)
+		switch {
+		case name == "BEGIN" && strings.EqualFold(prop.value, "VEVENT"):
+			event = map[string]icsProperty{}
+		case name == "END" && strings.EqualFold(prop.value, "VEVENT"):
+			if event == nil {
+				continue
+			}
+			eventEntries, err := icsEventEntries(event)
+			if err != nil {
+				return nil, err
+			}
+			entries = append(entries, eventEntries...)
+			event = nil
+		case event != nil:
+			// Only the first occurrence of a property matters here
+			if _, ok := event[name]; !ok {
+				event[name] = prop
+			}
+		}
+	}
+	return entries, nil
+}
+
+// Turn an event into the dates it covers
+func icsEventEntries(event map[string]icsProperty) ([]models.HolidayImportEntry, error) {
+	if strings.EqualFold(event["STATUS"].value, "CANCELLED") {
+		return nil, nil
+	}
+	name := unescapeICSText(event["SUMMARY"].value)
+	if _, ok := event["RRULE"]; ok {
+		return nil, fmt.Errorf("%w: %q is recurring, recurring events aren't supported", ErrInvalidHolidayFile, name)
+	}
+	start, ok := event["DTSTART"]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q has no DTSTART", ErrInvalidHolidayFile, name)
+	}
+
+	// All-day, DTEND is the day after the last one
+	if start.params["VALUE"] == "DATE" || len(start.value) == len("20060102") {
+		first, err := time.ParseInLocation("20060102", start.value, clinic.Location())
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q has an invalid DTSTART %q", ErrInvalidHolidayFile, name, start.value)
+		}
+		last := first
+		if end, ok := event["DTEND"]; ok {
+			dayAfter, err := time.ParseInLocation("20060102", end.value, clinic.Location())
+			if err != nil {
+				return nil, fmt.Errorf("%w: %q has an invalid DTEND %q", ErrInvalidHolidayFile, name, end.value)
+			}
+			if dayAfter.After(first) {
+				last = dayAfter.AddDate(0, 0, -1)
+			}
+		}
+		if last.After(first.AddDate(0, 0, maxHolidayDays-1)) {
+			return nil, fmt.Errorf("%w: %q lasts more than %d days", ErrInvalidHolidayFile, name, maxHolidayDays)
+		}
+
+		var entries []models.HolidayImportEntry
+		for day := first; !day.After(last); day = day.AddDate(0, 0, 1) {
+			entries = append(entries, models.HolidayImportEntry{
+				SpecialHours: models.SpecialHours{Date: day.Format("2006-01-02"), Closed: true},
+				Name:         name,
+			})
+		}
+		return entries, nil
+	}
+
+	// Timed, the clinic is open from start to end of that day
+	end, ok := event["DTEND"]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q has no DTEND", ErrInvalidHolidayFile, name)
+	}
+	startTime, err := parseICSDateTime(start)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %q has an invalid DTSTART: %v", ErrInvalidHolidayFile, name, err)
+	}
+	endTime, err := parseICSDateTime(end)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %q has an invalid DTEND: %v", ErrInvalidHolidayFile, name, err)
+	}
+	if startTime.Format("2006-01-02") != endTime.Format("2006-01-02") {
+		return nil, fmt.Errorf("%w: %q must start & end the same day", ErrInvalidHolidayFile, name)
+	}
+	return []models.HolidayImportEntry{{
+		SpecialHours: models.SpecialHours{
+			Date:      startTime.Format("2006-01-02"),
+			Intervals: []models.BusinessHourInterval{{Start: startTime.Format("15:04"), End: endTime.Format("15:04")}},
+		},
+		Name: name,
+	}}, nil
+}
+
+// Parse a DATE-TIME in the clinic time zone, UTC if it ends in Z, the TZID's if given
+// & clinic time otherwise
+func parseICSDateTime(prop icsProperty) (time.Time, error) {
+	if strings.HasSuffix(prop.value, "Z") {
+		t, err := time.Parse("20060102T150405Z", prop.value)
+		return clinic.In(t), err
+	}
+	loc := clinic.Location()
+	if tzid, ok := prop.params["TZID"]; ok {
+		var err error
+		if loc, err = time.LoadLocation(tzid); err != nil {
+			return time.Time{}, err
+		}
+	}
+	t, err := time.ParseInLocation("20060102T150405", prop.value, loc)
+	return clinic.In(t), err
+}
+
+// Split an iCalendar file into content lines, joining the folded ones
+func unfoldICS(data []byte) []string {
+	var lines []string
+	text := strings.ReplaceAll(string(data), "\r\n", "\n")
+	for _, line := range strings.Split(text, "\n") {
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// Split a content line into its upper-cased name, parameters & value
+func parseICSLine(line string) (string, icsProperty) {
+	prop := icsProperty{params: map[string]string{}}
+	head, value, _ := strings.Cut(line, ":")
+	prop.value = strings.TrimSpace(value)
+	parts := strings.Split(head, ";")
+	for _, param := range parts[1:] {
+		key, val, _ := strings.Cut(param, "=")
+		prop.params[strings.ToUpper(key)] = strings.Trim(val, `"`)
+	}
+	return strings.ToUpper(parts[0]), prop
+}
+
+// Undo the escaping of a TEXT value, line breaks become spaces
+func unescapeICSText(value string) string {
+	return strings.NewReplacer(`\n`, " ", `\N`, " ", `\,`, ",", `\;`, ";", `\\`, `\`).Replace(value)
+}
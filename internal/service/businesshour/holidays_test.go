@@ -0,0 +1,160 @@
+package businesshour
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"software-backend/internal/mocks"
+	"software-backend/internal/models"
+
+	"github.com/golang/mock/gomock"
+)
+
+func TestParseICSHolidays(t *testing.T) {
+	data := []byte("BEGIN:VCALENDAR\r\n" +
+		"BEGIN:VEVENT\r\n" +
+		"DTSTART;VALUE=DATE:20241224\r\n" +
+		"DTEND;VALUE=DATE:20241226\r\n" +
+		"SUMMARY:Noche\\, y día de \r\n" +
+		" Navidad\r\n" +
+		"END:VEVENT\r\n" +
+		"BEGIN:VEVENT\r\n" +
+		"DTSTART:20241231T080000\r\n" +
+		"DTEND:20241231T120000\r\n" +
+		"SUMMARY:Fin de año\r\n" +
+		"END:VEVENT\r\n" +
+		"BEGIN:VEVENT\r\n" +
+		"DTSTART;VALUE=DATE:20241101\r\n" +
+		"STATUS:CANCELLED\r\n" +
+		"END:VEVENT\r\n" +
+		"END:VCALENDAR\r\n")
+
+	entries, err := parseICSHolidays(data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("expected 3 dates, got %+v", entries)
+	}
+	// DTEND is exclusive, the all-day event covers the 24th & 25th
+	if entries[0].Date != "2024-12-24" || entries[1].Date != "2024-12-25" || !entries[1].Closed {
+		t.Errorf("unexpected all-day dates: %+v", entries[:2])
+	}
+	if entries[0].Name != "Noche, y día de Navidad" {
+		t.Errorf("unexpected name %q", entries[0].Name)
+	}
+	if entries[2].Date != "2024-12-31" || entries[2].Closed || entries[2].Intervals[0] != (models.BusinessHourInterval{Start: "08:00", End: "12:00"}) {
+		t.Errorf("unexpected reduced hours: %+v", entries[2])
+	}
+
+	// Recurring events would silently miss years
+	_, err = parseICSHolidays([]byte("BEGIN:VEVENT\nDTSTART;VALUE=DATE:20240101\nRRULE:FREQ=YEARLY\nEND:VEVENT\n"))
+	if !errors.Is(err, ErrInvalidHolidayFile) {
+		t.Errorf("expected invalid holiday file error, got %v", err)
+	}
+}
+
+func TestParseCSVHolidays(t *testing.T) {
+	data := []byte("date,name,start,end\n2024-09-15,Independencia\n2024-12-31,Fin de año,08:00,12:00\n")
+
+	entries, err := parseCSVHolidays(data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(entries) != 2 || !entries[0].Closed || entries[0].Name != "Independencia" {
+		t.Fatalf("unexpected entries: %+v", entries)
+	}
+	if entries[1].Closed || len(entries[1].Intervals) != 1 || entries[1].Intervals[0].End != "12:00" {
+		t.Errorf("unexpected reduced hours: %+v", entries[1])
+	}
+
+	_, err = parseCSVHolidays([]byte("2024-09-15,Independencia\n15/09/2024,Independencia\n"))
+	if !errors.Is(err, ErrInvalidHolidayFile) {
+		t.Errorf("expected invalid holiday file error, got %v", err)
+	}
+}
+
+func TestImportHolidays_DryRunSkipsExistingAndReportsAppointments(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockBusinessHoursRepository(ctrl)
+	mockApptRepo := mocks.NewMockAppointmentRepository(ctrl)
+	svc := NewBusinessHoursService(mockRepo, mockApptRepo)
+
+	from := time.Date(2024, 12, 24, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC)
+	mockRepo.EXPECT().
+		ListSpecialHours(from, to).
+		Return([]models.SpecialHours{{Date: "2024-12-24", Intervals: []models.BusinessHourInterval{{Start: "08:00", End: "12:00"}}}}, nil)
+	mockApptRepo.EXPECT().
+		ListUncheckedAppointments(from, to.AddDate(0, 0, 1)).
+		Return([]models.Appointment{
+			{ID: 1, Start: time.Date(2024, 12, 24, 9, 0, 0, 0, time.UTC)},
+			{ID: 2, Start: time.Date(2024, 12, 25, 10, 0, 0, 0, time.UTC)},
+			{ID: 3, Start: time.Date(2024, 12, 27, 10, 0, 0, 0, time.UTC)},
+		}, nil)
+
+	data := []byte("2024-12-31,Fin de año,08:00,12:00\n2024-12-24,Nochebuena\n2024-12-25,Navidad\n")
+	report, err := svc.ImportHolidays(data, HolidayFormatCSV, true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !report.DryRun || len(report.Imported) != 2 || report.Imported[0].Date != "2024-12-25" {
+		t.Errorf("unexpected imported dates: %+v", report.Imported)
+	}
+	if len(report.Skipped) != 1 || report.Skipped[0].Date != "2024-12-24" {
+		t.Errorf("unexpected skipped dates: %+v", report.Skipped)
+	}
+	if len(report.AffectedAppointments) != 1 || report.AffectedAppointments[0].ID != 2 {
+		t.Errorf("unexpected affected appointments: %+v", report.AffectedAppointments)
+	}
+}
+
+func TestImportHolidays_SavesWhenNotDryRun(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockBusinessHoursRepository(ctrl)
+	mockApptRepo := mocks.NewMockAppointmentRepository(ctrl)
+	svc := NewBusinessHoursService(mockRepo, mockApptRepo)
+
+	mockRepo.EXPECT().ListSpecialHours(gomock.Any(), gomock.Any()).Return([]models.SpecialHours{}, nil)
+	mockApptRepo.EXPECT().ListUncheckedAppointments(gomock.Any(), gomock.Any()).Return(nil, nil)
+	mockRepo.EXPECT().CreateSpecialHours([]models.SpecialHours{{Date: "2024-09-15", Closed: true}}).Return([]string{}, nil)
+
+	if _, err := svc.ImportHolidays([]byte("2024-09-15,Independencia\n"), HolidayFormatCSV, false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestImportHolidays_DateSetMeanwhileSkipped(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockBusinessHoursRepository(ctrl)
+	mockApptRepo := mocks.NewMockAppointmentRepository(ctrl)
+	svc := NewBusinessHoursService(mockRepo, mockApptRepo)
+
+	mockRepo.EXPECT().ListSpecialHours(gomock.Any(), gomock.Any()).Return([]models.SpecialHours{}, nil)
+	mockApptRepo.EXPECT().
+		ListUncheckedAppointments(gomock.Any(), gomock.Any()).
+		Return([]models.Appointment{{ID: 1, Start: time.Date(2024, 9, 15, 16, 0, 0, 0, time.UTC)}}, nil)
+	// Special hours were set for the 15th after they were listed
+	mockRepo.EXPECT().CreateSpecialHours(gomock.Any()).Return([]string{"2024-09-15"}, nil)
+
+	report, err := svc.ImportHolidays([]byte("2024-09-15,Independencia\n2024-11-01,Todos los Santos\n"), HolidayFormatCSV, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(report.Imported) != 1 || report.Imported[0].Date != "2024-11-01" {
+		t.Errorf("unexpected imported dates: %+v", report.Imported)
+	}
+	if len(report.Skipped) != 1 || report.Skipped[0].Date != "2024-09-15" {
+		t.Errorf("unexpected skipped dates: %+v", report.Skipped)
+	}
+	if len(report.AffectedAppointments) != 0 {
+		t.Errorf("expected no affected appointments, got %+v", report.AffectedAppointments)
+	}
+}
@@ -2,28 +2,272 @@
 package businesshour
 
 import (
+	"errors"
+	"fmt"
+	"sort"
 	"time"
 
+	"software-backend/internal/clinic"
 	"software-backend/internal/models"
+	"software-backend/internal/repository/appointment"
 	bh "software-backend/internal/repository/business_hour"
 )
 
+// Longest range of dates returned at once
+const maxRangeDays = 366
+
+// Custom errors
+var (
+	ErrInvalidBusinessHours = errors.New("invalid business hours")
+	ErrInvalidHolidayFile   = errors.New("invalid holiday file")
+)
+
 // BussinessHoursService interface defines the methods expected from the service
 type BusinessHoursService interface {
 	GetBusinessHoursForDate(date time.Time) ([]models.BusinessHourInterval, error)
+	GetProviderHoursForDate(providerID int, date time.Time) ([]models.BusinessHourInterval, error)
+	GetBusinessHoursForRange(from, to time.Time, providerID *int) ([]models.DayHours, error)
+	GetProviderWeeklyHours(providerID int) ([]models.WeeklyHourInterval, error)
+	SetProviderWeeklyHours(providerID int, hours []models.WeeklyHourInterval) error
+	GetWeeklyHours() ([]models.WeeklyHourInterval, error)
+	SetWeeklyHours(hours []models.WeeklyHourInterval) error
+	ListSpecialHours(from, to time.Time) ([]models.SpecialHours, error)
+	SetSpecialHours(special models.SpecialHours) error
+	DeleteSpecialHours(date time.Time) error
+	ImportHolidays(data []byte, format string, dryRun bool) (*models.HolidayImportReport, error)
 }
 
 // Struct to manage dependencies
 type businessHoursService struct {
-	repo bh.BusinessHoursRepository
+	repo     bh.BusinessHoursRepository
+	apptRepo appointment.AppointmentRepository
 }
 
 // Constructor to pass on dependencies
-func NewBusinessHoursService(repo bh.BusinessHoursRepository) BusinessHoursService {
-	return &businessHoursService{repo: repo}
+func NewBusinessHoursService(repo bh.BusinessHoursRepository, apptRepo appointment.AppointmentRepository) BusinessHoursService {
+	return &businessHoursService{repo: repo, apptRepo: apptRepo}
 }
 
 // Get business hours for a specific day, date in Go Time format
 func (s *businessHoursService) GetBusinessHoursForDate(date time.Time) ([]models.BusinessHourInterval, error) {
 	return s.repo.GetBusinessHoursForDate(date)
 }
+
+// Get the hours a provider can be booked on a specific day, that is their own weekly
+// hours within the clinic's hours. Providers without weekly hours follow the clinic
+func (s *businessHoursService) GetProviderHoursForDate(providerID int, date time.Time) ([]models.BusinessHourInterval, error) {
+	clinic, err := s.repo.GetBusinessHoursForDate(date)
+	if err != nil {
+		return nil, err
+	}
+	weekly, err := s.repo.GetProviderWeeklyHours(providerID)
+	if err != nil {
+		return nil, err
+	}
+	if len(weekly) == 0 {
+		return clinic, nil
+	}
+
+	return intersectIntervals(clinic, weekdayIntervals(weekly, date)), nil
+}
+
+// Pick the weekly intervals of a date's weekday, Monday = 1 & Sunday = 7
+func weekdayIntervals(weekly []models.WeeklyHourInterval, date time.Time) []models.BusinessHourInterval {
+	weekday := int(date.Weekday())
+	if weekday == 0 {
+		weekday = 7
+	}
+	var intervals []models.BusinessHourInterval
+	for _, h := range weekly {
+		if h.Weekday == weekday {
+			intervals = append(intervals, models.BusinessHourInterval{Start: h.Start, End: h.End})
+		}
+	}
+	return intervals
+}
+
+// Get the effective hours of each date between two dates, both included, in a fixed
+// number of queries. With a provider, their weekly hours within the clinic's
+func (s *businessHoursService) GetBusinessHoursForRange(from, to time.Time, providerID *int) ([]models.DayHours, error) {
+	// Basic input validation
+	if to.Before(from) {
+		return nil, fmt.Errorf("%w: 'to' must not be before 'from'", ErrInvalidBusinessHours)
+	}
+	if to.After(from.AddDate(0, 0, maxRangeDays-1)) {
+		return nil, fmt.Errorf("%w: range can't be longer than %d days", ErrInvalidBusinessHours, maxRangeDays)
+	}
+
+	specials, err := s.repo.ListSpecialHours(from, to)
+	if err != nil {
+		return nil, err
+	}
+	weekly, err := s.repo.GetWeeklyHours()
+	if err != nil {
+		return nil, err
+	}
+	var providerWeekly []models.WeeklyHourInterval
+	if providerID != nil {
+		if providerWeekly, err = s.repo.GetProviderWeeklyHours(*providerID); err != nil {
+			return nil, err
+		}
+	}
+
+	specialByDate := map[string]models.SpecialHours{}
+	for _, special := range specials {
+		specialByDate[special.Date] = special
+	}
+
+	days := []models.DayHours{}
+	for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+		date := day.Format("2006-01-02")
+		hours := models.DayHours{Date: date, Source: models.HoursSourceWeekly}
+		if special, ok := specialByDate[date]; ok {
+			hours.Source = models.HoursSourceSpecial
+			hours.Intervals = special.Intervals
+		} else {
+			hours.Intervals = weekdayIntervals(weekly, day)
+		}
+		// Providers without weekly hours follow the clinic
+		if len(providerWeekly) > 0 {
+			hours.Intervals = intersectIntervals(hours.Intervals, weekdayIntervals(providerWeekly, day))
+		}
+		if hours.Intervals == nil {
+			hours.Intervals = []models.BusinessHourInterval{}
+		}
+		hours.Closed = len(hours.Intervals) == 0
+		days = append(days, hours)
+	}
+	return days, nil
+}
+
+// Get the weekly hours of a provider
+func (s *businessHoursService) GetProviderWeeklyHours(providerID int) ([]models.WeeklyHourInterval, error) {
+	return s.repo.GetProviderWeeklyHours(providerID)
+}
+
+// Replace the weekly hours of a provider
+func (s *businessHoursService) SetProviderWeeklyHours(providerID int, hours []models.WeeklyHourInterval) error {
+	if err := validateWeeklyHours(hours); err != nil {
+		return err
+	}
+	return s.repo.ReplaceProviderWeeklyHours(providerID, hours)
+}
+
+// Get the clinic's weekly schedule
+func (s *businessHoursService) GetWeeklyHours() ([]models.WeeklyHourInterval, error) {
+	return s.repo.GetWeeklyHours()
+}
+
+// Replace the clinic's weekly schedule
+func (s *businessHoursService) SetWeeklyHours(hours []models.WeeklyHourInterval) error {
+	if err := validateWeeklyHours(hours); err != nil {
+		return err
+	}
+	return s.repo.ReplaceWeeklyHours(hours)
+}
+
+// List the special hours between two dates, both included
+func (s *businessHoursService) ListSpecialHours(from, to time.Time) ([]models.SpecialHours, error) {
+	if to.Before(from) {
+		return nil, fmt.Errorf("%w: 'to' must not be before 'from'", ErrInvalidBusinessHours)
+	}
+	return s.repo.ListSpecialHours(from, to)
+}
+
+// Set the special hours of a date, either closed all day or open on the given intervals
+func (s *businessHoursService) SetSpecialHours(special models.SpecialHours) error {
+	// Basic input validation
+	date, err := clinic.ParseDate(special.Date)
+	if err != nil {
+		return fmt.Errorf("%w: invalid date %q, expected YYYY-MM-DD", ErrInvalidBusinessHours, special.Date)
+	}
+	if special.Closed && len(special.Intervals) > 0 {
+		return fmt.Errorf("%w: a closed date can't have intervals", ErrInvalidBusinessHours)
+	}
+	if !special.Closed && len(special.Intervals) == 0 {
+		return fmt.Errorf("%w: intervals are required unless the date is closed", ErrInvalidBusinessHours)
+	}
+	if err := validateIntervals(special.Intervals); err != nil {
+		return err
+	}
+	special.Date = date.Format("2006-01-02")
+	return s.repo.SetSpecialHours(special)
+}
+
+// Delete the special hours of a date, it follows the weekly schedule again
+func (s *businessHoursService) DeleteSpecialHours(date time.Time) error {
+	return s.repo.DeleteSpecialHours(date)
+}
+
+// Check weekdays & that each weekday's intervals are valid
+func validateWeeklyHours(hours []models.WeeklyHourInterval) error {
+	byWeekday := map[int][]models.BusinessHourInterval{}
+	for _, h := range hours {
+		if h.Weekday < 1 || h.Weekday > 7 {
+			return fmt.Errorf("%w: weekday must be between 1 (Monday) and 7 (Sunday)", ErrInvalidBusinessHours)
+		}
+		byWeekday[h.Weekday] = append(byWeekday[h.Weekday], models.BusinessHourInterval{Start: h.Start, End: h.End})
+	}
+	for _, intervals := range byWeekday {
+		if err := validateIntervals(intervals); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Check intervals of a single day are "15:04", start before end & don't overlap
+func validateIntervals(intervals []models.BusinessHourInterval) error {
+	for _, h := range intervals {
+		// Zero padded so they compare as strings
+		start, err := time.Parse("15:04", h.Start)
+		if err != nil || start.Format("15:04") != h.Start {
+			return fmt.Errorf("%w: invalid start %q, expected HH:MM", ErrInvalidBusinessHours, h.Start)
+		}
+		end, err := time.Parse("15:04", h.End)
+		if err != nil || end.Format("15:04") != h.End {
+			return fmt.Errorf("%w: invalid end %q, expected HH:MM", ErrInvalidBusinessHours, h.End)
+		}
+		if !start.Before(end) {
+			return fmt.Errorf("%w: start must be before end", ErrInvalidBusinessHours)
+		}
+	}
+
+	// Once sorted an interval overlaps only if it starts before the previous one ends
+	sorted := append([]models.BusinessHourInterval(nil), intervals...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Start < sorted[j].Start
+	})
+	for i := 1; i < len(sorted); i++ {
+		if sorted[i].Start < sorted[i-1].End {
+			return fmt.Errorf("%w: %s-%s overlaps %s-%s", ErrInvalidBusinessHours,
+				sorted[i-1].Start, sorted[i-1].End, sorted[i].Start, sorted[i].End)
+		}
+	}
+	return nil
+}
+
+// Intersect two sets of "15:04" intervals, the result is sorted by start time
+func intersectIntervals(a, b []models.BusinessHourInterval) []models.BusinessHourInterval {
+	result := []models.BusinessHourInterval{}
+	for _, x := range a {
+		for _, y := range b {
+			// Zero padded "15:04" strings compare like times
+			start := x.Start
+			if y.Start > start {
+				start = y.Start
+			}
+			end := x.End
+			if y.End < end {
+				end = y.End
+			}
+			if start < end {
+				result = append(result, models.BusinessHourInterval{Start: start, End: end})
+			}
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Start < result[j].Start
+	})
+	return result
+}
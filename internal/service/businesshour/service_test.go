@@ -16,7 +16,7 @@ func TestGetBusinessHoursForDate_Success(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockRepo := mocks.NewMockBusinessHoursRepository(ctrl)
-	svc := NewBusinessHoursService(mockRepo)
+	svc := NewBusinessHoursService(mockRepo, mocks.NewMockAppointmentRepository(ctrl))
 
 	testDate := time.Date(2024, 7, 18, 0, 0, 0, 0, time.UTC)
 	expected := []models.BusinessHourInterval{
@@ -41,7 +41,7 @@ func TestGetBusinessHoursForDate_Error(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockRepo := mocks.NewMockBusinessHoursRepository(ctrl)
-	svc := NewBusinessHoursService(mockRepo)
+	svc := NewBusinessHoursService(mockRepo, mocks.NewMockAppointmentRepository(ctrl))
 
 	testDate := time.Date(2024, 7, 18, 0, 0, 0, 0, time.UTC)
 	mockRepo.EXPECT().
@@ -53,3 +53,168 @@ func TestGetBusinessHoursForDate_Error(t *testing.T) {
 		t.Errorf("expected db error, got %v", err)
 	}
 }
+
+func TestGetProviderHoursForDate_IntersectsClinicHours(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockBusinessHoursRepository(ctrl)
+	svc := NewBusinessHoursService(mockRepo, mocks.NewMockAppointmentRepository(ctrl))
+
+	testDate := time.Date(2024, 7, 18, 0, 0, 0, 0, time.UTC) // Thursday
+	mockRepo.EXPECT().
+		GetBusinessHoursForDate(testDate).
+		Return([]models.BusinessHourInterval{{Start: "08:00", End: "12:00"}, {Start: "13:00", End: "17:00"}}, nil)
+	mockRepo.EXPECT().
+		GetProviderWeeklyHours(3).
+		Return([]models.WeeklyHourInterval{
+			{Weekday: 1, Start: "08:00", End: "17:00"},
+			{Weekday: 4, Start: "10:00", End: "14:00"},
+		}, nil)
+
+	intervals, err := svc.GetProviderHoursForDate(3, testDate)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []models.BusinessHourInterval{{Start: "10:00", End: "12:00"}, {Start: "13:00", End: "14:00"}}
+	if len(intervals) != len(expected) || intervals[0] != expected[0] || intervals[1] != expected[1] {
+		t.Errorf("unexpected intervals: %+v", intervals)
+	}
+}
+
+func TestGetProviderHoursForDate_NoWeeklyHoursFollowsClinic(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockBusinessHoursRepository(ctrl)
+	svc := NewBusinessHoursService(mockRepo, mocks.NewMockAppointmentRepository(ctrl))
+
+	testDate := time.Date(2024, 7, 18, 0, 0, 0, 0, time.UTC)
+	clinic := []models.BusinessHourInterval{{Start: "09:00", End: "17:00"}}
+	mockRepo.EXPECT().GetBusinessHoursForDate(testDate).Return(clinic, nil)
+	mockRepo.EXPECT().GetProviderWeeklyHours(3).Return([]models.WeeklyHourInterval{}, nil)
+
+	intervals, err := svc.GetProviderHoursForDate(3, testDate)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(intervals) != 1 || intervals[0] != clinic[0] {
+		t.Errorf("unexpected intervals: %+v", intervals)
+	}
+}
+
+func TestSetProviderWeeklyHours_Invalid(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockBusinessHoursRepository(ctrl)
+	svc := NewBusinessHoursService(mockRepo, mocks.NewMockAppointmentRepository(ctrl))
+
+	err := svc.SetProviderWeeklyHours(3, []models.WeeklyHourInterval{{Weekday: 8, Start: "09:00", End: "17:00"}})
+	if !errors.Is(err, ErrInvalidBusinessHours) {
+		t.Errorf("expected invalid business hours error, got %v", err)
+	}
+	err = svc.SetProviderWeeklyHours(3, []models.WeeklyHourInterval{{Weekday: 1, Start: "17:00", End: "09:00"}})
+	if !errors.Is(err, ErrInvalidBusinessHours) {
+		t.Errorf("expected invalid business hours error, got %v", err)
+	}
+}
+
+func TestSetWeeklyHours_OverlappingIntervals(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockBusinessHoursRepository(ctrl)
+	svc := NewBusinessHoursService(mockRepo, mocks.NewMockAppointmentRepository(ctrl))
+
+	// Same weekday overlapping is refused
+	err := svc.SetWeeklyHours([]models.WeeklyHourInterval{
+		{Weekday: 1, Start: "09:00", End: "13:00"},
+		{Weekday: 1, Start: "12:00", End: "17:00"},
+	})
+	if !errors.Is(err, ErrInvalidBusinessHours) {
+		t.Errorf("expected invalid business hours error, got %v", err)
+	}
+
+	// A lunch break & the same hours on another weekday are fine
+	hours := []models.WeeklyHourInterval{
+		{Weekday: 1, Start: "09:00", End: "13:00"},
+		{Weekday: 1, Start: "14:00", End: "17:00"},
+		{Weekday: 2, Start: "09:00", End: "13:00"},
+	}
+	mockRepo.EXPECT().ReplaceWeeklyHours(hours).Return(nil)
+	if err := svc.SetWeeklyHours(hours); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestSetSpecialHours(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockBusinessHoursRepository(ctrl)
+	svc := NewBusinessHoursService(mockRepo, mocks.NewMockAppointmentRepository(ctrl))
+
+	invalid := []models.SpecialHours{
+		{Date: "25/12/2024", Closed: true},
+		{Date: "2024-12-25", Closed: true, Intervals: []models.BusinessHourInterval{{Start: "09:00", End: "12:00"}}},
+		{Date: "2024-12-24"},
+		{Date: "2024-12-24", Intervals: []models.BusinessHourInterval{{Start: "9:00", End: "12:00"}}},
+	}
+	for _, special := range invalid {
+		if err := svc.SetSpecialHours(special); !errors.Is(err, ErrInvalidBusinessHours) {
+			t.Errorf("expected invalid business hours error for %+v, got %v", special, err)
+		}
+	}
+
+	closed := models.SpecialHours{Date: "2024-12-25", Closed: true}
+	mockRepo.EXPECT().SetSpecialHours(closed).Return(nil)
+	if err := svc.SetSpecialHours(closed); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestGetBusinessHoursForRange_MarksSource(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockBusinessHoursRepository(ctrl)
+	svc := NewBusinessHoursService(mockRepo, mocks.NewMockAppointmentRepository(ctrl))
+
+	// Tuesday to Thursday, the clinic closes on Wednesday
+	from := time.Date(2024, 12, 24, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 12, 26, 0, 0, 0, 0, time.UTC)
+	mockRepo.EXPECT().
+		ListSpecialHours(from, to).
+		Return([]models.SpecialHours{{Date: "2024-12-25", Closed: true, Intervals: []models.BusinessHourInterval{}}}, nil)
+	mockRepo.EXPECT().
+		GetWeeklyHours().
+		Return([]models.WeeklyHourInterval{
+			{Weekday: 2, Start: "09:00", End: "17:00"},
+			{Weekday: 4, Start: "09:00", End: "13:00"},
+			{Weekday: 4, Start: "14:00", End: "17:00"},
+		}, nil)
+
+	days, err := svc.GetBusinessHoursForRange(from, to, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(days) != 3 {
+		t.Fatalf("expected 3 days, got %+v", days)
+	}
+	if days[0].Source != models.HoursSourceWeekly || days[0].Closed || len(days[0].Intervals) != 1 {
+		t.Errorf("unexpected Tuesday: %+v", days[0])
+	}
+	if days[1].Source != models.HoursSourceSpecial || !days[1].Closed {
+		t.Errorf("unexpected Wednesday: %+v", days[1])
+	}
+	if days[2].Date != "2024-12-26" || len(days[2].Intervals) != 2 {
+		t.Errorf("unexpected Thursday: %+v", days[2])
+	}
+
+	// Too long a range
+	_, err = svc.GetBusinessHoursForRange(from, from.AddDate(2, 0, 0), nil)
+	if !errors.Is(err, ErrInvalidBusinessHours) {
+		t.Errorf("expected invalid business hours error, got %v", err)
+	}
+}
@@ -0,0 +1,131 @@
+package calendar
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"software-backend/internal/models"
+)
+
+// Domain part of the event UIDs, UIDs must never change for a given appointment
+const uidDomain = "agenda.clinica"
+
+// Max length of a content line in octets, longer ones are folded (RFC 5545 3.1)
+const maxLineOctets = 75
+
+// Builds an iCalendar document line by line, lines end with CRLF
+type icsWriter struct {
+	b strings.Builder
+}
+
+// Write a "NAME:value" content line, the value must already be escaped
+func (w *icsWriter) line(name, value string) {
+	w.fold(name + ":" + value)
+}
+
+// Write a content line folding it into continuation lines starting with a space,
+// without splitting multi-byte characters
+func (w *icsWriter) fold(line string) {
+	limit := maxLineOctets
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !isRuneStart(line[cut]) {
+			cut--
+		}
+		w.b.WriteString(line[:cut])
+		w.b.WriteString("\r\n ")
+		line = line[cut:]
+		// Continuation lines lose an octet to the leading space
+		limit = maxLineOctets - 1
+	}
+	w.b.WriteString(line)
+	w.b.WriteString("\r\n")
+}
+
+// Returns true if the byte starts a UTF-8 sequence
+func isRuneStart(b byte) bool {
+	return b&0xC0 != 0x80
+}
+
+// Escape a TEXT value (RFC 5545 3.3.11)
+func escapeText(value string) string {
+	replacer := strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+	)
+	return replacer.Replace(value)
+}
+
+// Format a time as a UTC DATE-TIME value
+func formatTime(t time.Time) string {
+	return t.UTC().Format("20060102T150405Z")
+}
+
+// Map an appointment status onto an event STATUS, cancelled appointments are kept in
+// the feed so subscribed calendars drop them instead of keeping a stale copy
+func eventStatus(status models.AppointmentStatus) string {
+	switch status {
+	case models.StatusScheduled:
+		return "TENTATIVE"
+	case models.StatusCancelled:
+		return "CANCELLED"
+	default:
+		return "CONFIRMED"
+	}
+}
+
+// Render the appointments as an iCalendar feed, one VEVENT per appointment. Feeds are
+// published as a whole (METHOD:PUBLISH), changes are carried by each event's SEQUENCE
+// & STATUS. Private feeds leave patient details out
+func renderFeed(appointments []models.Appointment, private bool, now time.Time) []byte {
+	w := &icsWriter{}
+	w.line("BEGIN", "VCALENDAR")
+	w.line("VERSION", "2.0")
+	w.line("PRODID", "-//Clinica//Agenda//ES")
+	w.line("CALSCALE", "GREGORIAN")
+	w.line("METHOD", "PUBLISH")
+	w.line("X-WR-CALNAME", "Agenda")
+
+	for _, appt := range appointments {
+		// Rows from before the update tracking fall back to the time of the request
+		stamp := appt.UpdatedAt
+		if stamp.IsZero() {
+			stamp = now
+		}
+
+		w.line("BEGIN", "VEVENT")
+		w.line("UID", fmt.Sprintf("cita-%d@%s", appt.ID, uidDomain))
+		w.line("DTSTAMP", formatTime(stamp))
+		w.line("LAST-MODIFIED", formatTime(stamp))
+		w.line("SEQUENCE", fmt.Sprintf("%d", appt.Sequence))
+		w.line("DTSTART", formatTime(appt.Start))
+		w.line("DTEND", formatTime(appt.Start.Add(appt.Duration)))
+		w.line("STATUS", eventStatus(appt.Status))
+		if private {
+			w.line("SUMMARY", "Appointment")
+			w.line("CLASS", "PRIVATE")
+		} else {
+			w.line("SUMMARY", escapeText(eventSummary(appt)))
+			w.line("DESCRIPTION", escapeText(fmt.Sprintf("Status: %s", appt.Status)))
+		}
+		w.line("END", "VEVENT")
+	}
+
+	w.line("END", "VCALENDAR")
+	return []byte(w.b.String())
+}
+
+// Title of an event, the patient's name or their ID when the name isn't stored
+func eventSummary(appt models.Appointment) string {
+	if appt.Name != "" {
+		return appt.Name
+	}
+	if appt.PatientID != 0 {
+		return fmt.Sprintf("Patient #%d", appt.PatientID)
+	}
+	return "Appointment"
+}
@@ -0,0 +1,97 @@
+package calendar
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"software-backend/internal/models"
+	"software-backend/internal/repository/appointment"
+	"software-backend/internal/repository/calendar"
+)
+
+// Window of appointments included in a feed around the time it's fetched
+const (
+	feedPast   = 30 * 24 * time.Hour
+	feedFuture = 365 * 24 * time.Hour
+)
+
+// Interface defines methods expected from the service
+type CalendarService interface {
+	CreateFeedToken(userID int, private bool, providerID *int) (*models.CalendarFeedToken, error)
+	ListFeedTokens(userID int) ([]models.CalendarFeedToken, error)
+	RevokeFeedToken(id, userID int) error
+	RenderFeed(token string) ([]byte, error)
+}
+
+// Struct to manage dependencies
+type calendarService struct {
+	repo     calendar.CalendarRepository
+	apptRepo appointment.AppointmentRepository
+	now      func() time.Time
+}
+
+// Constructor to pass on dependencies
+func NewCalendarService(repo calendar.CalendarRepository, apptRepo appointment.AppointmentRepository) CalendarService {
+	return &calendarService{
+		repo:     repo,
+		apptRepo: apptRepo,
+		now:      time.Now,
+	}
+}
+
+// Create a feed token for a user, optionally limited to a provider's appointments
+func (s *calendarService) CreateFeedToken(userID int, private bool, providerID *int) (*models.CalendarFeedToken, error) {
+	token, err := newFeedToken()
+	if err != nil {
+		return nil, fmt.Errorf("service: failed to generate calendar feed token: %w", err)
+	}
+	return s.repo.CreateFeedToken(models.CalendarFeedToken{
+		UserID:     userID,
+		Token:      token,
+		Private:    private,
+		ProviderID: providerID,
+	})
+}
+
+// List the feed tokens of a user
+func (s *calendarService) ListFeedTokens(userID int) ([]models.CalendarFeedToken, error) {
+	return s.repo.ListFeedTokens(userID)
+}
+
+// Revoke one of a user's feed tokens, the feed stops working right away
+func (s *calendarService) RevokeFeedToken(id, userID int) error {
+	return s.repo.RevokeFeedToken(id, userID)
+}
+
+// Render the iCalendar feed for a token, revoked or unknown tokens are not found
+func (s *calendarService) RenderFeed(token string) ([]byte, error) {
+	feedToken, err := s.repo.GetActiveFeedToken(token)
+	if err != nil {
+		return nil, err
+	}
+
+	// Provider feeds only list the provider's appointments
+	now := s.now()
+	var appointments []models.Appointment
+	if feedToken.ProviderID != nil {
+		appointments, err = s.apptRepo.ListProviderAppointmentsInDateRange(now.Add(-feedPast), now.Add(feedFuture), *feedToken.ProviderID)
+	} else {
+		appointments, err = s.apptRepo.ListAppointmentsInDateRange(now.Add(-feedPast), now.Add(feedFuture))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("service: failed to fetch appointments for calendar feed: %w", err)
+	}
+
+	return renderFeed(appointments, feedToken.Private, now), nil
+}
+
+// Random token identifying a feed, hard to guess as it's all that's needed to read it
+func newFeedToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,125 @@
+package calendar
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"software-backend/internal/mocks"
+	"software-backend/internal/models"
+	"software-backend/internal/repository/calendar"
+
+	"github.com/golang/mock/gomock"
+)
+
+func TestRenderFeed_EventsCarryStatusAndSequence(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockCalendarRepository(ctrl)
+	mockApptRepo := mocks.NewMockAppointmentRepository(ctrl)
+	svc := NewCalendarService(mockRepo, mockApptRepo).(*calendarService)
+	now := time.Date(2024, 7, 18, 8, 0, 0, 0, time.UTC)
+	svc.now = func() time.Time { return now }
+
+	mockRepo.EXPECT().
+		GetActiveFeedToken("abc").
+		Return(&models.CalendarFeedToken{ID: 1, UserID: 2, Token: "abc"}, nil)
+	mockApptRepo.EXPECT().
+		ListAppointmentsInDateRange(now.Add(-feedPast), now.Add(feedFuture)).
+		Return([]models.Appointment{
+			{ID: 10, Name: "Pérez, Ana", Start: now.Add(2 * time.Hour), Duration: 30 * time.Minute, Status: models.StatusConfirmed, UpdatedAt: now, Sequence: 2},
+			{ID: 11, PatientID: 7, Start: now.Add(4 * time.Hour), Duration: time.Hour, Status: models.StatusCancelled, UpdatedAt: now},
+		}, nil)
+
+	feed, err := svc.RenderFeed("abc")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ics := string(feed)
+
+	for _, expected := range []string{
+		"BEGIN:VCALENDAR\r\n",
+		"METHOD:PUBLISH\r\n",
+		"UID:cita-10@" + uidDomain + "\r\n",
+		"SEQUENCE:2\r\n",
+		"DTSTART:20240718T100000Z\r\n",
+		"DTEND:20240718T103000Z\r\n",
+		"STATUS:CONFIRMED\r\n",
+		`SUMMARY:Pérez\, Ana` + "\r\n",
+		"UID:cita-11@" + uidDomain + "\r\n",
+		"STATUS:CANCELLED\r\n",
+		"SUMMARY:Patient #7\r\n",
+		"END:VCALENDAR\r\n",
+	} {
+		if !strings.Contains(ics, expected) {
+			t.Errorf("expected feed to contain %q, got:\n%s", expected, ics)
+		}
+	}
+}
+
+func TestRenderFeed_PrivateHidesPatientsAndFiltersProvider(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockCalendarRepository(ctrl)
+	mockApptRepo := mocks.NewMockAppointmentRepository(ctrl)
+	svc := NewCalendarService(mockRepo, mockApptRepo)
+
+	providerID := 3
+	mockRepo.EXPECT().
+		GetActiveFeedToken("abc").
+		Return(&models.CalendarFeedToken{ID: 1, Token: "abc", Private: true, ProviderID: &providerID}, nil)
+	mockApptRepo.EXPECT().
+		ListProviderAppointmentsInDateRange(gomock.Any(), gomock.Any(), providerID).
+		Return([]models.Appointment{
+			{ID: 10, Name: "Ana Pérez", Start: time.Now(), Duration: time.Hour, ProviderID: &providerID, Status: models.StatusScheduled},
+		}, nil)
+
+	feed, err := svc.RenderFeed("abc")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ics := string(feed)
+
+	if strings.Contains(ics, "Ana") || strings.Contains(ics, "Luis") {
+		t.Errorf("expected patient names to be hidden, got:\n%s", ics)
+	}
+	if !strings.Contains(ics, "UID:cita-10@") {
+		t.Errorf("expected the provider's appointment, got:\n%s", ics)
+	}
+}
+
+func TestRenderFeed_RevokedToken(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockCalendarRepository(ctrl)
+	svc := NewCalendarService(mockRepo, mocks.NewMockAppointmentRepository(ctrl))
+
+	// Revoked tokens are no longer found
+	mockRepo.EXPECT().
+		GetActiveFeedToken("abc").
+		Return(nil, calendar.ErrFeedTokenNotFound)
+
+	_, err := svc.RenderFeed("abc")
+	if !errors.Is(err, calendar.ErrFeedTokenNotFound) {
+		t.Errorf("expected token not found error, got %v", err)
+	}
+}
+
+func TestIcsWriter_FoldsLongLines(t *testing.T) {
+	w := &icsWriter{}
+	w.line("SUMMARY", strings.Repeat("ñ", 60))
+
+	for _, line := range strings.Split(strings.TrimSuffix(w.b.String(), "\r\n"), "\r\n") {
+		if len(line) > maxLineOctets {
+			t.Errorf("line longer than %d octets: %q", maxLineOctets, line)
+		}
+	}
+	unfolded := strings.ReplaceAll(w.b.String(), "\r\n ", "")
+	if unfolded != "SUMMARY:"+strings.Repeat("ñ", 60)+"\r\n" {
+		t.Errorf("unexpected unfolded line: %q", unfolded)
+	}
+}
@@ -0,0 +1,131 @@
+package consent
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"software-backend/internal/models"
+	"software-backend/internal/repository/consent"
+	"software-backend/internal/repository/patient"
+)
+
+// Custom errors, probably moved onto separate file in the future
+var ErrInvalidConsent = errors.New("invalid contact preferences")
+
+// Channels patients can prefer or consent to
+var channels = map[string]bool{
+	models.ChannelWhatsApp: true,
+	models.ChannelPhone:    true,
+	models.ChannelEmail:    true,
+}
+
+// Sources staff can record consents from, replies are only recorded by the webhook
+var consentSources = map[string]bool{
+	models.ConsentSourceStaff:   true,
+	models.ConsentSourcePatient: true,
+}
+
+// Language codes like WhatsApp templates use them, e.g. "es" or "es_MX"
+var languagePattern = regexp.MustCompile(`^[a-z]{2,3}(_[A-Z]{2})?$`)
+
+// Interface defines methods expected from the service
+type ConsentService interface {
+	GetContactPreferences(patientID int) (*models.ContactPreferences, error)
+	SetContactPreferences(preferences models.ContactPreferences, userID *int) (*models.ContactPreferences, error)
+	RecordConsent(record models.ConsentRecord, userID *int) (*models.ConsentRecord, error)
+	ListConsents(patientID int) ([]models.ConsentRecord, error)
+}
+
+// Struct to manage dependencies
+type consentService struct {
+	repo        consent.ConsentRepository
+	patientRepo patient.PatientRepository
+}
+
+// Constructor to pass on dependencies
+func NewConsentService(repo consent.ConsentRepository, patientRepo patient.PatientRepository) ConsentService {
+	return &consentService{repo: repo, patientRepo: patientRepo}
+}
+
+// Get how a patient wants to be contacted
+func (s *consentService) GetContactPreferences(patientID int) (*models.ContactPreferences, error) {
+	if _, err := s.patientRepo.GetPatientByID(patientID); err != nil {
+		return nil, err
+	}
+	return s.repo.GetContactPreferences(patientID)
+}
+
+// Replace a patient's contact preferences, consents are recorded apart
+func (s *consentService) SetContactPreferences(preferences models.ContactPreferences, userID *int) (*models.ContactPreferences, error) {
+	// Basic input validation
+	preferences.PreferredChannel = strings.ToLower(strings.TrimSpace(preferences.PreferredChannel))
+	if preferences.PreferredChannel != "" && !channels[preferences.PreferredChannel] {
+		return nil, fmt.Errorf("%w: unknown channel %q", ErrInvalidConsent, preferences.PreferredChannel)
+	}
+	language, err := normalizeLanguage(preferences.Language)
+	if err != nil {
+		return nil, err
+	}
+	preferences.Language = language
+	preferences.UserID = userID
+
+	if _, err := s.patientRepo.GetPatientByID(preferences.PatientID); err != nil {
+		return nil, err
+	}
+	if err := s.repo.SetContactPreferences(preferences); err != nil {
+		return nil, err
+	}
+	return s.repo.GetContactPreferences(preferences.PatientID)
+}
+
+// Record a patient opting into or out of a channel
+func (s *consentService) RecordConsent(record models.ConsentRecord, userID *int) (*models.ConsentRecord, error) {
+	// Basic input validation
+	record.Channel = strings.ToLower(strings.TrimSpace(record.Channel))
+	if !channels[record.Channel] {
+		return nil, fmt.Errorf("%w: unknown channel %q", ErrInvalidConsent, record.Channel)
+	}
+	if record.Status != models.ConsentOptIn && record.Status != models.ConsentOptOut {
+		return nil, fmt.Errorf("%w: status must be %s or %s", ErrInvalidConsent, models.ConsentOptIn, models.ConsentOptOut)
+	}
+	if record.Source == "" {
+		record.Source = models.ConsentSourceStaff
+	}
+	if !consentSources[record.Source] {
+		return nil, fmt.Errorf("%w: source must be %s or %s", ErrInvalidConsent, models.ConsentSourceStaff, models.ConsentSourcePatient)
+	}
+	record.Note = strings.TrimSpace(record.Note)
+	record.UserID = userID
+
+	if _, err := s.patientRepo.GetPatientByID(record.PatientID); err != nil {
+		return nil, err
+	}
+	return s.repo.CreateConsent(record)
+}
+
+// List every consent record of a patient, latest first
+func (s *consentService) ListConsents(patientID int) ([]models.ConsentRecord, error) {
+	if _, err := s.patientRepo.GetPatientByID(patientID); err != nil {
+		return nil, err
+	}
+	return s.repo.ListConsents(patientID)
+}
+
+// Normalize a language code to how templates use it, "es-mx" becomes "es_MX"
+func normalizeLanguage(language string) (string, error) {
+	language = strings.TrimSpace(language)
+	if language == "" {
+		return "", nil
+	}
+	parts := strings.SplitN(strings.ReplaceAll(language, "-", "_"), "_", 2)
+	normalized := strings.ToLower(parts[0])
+	if len(parts) == 2 {
+		normalized += "_" + strings.ToUpper(parts[1])
+	}
+	if !languagePattern.MatchString(normalized) {
+		return "", fmt.Errorf("%w: invalid language %q", ErrInvalidConsent, language)
+	}
+	return normalized, nil
+}
@@ -0,0 +1,105 @@
+package consent
+
+import (
+	"errors"
+	"testing"
+
+	"software-backend/internal/mocks"
+	"software-backend/internal/models"
+
+	"github.com/golang/mock/gomock"
+)
+
+func TestContactPreferences_Allows(t *testing.T) {
+	optIn := models.ConsentRecord{Channel: models.ChannelWhatsApp, Status: models.ConsentOptIn}
+	optOut := models.ConsentRecord{Channel: models.ChannelWhatsApp, Status: models.ConsentOptOut}
+	cases := []struct {
+		name        string
+		preferences models.ContactPreferences
+		allowed     bool
+	}{
+		{"nothing set", models.ContactPreferences{}, true},
+		{"opted out", models.ContactPreferences{Consents: map[string]models.ConsentRecord{models.ChannelWhatsApp: optOut}}, false},
+		{"do not contact", models.ContactPreferences{DoNotContact: true, Consents: map[string]models.ConsentRecord{models.ChannelWhatsApp: optIn}}, false},
+		{"prefers phone", models.ContactPreferences{PreferredChannel: models.ChannelPhone}, false},
+		{"prefers phone but opted in", models.ContactPreferences{PreferredChannel: models.ChannelPhone, Consents: map[string]models.ConsentRecord{models.ChannelWhatsApp: optIn}}, true},
+	}
+	for _, c := range cases {
+		if got := c.preferences.Allows(models.ChannelWhatsApp); got != c.allowed {
+			t.Errorf("%s: expected allowed to be %v, got %v", c.name, c.allowed, got)
+		}
+	}
+}
+
+func TestSetContactPreferences_Validation(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockConsentRepository(ctrl)
+	mockPatientRepo := mocks.NewMockPatientRepository(ctrl)
+	svc := NewConsentService(mockRepo, mockPatientRepo)
+
+	invalid := map[string]models.ContactPreferences{
+		"unknown channel":  {PatientID: 1, PreferredChannel: "pigeon"},
+		"invalid language": {PatientID: 1, Language: "spanish"},
+	}
+	for name, preferences := range invalid {
+		if _, err := svc.SetContactPreferences(preferences, nil); !errors.Is(err, ErrInvalidConsent) {
+			t.Errorf("%s: expected invalid consent error, got %v", name, err)
+		}
+	}
+
+	// Channel & language are normalized
+	userID := 3
+	mockPatientRepo.EXPECT().GetPatientByID(1).Return(&models.Patient{ID: 1}, nil)
+	mockRepo.EXPECT().
+		SetContactPreferences(gomock.Any()).
+		DoAndReturn(func(p models.ContactPreferences) error {
+			if p.PreferredChannel != models.ChannelWhatsApp || p.Language != "es_MX" || p.UserID == nil || *p.UserID != userID {
+				t.Errorf("unexpected normalized preferences: %+v", p)
+			}
+			return nil
+		})
+	mockRepo.EXPECT().GetContactPreferences(1).Return(&models.ContactPreferences{PatientID: 1}, nil)
+
+	if _, err := svc.SetContactPreferences(models.ContactPreferences{PatientID: 1, PreferredChannel: " WhatsApp", Language: "es-mx"}, &userID); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestRecordConsent_Validation(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockConsentRepository(ctrl)
+	mockPatientRepo := mocks.NewMockPatientRepository(ctrl)
+	svc := NewConsentService(mockRepo, mockPatientRepo)
+
+	invalid := map[string]models.ConsentRecord{
+		"unknown channel": {PatientID: 1, Channel: "fax", Status: models.ConsentOptIn},
+		"unknown status":  {PatientID: 1, Channel: models.ChannelWhatsApp, Status: "maybe"},
+		"reply source":    {PatientID: 1, Channel: models.ChannelWhatsApp, Status: models.ConsentOptOut, Source: models.ConsentSourceWhatsAppReply},
+	}
+	for name, record := range invalid {
+		if _, err := svc.RecordConsent(record, nil); !errors.Is(err, ErrInvalidConsent) {
+			t.Errorf("%s: expected invalid consent error, got %v", name, err)
+		}
+	}
+
+	// Staff is the default source
+	mockPatientRepo.EXPECT().GetPatientByID(1).Return(&models.Patient{ID: 1}, nil)
+	mockRepo.EXPECT().
+		CreateConsent(gomock.Any()).
+		DoAndReturn(func(r models.ConsentRecord) (*models.ConsentRecord, error) {
+			r.ID = 9
+			return &r, nil
+		})
+
+	record, err := svc.RecordConsent(models.ConsentRecord{PatientID: 1, Channel: models.ChannelWhatsApp, Status: models.ConsentOptOut}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if record.Source != models.ConsentSourceStaff {
+		t.Errorf("expected staff source, got %q", record.Source)
+	}
+}
@@ -6,11 +6,24 @@ import (
 	"time"
 
 	"software-backend/internal/models"
+	apptrepository "software-backend/internal/repository/appointment"
+	"software-backend/internal/repository/appointmenttype"
 	"software-backend/internal/repository/consultation"
 	"software-backend/internal/repository/diagnostic"
+	"software-backend/internal/service/appointment"
 	questionnaire "software-backend/internal/service/questionnaire"
 )
 
+// Reason used for visits started without one
+const defaultVisitReason = "Consulta"
+
+// Custom errors, probably moved onto separate file in the future
+var (
+	ErrVisitAlreadyStarted  = errors.New("a consultation was already started from this appointment")
+	ErrAppointmentNoPatient = errors.New("appointment has no registered patient")
+	ErrInvalidVisit         = errors.New("invalid visit")
+)
+
 type ConsultationService interface {
 	// Existing operation
 	GetByPatientID(patientID int) ([]models.Consultation, error)
@@ -21,23 +34,32 @@ type ConsultationService interface {
 	GetWithDetails(id int) (*models.CompleteConsultation, error)
 	Update(id int, req UpdateConsultationRequest) (*models.Consultation, error)
 	Delete(id int) error
+	StartVisit(appointmentID int, req StartVisitRequest, userID *int) (*models.Consultation, error)
 }
 
 type consultationService struct {
 	repo                 consultation.ConsultationRepository
 	diagnosticRepo       diagnostic.DiagnosticRepository
 	questionnaireService questionnaire.QuestionnaireService
+	apptTypeRepo         appointmenttype.AppointmentTypeRepository
+	appointmentService   appointment.AppointmentService
+	now                  func() time.Time
 }
 
 func NewConsultationService(
 	repo consultation.ConsultationRepository,
 	diagnosticRepo diagnostic.DiagnosticRepository,
 	questionnaireService questionnaire.QuestionnaireService,
+	apptTypeRepo appointmenttype.AppointmentTypeRepository,
+	appointmentService appointment.AppointmentService,
 ) ConsultationService {
 	return &consultationService{
 		repo:                 repo,
 		diagnosticRepo:       diagnosticRepo,
 		questionnaireService: questionnaireService,
+		apptTypeRepo:         apptTypeRepo,
+		appointmentService:   appointmentService,
+		now:                  time.Now,
 	}
 }
 
@@ -48,6 +70,35 @@ func (s *consultationService) GetByPatientID(patientID int) ([]models.Consultati
 
 // New methods
 func (s *consultationService) Create(req CreateConsultationRequest) (*models.Consultation, error) {
+	consultation, err := s.newConsultation(req, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := s.repo.Create(*consultation)
+	if err != nil {
+		return nil, err
+	}
+
+	consultation.ID = id
+	return consultation, nil
+}
+
+// Validate a consultation request & build the consultation, linked to the appointment it
+// was started from if given. Nothing is stored
+func (s *consultationService) newConsultation(req CreateConsultationRequest, appointmentID *int) (*models.Consultation, error) {
+	// Pre-select the questionnaire of the appointment type the visit was booked as
+	if req.QuestionnaireID == nil && req.AppointmentTypeID != nil {
+		apptType, err := s.apptTypeRepo.GetAppointmentTypeByID(*req.AppointmentTypeID)
+		if err != nil {
+			if errors.Is(err, appointmenttype.ErrAppointmentTypeNotFound) {
+				return nil, errors.New("appointment type not found")
+			}
+			return nil, err
+		}
+		req.QuestionnaireID = apptType.QuestionnaireID
+	}
+
 	// Validate questionnaire exists if provided
 	if req.QuestionnaireID != nil {
 		if err := s.questionnaireService.ValidateQuestionnaireExists(*req.QuestionnaireID); err != nil {
@@ -66,21 +117,61 @@ func (s *consultationService) Create(req CreateConsultationRequest) (*models.Con
 	consultation := models.Consultation{
 		PatientID:       req.PatientID,
 		QuestionnaireID: req.QuestionnaireID,
+		AppointmentID:   appointmentID,
 		Reason:          req.Reason,
-		Date:            time.Now(),
+		Date:            s.now(),
 	}
 
 	if !req.Date.IsZero() {
 		consultation.Date = req.Date
 	}
+	return &consultation, nil
+}
 
-	id, err := s.repo.Create(consultation)
+// Start the visit of an appointment: creates a consultation for the appointment's patient
+// & moves the appointment to in consultation. The questionnaire defaults to the one of the
+// appointment's type
+func (s *consultationService) StartVisit(appointmentID int, req StartVisitRequest, userID *int) (*models.Consultation, error) {
+	appt, err := s.appointmentService.GetAppointment(appointmentID)
 	if err != nil {
 		return nil, err
 	}
+	if appt.ConsultationID != nil {
+		return nil, ErrVisitAlreadyStarted
+	}
+	if appt.PatientID == 0 {
+		return nil, ErrAppointmentNoPatient
+	}
+	// Check the transition before creating anything
+	if !appt.Status.CanTransitionTo(models.StatusInConsultation) {
+		return nil, fmt.Errorf("%w: %s to %s", appointment.ErrInvalidTransition, appt.Status, models.StatusInConsultation)
+	}
+
+	if req.Reason == "" {
+		req.Reason = defaultVisitReason
+	}
+	consultation, err := s.newConsultation(CreateConsultationRequest{
+		PatientID:         appt.PatientID,
+		QuestionnaireID:   req.QuestionnaireID,
+		Reason:            req.Reason,
+		Date:              s.now(),
+		AppointmentTypeID: appt.AppointmentTypeID,
+	}, &appt.ID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidVisit, err)
+	}
 
+	// The consultation & the status change are stored together, a concurrent start finds
+	// the consultation already there
+	id, err := s.appointmentService.StartConsultation(*consultation, userID)
+	if errors.Is(err, apptrepository.ErrConsultationExists) {
+		return nil, ErrVisitAlreadyStarted
+	}
+	if err != nil {
+		return nil, err
+	}
 	consultation.ID = id
-	return &consultation, nil
+	return consultation, nil
 }
 
 func (s *consultationService) GetByID(id int) (*models.Consultation, error) {
@@ -139,6 +230,14 @@ type CreateConsultationRequest struct {
 	QuestionnaireID *int      `json:"questionnaire_id,omitempty"`
 	Reason          string    `json:"reason" validate:"required"`
 	Date            time.Time `json:"date,omitempty"`
+
+	// Type of the appointment the visit comes from, its questionnaire is used if none is given
+	AppointmentTypeID *int `json:"appointment_type_id,omitempty"`
+}
+
+type StartVisitRequest struct {
+	Reason          string `json:"reason,omitempty"`
+	QuestionnaireID *int   `json:"questionnaire_id,omitempty"`
 }
 
 type UpdateConsultationRequest struct {
@@ -0,0 +1,135 @@
+package consultation
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"software-backend/internal/mocks"
+	"software-backend/internal/models"
+	"software-backend/internal/repository/appointment"
+	apptservice "software-backend/internal/service/appointment"
+	questionnaire "software-backend/internal/service/questionnaire"
+
+	"github.com/golang/mock/gomock"
+)
+
+// Hand-written fake for AppointmentService, only what starting a visit uses
+type fakeAppointmentService struct {
+	apptservice.AppointmentService
+	appointment  *models.Appointment
+	changeErr    error
+	started      *models.Consultation
+	changeCalled bool
+}
+
+func (f *fakeAppointmentService) GetAppointment(id int) (*models.Appointment, error) {
+	if f.appointment == nil || f.appointment.ID != id {
+		return nil, appointment.ErrAppointmentNotFound
+	}
+	return f.appointment, nil
+}
+
+func (f *fakeAppointmentService) StartConsultation(consultation models.Consultation, userID *int) (int, error) {
+	f.changeCalled = true
+	if f.changeErr != nil {
+		return 0, f.changeErr
+	}
+	f.started = &consultation
+	return 30, nil
+}
+
+// Hand-written fake for QuestionnaireService, every questionnaire exists
+type fakeQuestionnaireService struct {
+	questionnaire.QuestionnaireService
+}
+
+func (f *fakeQuestionnaireService) ValidateQuestionnaireExists(id int) error {
+	return nil
+}
+
+func TestStartVisit_CreatesLinkedConsultation(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockConsultationRepository(ctrl)
+	mockTypeRepo := mocks.NewMockAppointmentTypeRepository(ctrl)
+	typeID, questionnaireID := 2, 5
+	apptService := &fakeAppointmentService{
+		appointment: &models.Appointment{ID: 10, PatientID: 4, Status: models.StatusCheckedIn, AppointmentTypeID: &typeID},
+	}
+	svc := NewConsultationService(mockRepo, nil, &fakeQuestionnaireService{}, mockTypeRepo, apptService).(*consultationService)
+	now := time.Date(2024, 7, 18, 10, 0, 0, 0, time.UTC)
+	svc.now = func() time.Time { return now }
+
+	// The questionnaire is pre-selected from the appointment type
+	mockTypeRepo.EXPECT().
+		GetAppointmentTypeByID(typeID).
+		Return(&models.AppointmentType{ID: typeID, QuestionnaireID: &questionnaireID}, nil)
+
+	// Created together with the status change
+	created, err := svc.StartVisit(10, StartVisitRequest{}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if created.ID != 30 || created.Reason != defaultVisitReason {
+		t.Errorf("unexpected consultation: %+v", created)
+	}
+	c := apptService.started
+	if c == nil || c.PatientID != 4 || c.AppointmentID == nil || *c.AppointmentID != 10 ||
+		c.QuestionnaireID == nil || *c.QuestionnaireID != questionnaireID || !c.Date.Equal(now) {
+		t.Errorf("unexpected consultation started: %+v", c)
+	}
+}
+
+func TestStartVisit_NotCheckedIn(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	apptService := &fakeAppointmentService{
+		appointment: &models.Appointment{ID: 10, PatientID: 4, Status: models.StatusScheduled},
+	}
+	// No repository calls expected
+	svc := NewConsultationService(mocks.NewMockConsultationRepository(ctrl), nil, &fakeQuestionnaireService{}, mocks.NewMockAppointmentTypeRepository(ctrl), apptService)
+
+	_, err := svc.StartVisit(10, StartVisitRequest{Reason: "Control"}, nil)
+	if !errors.Is(err, apptservice.ErrInvalidTransition) {
+		t.Errorf("expected invalid transition error, got %v", err)
+	}
+	if apptService.changeCalled {
+		t.Error("expected the appointment status to be left alone")
+	}
+}
+
+func TestStartVisit_StatusChangeFails(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// Nothing to undo, the consultation is rolled back with the status change
+	apptService := &fakeAppointmentService{
+		appointment: &models.Appointment{ID: 10, PatientID: 4, Status: models.StatusCheckedIn},
+		changeErr:   appointment.ErrStatusChanged,
+	}
+	svc := NewConsultationService(mocks.NewMockConsultationRepository(ctrl), nil, &fakeQuestionnaireService{}, mocks.NewMockAppointmentTypeRepository(ctrl), apptService)
+
+	_, err := svc.StartVisit(10, StartVisitRequest{Reason: "Control"}, nil)
+	if !errors.Is(err, appointment.ErrStatusChanged) {
+		t.Errorf("expected status changed error, got %v", err)
+	}
+}
+
+func TestStartVisit_StartedConcurrently(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	apptService := &fakeAppointmentService{
+		appointment: &models.Appointment{ID: 10, PatientID: 4, Status: models.StatusCheckedIn},
+		changeErr:   appointment.ErrConsultationExists,
+	}
+	svc := NewConsultationService(mocks.NewMockConsultationRepository(ctrl), nil, &fakeQuestionnaireService{}, mocks.NewMockAppointmentTypeRepository(ctrl), apptService)
+
+	_, err := svc.StartVisit(10, StartVisitRequest{Reason: "Control"}, nil)
+	if !errors.Is(err, ErrVisitAlreadyStarted) {
+		t.Errorf("expected visit already started error, got %v", err)
+	}
+}
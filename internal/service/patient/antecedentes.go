@@ -0,0 +1,133 @@
+package patient
+
+import (
+	"fmt"
+	"time"
+
+	"software-backend/internal/models"
+)
+
+// Given antecedentes are a new version, its number & date are set by the repository
+func setAntecedentesAuthor(patient *models.Patient, userID *int) {
+	if patient.Antecedentes == nil {
+		return
+	}
+	antecedentes := *patient.Antecedentes
+	antecedentes.Version = 0
+	antecedentes.UserID = userID
+	antecedentes.CreatedAt = time.Time{}
+	patient.Antecedentes = &antecedentes
+}
+
+// Every antecedentes version needs an author, so anonymous requests can only send them
+// unchanged. 'patientID' is 0 for a new patient
+func (s *patientService) checkAntecedentesAuthor(patientID int, antecedentes *models.Antecedentes, userID *int) error {
+	if antecedentes == nil || userID != nil {
+		return nil
+	}
+	var current models.Antecedentes
+	if patientID != 0 {
+		existing, err := s.patientRepo.GetPatientByID(patientID)
+		if err != nil {
+			return err
+		}
+		if existing.Antecedentes != nil {
+			current = *existing.Antecedentes
+		}
+	}
+	if len(antecedentesChanges(current, *antecedentes)) > 0 {
+		return fmt.Errorf("%w: changing antecedentes requires a logged in user", ErrInvalidPatient)
+	}
+	return nil
+}
+
+// List every version of a patient's antecedentes, latest first
+func (s *patientService) ListAntecedentes(patientID int) ([]models.Antecedentes, error) {
+	if _, err := s.patientRepo.GetPatientByID(patientID); err != nil {
+		return nil, err
+	}
+	return s.patientRepo.ListAntecedentes(patientID)
+}
+
+// Get one version of a patient's antecedentes
+func (s *patientService) GetAntecedentesVersion(patientID, version int) (*models.Antecedentes, error) {
+	versions, err := s.ListAntecedentes(patientID)
+	if err != nil {
+		return nil, err
+	}
+	return findAntecedentesVersion(versions, version)
+}
+
+// Compare two versions of a patient's antecedentes. When to is 0 the current version
+// is used & when from is 0 the one right before to
+func (s *patientService) DiffAntecedentes(patientID, from, to int) (*models.AntecedentesDiff, error) {
+	// Basic input validation
+	if from < 0 || to < 0 {
+		return nil, fmt.Errorf("%w: versions can't be negative", ErrInvalidPatient)
+	}
+
+	versions, err := s.ListAntecedentes(patientID)
+	if err != nil {
+		return nil, err
+	}
+	if len(versions) == 0 {
+		return nil, ErrAntecedentesNotFound
+	}
+	if to == 0 {
+		to = versions[0].Version
+	}
+	newer, err := findAntecedentesVersion(versions, to)
+	if err != nil {
+		return nil, err
+	}
+
+	diff := &models.AntecedentesDiff{PatientID: patientID, To: *newer}
+	var older models.Antecedentes
+	if from == 0 {
+		from = to - 1
+	}
+	if from > 0 {
+		if from >= to {
+			return nil, fmt.Errorf("%w: 'from' must be an earlier version than 'to'", ErrInvalidPatient)
+		}
+		previous, err := findAntecedentesVersion(versions, from)
+		if err != nil {
+			return nil, err
+		}
+		diff.From = previous
+		older = *previous
+	}
+	diff.Changes = antecedentesChanges(older, *newer)
+	return diff, nil
+}
+
+// Find a version in a list of them
+func findAntecedentesVersion(versions []models.Antecedentes, version int) (*models.Antecedentes, error) {
+	for i := range versions {
+		if versions[i].Version == version {
+			return &versions[i], nil
+		}
+	}
+	return nil, fmt.Errorf("%w: version %d", ErrAntecedentesNotFound, version)
+}
+
+// Fields changed from a version to another, keyed by JSON field name
+func antecedentesChanges(before, after models.Antecedentes) map[string]models.FieldChange {
+	fields := []struct {
+		name          string
+		before, after string
+	}{
+		{"medical", before.Medical, after.Medical},
+		{"family", before.Family, after.Family},
+		{"ocular", before.Ocular, after.Ocular},
+		{"alergic", before.Alergic, after.Alergic},
+		{"other", before.Other, after.Other},
+	}
+	changes := map[string]models.FieldChange{}
+	for _, field := range fields {
+		if field.before != field.after {
+			changes[field.name] = models.FieldChange{Before: field.before, After: field.after}
+		}
+	}
+	return changes
+}
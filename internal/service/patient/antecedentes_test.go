@@ -0,0 +1,112 @@
+package patient
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"software-backend/internal/mocks"
+	"software-backend/internal/models"
+
+	"github.com/golang/mock/gomock"
+)
+
+func TestDiffAntecedentes(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockPatientRepository(ctrl)
+	svc := NewPatientService(mockRepo, nil)
+
+	versions := []models.Antecedentes{
+		{Version: 3, Family: "Glaucoma", Alergic: "Penicilina, AINEs"},
+		{Version: 2, Family: "Glaucoma", Alergic: "Penicilina"},
+		{Version: 1, Family: "Glaucoma"},
+	}
+	mockRepo.EXPECT().GetPatientByID(1).Return(&models.Patient{ID: 1}, nil).AnyTimes()
+	mockRepo.EXPECT().ListAntecedentes(1).Return(versions, nil).AnyTimes()
+
+	// Current against the one before
+	diff, err := svc.DiffAntecedentes(1, 0, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if diff.From.Version != 2 || diff.To.Version != 3 || len(diff.Changes) != 1 {
+		t.Fatalf("unexpected diff: %+v", diff)
+	}
+	if change := diff.Changes["alergic"]; change.Before != "Penicilina" || change.After != "Penicilina, AINEs" {
+		t.Errorf("unexpected change: %+v", change)
+	}
+
+	// The first version is compared against nothing
+	diff, err = svc.DiffAntecedentes(1, 0, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if diff.From != nil || len(diff.Changes) != 1 || diff.Changes["family"].After != "Glaucoma" {
+		t.Errorf("unexpected diff of the first version: %+v", diff)
+	}
+
+	if _, err := svc.DiffAntecedentes(1, 3, 2); !errors.Is(err, ErrInvalidPatient) {
+		t.Errorf("expected invalid patient error for reversed versions, got %v", err)
+	}
+	if _, err := svc.DiffAntecedentes(1, 1, 9); !errors.Is(err, ErrAntecedentesNotFound) {
+		t.Errorf("expected antecedentes not found, got %v", err)
+	}
+}
+
+func TestUpdatePatient_SetsAntecedentesAuthor(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockPatientRepository(ctrl)
+	svc := NewPatientService(mockRepo, nil)
+	userID := 3
+
+	patient := models.Patient{ID: 1, Name: "Ana López", DateOfBirth: time.Date(1990, 5, 1, 0, 0, 0, 0, time.UTC), Sex: "F",
+		Antecedentes: &models.Antecedentes{Alergic: "Penicilina", Version: 8}}
+	mockRepo.EXPECT().
+		UpdatePatient(gomock.Any()).
+		DoAndReturn(func(p models.Patient) error {
+			if p.Antecedentes.UserID == nil || *p.Antecedentes.UserID != userID || p.Antecedentes.Version != 0 {
+				t.Errorf("expected a new version by user %d, got %+v", userID, p.Antecedentes)
+			}
+			return nil
+		})
+	mockRepo.EXPECT().GetPatientByID(1).Return(&patient, nil)
+
+	if _, err := svc.UpdatePatient(patient, &userID); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestUpdatePatient_AnonymousAntecedentesChange(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockPatientRepository(ctrl)
+	svc := NewPatientService(mockRepo, nil)
+
+	stored := models.Patient{ID: 1, Name: "Ana López", DateOfBirth: time.Date(1990, 5, 1, 0, 0, 0, 0, time.UTC), Sex: "F",
+		Antecedentes: &models.Antecedentes{Alergic: "Penicilina", Version: 2}}
+	mockRepo.EXPECT().GetPatientByID(1).Return(&stored, nil).AnyTimes()
+
+	// Changed antecedentes need an author
+	changed := stored
+	changed.Antecedentes = &models.Antecedentes{Alergic: "Penicilina, sulfas"}
+	if _, err := svc.UpdatePatient(changed, nil); !errors.Is(err, ErrInvalidPatient) {
+		t.Errorf("expected invalid patient error, got %v", err)
+	}
+
+	// Sending them back unchanged is fine
+	mockRepo.EXPECT().UpdatePatient(gomock.Any()).Return(nil)
+	if _, err := svc.UpdatePatient(stored, nil); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	// New patients can't be created with antecedentes anonymously
+	if _, err := svc.CreatePatient(models.Patient{Name: "Luis Pérez", DateOfBirth: time.Date(1985, 1, 1, 0, 0, 0, 0, time.UTC), Sex: "M",
+		Antecedentes: &models.Antecedentes{Medical: "Diabetes"}}, nil); !errors.Is(err, ErrInvalidPatient) {
+		t.Errorf("expected invalid patient error, got %v", err)
+	}
+}
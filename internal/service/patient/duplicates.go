@@ -0,0 +1,190 @@
+package patient
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"software-backend/internal/models"
+)
+
+// Candidate pairs fetched at a time when finding duplicates, every pair is scored
+const duplicateCandidatesPage = 5000
+
+// Weight of each match in a duplicate's score, they add up to 1
+const (
+	nameWeight  = 0.5
+	birthWeight = 0.3
+	phoneWeight = 0.2
+)
+
+// Names at least this similar are reported as a reason
+const similarNameThreshold = 0.8
+
+// Find patients that may be the same person, best matches first. Pairs share their
+// birth date, phone or name & are scored on how similar the rest is
+func (s *patientService) FindDuplicates(minScore float64, limit int) ([]models.DuplicateCandidate, error) {
+	// Basic input validation
+	if minScore < 0 || minScore > 1 {
+		return nil, fmt.Errorf("%w: min score must be between 0 & 1", ErrInvalidPatient)
+	}
+	if limit <= 0 {
+		limit = DefaultPageSize
+	}
+	if limit > MaxPageSize {
+		return nil, fmt.Errorf("%w: limit can't be over %d", ErrInvalidPatient, MaxPageSize)
+	}
+
+	// Page through every pair, keeping the ones that score high enough
+	duplicates := []models.DuplicateCandidate{}
+	afterID, afterDuplicateID := 0, 0
+	for {
+		candidates, err := s.patientRepo.ListDuplicateCandidates(afterID, afterDuplicateID, duplicateCandidatesPage)
+		if err != nil {
+			return nil, fmt.Errorf("service: failed to list duplicate candidates: %w", err)
+		}
+		for _, candidate := range candidates {
+			scoreDuplicate(&candidate)
+			if candidate.Score >= minScore {
+				duplicates = append(duplicates, candidate)
+			}
+		}
+		if len(candidates) < duplicateCandidatesPage {
+			break
+		}
+		last := candidates[len(candidates)-1]
+		afterID, afterDuplicateID = last.Patient.ID, last.Duplicate.ID
+	}
+	sort.SliceStable(duplicates, func(i, j int) bool {
+		return duplicates[i].Score > duplicates[j].Score
+	})
+	if len(duplicates) > limit {
+		duplicates = duplicates[:limit]
+	}
+	return duplicates, nil
+}
+
+// Merge a duplicate into a patient, the duplicate's appointments, visits, exams &
+// antecedentes are moved over & the duplicate is deleted
+func (s *patientService) MergePatients(patientID, duplicateID int, userID *int) (*models.PatientMerge, error) {
+	if patientID == duplicateID {
+		return nil, fmt.Errorf("%w: a patient can't be merged into itself", ErrInvalidPatient)
+	}
+	if _, err := s.patientRepo.GetPatientByID(patientID); err != nil {
+		return nil, err
+	}
+	// Kept as it was in the merge record
+	duplicate, err := s.patientRepo.GetPatientByID(duplicateID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.patientRepo.MergePatients(models.PatientMerge{
+		PatientID:       patientID,
+		MergedPatientID: duplicateID,
+		MergedPatient:   *duplicate,
+		UserID:          userID,
+	})
+}
+
+// List the patients merged into a patient
+func (s *patientService) ListMerges(patientID int) ([]models.PatientMerge, error) {
+	return s.patientRepo.ListMerges(patientID)
+}
+
+// Score a candidate pair & note what matched
+func scoreDuplicate(candidate *models.DuplicateCandidate) {
+	a, b := candidate.Patient, candidate.Duplicate
+	candidate.Reasons = []string{}
+
+	similarity := nameSimilarity(a.Name, b.Name)
+	candidate.Score = nameWeight * similarity
+	if similarity >= similarNameThreshold {
+		candidate.Reasons = append(candidate.Reasons, models.DuplicateSimilarName)
+	}
+	if !a.DateOfBirth.IsZero() && a.DateOfBirth.Format("2006-01-02") == b.DateOfBirth.Format("2006-01-02") {
+		candidate.Score += birthWeight
+		candidate.Reasons = append(candidate.Reasons, models.DuplicateSameBirth)
+	}
+	if samePhone(a.Phone, b.Phone) {
+		candidate.Score += phoneWeight
+		candidate.Reasons = append(candidate.Reasons, models.DuplicateSamePhone)
+	}
+	// Rounded so scores read well in the API
+	candidate.Score = float64(int(candidate.Score*100+0.5)) / 100
+}
+
+// How alike two names are from 0 to 1, ignoring case, accents & word order. A name
+// fully contained in the other, e.g. without the second surname, counts as alike
+func nameSimilarity(a, b string) float64 {
+	wordsA, wordsB := nameWords(a), nameWords(b)
+	if len(wordsA) == 0 || len(wordsB) == 0 {
+		return 0
+	}
+
+	// Edit distance over the words sorted
+	sortedA, sortedB := strings.Join(wordsA, " "), strings.Join(wordsB, " ")
+	longest := max(len([]rune(sortedA)), len([]rune(sortedB)))
+	similarity := 1 - float64(levenshtein(sortedA, sortedB))/float64(longest)
+
+	// Share of the shorter name's words found in the other
+	inB := map[string]bool{}
+	for _, w := range wordsB {
+		inB[w] = true
+	}
+	common := 0
+	for _, w := range wordsA {
+		if inB[w] {
+			common++
+		}
+	}
+	if len(wordsA) >= 2 && len(wordsB) >= 2 {
+		containment := float64(common) / float64(min(len(wordsA), len(wordsB)))
+		similarity = max(similarity, containment)
+	}
+	return similarity
+}
+
+// Lower-cased words of a name without accents, sorted
+func nameWords(name string) []string {
+	folded := strings.NewReplacer("á", "a", "é", "e", "í", "i", "ó", "o", "ú", "u", "ü", "u", "ñ", "n").
+		Replace(strings.ToLower(name))
+	words := strings.Fields(folded)
+	sort.Strings(words)
+	return words
+}
+
+// Edit distance between two strings in runes
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	previous := make([]int, len(rb)+1)
+	current := make([]int, len(rb)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		current[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(rb)]
+}
+
+// Whether two phones are the same number, local & international forms included
+func samePhone(a, b string) bool {
+	const localDigits = 8
+	a, b = strings.TrimPrefix(a, "+"), strings.TrimPrefix(b, "+")
+	if a == "" || b == "" {
+		return false
+	}
+	if len(a) >= localDigits && len(b) >= localDigits {
+		return a[len(a)-localDigits:] == b[len(b)-localDigits:]
+	}
+	return a == b
+}
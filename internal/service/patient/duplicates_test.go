@@ -0,0 +1,133 @@
+package patient
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"software-backend/internal/mocks"
+	"software-backend/internal/models"
+
+	"github.com/golang/mock/gomock"
+)
+
+func TestNameSimilarity(t *testing.T) {
+	cases := []struct {
+		a, b string
+		min  float64
+	}{
+		{"Ana López", "ana lopez", 1},
+		{"López Ana", "Ana López", 1},
+		{"Ana María López", "Ana López", 1},
+		{"Jose Perez", "José Pérez", 1},
+		{"Jorge Pérez", "Jorje Pérez", 0.9},
+	}
+	for _, c := range cases {
+		if got := nameSimilarity(c.a, c.b); got < c.min {
+			t.Errorf("nameSimilarity(%q, %q) = %.2f, expected at least %.2f", c.a, c.b, got, c.min)
+		}
+	}
+	if got := nameSimilarity("Ana López", "Carlos Méndez"); got > 0.5 {
+		t.Errorf("expected different names to score low, got %.2f", got)
+	}
+}
+
+func TestFindDuplicates_ScoresAndOrders(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockPatientRepository(ctrl)
+	svc := NewPatientService(mockRepo, nil)
+
+	birth := time.Date(1990, 5, 1, 0, 0, 0, 0, time.UTC)
+	mockRepo.EXPECT().
+		ListDuplicateCandidates(0, 0, duplicateCandidatesPage).
+		Return([]models.DuplicateCandidate{
+			// Same birth date only
+			{Patient: models.Patient{ID: 1, Name: "Ana López", DateOfBirth: birth}, Duplicate: models.Patient{ID: 2, Name: "Carlos Méndez", DateOfBirth: birth}},
+			// Same person, phone typed with the country code
+			{Patient: models.Patient{ID: 1, Name: "Ana López", DateOfBirth: birth, Phone: "12345678"}, Duplicate: models.Patient{ID: 3, Name: "ana lopez", DateOfBirth: birth, Phone: "+50212345678"}},
+			// Same name, nothing else
+			{Patient: models.Patient{ID: 4, Name: "Ana López", DateOfBirth: birth}, Duplicate: models.Patient{ID: 5, Name: "Ana López", DateOfBirth: birth.AddDate(-30, 0, 0)}},
+		}, nil)
+
+	duplicates, err := svc.FindDuplicates(0.5, 10)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(duplicates) != 2 {
+		t.Fatalf("expected 2 duplicates, got %+v", duplicates)
+	}
+	if duplicates[0].Duplicate.ID != 3 || duplicates[0].Score != 1 || len(duplicates[0].Reasons) != 3 {
+		t.Errorf("unexpected best duplicate: %+v", duplicates[0])
+	}
+	if duplicates[1].Duplicate.ID != 5 || duplicates[1].Score != 0.5 {
+		t.Errorf("unexpected second duplicate: %+v", duplicates[1])
+	}
+}
+
+func TestFindDuplicates_PagesThroughEveryPair(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockPatientRepository(ctrl)
+	svc := NewPatientService(mockRepo, nil)
+
+	// A full page of pairs that only share a birth date
+	birth := time.Date(1990, 5, 1, 0, 0, 0, 0, time.UTC)
+	page := make([]models.DuplicateCandidate, duplicateCandidatesPage)
+	for i := range page {
+		page[i] = models.DuplicateCandidate{
+			Patient:   models.Patient{ID: 1, Name: "Ana López", DateOfBirth: birth},
+			Duplicate: models.Patient{ID: i + 2, Name: "Carlos Méndez", DateOfBirth: birth},
+		}
+	}
+	lastID := duplicateCandidatesPage + 1
+	gomock.InOrder(
+		mockRepo.EXPECT().ListDuplicateCandidates(0, 0, duplicateCandidatesPage).Return(page, nil),
+		// The best match comes after the first page
+		mockRepo.EXPECT().ListDuplicateCandidates(1, lastID, duplicateCandidatesPage).Return([]models.DuplicateCandidate{
+			{Patient: models.Patient{ID: 2, Name: "Ana López", DateOfBirth: birth}, Duplicate: models.Patient{ID: lastID + 1, Name: "Ana Lopez", DateOfBirth: birth}},
+		}, nil),
+	)
+
+	duplicates, err := svc.FindDuplicates(0.5, 10)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(duplicates) != 1 || duplicates[0].Duplicate.ID != lastID+1 {
+		t.Errorf("expected the pair past the first page, got %+v", duplicates)
+	}
+}
+
+func TestMergePatients(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockPatientRepository(ctrl)
+	svc := NewPatientService(mockRepo, nil)
+
+	if _, err := svc.MergePatients(1, 1, nil); !errors.Is(err, ErrInvalidPatient) {
+		t.Errorf("expected invalid patient error, got %v", err)
+	}
+
+	userID := 3
+	duplicate := &models.Patient{ID: 2, Name: "ana lopez"}
+	mockRepo.EXPECT().GetPatientByID(1).Return(&models.Patient{ID: 1, Name: "Ana López"}, nil)
+	mockRepo.EXPECT().GetPatientByID(2).Return(duplicate, nil)
+	mockRepo.EXPECT().
+		MergePatients(models.PatientMerge{PatientID: 1, MergedPatientID: 2, MergedPatient: *duplicate, UserID: &userID}).
+		DoAndReturn(func(merge models.PatientMerge) (*models.PatientMerge, error) {
+			merge.ID = 9
+			merge.Moved = map[string]int{"citas": 2}
+			return &merge, nil
+		})
+
+	merge, err := svc.MergePatients(1, 2, &userID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if merge.ID != 9 || merge.Moved["citas"] != 2 || merge.MergedPatient.Name != "ana lopez" {
+		t.Errorf("unexpected merge: %+v", merge)
+	}
+}
@@ -0,0 +1,53 @@
+package patient
+
+import (
+	"fmt"
+
+	"software-backend/internal/clinic"
+	"software-backend/internal/models"
+)
+
+// Rewrite every stored phone to E.164, phones that can't be parsed are left as they
+// are & reported. Nothing is written on a dry run
+func (s *patientService) NormalizePhones(dryRun bool) (*models.PhoneNormalizationReport, error) {
+	patients, err := s.patientRepo.ListPatientPhones()
+	if err != nil {
+		return nil, fmt.Errorf("service: failed to list patient phones: %w", err)
+	}
+
+	report := &models.PhoneNormalizationReport{
+		DryRun:      dryRun,
+		Checked:     len(patients),
+		Changed:     []models.PhoneChange{},
+		Unparseable: []models.PhoneProblem{},
+	}
+	for _, patient := range patients {
+		phone, err := clinic.NormalizePhone(patient.Phone)
+		switch {
+		case err != nil:
+			report.Unparseable = append(report.Unparseable, models.PhoneProblem{
+				PatientID: patient.ID,
+				Name:      patient.Name,
+				Phone:     patient.Phone,
+				Error:     err.Error(),
+			})
+		case phone == patient.Phone:
+			report.Unchanged++
+		default:
+			report.Changed = append(report.Changed, models.PhoneChange{
+				PatientID: patient.ID,
+				Name:      patient.Name,
+				Before:    patient.Phone,
+				After:     phone,
+			})
+		}
+	}
+
+	if dryRun || len(report.Changed) == 0 {
+		return report, nil
+	}
+	if _, err := s.patientRepo.UpdatePatientPhones(report.Changed); err != nil {
+		return nil, fmt.Errorf("service: failed to update patient phones: %w", err)
+	}
+	return report, nil
+}
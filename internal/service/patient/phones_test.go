@@ -0,0 +1,49 @@
+package patient
+
+import (
+	"testing"
+
+	"software-backend/internal/clinic"
+	"software-backend/internal/mocks"
+	"software-backend/internal/models"
+
+	"github.com/golang/mock/gomock"
+)
+
+func TestNormalizePhones(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	if err := clinic.SetCountryCode("502"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer clinic.SetCountryCode("")
+
+	mockRepo := mocks.NewMockPatientRepository(ctrl)
+	svc := NewPatientService(mockRepo, nil)
+
+	patients := []models.Patient{
+		{ID: 1, Name: "Ana López", Phone: "5555-1234"},
+		{ID: 2, Name: "Luis Pérez", Phone: "+50255554321"},
+		{ID: 3, Name: "Carla Méndez", Phone: "ext. 12"},
+	}
+	mockRepo.EXPECT().ListPatientPhones().Return(patients, nil).Times(2)
+
+	// A dry run only reports
+	report, err := svc.NormalizePhones(true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if report.Checked != 3 || report.Unchanged != 1 || len(report.Changed) != 1 || len(report.Unparseable) != 1 {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+	if report.Changed[0].After != "+50255551234" || report.Unparseable[0].PatientID != 3 {
+		t.Errorf("unexpected report: %+v", report)
+	}
+
+	// Otherwise the changes are written
+	mockRepo.EXPECT().UpdatePatientPhones(report.Changed).Return(1, nil)
+	if _, err := svc.NormalizePhones(false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
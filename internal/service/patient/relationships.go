@@ -0,0 +1,76 @@
+package patient
+
+import (
+	"fmt"
+
+	"software-backend/internal/models"
+)
+
+// Kinds of relationships patients can have
+var relationTypes = map[string]bool{
+	models.RelationGuardian:         true,
+	models.RelationParent:           true,
+	models.RelationSpouse:           true,
+	models.RelationEmergencyContact: true,
+}
+
+// List the patients related to a patient, the notification contact first
+func (s *patientService) ListRelationships(patientID int) ([]models.PatientRelationship, error) {
+	if _, err := s.patientRepo.GetPatientByID(patientID); err != nil {
+		return nil, err
+	}
+	return s.patientRepo.ListRelationships(patientID)
+}
+
+// Relate a patient to another, e.g. a child to their guardian
+func (s *patientService) CreateRelationship(relationship models.PatientRelationship) (*models.PatientRelationship, error) {
+	// Basic input validation
+	if !relationTypes[relationship.Type] {
+		return nil, fmt.Errorf("%w: relationship type must be one of %s, %s, %s or %s", ErrInvalidPatient,
+			models.RelationGuardian, models.RelationParent, models.RelationSpouse, models.RelationEmergencyContact)
+	}
+	if relationship.RelatedPatientID <= 0 {
+		return nil, fmt.Errorf("%w: related patient is required", ErrInvalidPatient)
+	}
+	if relationship.RelatedPatientID == relationship.PatientID {
+		return nil, fmt.Errorf("%w: a patient can't be related to themselves", ErrInvalidPatient)
+	}
+
+	if _, err := s.patientRepo.GetPatientByID(relationship.PatientID); err != nil {
+		return nil, err
+	}
+	related, err := s.patientRepo.GetPatientByID(relationship.RelatedPatientID)
+	if err != nil {
+		return nil, fmt.Errorf("related patient: %w", err)
+	}
+
+	created, err := s.patientRepo.CreateRelationship(relationship)
+	if err != nil {
+		return nil, err
+	}
+	created.RelatedPatient = &models.Patient{
+		ID:          related.ID,
+		Name:        related.Name,
+		DateOfBirth: related.DateOfBirth,
+		Phone:       related.Phone,
+		Sex:         related.Sex,
+	}
+	return created, nil
+}
+
+// Remove one of a patient's relationships
+func (s *patientService) DeleteRelationship(patientID, relationshipID int) error {
+	return s.patientRepo.DeleteRelationship(patientID, relationshipID)
+}
+
+// Choose which relationship notifications about a patient go to, nil to go back to
+// the patient or, for minors & patients without a phone, their guardian
+func (s *patientService) SetNotificationContact(patientID int, relationshipID *int) ([]models.PatientRelationship, error) {
+	if _, err := s.patientRepo.GetPatientByID(patientID); err != nil {
+		return nil, err
+	}
+	if err := s.patientRepo.SetNotificationContact(patientID, relationshipID); err != nil {
+		return nil, err
+	}
+	return s.patientRepo.ListRelationships(patientID)
+}
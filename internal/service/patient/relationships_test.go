@@ -0,0 +1,66 @@
+package patient
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"software-backend/internal/mocks"
+	"software-backend/internal/models"
+
+	"github.com/golang/mock/gomock"
+)
+
+func TestCreateRelationship(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockPatientRepository(ctrl)
+	svc := NewPatientService(mockRepo, nil)
+
+	invalid := map[string]models.PatientRelationship{
+		"unknown type":    {PatientID: 1, RelatedPatientID: 2, Type: "cousin"},
+		"missing related": {PatientID: 1, Type: models.RelationGuardian},
+		"self":            {PatientID: 1, RelatedPatientID: 1, Type: models.RelationGuardian},
+	}
+	for name, relationship := range invalid {
+		if _, err := svc.CreateRelationship(relationship); !errors.Is(err, ErrInvalidPatient) {
+			t.Errorf("%s: expected invalid patient error, got %v", name, err)
+		}
+	}
+
+	mockRepo.EXPECT().GetPatientByID(1).Return(&models.Patient{ID: 1, Name: "Sofía López"}, nil)
+	mockRepo.EXPECT().GetPatientByID(2).Return(&models.Patient{ID: 2, Name: "Ana López", Phone: "+50255551234"}, nil)
+	mockRepo.EXPECT().
+		CreateRelationship(gomock.Any()).
+		DoAndReturn(func(r models.PatientRelationship) (*models.PatientRelationship, error) {
+			r.ID = 4
+			return &r, nil
+		})
+
+	created, err := svc.CreateRelationship(models.PatientRelationship{PatientID: 1, RelatedPatientID: 2, Type: models.RelationGuardian, NotificationContact: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if created.ID != 4 || created.RelatedPatient == nil || created.RelatedPatient.Phone != "+50255551234" {
+		t.Errorf("unexpected relationship: %+v", created)
+	}
+}
+
+func TestPatient_IsMinor(t *testing.T) {
+	now := time.Date(2024, 7, 18, 12, 0, 0, 0, time.UTC)
+	cases := map[string]struct {
+		birth time.Time
+		minor bool
+	}{
+		"seventeen":        {time.Date(2006, 7, 19, 0, 0, 0, 0, time.UTC), true},
+		"eighteen today":   {time.Date(2006, 7, 18, 0, 0, 0, 0, time.UTC), false},
+		"adult":            {time.Date(1990, 5, 1, 0, 0, 0, 0, time.UTC), false},
+		"no date of birth": {time.Time{}, false},
+	}
+	for name, c := range cases {
+		if got := (models.Patient{DateOfBirth: c.birth}).IsMinor(now); got != c.minor {
+			t.Errorf("%s: expected minor to be %v, got %v", name, c.minor, got)
+		}
+	}
+}
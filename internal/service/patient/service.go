@@ -1,29 +1,88 @@
 package patient
 
 import (
+	"errors"
 	"fmt"
+	"regexp"
+	"strings"
+	"time"
 
+	"software-backend/internal/clinic"
 	"software-backend/internal/models"
 	repository "software-backend/internal/repository/patient"
+	"software-backend/internal/service/appointment"
 )
 
-// TODO custom errors for service
+// Custom errors, probably moved onto separate file in the future
+var (
+	ErrInvalidPatient       = errors.New("invalid patient data")
+	ErrAntecedentesNotFound = errors.New("antecedentes version not found")
+)
+
+// Page sizes for listing patients
+const (
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+)
+
+// Sex values accepted from clients & how they're stored
+var sexValues = map[string]string{
+	"m":         models.SexMale,
+	"male":      models.SexMale,
+	"masculino": models.SexMale,
+	"hombre":    models.SexMale,
+	"f":         models.SexFemale,
+	"female":    models.SexFemale,
+	"femenino":  models.SexFemale,
+	"mujer":     models.SexFemale,
+	"o":         models.SexOther,
+	"other":     models.SexOther,
+	"otro":      models.SexOther,
+}
+
+// Searches made only of at least this many digits look for a phone
+var phoneSearchPattern = regexp.MustCompile(`^[0-9]{4,15}$`)
+
+// Birth dates accepted in searches
+var birthDateLayouts = []string{"2006-01-02", "02/01/2006", "2/1/2006", "02-01-2006"}
+
+// Words of a name search looked at, the rest are ignored
+const maxSearchWords = 6
 
 // Interface PatientService defines methods expected from the service
 type PatientService interface {
 	GetPatientByID(patientID int) (*models.Patient, error)
 	SearchPatients(query string, limit int) ([]models.Patient, error)
+	ListPatients(limit, offset int) (*models.PatientList, error)
+	CreatePatient(patient models.Patient, userID *int) (*models.Patient, error)
+	UpdatePatient(patient models.Patient, userID *int) (*models.Patient, error)
+	DeletePatient(patientID int) error
+	FindDuplicates(minScore float64, limit int) ([]models.DuplicateCandidate, error)
+	MergePatients(patientID, duplicateID int, userID *int) (*models.PatientMerge, error)
+	ListMerges(patientID int) ([]models.PatientMerge, error)
+	ListAntecedentes(patientID int) ([]models.Antecedentes, error)
+	GetAntecedentesVersion(patientID, version int) (*models.Antecedentes, error)
+	DiffAntecedentes(patientID, from, to int) (*models.AntecedentesDiff, error)
+	NormalizePhones(dryRun bool) (*models.PhoneNormalizationReport, error)
+	ListRelationships(patientID int) ([]models.PatientRelationship, error)
+	CreateRelationship(relationship models.PatientRelationship) (*models.PatientRelationship, error)
+	DeleteRelationship(patientID, relationshipID int) error
+	SetNotificationContact(patientID int, relationshipID *int) ([]models.PatientRelationship, error)
 }
 
 // Struct to manage dependencies
 type patientService struct {
-	patientRepo repository.PatientRepository
+	patientRepo        repository.PatientRepository
+	appointmentService appointment.AppointmentService
+	now                func() time.Time
 }
 
 // Constructor to pass on dependencies
-func NewPatientService(patientRepo repository.PatientRepository) PatientService {
+func NewPatientService(patientRepo repository.PatientRepository, apptService appointment.AppointmentService) PatientService {
 	return &patientService{
-		patientRepo: patientRepo,
+		patientRepo:        patientRepo,
+		appointmentService: apptService,
+		now:                time.Now,
 	}
 }
 
@@ -36,13 +95,135 @@ func (s *patientService) GetPatientByID(patientID int) (*models.Patient, error)
 		return nil, fmt.Errorf("service: failed to get patient by ID %d from repository: %w", patientID, err)
 	}
 
+	// Attendance is part of the patient record
+	patient.Attendance, err = s.appointmentService.GetAttendanceStats(patientID)
+	if err != nil {
+		return nil, fmt.Errorf("service: failed to get attendance of patient %d: %w", patientID, err)
+	}
+
 	return patient, nil
 }
 
-// Fuzzy-search patient names & return patients that match
+// Search patients by birth date, phone or name depending on what the query looks like,
+// best matches first
 func (s *patientService) SearchPatients(query string, limit int) ([]models.Patient, error) {
+	query = strings.TrimSpace(query)
 	if len(query) < 2 {
 		return nil, fmt.Errorf("query too short")
 	}
-	return s.patientRepo.SearchPatients(query, limit)
+
+	// Birth date, typed either way
+	for _, layout := range birthDateLayouts {
+		if date, err := time.Parse(layout, query); err == nil {
+			return s.patientRepo.SearchPatientsByBirthDate(date, limit)
+		}
+	}
+
+	// Phone, part of the number is enough
+	if digits := strings.TrimPrefix(stripPhoneSeparators(query), "+"); phoneSearchPattern.MatchString(digits) {
+		return s.patientRepo.SearchPatientsByPhone(digits, limit)
+	}
+
+	// Name, words in any order
+	words := strings.Fields(query)
+	if len(words) > maxSearchWords {
+		words = words[:maxSearchWords]
+	}
+	return s.patientRepo.SearchPatientsByName(words, limit)
+}
+
+// List a page of patients ordered by name
+func (s *patientService) ListPatients(limit, offset int) (*models.PatientList, error) {
+	// Basic input validation
+	if limit <= 0 {
+		limit = DefaultPageSize
+	}
+	if limit > MaxPageSize {
+		return nil, fmt.Errorf("%w: limit can't be over %d", ErrInvalidPatient, MaxPageSize)
+	}
+	if offset < 0 {
+		return nil, fmt.Errorf("%w: offset can't be negative", ErrInvalidPatient)
+	}
+
+	patients, total, err := s.patientRepo.ListPatients(limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("service: failed to list patients: %w", err)
+	}
+	return &models.PatientList{Patients: patients, Total: total, Limit: limit, Offset: offset}, nil
+}
+
+// Create a patient, optionally with their antecedentes written by the given user
+func (s *patientService) CreatePatient(patient models.Patient, userID *int) (*models.Patient, error) {
+	if err := s.validatePatient(&patient); err != nil {
+		return nil, err
+	}
+	if err := s.checkAntecedentesAuthor(0, patient.Antecedentes, userID); err != nil {
+		return nil, err
+	}
+	setAntecedentesAuthor(&patient, userID)
+	return s.patientRepo.CreatePatient(patient)
+}
+
+// Update a patient, antecedentes are left as they were unless given, in which case
+// they're added as a new version written by the given user
+func (s *patientService) UpdatePatient(patient models.Patient, userID *int) (*models.Patient, error) {
+	if err := s.validatePatient(&patient); err != nil {
+		return nil, err
+	}
+	if err := s.checkAntecedentesAuthor(patient.ID, patient.Antecedentes, userID); err != nil {
+		return nil, err
+	}
+	setAntecedentesAuthor(&patient, userID)
+	if err := s.patientRepo.UpdatePatient(patient); err != nil {
+		return nil, err
+	}
+	return s.patientRepo.GetPatientByID(patient.ID)
+}
+
+// Delete a patient, refused while they still have appointments or records
+func (s *patientService) DeletePatient(patientID int) error {
+	return s.patientRepo.DeletePatient(patientID)
+}
+
+// Basic input validation, normalizes the name, sex & phone on the way
+func (s *patientService) validatePatient(patient *models.Patient) error {
+	patient.Name = strings.Join(strings.Fields(patient.Name), " ")
+	if patient.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidPatient)
+	}
+
+	if patient.DateOfBirth.IsZero() {
+		return fmt.Errorf("%w: date of birth is required", ErrInvalidPatient)
+	}
+	if clinic.StartOfDay(patient.DateOfBirth).After(clinic.StartOfDay(s.now())) {
+		return fmt.Errorf("%w: date of birth can't be in the future", ErrInvalidPatient)
+	}
+
+	sex, ok := sexValues[strings.ToLower(strings.TrimSpace(patient.Sex))]
+	if !ok {
+		return fmt.Errorf("%w: sex must be one of %s, %s or %s", ErrInvalidPatient, models.SexMale, models.SexFemale, models.SexOther)
+	}
+	patient.Sex = sex
+
+	// Phone is optional, stored as E.164 with the clinic's country code when it has none
+	if strings.TrimSpace(patient.Phone) == "" {
+		patient.Phone = ""
+		return nil
+	}
+	phone, err := clinic.NormalizePhone(patient.Phone)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidPatient, err)
+	}
+	patient.Phone = phone
+	return nil
+}
+
+// Drop the separators people usually type in phone numbers
+func stripPhoneSeparators(phone string) string {
+	return strings.Map(func(r rune) rune {
+		if strings.ContainsRune(" -().", r) {
+			return -1
+		}
+		return r
+	}, phone)
 }
@@ -0,0 +1,100 @@
+package patient
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"software-backend/internal/mocks"
+	"software-backend/internal/models"
+
+	"github.com/golang/mock/gomock"
+)
+
+func TestCreatePatient_Validation(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockPatientRepository(ctrl)
+	svc := NewPatientService(mockRepo, nil).(*patientService)
+	svc.now = func() time.Time { return time.Date(2024, 7, 18, 12, 0, 0, 0, time.UTC) }
+
+	valid := models.Patient{Name: "Ana López", DateOfBirth: time.Date(1990, 5, 1, 0, 0, 0, 0, time.UTC), Sex: "F"}
+	invalid := map[string]func(p *models.Patient){
+		"missing name":        func(p *models.Patient) { p.Name = "  " },
+		"missing birth date":  func(p *models.Patient) { p.DateOfBirth = time.Time{} },
+		"birth in the future": func(p *models.Patient) { p.DateOfBirth = time.Date(2024, 7, 19, 0, 0, 0, 0, time.UTC) },
+		"unknown sex":         func(p *models.Patient) { p.Sex = "x" },
+		"invalid phone":       func(p *models.Patient) { p.Phone = "12-ab" },
+	}
+	for name, change := range invalid {
+		patient := valid
+		change(&patient)
+		if _, err := svc.CreatePatient(patient, nil); !errors.Is(err, ErrInvalidPatient) {
+			t.Errorf("%s: expected invalid patient error, got %v", name, err)
+		}
+	}
+
+	// Name, sex & phone are normalized
+	patient := valid
+	patient.Name = "  Ana   López "
+	patient.Sex = "Femenino"
+	patient.Phone = "+502 1234-5678"
+	mockRepo.EXPECT().
+		CreatePatient(gomock.Any()).
+		DoAndReturn(func(p models.Patient) (*models.Patient, error) {
+			p.ID = 1
+			return &p, nil
+		})
+	created, err := svc.CreatePatient(patient, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if created.Name != "Ana López" || created.Sex != models.SexFemale || created.Phone != "+50212345678" {
+		t.Errorf("unexpected normalized patient: %+v", created)
+	}
+}
+
+func TestListPatients_Paging(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockPatientRepository(ctrl)
+	svc := NewPatientService(mockRepo, nil)
+
+	mockRepo.EXPECT().ListPatients(DefaultPageSize, 40).Return([]models.Patient{{ID: 1}}, 41, nil)
+	list, err := svc.ListPatients(0, 40)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if list.Total != 41 || list.Limit != DefaultPageSize || len(list.Patients) != 1 {
+		t.Errorf("unexpected list: %+v", list)
+	}
+
+	if _, err := svc.ListPatients(MaxPageSize+1, 0); !errors.Is(err, ErrInvalidPatient) {
+		t.Errorf("expected invalid patient error, got %v", err)
+	}
+}
+
+func TestSearchPatients_DetectsQueryKind(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockPatientRepository(ctrl)
+	svc := NewPatientService(mockRepo, nil)
+
+	birth := time.Date(1990, 5, 1, 0, 0, 0, 0, time.UTC)
+	mockRepo.EXPECT().SearchPatientsByBirthDate(birth, 10).Return(nil, nil).Times(2)
+	mockRepo.EXPECT().SearchPatientsByPhone("12345678", 10).Return(nil, nil)
+	mockRepo.EXPECT().SearchPatientsByPhone("5678", 10).Return(nil, nil)
+	mockRepo.EXPECT().SearchPatientsByName([]string{"Perez", "Juan"}, 10).Return(nil, nil)
+
+	for _, query := range []string{"1990-05-01", "01/05/1990", "+1234-5678", " 5678 ", "Perez  Juan"} {
+		if _, err := svc.SearchPatients(query, 10); err != nil {
+			t.Errorf("%q: unexpected error: %v", query, err)
+		}
+	}
+	if _, err := svc.SearchPatients(" a ", 10); err == nil {
+		t.Errorf("expected short query to be refused")
+	}
+}
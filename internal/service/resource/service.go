@@ -0,0 +1,121 @@
+package resource
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"software-backend/internal/models"
+	"software-backend/internal/repository/resource"
+)
+
+// Custom errors, probably moved onto separate file in the future
+var ErrInvalidResource = errors.New("invalid resource data")
+
+// Interface defines methods expected from the service
+type ResourceService interface {
+	ListProviders(onlyActive bool) ([]models.Provider, error)
+	GetProvider(id int) (*models.Provider, error)
+	CreateProvider(provider models.Provider) (*models.Provider, error)
+	UpdateProvider(provider models.Provider) error
+	ListRooms(onlyActive bool) ([]models.Room, error)
+	GetRoom(id int) (*models.Room, error)
+	CreateRoom(room models.Room) (*models.Room, error)
+	UpdateRoom(room models.Room) error
+}
+
+// Struct to manage dependencies
+type resourceService struct {
+	repo resource.ResourceRepository
+}
+
+// Constructor to pass on dependencies
+func NewResourceService(repo resource.ResourceRepository) ResourceService {
+	return &resourceService{repo: repo}
+}
+
+// List providers, optionally only the active ones
+func (s *resourceService) ListProviders(onlyActive bool) ([]models.Provider, error) {
+	return s.repo.ListProviders(onlyActive)
+}
+
+// Get a provider by ID
+func (s *resourceService) GetProvider(id int) (*models.Provider, error) {
+	return s.repo.GetProviderByID(id)
+}
+
+// Create a provider, new providers are always active
+func (s *resourceService) CreateProvider(provider models.Provider) (*models.Provider, error) {
+	if err := validateProvider(&provider); err != nil {
+		return nil, err
+	}
+	provider.Active = true
+	return s.repo.CreateProvider(provider)
+}
+
+// Update a provider given the new values including ID
+func (s *resourceService) UpdateProvider(provider models.Provider) error {
+	if err := validateProvider(&provider); err != nil {
+		return err
+	}
+	return s.repo.UpdateProvider(provider)
+}
+
+// List rooms, optionally only the active ones
+func (s *resourceService) ListRooms(onlyActive bool) ([]models.Room, error) {
+	return s.repo.ListRooms(onlyActive)
+}
+
+// Get a room by ID
+func (s *resourceService) GetRoom(id int) (*models.Room, error) {
+	return s.repo.GetRoomByID(id)
+}
+
+// Create a room, new rooms are always active
+func (s *resourceService) CreateRoom(room models.Room) (*models.Room, error) {
+	if err := validateRoom(&room); err != nil {
+		return nil, err
+	}
+	room.Active = true
+	return s.repo.CreateRoom(room)
+}
+
+// Update a room given the new values including ID
+func (s *resourceService) UpdateRoom(room models.Room) error {
+	if err := validateRoom(&room); err != nil {
+		return err
+	}
+	return s.repo.UpdateRoom(room)
+}
+
+// Basic input validation, the kind defaults to doctor
+func validateProvider(provider *models.Provider) error {
+	provider.Name = strings.TrimSpace(provider.Name)
+	if provider.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidResource)
+	}
+	switch provider.Kind {
+	case "":
+		provider.Kind = models.ProviderDoctor
+	case models.ProviderDoctor, models.ProviderTechnician:
+	default:
+		return fmt.Errorf("%w: unknown provider kind %q", ErrInvalidResource, provider.Kind)
+	}
+	return nil
+}
+
+// Basic input validation, the kind defaults to room
+func validateRoom(room *models.Room) error {
+	room.Name = strings.TrimSpace(room.Name)
+	if room.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidResource)
+	}
+	switch room.Kind {
+	case "":
+		room.Kind = models.RoomKindRoom
+	case models.RoomKindRoom, models.RoomKindEquipment:
+	default:
+		return fmt.Errorf("%w: unknown room kind %q", ErrInvalidResource, room.Kind)
+	}
+	return nil
+}
@@ -0,0 +1,144 @@
+package timeline
+
+import (
+	"errors"
+	"fmt"
+
+	"software-backend/internal/models"
+	"software-backend/internal/repository/appointment"
+	"software-backend/internal/repository/patient"
+	"software-backend/internal/repository/timeline"
+)
+
+// Custom errors, probably moved onto separate file in the future
+var ErrInvalidTimelineFilter = errors.New("invalid timeline filter")
+
+// Page sizes for timelines
+const (
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+)
+
+// Event types that can be asked for
+var eventTypes = map[string]bool{
+	models.TimelineAppointment:  true,
+	models.TimelineConsultation: true,
+	models.TimelineExam:         true,
+	models.TimelineNotification: true,
+}
+
+// Interface defines methods expected from the service
+type TimelineService interface {
+	GetPatientTimeline(patientID int, filter models.TimelineFilter) (*models.Timeline, error)
+}
+
+// Struct to manage dependencies
+type timelineService struct {
+	repo        timeline.TimelineRepository
+	apptRepo    appointment.AppointmentRepository
+	patientRepo patient.PatientRepository
+}
+
+// Constructor to pass on dependencies
+func NewTimelineService(repo timeline.TimelineRepository, apptRepo appointment.AppointmentRepository, patientRepo patient.PatientRepository) TimelineService {
+	return &timelineService{repo: repo, apptRepo: apptRepo, patientRepo: patientRepo}
+}
+
+// Get a page of everything that happened to a patient, latest first. Each event comes
+// with its details, loaded a type at a time for the whole page
+func (s *timelineService) GetPatientTimeline(patientID int, filter models.TimelineFilter) (*models.Timeline, error) {
+	// Basic input validation
+	for _, t := range filter.Types {
+		if !eventTypes[t] {
+			return nil, fmt.Errorf("%w: unknown event type %q", ErrInvalidTimelineFilter, t)
+		}
+	}
+	if filter.From != nil && filter.To != nil && !filter.From.Before(*filter.To) {
+		return nil, fmt.Errorf("%w: 'from' must be before 'to'", ErrInvalidTimelineFilter)
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = DefaultPageSize
+	}
+	if filter.Limit > MaxPageSize {
+		return nil, fmt.Errorf("%w: limit can't be over %d", ErrInvalidTimelineFilter, MaxPageSize)
+	}
+	if filter.Offset < 0 {
+		return nil, fmt.Errorf("%w: offset can't be negative", ErrInvalidTimelineFilter)
+	}
+	if _, err := s.patientRepo.GetPatientByID(patientID); err != nil {
+		return nil, err
+	}
+
+	events, total, err := s.repo.ListEvents(patientID, filter)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.loadDetails(events); err != nil {
+		return nil, err
+	}
+	return &models.Timeline{Events: events, Total: total, Limit: filter.Limit, Offset: filter.Offset}, nil
+}
+
+// Fill in the details of each event, one query per type
+func (s *timelineService) loadDetails(events []models.TimelineEvent) error {
+	// Where each event is, by type & ID
+	type eventKey struct {
+		eventType string
+		id        int
+	}
+	ids := map[string][]int{}
+	positions := map[eventKey]*models.TimelineEvent{}
+	for i, event := range events {
+		ids[event.Type] = append(ids[event.Type], event.ID)
+		positions[eventKey{event.Type, event.ID}] = &events[i]
+	}
+	index := func(eventType string, id int) *models.TimelineEvent {
+		return positions[eventKey{eventType, id}]
+	}
+
+	if len(ids[models.TimelineAppointment]) > 0 {
+		appointments, err := s.apptRepo.ListAppointmentsByIDs(ids[models.TimelineAppointment])
+		if err != nil {
+			return err
+		}
+		for i := range appointments {
+			if event := index(models.TimelineAppointment, appointments[i].ID); event != nil {
+				event.Appointment = &appointments[i]
+			}
+		}
+	}
+	if len(ids[models.TimelineConsultation]) > 0 {
+		consultations, err := s.repo.ListConsultations(ids[models.TimelineConsultation])
+		if err != nil {
+			return err
+		}
+		for i := range consultations {
+			if event := index(models.TimelineConsultation, consultations[i].ID); event != nil {
+				event.Consultation = &consultations[i]
+			}
+		}
+	}
+	if len(ids[models.TimelineExam]) > 0 {
+		exams, err := s.repo.ListExams(ids[models.TimelineExam])
+		if err != nil {
+			return err
+		}
+		for i := range exams {
+			if event := index(models.TimelineExam, exams[i].ID); event != nil {
+				event.Exam = &exams[i]
+			}
+		}
+	}
+	if len(ids[models.TimelineNotification]) > 0 {
+		notifications, err := s.repo.ListNotifications(ids[models.TimelineNotification])
+		if err != nil {
+			return err
+		}
+		for i := range notifications {
+			if event := index(models.TimelineNotification, notifications[i].ID); event != nil {
+				event.Notification = &notifications[i]
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package timeline
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"software-backend/internal/mocks"
+	"software-backend/internal/models"
+
+	"github.com/golang/mock/gomock"
+)
+
+func TestGetPatientTimeline_LoadsDetails(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockTimelineRepository(ctrl)
+	mockApptRepo := mocks.NewMockAppointmentRepository(ctrl)
+	mockPatientRepo := mocks.NewMockPatientRepository(ctrl)
+	svc := NewTimelineService(mockRepo, mockApptRepo, mockPatientRepo)
+
+	day := time.Date(2024, 7, 18, 10, 0, 0, 0, time.UTC)
+	events := []models.TimelineEvent{
+		{Type: models.TimelineExam, ID: 7, Date: day},
+		{Type: models.TimelineAppointment, ID: 3, Date: day.Add(-time.Hour)},
+		{Type: models.TimelineConsultation, ID: 5, Date: day.Add(-2 * time.Hour)},
+	}
+
+	mockPatientRepo.EXPECT().GetPatientByID(1).Return(&models.Patient{ID: 1}, nil)
+	mockRepo.EXPECT().
+		ListEvents(1, gomock.Any()).
+		DoAndReturn(func(_ int, filter models.TimelineFilter) ([]models.TimelineEvent, int, error) {
+			if filter.Limit != DefaultPageSize {
+				t.Errorf("expected default page size, got %d", filter.Limit)
+			}
+			return events, 3, nil
+		})
+	mockApptRepo.EXPECT().ListAppointmentsByIDs([]int{3}).Return([]models.Appointment{{ID: 3}}, nil)
+	mockRepo.EXPECT().ListConsultations([]int{5}).Return([]models.ConsultationWithDetails{{Consultation: models.Consultation{ID: 5}}}, nil)
+	mockRepo.EXPECT().ListExams([]int{7}).Return([]models.Exam{{ID: 7}}, nil)
+
+	timeline, err := svc.GetPatientTimeline(1, models.TimelineFilter{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if timeline.Total != 3 || len(timeline.Events) != 3 {
+		t.Fatalf("unexpected timeline: %+v", timeline)
+	}
+	if timeline.Events[0].Exam == nil || timeline.Events[1].Appointment == nil || timeline.Events[2].Consultation == nil {
+		t.Errorf("expected every event to have its details, got %+v", timeline.Events)
+	}
+}
+
+func TestGetPatientTimeline_InvalidFilter(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	svc := NewTimelineService(mocks.NewMockTimelineRepository(ctrl), mocks.NewMockAppointmentRepository(ctrl), mocks.NewMockPatientRepository(ctrl))
+
+	from := time.Date(2024, 7, 18, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 0, -1)
+	invalid := map[string]models.TimelineFilter{
+		"unknown type":    {Types: []string{"surgery"}},
+		"reversed range":  {From: &from, To: &to},
+		"limit too big":   {Limit: MaxPageSize + 1},
+		"negative offset": {Offset: -1},
+	}
+	for name, filter := range invalid {
+		if _, err := svc.GetPatientTimeline(1, filter); !errors.Is(err, ErrInvalidTimelineFilter) {
+			t.Errorf("%s: expected invalid filter error, got %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,269 @@
+package waitlist
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"software-backend/internal/clinic"
+	"software-backend/internal/models"
+	patientrepo "software-backend/internal/repository/patient"
+	"software-backend/internal/repository/waitlist"
+	"software-backend/internal/service/appointment"
+)
+
+// Offer limits
+const (
+	// How long a patient has to accept an offer, never past the slot itself
+	OfferTTL = 2 * time.Hour
+	// Entries offered the same slot at once, the first to accept gets it
+	maxOffersPerSlot = 3
+)
+
+// Custom errors, probably moved onto separate file in the future
+var (
+	ErrInvalidEntry = errors.New("invalid waitlist entry")
+	ErrOfferExpired = errors.New("waitlist offer expired")
+	ErrOfferTaken   = errors.New("waitlist offer no longer available")
+)
+
+// Channel used to deliver slot offers to patients, e.g. WhatsApp
+type OfferNotifier interface {
+	SendOffer(ctx context.Context, patient *models.Patient, offer models.WaitlistOffer) error
+}
+
+// Interface defines methods expected from the service
+type WaitlistService interface {
+	CreateEntry(entry models.WaitlistEntry) (*models.WaitlistEntry, error)
+	ListEntries(status string) ([]models.WaitlistEntry, error)
+	CancelEntry(id int) error
+	OfferSlot(released models.Appointment)
+	AcceptOffer(token string) (*models.Appointment, error)
+}
+
+// Struct to manage dependencies
+type waitlistService struct {
+	repo               waitlist.WaitlistRepository
+	patientRepo        patientrepo.PatientRepository
+	appointmentService appointment.AppointmentService
+	notifier           OfferNotifier
+	now                func() time.Time
+}
+
+// Constructor to pass on dependencies
+func NewWaitlistService(repo waitlist.WaitlistRepository, patientRepo patientrepo.PatientRepository, apptService appointment.AppointmentService, notifier OfferNotifier) WaitlistService {
+	return &waitlistService{
+		repo:               repo,
+		patientRepo:        patientRepo,
+		appointmentService: apptService,
+		notifier:           notifier,
+		now:                time.Now,
+	}
+}
+
+// Add a patient to the waitlist
+func (s *waitlistService) CreateEntry(entry models.WaitlistEntry) (*models.WaitlistEntry, error) {
+	// Basic input validation
+	if entry.PatientID == 0 {
+		return nil, fmt.Errorf("%w: patient ID is required", ErrInvalidEntry)
+	}
+	if entry.Duration <= 0 {
+		return nil, fmt.Errorf("%w: duration must be positive", ErrInvalidEntry)
+	}
+	from, err := time.Parse("2006-01-02", entry.DateFrom)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid date_from, expected YYYY-MM-DD", ErrInvalidEntry)
+	}
+	to, err := time.Parse("2006-01-02", entry.DateTo)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid date_to, expected YYYY-MM-DD", ErrInvalidEntry)
+	}
+	if to.Before(from) {
+		return nil, fmt.Errorf("%w: date_to can't be before date_from", ErrInvalidEntry)
+	}
+	for _, t := range []string{entry.TimeFrom, entry.TimeTo} {
+		if t == "" {
+			continue
+		}
+		if _, err := time.Parse("15:04", t); err != nil {
+			return nil, fmt.Errorf("%w: invalid time %q, expected HH:MM", ErrInvalidEntry, t)
+		}
+	}
+	if entry.TimeFrom != "" && entry.TimeTo != "" && entry.TimeFrom >= entry.TimeTo {
+		return nil, fmt.Errorf("%w: time_from must be before time_to", ErrInvalidEntry)
+	}
+
+	entry.Status = models.WaitlistWaiting
+	return s.repo.CreateEntry(entry)
+}
+
+// List waitlist entries, every entry if status is empty
+func (s *waitlistService) ListEntries(status string) ([]models.WaitlistEntry, error) {
+	return s.repo.ListEntries(status)
+}
+
+// Take a patient off the waitlist, offers still pending for it can't be accepted anymore
+func (s *waitlistService) CancelEntry(id int) error {
+	return s.repo.UpdateEntryStatus(id, models.WaitlistCancelled)
+}
+
+// Offer a freed slot to the matching waitlist entries, meant to be registered as the
+// appointment service's slot released hook. Errors are logged as nobody is waiting on them
+func (s *waitlistService) OfferSlot(released models.Appointment) {
+	now := s.now()
+	// Nothing to offer once the slot has started
+	if !released.Start.After(now) {
+		return
+	}
+
+	entries, err := s.repo.ListEntries(models.WaitlistWaiting)
+	if err != nil {
+		log.Printf("waitlist: failed to list entries for appointment %d: %v", released.ID, err)
+		return
+	}
+
+	// Offers expire after the TTL or when the slot starts, whatever comes first
+	expiresAt := now.Add(OfferTTL)
+	if released.Start.Before(expiresAt) {
+		expiresAt = released.Start
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	offered := 0
+	for _, entry := range entries {
+		if offered >= maxOffersPerSlot {
+			break
+		}
+		if !entryMatchesSlot(entry, released) {
+			continue
+		}
+
+		token, err := newOfferToken()
+		if err != nil {
+			log.Printf("waitlist: failed to generate offer token: %v", err)
+			return
+		}
+		offer, err := s.repo.CreateOffer(models.WaitlistOffer{
+			EntryID:               entry.ID,
+			ReleasedAppointmentID: released.ID,
+			Start:                 released.Start,
+			Duration:              entry.Duration,
+			ProviderID:            released.ProviderID,
+			RoomID:                released.RoomID,
+			Token:                 token,
+			ExpiresAt:             expiresAt,
+			Status:                models.OfferPending,
+		})
+		if err != nil {
+			log.Printf("waitlist: failed to create offer for entry %d: %v", entry.ID, err)
+			continue
+		}
+		offered++
+
+		// Deliver the offer, a failed delivery still leaves the offer open
+		patient, err := s.patientRepo.GetPatientByID(entry.PatientID)
+		if err != nil {
+			log.Printf("waitlist: failed to get patient %d: %v", entry.PatientID, err)
+			continue
+		}
+		if err := s.notifier.SendOffer(ctx, patient, *offer); err != nil {
+			log.Printf("waitlist: failed to send offer %d to patient %d: %v", offer.ID, patient.ID, err)
+		}
+	}
+}
+
+// Accept an offer & book its slot, only the first acceptance for a slot succeeds
+func (s *waitlistService) AcceptOffer(token string) (*models.Appointment, error) {
+	offer, err := s.repo.GetOfferByToken(token)
+	if err != nil {
+		return nil, err
+	}
+	if offer.Status != models.OfferPending {
+		return nil, ErrOfferTaken
+	}
+	if !s.now().Before(offer.ExpiresAt) {
+		return nil, ErrOfferExpired
+	}
+	entry, err := s.repo.GetEntryByID(offer.EntryID)
+	if err != nil {
+		return nil, err
+	}
+	// The entry was booked through another offer or taken off the waitlist
+	if entry.Status != models.WaitlistWaiting {
+		return nil, ErrOfferTaken
+	}
+
+	// Book through the appointment service so the usual checks apply, a concurrent
+	// acceptance of the same slot ends up as a conflict. The patient books it, not a user
+	booked, err := s.appointmentService.CreateAppointment(models.Appointment{
+		PatientID:  entry.PatientID,
+		Start:      offer.Start,
+		Duration:   offer.Duration,
+		ProviderID: offer.ProviderID,
+		RoomID:     offer.RoomID,
+	}, nil)
+	if err != nil {
+		if errors.Is(err, appointment.ErrAppointmentConflict) {
+			if err := s.repo.MarkOfferTaken(offer.ID); err != nil {
+				log.Printf("waitlist: %v", err)
+			}
+			return nil, ErrOfferTaken
+		}
+		return nil, err
+	}
+
+	// Don't leave a booking behind for an offer that couldn't be accepted
+	if err := s.repo.AcceptOffer(offer.ID, booked.ID); err != nil {
+		if _, cancelErr := s.appointmentService.CancelAppointment(booked.ID, nil); cancelErr != nil {
+			log.Printf("waitlist: failed to cancel appointment %d of offer %d: %v", booked.ID, offer.ID, cancelErr)
+		}
+		if errors.Is(err, waitlist.ErrOfferClosed) {
+			return nil, ErrOfferTaken
+		}
+		return nil, err
+	}
+	return booked, nil
+}
+
+// Returns true if a freed slot fits what the waitlist entry asked for
+func entryMatchesSlot(entry models.WaitlistEntry, slot models.Appointment) bool {
+	// Entry must fit in the freed slot
+	if entry.Duration > slot.Duration {
+		return false
+	}
+	// Entries asking for a provider only get that provider's slots
+	if entry.ProviderID != nil && (slot.ProviderID == nil || *slot.ProviderID != *entry.ProviderID) {
+		return false
+	}
+
+	// Zero padded dates & times compare like the values they represent, entries are in clinic time
+	slotStart := clinic.In(slot.Start)
+	date := slotStart.Format("2006-01-02")
+	if date < entry.DateFrom || date > entry.DateTo {
+		return false
+	}
+	start := slotStart.Format("15:04")
+	end := slotStart.Add(entry.Duration).Format("15:04")
+	if entry.TimeFrom != "" && start < entry.TimeFrom {
+		return false
+	}
+	if entry.TimeTo != "" && end > entry.TimeTo {
+		return false
+	}
+	return true
+}
+
+// Random token identifying an offer, hard to guess as it's all that's needed to accept
+func newOfferToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,270 @@
+package waitlist
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"software-backend/internal/mocks"
+	"software-backend/internal/models"
+	"software-backend/internal/repository/waitlist"
+	"software-backend/internal/service/appointment"
+
+	"github.com/golang/mock/gomock"
+)
+
+// Hand-written fake for AppointmentService, only booking & cancelling are used by the waitlist
+type fakeAppointmentService struct {
+	appointment.AppointmentService
+	CreateAppointmentFunc func(appt models.Appointment) (*models.Appointment, error)
+	cancelled             []int
+}
+
+func (f *fakeAppointmentService) CreateAppointment(appt models.Appointment, userID *int) (*models.Appointment, error) {
+	return f.CreateAppointmentFunc(appt)
+}
+
+func (f *fakeAppointmentService) CancelAppointment(id int, userID *int) (*models.Appointment, error) {
+	f.cancelled = append(f.cancelled, id)
+	return &models.Appointment{ID: id, Status: models.StatusCancelled}, nil
+}
+
+// Records the offers sent
+type fakeNotifier struct {
+	sent []models.WaitlistOffer
+}
+
+func (f *fakeNotifier) SendOffer(ctx context.Context, patient *models.Patient, offer models.WaitlistOffer) error {
+	f.sent = append(f.sent, offer)
+	return nil
+}
+
+func TestOfferSlot_OffersMatchingEntries(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockWaitlistRepository(ctrl)
+	mockPatientRepo := mocks.NewMockPatientRepository(ctrl)
+	notifier := &fakeNotifier{}
+	svc := NewWaitlistService(mockRepo, mockPatientRepo, &fakeAppointmentService{}, notifier).(*waitlistService)
+	now := time.Date(2024, 7, 18, 8, 0, 0, 0, time.UTC)
+	svc.now = func() time.Time { return now }
+
+	providerID, otherProviderID := 3, 4
+	released := models.Appointment{
+		ID:         10,
+		Start:      time.Date(2024, 7, 18, 11, 0, 0, 0, time.UTC),
+		Duration:   time.Hour,
+		ProviderID: &providerID,
+		Status:     models.StatusCancelled,
+	}
+
+	mockRepo.EXPECT().
+		ListEntries(models.WaitlistWaiting).
+		Return([]models.WaitlistEntry{
+			// Wants another provider
+			{ID: 1, PatientID: 1, DateFrom: "2024-07-01", DateTo: "2024-07-31", Duration: time.Hour, ProviderID: &otherProviderID},
+			// Only afternoons
+			{ID: 2, PatientID: 2, DateFrom: "2024-07-01", DateTo: "2024-07-31", TimeFrom: "14:00", Duration: time.Hour},
+			// Too long for the slot
+			{ID: 3, PatientID: 3, DateFrom: "2024-07-01", DateTo: "2024-07-31", Duration: 2 * time.Hour},
+			// Matches
+			{ID: 4, PatientID: 4, DateFrom: "2024-07-18", DateTo: "2024-07-18", TimeFrom: "09:00", TimeTo: "12:00", Duration: 30 * time.Minute},
+		}, nil)
+	mockRepo.EXPECT().
+		CreateOffer(gomock.Any()).
+		DoAndReturn(func(offer models.WaitlistOffer) (*models.WaitlistOffer, error) {
+			if offer.EntryID != 4 || offer.ReleasedAppointmentID != 10 || offer.Token == "" {
+				t.Errorf("unexpected offer: %+v", offer)
+			}
+			// Expires after the TTL as the slot is later than that
+			if !offer.ExpiresAt.Equal(now.Add(OfferTTL)) {
+				t.Errorf("unexpected expiry: %v", offer.ExpiresAt)
+			}
+			offer.ID = 99
+			return &offer, nil
+		})
+	mockPatientRepo.EXPECT().
+		GetPatientByID(4).
+		Return(&models.Patient{ID: 4, Name: "Ana", Phone: "50212345678"}, nil)
+
+	svc.OfferSlot(released)
+
+	if len(notifier.sent) != 1 || notifier.sent[0].ID != 99 {
+		t.Errorf("expected a single offer sent, got %+v", notifier.sent)
+	}
+}
+
+func TestOfferSlot_PastSlotIsIgnored(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockWaitlistRepository(ctrl)
+	svc := NewWaitlistService(mockRepo, mocks.NewMockPatientRepository(ctrl), &fakeAppointmentService{}, &fakeNotifier{}).(*waitlistService)
+	svc.now = func() time.Time { return time.Date(2024, 7, 18, 12, 0, 0, 0, time.UTC) }
+
+	// No repository calls expected
+	svc.OfferSlot(models.Appointment{ID: 10, Start: time.Date(2024, 7, 18, 11, 0, 0, 0, time.UTC), Duration: time.Hour})
+}
+
+func TestAcceptOffer_BooksSlot(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockWaitlistRepository(ctrl)
+	apptService := &fakeAppointmentService{
+		CreateAppointmentFunc: func(appt models.Appointment) (*models.Appointment, error) {
+			if appt.PatientID != 4 {
+				t.Errorf("expected booking for patient 4, got %d", appt.PatientID)
+			}
+			appt.ID = 50
+			return &appt, nil
+		},
+	}
+	svc := NewWaitlistService(mockRepo, mocks.NewMockPatientRepository(ctrl), apptService, &fakeNotifier{}).(*waitlistService)
+	now := time.Date(2024, 7, 18, 8, 0, 0, 0, time.UTC)
+	svc.now = func() time.Time { return now }
+
+	mockRepo.EXPECT().
+		GetOfferByToken("abc").
+		Return(&models.WaitlistOffer{ID: 99, EntryID: 4, Start: now.Add(3 * time.Hour), Duration: 30 * time.Minute, ExpiresAt: now.Add(time.Hour), Status: models.OfferPending}, nil)
+	mockRepo.EXPECT().
+		GetEntryByID(4).
+		Return(&models.WaitlistEntry{ID: 4, PatientID: 4, Status: models.WaitlistWaiting}, nil)
+	mockRepo.EXPECT().
+		AcceptOffer(99, 50).
+		Return(nil)
+
+	booked, err := svc.AcceptOffer("abc")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if booked.ID != 50 {
+		t.Errorf("unexpected appointment: %+v", booked)
+	}
+}
+
+func TestAcceptOffer_SlotAlreadyTaken(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockWaitlistRepository(ctrl)
+	apptService := &fakeAppointmentService{
+		CreateAppointmentFunc: func(appt models.Appointment) (*models.Appointment, error) {
+			return nil, appointment.ErrAppointmentConflict
+		},
+	}
+	svc := NewWaitlistService(mockRepo, mocks.NewMockPatientRepository(ctrl), apptService, &fakeNotifier{}).(*waitlistService)
+	now := time.Date(2024, 7, 18, 8, 0, 0, 0, time.UTC)
+	svc.now = func() time.Time { return now }
+
+	mockRepo.EXPECT().
+		GetOfferByToken("abc").
+		Return(&models.WaitlistOffer{ID: 99, EntryID: 4, Start: now.Add(3 * time.Hour), Duration: 30 * time.Minute, ExpiresAt: now.Add(time.Hour), Status: models.OfferPending}, nil)
+	mockRepo.EXPECT().
+		GetEntryByID(4).
+		Return(&models.WaitlistEntry{ID: 4, PatientID: 4, Status: models.WaitlistWaiting}, nil)
+	mockRepo.EXPECT().
+		MarkOfferTaken(99).
+		Return(nil)
+
+	_, err := svc.AcceptOffer("abc")
+	if !errors.Is(err, ErrOfferTaken) {
+		t.Errorf("expected offer taken error, got %v", err)
+	}
+}
+
+func TestAcceptOffer_EntryNoLongerWaiting(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockWaitlistRepository(ctrl)
+	apptService := &fakeAppointmentService{
+		CreateAppointmentFunc: func(appt models.Appointment) (*models.Appointment, error) {
+			t.Error("expected no booking for a cancelled entry")
+			return &appt, nil
+		},
+	}
+	svc := NewWaitlistService(mockRepo, mocks.NewMockPatientRepository(ctrl), apptService, &fakeNotifier{}).(*waitlistService)
+	now := time.Date(2024, 7, 18, 8, 0, 0, 0, time.UTC)
+	svc.now = func() time.Time { return now }
+
+	mockRepo.EXPECT().
+		GetOfferByToken("abc").
+		Return(&models.WaitlistOffer{ID: 99, EntryID: 4, Start: now.Add(3 * time.Hour), Duration: 30 * time.Minute, ExpiresAt: now.Add(time.Hour), Status: models.OfferPending}, nil)
+	mockRepo.EXPECT().
+		GetEntryByID(4).
+		Return(&models.WaitlistEntry{ID: 4, PatientID: 4, Status: models.WaitlistCancelled}, nil)
+
+	_, err := svc.AcceptOffer("abc")
+	if !errors.Is(err, ErrOfferTaken) {
+		t.Errorf("expected offer taken error, got %v", err)
+	}
+}
+
+func TestAcceptOffer_ClosedMeanwhileCancelsBooking(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockWaitlistRepository(ctrl)
+	apptService := &fakeAppointmentService{
+		CreateAppointmentFunc: func(appt models.Appointment) (*models.Appointment, error) {
+			appt.ID = 50
+			return &appt, nil
+		},
+	}
+	svc := NewWaitlistService(mockRepo, mocks.NewMockPatientRepository(ctrl), apptService, &fakeNotifier{}).(*waitlistService)
+	now := time.Date(2024, 7, 18, 8, 0, 0, 0, time.UTC)
+	svc.now = func() time.Time { return now }
+
+	mockRepo.EXPECT().
+		GetOfferByToken("abc").
+		Return(&models.WaitlistOffer{ID: 99, EntryID: 4, Start: now.Add(3 * time.Hour), Duration: 30 * time.Minute, ExpiresAt: now.Add(time.Hour), Status: models.OfferPending}, nil)
+	mockRepo.EXPECT().
+		GetEntryByID(4).
+		Return(&models.WaitlistEntry{ID: 4, PatientID: 4, Status: models.WaitlistWaiting}, nil)
+	// Entry got booked through another offer between the checks & the acceptance
+	mockRepo.EXPECT().
+		AcceptOffer(99, 50).
+		Return(waitlist.ErrOfferClosed)
+
+	_, err := svc.AcceptOffer("abc")
+	if !errors.Is(err, ErrOfferTaken) {
+		t.Errorf("expected offer taken error, got %v", err)
+	}
+	if len(apptService.cancelled) != 1 || apptService.cancelled[0] != 50 {
+		t.Errorf("expected appointment 50 to be cancelled, got %v", apptService.cancelled)
+	}
+}
+
+func TestAcceptOffer_Expired(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockWaitlistRepository(ctrl)
+	svc := NewWaitlistService(mockRepo, mocks.NewMockPatientRepository(ctrl), &fakeAppointmentService{}, &fakeNotifier{}).(*waitlistService)
+	now := time.Date(2024, 7, 18, 8, 0, 0, 0, time.UTC)
+	svc.now = func() time.Time { return now }
+
+	mockRepo.EXPECT().
+		GetOfferByToken("abc").
+		Return(&models.WaitlistOffer{ID: 99, EntryID: 4, ExpiresAt: now.Add(-time.Minute), Status: models.OfferPending}, nil)
+
+	_, err := svc.AcceptOffer("abc")
+	if !errors.Is(err, ErrOfferExpired) {
+		t.Errorf("expected offer expired error, got %v", err)
+	}
+}
+
+func TestCreateEntry_InvalidRange(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	svc := NewWaitlistService(mocks.NewMockWaitlistRepository(ctrl), mocks.NewMockPatientRepository(ctrl), &fakeAppointmentService{}, &fakeNotifier{})
+
+	_, err := svc.CreateEntry(models.WaitlistEntry{PatientID: 1, DateFrom: "2024-07-20", DateTo: "2024-07-18", Duration: time.Hour})
+	if !errors.Is(err, ErrInvalidEntry) {
+		t.Errorf("expected invalid entry error, got %v", err)
+	}
+}
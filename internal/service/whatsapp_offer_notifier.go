@@ -0,0 +1,68 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"software-backend/internal/clinic"
+	"software-backend/internal/models"
+	"software-backend/internal/repository"
+	"software-backend/internal/repository/consent"
+	"software-backend/internal/repository/patient"
+	"software-backend/internal/whatsapp"
+)
+
+// WhatsAppOfferNotifier delivers waitlist slot offers through the WhatsApp offer template,
+// the template body gets the patient name, date, time & the token used to accept
+type WhatsAppOfferNotifier struct {
+	whatsAppRepo repository.WhatsAppRepository
+	consentRepo  consent.ConsentRepository
+	patientRepo  patient.PatientRepository
+}
+
+// NewWhatsAppOfferNotifier creates a notifier using the stored WhatsApp configuration,
+// patients that don't allow WhatsApp messages get no offers & minors get them through
+// their guardian
+func NewWhatsAppOfferNotifier(whatsAppRepo repository.WhatsAppRepository, consentRepo consent.ConsentRepository, patientRepo patient.PatientRepository) *WhatsAppOfferNotifier {
+	return &WhatsAppOfferNotifier{whatsAppRepo: whatsAppRepo, consentRepo: consentRepo, patientRepo: patientRepo}
+}
+
+// SendOffer sends a single offer to the patient's phone
+func (n *WhatsAppOfferNotifier) SendOffer(ctx context.Context, patient *models.Patient, offer models.WaitlistOffer) error {
+	config, err := n.whatsAppRepo.GetConfig(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get WhatsApp config: %w", err)
+	}
+	if config.TemplateNameOffer == "" {
+		return fmt.Errorf("WhatsApp waitlist offer template is not configured")
+	}
+
+	// Validate phone number
+	recipient, err := notificationRecipient(n.patientRepo, patient)
+	if err != nil {
+		return err
+	}
+	if recipient.Phone == "" {
+		return fmt.Errorf("patient has no phone number")
+	}
+	config, err = whatsAppConfigFor(n.consentRepo, config, patient.ID, recipient.ID)
+	if err != nil {
+		return err
+	}
+	phoneNumber, err := clinic.NormalizePhone(recipient.Phone)
+	if err != nil {
+		return err
+	}
+
+	client := whatsapp.NewClient(config)
+	_, err = client.SendTemplate(ctx, phoneNumber, config.TemplateNameOffer,
+		recipient.Name,
+		clinic.In(offer.Start).Format("02/01/2006"),
+		clinic.In(offer.Start).Format("15:04"),
+		offer.Token,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to send WhatsApp offer: %w", err)
+	}
+	return nil
+}
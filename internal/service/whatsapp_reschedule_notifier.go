@@ -0,0 +1,185 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"software-backend/internal/clinic"
+	"software-backend/internal/models"
+	"software-backend/internal/repository"
+	"software-backend/internal/repository/appointment"
+	"software-backend/internal/repository/consent"
+	"software-backend/internal/repository/patient"
+	"software-backend/internal/whatsapp"
+)
+
+// Message type of the notification recorded for a rescheduled appointment
+const rescheduledMessageType = "rescheduled"
+
+// Most pending reschedule notices sent on a single run
+const pendingRescheduledBatch = 50
+
+// WhatsAppRescheduleNotifier tells patients their appointment was moved through the WhatsApp
+// rescheduled template, the template body gets the patient name, the new date & time and
+// the previous date & time
+type WhatsAppRescheduleNotifier struct {
+	whatsAppRepo    repository.WhatsAppRepository
+	appointmentRepo appointment.AppointmentRepository
+	consentRepo     consent.ConsentRepository
+	patientRepo     patient.PatientRepository
+}
+
+// NewWhatsAppRescheduleNotifier creates a notifier using the stored WhatsApp configuration,
+// patients that don't allow WhatsApp messages aren't notified & minors are notified
+// through their guardian
+func NewWhatsAppRescheduleNotifier(whatsAppRepo repository.WhatsAppRepository, appointmentRepo appointment.AppointmentRepository, consentRepo consent.ConsentRepository, patientRepo patient.PatientRepository) *WhatsAppRescheduleNotifier {
+	return &WhatsAppRescheduleNotifier{whatsAppRepo: whatsAppRepo, appointmentRepo: appointmentRepo, consentRepo: consentRepo, patientRepo: patientRepo}
+}
+
+// NotifyRescheduled is meant to be registered as the appointment service's rescheduled
+// hook. The notice is only stored as pending, SendPendingRescheduled sends it
+func (n *WhatsAppRescheduleNotifier) NotifyRescheduled(moved models.Appointment, previousStart time.Time) {
+	// Unregistered patients have no phone to send to
+	if moved.PatientID == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// A notice still pending from an earlier move is replaced by this one
+	notification := &models.WhatsAppNotification{
+		AppointmentID: moved.ID,
+		PatientID:     moved.PatientID,
+		MessageType:   rescheduledMessageType,
+		Status:        "pending",
+		SentAt:        time.Now(),
+		PreviousStart: &previousStart,
+	}
+	if err := n.whatsAppRepo.CreateNotification(ctx, notification); err != nil {
+		log.Printf("whatsapp: failed to queue reschedule notice of appointment %d: %v", moved.ID, err)
+	}
+}
+
+// SendPendingRescheduled sends the pending reschedule notices, meant to run periodically.
+// Notices that fail to send stay pending & are retried on the next run until their
+// appointment starts, the ones that can't be sent are marked failed
+func (n *WhatsAppRescheduleNotifier) SendPendingRescheduled(ctx context.Context) (int, error) {
+	notifications, err := n.whatsAppRepo.GetPendingNotificationsByType(ctx, rescheduledMessageType, pendingRescheduledBatch)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for _, notification := range notifications {
+		err := n.sendPending(ctx, notification)
+		if err == nil {
+			sent++
+			continue
+		}
+
+		// Keep the error on the notice, it's only retried while it can still be sent
+		notification.ErrorMessage = err.Error()
+		if errors.Is(err, errNoticeUndeliverable) || errors.Is(err, ErrContactNotAllowed) {
+			notification.Status = "failed"
+		} else {
+			log.Printf("whatsapp: failed to send reschedule notice of appointment %d, will retry: %v", notification.AppointmentID, err)
+		}
+		if err := n.whatsAppRepo.CreateNotification(ctx, notification); err != nil {
+			log.Printf("whatsapp: failed to save reschedule notice of appointment %d: %v", notification.AppointmentID, err)
+		}
+	}
+	return sent, nil
+}
+
+// Returned for pending notices that are no use anymore
+var errNoticeUndeliverable = errors.New("notice can't be delivered")
+
+// Send a pending notice with the appointment's current time, notices about appointments
+// no longer upcoming are undeliverable
+func (n *WhatsAppRescheduleNotifier) sendPending(ctx context.Context, notification *models.WhatsAppNotification) error {
+	if notification.PreviousStart == nil {
+		return fmt.Errorf("%w: previous time is missing", errNoticeUndeliverable)
+	}
+	appt, err := n.appointmentRepo.GetAppointmentByID(notification.AppointmentID)
+	if errors.Is(err, appointment.ErrAppointmentNotFound) {
+		return fmt.Errorf("%w: appointment was deleted", errNoticeUndeliverable)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get appointment %d: %w", notification.AppointmentID, err)
+	}
+	if appt.Status != models.StatusScheduled && appt.Status != models.StatusConfirmed {
+		return fmt.Errorf("%w: appointment is %s", errNoticeUndeliverable, appt.Status)
+	}
+	if !appt.Start.After(time.Now()) {
+		return fmt.Errorf("%w: appointment already started", errNoticeUndeliverable)
+	}
+	return n.SendRescheduled(ctx, *appt, *notification.PreviousStart)
+}
+
+// SendRescheduled sends the new time of an appointment to its patient & records the
+// notification once it's sent
+func (n *WhatsAppRescheduleNotifier) SendRescheduled(ctx context.Context, moved models.Appointment, previousStart time.Time) error {
+	config, err := n.whatsAppRepo.GetConfig(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get WhatsApp config: %w", err)
+	}
+	if config.TemplateNameRescheduled == "" {
+		return fmt.Errorf("WhatsApp rescheduled template is not configured")
+	}
+
+	patient, err := n.patientRepo.GetPatientByID(moved.PatientID)
+	if err != nil {
+		return fmt.Errorf("failed to get patient %d: %w", moved.PatientID, err)
+	}
+
+	// Validate phone number
+	recipient, err := notificationRecipient(n.patientRepo, patient)
+	if err != nil {
+		return err
+	}
+	if recipient.Phone == "" {
+		return fmt.Errorf("patient has no phone number")
+	}
+	config, err = whatsAppConfigFor(n.consentRepo, config, patient.ID, recipient.ID)
+	if err != nil {
+		return err
+	}
+	phoneNumber, err := clinic.NormalizePhone(recipient.Phone)
+	if err != nil {
+		return err
+	}
+
+	client := whatsapp.NewClient(config)
+	response, err := client.SendTemplate(ctx, phoneNumber, config.TemplateNameRescheduled,
+		recipient.Name,
+		clinic.In(moved.Start).Format("02/01/2006"),
+		clinic.In(moved.Start).Format("15:04"),
+		clinic.In(previousStart).Format("02/01/2006"),
+		clinic.In(previousStart).Format("15:04"),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to send WhatsApp reschedule notice: %w", err)
+	}
+
+	// Save notification record
+	notification := &models.WhatsAppNotification{
+		AppointmentID: moved.ID,
+		PatientID:     patient.ID,
+		PhoneNumber:   phoneNumber,
+		MessageType:   rescheduledMessageType,
+		Status:        "sent",
+		SentAt:        time.Now(),
+		PreviousStart: &previousStart,
+	}
+	if len(response.Messages) > 0 {
+		notification.WhatsAppMsgID = response.Messages[0].ID
+	}
+	if err := n.whatsAppRepo.CreateNotification(ctx, notification); err != nil {
+		return fmt.Errorf("failed to save notification record: %w", err)
+	}
+	return nil
+}
@@ -3,17 +3,30 @@ package service
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"log"
+	"strings"
 	"time"
 
+	"software-backend/internal/clinic"
 	"software-backend/internal/models"
 	"software-backend/internal/repository"
 	appointment_repo "software-backend/internal/repository/appointment"
+	consent_repo "software-backend/internal/repository/consent"
 	patient_repo "software-backend/internal/repository/patient"
 	"software-backend/internal/whatsapp"
 )
 
+// Returned when a patient doesn't want to be messaged over WhatsApp
+var ErrContactNotAllowed = errors.New("patient doesn't allow WhatsApp messages")
+
+// Replies that opt a patient out of WhatsApp messages or back in
+var (
+	optOutReplies = map[string]bool{"stop": true, "stopall": true, "unsubscribe": true, "baja": true, "alto": true, "parar": true}
+	optInReplies  = map[string]bool{"start": true, "unstop": true, "alta": true}
+)
+
 type WhatsAppService interface {
 	GetConfig(ctx context.Context) (*models.WhatsAppConfig, error)
 	UpdateConfig(ctx context.Context, config *models.WhatsAppConfig) error
@@ -21,23 +34,27 @@ type WhatsAppService interface {
 	ProcessPendingReminders(ctx context.Context) error
 	CheckAndScheduleReminders(ctx context.Context) error
 	ProcessWebhookStatus(ctx context.Context, payload *whatsapp.WebhookPayload) error
+	ProcessInboundMessages(ctx context.Context, payload *whatsapp.WebhookPayload) error
 }
 
 type whatsAppService struct {
 	whatsAppRepo    repository.WhatsAppRepository
 	appointmentRepo appointment_repo.AppointmentRepository
 	patientRepo     patient_repo.PatientRepository
+	consentRepo     consent_repo.ConsentRepository
 }
 
 func NewWhatsAppService(
 	whatsAppRepo repository.WhatsAppRepository,
 	appointmentRepo appointment_repo.AppointmentRepository,
 	patientRepo patient_repo.PatientRepository,
+	consentRepo consent_repo.ConsentRepository,
 ) WhatsAppService {
 	return &whatsAppService{
 		whatsAppRepo:    whatsAppRepo,
 		appointmentRepo: appointmentRepo,
 		patientRepo:     patientRepo,
+		consentRepo:     consentRepo,
 	}
 }
 
@@ -59,26 +76,38 @@ func (s *whatsAppService) SendReminder(ctx context.Context, appointment *models.
 		return fmt.Errorf("WhatsApp reminders are not enabled")
 	}
 
+	// Minors & patients without a phone get theirs through their guardian
+	recipient, err := notificationRecipient(s.patientRepo, patient)
+	if err != nil {
+		return err
+	}
+
 	// Validate phone number
-	if patient.Phone == "" {
+	if recipient.Phone == "" {
 		return fmt.Errorf("patient has no phone number")
 	}
 
-	// Format phone number (ensure it starts with +)
-	phoneNumber := patient.Phone
-	if phoneNumber[0] != '+' {
-		phoneNumber = "+" + phoneNumber
+	// Recipient must allow WhatsApp messages, templates go in their language
+	config, err = whatsAppConfigFor(s.consentRepo, config, patient.ID, recipient.ID)
+	if err != nil {
+		return err
+	}
+
+	// WhatsApp needs E.164 numbers, local ones get the clinic's country code
+	phoneNumber, err := clinic.NormalizePhone(recipient.Phone)
+	if err != nil {
+		return err
 	}
 
 	// Format appointment date and time
-	appointmentDate := appointment.Start.Format("02/01/2006")
-	appointmentTime := appointment.Start.Format("15:04")
+	appointmentDate := clinic.In(appointment.Start).Format("02/01/2006")
+	appointmentTime := clinic.In(appointment.Start).Format("15:04")
 
 	// Create WhatsApp client
 	client := whatsapp.NewClient(config)
 
 	// Send the message
-	response, err := client.SendTemplateMessage(ctx, phoneNumber, patient.Name, appointmentDate, appointmentTime)
+	response, err := client.SendTemplateMessage(ctx, phoneNumber, recipient.Name, appointmentDate, appointmentTime)
 
 	notification := &models.WhatsAppNotification{
 		AppointmentID: appointment.ID,
@@ -173,6 +202,11 @@ func (s *whatsAppService) CheckAndScheduleReminders(ctx context.Context) error {
 		}
 
 		for _, appointment := range appointments {
+			// Cancelled appointments and no-shows don't get reminders
+			if appointment.Status.ReleasesSlot() {
+				continue
+			}
+
 			// Check if notification already exists for this appointment and type
 			existing, _ := s.whatsAppRepo.GetNotificationsByAppointment(ctx, appointment.ID)
 			alreadySent := false
@@ -196,7 +230,9 @@ func (s *whatsAppService) CheckAndScheduleReminders(ctx context.Context) error {
 
 			// Send reminder (convert to pointer)
 			apptPtr := &appointment
-			if err := s.SendReminder(ctx, apptPtr, patient, window.messageType); err != nil {
+			if err := s.SendReminder(ctx, apptPtr, patient, window.messageType); errors.Is(err, ErrContactNotAllowed) {
+				log.Printf("Skipping %s reminder for appointment %d, patient %d doesn't allow WhatsApp messages", window.messageType, appointment.ID, patient.ID)
+			} else if err != nil {
 				log.Printf("Error sending %s reminder for appointment %d: %v", window.messageType, appointment.ID, err)
 			} else {
 				log.Printf("Successfully sent %s reminder for appointment %d to patient %s", window.messageType, appointment.ID, patient.Name)
@@ -215,6 +251,11 @@ func (s *whatsAppService) ProcessPendingReminders(ctx context.Context) error {
 	}
 
 	for _, notification := range notifications {
+		// Reschedule notices aren't reminders, their notifier sends them
+		if notification.MessageType == rescheduledMessageType {
+			continue
+		}
+
 		// Get appointment and patient
 		appointment, err := s.appointmentRepo.GetAppointmentByID(notification.AppointmentID)
 		if err != nil {
@@ -262,3 +303,96 @@ func (s *whatsAppService) ProcessWebhookStatus(ctx context.Context, payload *wha
 
 	return nil
 }
+
+// Record opt-outs & opt-ins replied to our messages, STOP-like replies opt every patient
+// with the sender's phone out of WhatsApp messages
+func (s *whatsAppService) ProcessInboundMessages(ctx context.Context, payload *whatsapp.WebhookPayload) error {
+	for _, entry := range payload.Entry {
+		for _, change := range entry.Changes {
+			for _, message := range change.Value.Messages {
+				if message.Type != "text" {
+					continue
+				}
+				reply := strings.ToLower(strings.Trim(message.Text.Body, " \t\r\n.!¡"))
+				status := ""
+				switch {
+				case optOutReplies[reply]:
+					status = models.ConsentOptOut
+				case optInReplies[reply]:
+					status = models.ConsentOptIn
+				default:
+					continue
+				}
+
+				record := models.ConsentRecord{
+					Channel: models.ChannelWhatsApp,
+					Status:  status,
+					Source:  models.ConsentSourceWhatsAppReply,
+					Note:    fmt.Sprintf("Replied %q to message %s", message.Text.Body, message.ID),
+				}
+				// WhatsApp sends the number as digits with the country code
+				phone, err := clinic.NormalizePhone("+" + message.From)
+				if err != nil {
+					log.Printf("Got %s from unusable number %s: %v", status, message.From, err)
+					continue
+				}
+				patientIDs, err := s.consentRepo.CreateConsentByPhone(phone, record)
+				if err != nil {
+					log.Printf("Error recording %s from %s: %v", status, message.From, err)
+					continue
+				}
+				if len(patientIDs) == 0 {
+					log.Printf("Got %s from %s but no patient has that phone", status, message.From)
+					continue
+				}
+				log.Printf("Recorded %s from %s for patients %v", status, message.From, patientIDs)
+			}
+		}
+	}
+	return nil
+}
+
+// Who messages about a patient go to: their notification contact when they chose one
+// with a phone, else the patient unless they're a minor or have no phone, then their
+// guardian or parent
+func notificationRecipient(patientRepo patient_repo.PatientRepository, patient *models.Patient) (*models.Patient, error) {
+	contact, err := patientRepo.GetNotificationContact(patient.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get notification contact: %w", err)
+	}
+	if contact == nil || contact.RelatedPatient.Phone == "" {
+		return patient, nil
+	}
+	if contact.NotificationContact || patient.Phone == "" || patient.IsMinor(time.Now()) {
+		return contact.RelatedPatient, nil
+	}
+	return patient, nil
+}
+
+// Check the recipient allows WhatsApp messages & get the config to send them with, set
+// to the recipient's language when they have one. Messages to someone else about a
+// patient that doesn't want to be contacted aren't allowed either
+func whatsAppConfigFor(consentRepo consent_repo.ConsentRepository, config *models.WhatsAppConfig, patientID, recipientID int) (*models.WhatsAppConfig, error) {
+	preferences, err := consentRepo.GetContactPreferences(recipientID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get contact preferences: %w", err)
+	}
+	if !preferences.Allows(models.ChannelWhatsApp) {
+		return nil, ErrContactNotAllowed
+	}
+	if recipientID != patientID {
+		patientPreferences, err := consentRepo.GetContactPreferences(patientID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get contact preferences: %w", err)
+		}
+		if patientPreferences.DoNotContact {
+			return nil, ErrContactNotAllowed
+		}
+	}
+	if preferences.Language != "" {
+		localized := *config
+		localized.TemplateLangCode = preferences.Language
+		return &localized, nil
+	}
+	return config, nil
+}
@@ -3,13 +3,16 @@ package service
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"log"
 	"time"
 
+	"software-backend/internal/clinic"
 	"software-backend/internal/models"
 	"software-backend/internal/repository"
 	appointment_repo "software-backend/internal/repository/appointment"
+	consent_repo "software-backend/internal/repository/consent"
 	patient_repo "software-backend/internal/repository/patient"
 	"software-backend/internal/whatsapp"
 )
@@ -26,17 +29,20 @@ type whatsAppServiceSimple struct {
 	whatsAppRepo    repository.WhatsAppRepository
 	appointmentRepo appointment_repo.AppointmentRepository
 	patientRepo     patient_repo.PatientRepository
+	consentRepo     consent_repo.ConsentRepository
 }
 
 func NewWhatsAppServiceSimple(
 	whatsAppRepo repository.WhatsAppRepository,
 	appointmentRepo appointment_repo.AppointmentRepository,
 	patientRepo patient_repo.PatientRepository,
+	consentRepo consent_repo.ConsentRepository,
 ) WhatsAppServiceSimple {
 	return &whatsAppServiceSimple{
 		whatsAppRepo:    whatsAppRepo,
 		appointmentRepo: appointmentRepo,
 		patientRepo:     patientRepo,
+		consentRepo:     consentRepo,
 	}
 }
 
@@ -106,6 +112,11 @@ func (s *whatsAppServiceSimple) CheckAndScheduleReminders(ctx context.Context) e
 		}
 
 		for _, appointment := range appointments {
+			// Skip cancelled appointments and no-shows
+			if appointment.Status.ReleasesSlot() {
+				continue
+			}
+
 			// Check if already sent
 			existing, _ := s.whatsAppRepo.GetNotificationsByAppointment(ctx, appointment.ID)
 			alreadySent := false
@@ -128,7 +139,9 @@ func (s *whatsAppServiceSimple) CheckAndScheduleReminders(ctx context.Context) e
 			}
 
 			// Send reminder
-			if err := s.sendReminder(ctx, config, &appointment, patient, window.MessageType); err != nil {
+			if err := s.sendReminder(ctx, config, &appointment, patient, window.MessageType); errors.Is(err, ErrContactNotAllowed) {
+				log.Printf("Skipping %s reminder for appointment %d, patient %d doesn't allow WhatsApp messages", window.MessageType, appointment.ID, patient.ID)
+			} else if err != nil {
 				log.Printf("Error sending %s reminder for appointment %d: %v", window.MessageType, appointment.ID, err)
 			} else {
 				log.Printf("✅ Sent %s reminder for appointment %d to %s", window.MessageType, appointment.ID, patient.Name)
@@ -140,23 +153,35 @@ func (s *whatsAppServiceSimple) CheckAndScheduleReminders(ctx context.Context) e
 }
 
 func (s *whatsAppServiceSimple) sendReminder(ctx context.Context, config *models.WhatsAppConfig, appointment *models.Appointment, patient *models.Patient, messageType string) error {
+	// Minors & patients without a phone go through their guardian
+	recipient, err := notificationRecipient(s.patientRepo, patient)
+	if err != nil {
+		return err
+	}
+
 	// Validate phone
-	if patient.Phone == "" {
+	if recipient.Phone == "" {
 		return fmt.Errorf("patient has no phone number")
 	}
 
-	phoneNumber := patient.Phone
-	if phoneNumber[0] != '+' {
-		phoneNumber = "+" + phoneNumber
+	// Check consent & language
+	config, err = whatsAppConfigFor(s.consentRepo, config, patient.ID, recipient.ID)
+	if err != nil {
+		return err
+	}
+
+	phoneNumber, err := clinic.NormalizePhone(recipient.Phone)
+	if err != nil {
+		return err
 	}
 
 	// Format date and time
-	appointmentDate := appointment.Start.Format("02/01/2006")
-	appointmentTime := appointment.Start.Format("15:04")
+	appointmentDate := clinic.In(appointment.Start).Format("02/01/2006")
+	appointmentTime := clinic.In(appointment.Start).Format("15:04")
 
 	// Create client and send
 	client := whatsapp.NewClient(config)
-	response, err := client.SendTemplateMessage(ctx, phoneNumber, patient.Name, appointmentDate, appointmentTime)
+	response, err := client.SendTemplateMessage(ctx, phoneNumber, recipient.Name, appointmentDate, appointmentTime)
 
 	// Create notification record
 	notification := &models.WhatsAppNotification{
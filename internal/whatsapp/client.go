@@ -75,12 +75,26 @@ type ErrorResponse struct {
 	} `json:"error"`
 }
 
-// SendTemplateMessage sends a template message to a WhatsApp number
+// SendTemplateMessage sends the reminder template message to a WhatsApp number
 func (c *Client) SendTemplateMessage(ctx context.Context, phoneNumber string, patientName string, appointmentDate string, appointmentTime string) (*SendMessageResponse, error) {
+	return c.SendTemplate(ctx, phoneNumber, c.config.TemplateNameReminder, patientName, appointmentDate, appointmentTime)
+}
+
+// SendTemplate sends any approved template to a WhatsApp number, params fill the body placeholders in order
+func (c *Client) SendTemplate(ctx context.Context, phoneNumber string, templateName string, params ...string) (*SendMessageResponse, error) {
 	if !c.config.IsActive {
 		return nil, fmt.Errorf("WhatsApp integration is not active")
 	}
 
+	// Body parameters
+	parameters := make([]map[string]interface{}, 0, len(params))
+	for _, param := range params {
+		parameters = append(parameters, map[string]interface{}{
+			"type": "text",
+			"text": param,
+		})
+	}
+
 	// Build the message payload
 	msg := TemplateMessage{
 		MessagingProduct: "whatsapp",
@@ -88,27 +102,14 @@ func (c *Client) SendTemplateMessage(ctx context.Context, phoneNumber string, pa
 		To:               phoneNumber,
 		Type:             "template",
 		Template: Template{
-			Name: c.config.TemplateNameReminder,
+			Name: templateName,
 			Language: TemplateLanguage{
 				Code: c.config.TemplateLangCode,
 			},
 			Components: []map[string]interface{}{
 				{
-					"type": "body",
-					"parameters": []map[string]interface{}{
-						{
-							"type": "text",
-							"text": patientName,
-						},
-						{
-							"type": "text",
-							"text": appointmentDate,
-						},
-						{
-							"type": "text",
-							"text": appointmentTime,
-						},
-					},
+					"type":       "body",
+					"parameters": parameters,
 				},
 			},
 		},
@@ -0,0 +1,15 @@
+-- Recurring appointment series, each occurrence is still a row in citas
+CREATE TABLE IF NOT EXISTS series_citas (
+    id SERIAL PRIMARY KEY,
+    paciente_id INT REFERENCES pacientes(id),
+    nombre TEXT,
+    fecha_inicio TIMESTAMPTZ NOT NULL,
+    duracion BIGINT NOT NULL, -- Seconds, same as citas.duracion
+    regla TEXT NOT NULL, -- RFC 5545 RRULE subset
+    excepciones DATE[] NOT NULL DEFAULT '{}'
+);
+
+ALTER TABLE citas
+    ADD COLUMN IF NOT EXISTS serie_id INT REFERENCES series_citas(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_citas_serie_id ON citas (serie_id, fecha);
@@ -0,0 +1,18 @@
+-- Appointment status lifecycle, cancelled & no-show appointments are kept instead of deleted
+ALTER TABLE citas
+    ADD COLUMN IF NOT EXISTS estado TEXT NOT NULL DEFAULT 'scheduled'
+        CHECK (estado IN ('scheduled', 'confirmed', 'checked_in', 'in_consultation', 'completed', 'cancelled', 'no_show')),
+    ADD COLUMN IF NOT EXISTS estado_actualizado TIMESTAMPTZ,
+    ADD COLUMN IF NOT EXISTS estado_usuario_id INT REFERENCES usuarios(id);
+
+-- Every status change with who made it
+CREATE TABLE IF NOT EXISTS citas_estados (
+    id SERIAL PRIMARY KEY,
+    cita_id INT NOT NULL REFERENCES citas(id) ON DELETE CASCADE,
+    estado_anterior TEXT NOT NULL,
+    estado TEXT NOT NULL,
+    fecha TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    usuario_id INT REFERENCES usuarios(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_citas_estados_cita_id ON citas_estados (cita_id, fecha);
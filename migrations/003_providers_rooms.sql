@@ -0,0 +1,38 @@
+-- Providers (doctors, technicians) & rooms / equipment appointments are booked with
+CREATE TABLE IF NOT EXISTS proveedores (
+    id SERIAL PRIMARY KEY,
+    nombre TEXT NOT NULL,
+    tipo TEXT NOT NULL DEFAULT 'doctor' CHECK (tipo IN ('doctor', 'technician')),
+    activo BOOLEAN NOT NULL DEFAULT TRUE
+);
+
+CREATE TABLE IF NOT EXISTS salas (
+    id SERIAL PRIMARY KEY,
+    nombre TEXT NOT NULL,
+    tipo TEXT NOT NULL DEFAULT 'room' CHECK (tipo IN ('room', 'equipment')),
+    activo BOOLEAN NOT NULL DEFAULT TRUE
+);
+
+-- Appointments without provider or room keep sharing the clinic-wide schedule
+ALTER TABLE citas
+    ADD COLUMN IF NOT EXISTS proveedor_id INT REFERENCES proveedores(id),
+    ADD COLUMN IF NOT EXISTS sala_id INT REFERENCES salas(id);
+
+ALTER TABLE series_citas
+    ADD COLUMN IF NOT EXISTS proveedor_id INT REFERENCES proveedores(id),
+    ADD COLUMN IF NOT EXISTS sala_id INT REFERENCES salas(id);
+
+CREATE INDEX IF NOT EXISTS idx_citas_proveedor_fecha ON citas (proveedor_id, fecha);
+CREATE INDEX IF NOT EXISTS idx_citas_sala_fecha ON citas (sala_id, fecha);
+
+-- Weekly hours per provider, same shape as horarios_laborales. Providers without
+-- rows simply follow the clinic hours
+CREATE TABLE IF NOT EXISTS horarios_proveedores (
+    id SERIAL PRIMARY KEY,
+    proveedor_id INT NOT NULL REFERENCES proveedores(id) ON DELETE CASCADE,
+    dia_semana INT NOT NULL CHECK (dia_semana BETWEEN 1 AND 7),
+    hora_apertura TIME NOT NULL,
+    hora_cierre TIME NOT NULL CHECK (hora_cierre > hora_apertura)
+);
+
+CREATE INDEX IF NOT EXISTS idx_horarios_proveedores ON horarios_proveedores (proveedor_id, dia_semana);
@@ -0,0 +1,23 @@
+-- Let the database reject overlapping bookings so two concurrent requests can't
+-- double-book a slot. Scopes match HasOverlappingAppointment: per provider, per
+-- room & a clinic-wide pool for appointments without either. Cancelled & no-show
+-- appointments free their slot. Existing overlaps must be resolved before running this
+CREATE EXTENSION IF NOT EXISTS btree_gist;
+
+-- Time range taken by an appointment, duracion is in seconds. Adding a plain
+-- seconds interval doesn't depend on the session time zone so it's safe to index
+CREATE OR REPLACE FUNCTION citas_rango(fecha TIMESTAMPTZ, duracion BIGINT)
+RETURNS TSTZRANGE AS $$
+    SELECT tstzrange(fecha, fecha + make_interval(secs => duracion))
+$$ LANGUAGE sql IMMUTABLE;
+
+ALTER TABLE citas
+    ADD CONSTRAINT citas_sin_solape_proveedor
+        EXCLUDE USING gist (proveedor_id WITH =, citas_rango(fecha, duracion) WITH &&)
+        WHERE (proveedor_id IS NOT NULL AND estado NOT IN ('cancelled', 'no_show')),
+    ADD CONSTRAINT citas_sin_solape_sala
+        EXCLUDE USING gist (sala_id WITH =, citas_rango(fecha, duracion) WITH &&)
+        WHERE (sala_id IS NOT NULL AND estado NOT IN ('cancelled', 'no_show')),
+    ADD CONSTRAINT citas_sin_solape_clinica
+        EXCLUDE USING gist (citas_rango(fecha, duracion) WITH &&)
+        WHERE (proveedor_id IS NULL AND sala_id IS NULL AND estado NOT IN ('cancelled', 'no_show'));
@@ -0,0 +1,38 @@
+-- Patients waiting for a free slot
+CREATE TABLE IF NOT EXISTS lista_espera (
+    id SERIAL PRIMARY KEY,
+    paciente_id INT NOT NULL REFERENCES pacientes(id),
+    fecha_desde DATE NOT NULL,
+    fecha_hasta DATE NOT NULL CHECK (fecha_hasta >= fecha_desde),
+    hora_desde TIME,
+    hora_hasta TIME,
+    duracion BIGINT NOT NULL CHECK (duracion > 0),
+    proveedor_id INT REFERENCES proveedores(id),
+    notas TEXT NOT NULL DEFAULT '',
+    estado TEXT NOT NULL DEFAULT 'waiting' CHECK (estado IN ('waiting', 'booked', 'cancelled')),
+    creado TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_lista_espera_estado ON lista_espera (estado, fecha_desde, fecha_hasta);
+
+-- Freed slots offered to waitlist entries, the token is what the patient uses to accept
+CREATE TABLE IF NOT EXISTS ofertas_lista_espera (
+    id SERIAL PRIMARY KEY,
+    entrada_id INT NOT NULL REFERENCES lista_espera(id) ON DELETE CASCADE,
+    cita_liberada_id INT NOT NULL REFERENCES citas(id),
+    fecha TIMESTAMPTZ NOT NULL,
+    duracion BIGINT NOT NULL,
+    proveedor_id INT REFERENCES proveedores(id),
+    sala_id INT REFERENCES salas(id),
+    token TEXT NOT NULL UNIQUE,
+    expira TIMESTAMPTZ NOT NULL,
+    estado TEXT NOT NULL DEFAULT 'pending' CHECK (estado IN ('pending', 'accepted', 'taken')),
+    cita_id INT REFERENCES citas(id),
+    creado TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_ofertas_lista_espera_cita ON ofertas_lista_espera (cita_liberada_id);
+
+-- WhatsApp template used for the offers
+ALTER TABLE whatsapp_config
+    ADD COLUMN IF NOT EXISTS template_name_waitlist_offer TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,45 @@
+-- Catalog of appointment types, duracion & margen are in seconds like citas.duracion.
+-- The margin is kept free after each appointment of the type
+CREATE TABLE IF NOT EXISTS tipos_cita (
+    id SERIAL PRIMARY KEY,
+    nombre TEXT NOT NULL UNIQUE,
+    duracion BIGINT NOT NULL CHECK (duracion > 0),
+    color TEXT NOT NULL DEFAULT '#9E9E9E',
+    margen BIGINT NOT NULL DEFAULT 0 CHECK (margen >= 0),
+    cuestionario_id INT REFERENCES cuestionarios(id),
+    activo BOOLEAN NOT NULL DEFAULT TRUE
+);
+
+INSERT INTO tipos_cita (nombre, duracion, color, margen) VALUES
+    ('Primera consulta', 2700, '#1E88E5', 300),
+    ('Seguimiento', 1200, '#43A047', 0),
+    ('Control de presion intraocular', 900, '#FB8C00', 0),
+    ('Control post operatorio', 1200, '#8E24AA', 300),
+    ('Adaptacion de lentes', 1800, '#00ACC1', 600)
+ON CONFLICT (nombre) DO NOTHING;
+
+-- The margin is copied onto the appointment when booking so later changes to the
+-- type don't move existing bookings
+ALTER TABLE citas
+    ADD COLUMN IF NOT EXISTS tipo_cita_id INT REFERENCES tipos_cita(id),
+    ADD COLUMN IF NOT EXISTS margen BIGINT NOT NULL DEFAULT 0 CHECK (margen >= 0);
+
+ALTER TABLE series_citas
+    ADD COLUMN IF NOT EXISTS tipo_cita_id INT REFERENCES tipos_cita(id);
+
+-- The margin is part of the slot an appointment takes up
+ALTER TABLE citas
+    DROP CONSTRAINT IF EXISTS citas_sin_solape_proveedor,
+    DROP CONSTRAINT IF EXISTS citas_sin_solape_sala,
+    DROP CONSTRAINT IF EXISTS citas_sin_solape_clinica;
+
+ALTER TABLE citas
+    ADD CONSTRAINT citas_sin_solape_proveedor
+        EXCLUDE USING gist (proveedor_id WITH =, citas_rango(fecha, duracion + margen) WITH &&)
+        WHERE (proveedor_id IS NOT NULL AND estado NOT IN ('cancelled', 'no_show')),
+    ADD CONSTRAINT citas_sin_solape_sala
+        EXCLUDE USING gist (sala_id WITH =, citas_rango(fecha, duracion + margen) WITH &&)
+        WHERE (sala_id IS NOT NULL AND estado NOT IN ('cancelled', 'no_show')),
+    ADD CONSTRAINT citas_sin_solape_clinica
+        EXCLUDE USING gist (citas_rango(fecha, duracion + margen) WITH &&)
+        WHERE (proveedor_id IS NULL AND sala_id IS NULL AND estado NOT IN ('cancelled', 'no_show'));
@@ -0,0 +1,37 @@
+-- Per-user tokens for the read-only iCalendar feed, a revoked token stops working
+-- right away. Private feeds hide patient names
+CREATE TABLE IF NOT EXISTS tokens_calendario (
+    id SERIAL PRIMARY KEY,
+    usuario_id INT NOT NULL REFERENCES usuarios(id) ON DELETE CASCADE,
+    token TEXT NOT NULL UNIQUE,
+    privado BOOLEAN NOT NULL DEFAULT FALSE,
+    proveedor_id INT REFERENCES proveedores(id),
+    creado TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    revocado TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_tokens_calendario_usuario ON tokens_calendario (usuario_id);
+
+-- Calendar clients need to know when an event changed, the sequence goes up on
+-- every change of the time slot or status (RFC 5545 SEQUENCE)
+ALTER TABLE citas
+    ADD COLUMN IF NOT EXISTS actualizado TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    ADD COLUMN IF NOT EXISTS secuencia INT NOT NULL DEFAULT 0;
+
+CREATE OR REPLACE FUNCTION citas_marcar_actualizada()
+RETURNS TRIGGER AS $$
+BEGIN
+    NEW.actualizado := NOW();
+    IF NEW.fecha IS DISTINCT FROM OLD.fecha
+        OR NEW.duracion IS DISTINCT FROM OLD.duracion
+        OR NEW.estado IS DISTINCT FROM OLD.estado THEN
+        NEW.secuencia := OLD.secuencia + 1;
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS citas_actualizada ON citas;
+CREATE TRIGGER citas_actualizada
+    BEFORE UPDATE ON citas
+    FOR EACH ROW EXECUTE FUNCTION citas_marcar_actualizada();
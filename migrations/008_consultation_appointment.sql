@@ -0,0 +1,4 @@
+-- Consultations started from an appointment keep a link to it, an appointment
+-- leads to a single consultation
+ALTER TABLE consultas
+    ADD COLUMN IF NOT EXISTS cita_id INT UNIQUE REFERENCES citas(id);
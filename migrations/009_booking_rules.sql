@@ -0,0 +1,26 @@
+-- Limits checked when booking, a single row where 0 disables a limit. Durations
+-- are in seconds like the rest of the schedule. Buffers after a visit are set per
+-- appointment type, see migrations/006
+CREATE TABLE IF NOT EXISTS reglas_agenda (
+    id BOOLEAN PRIMARY KEY DEFAULT TRUE CHECK (id),
+    antelacion_minima BIGINT NOT NULL DEFAULT 0 CHECK (antelacion_minima >= 0),
+    horizonte_maximo BIGINT NOT NULL DEFAULT 0 CHECK (horizonte_maximo >= 0),
+    max_primera_vez_dia INT NOT NULL DEFAULT 0 CHECK (max_primera_vez_dia >= 0),
+    max_citas_paciente_dia INT NOT NULL DEFAULT 0 CHECK (max_citas_paciente_dia >= 0),
+    actualizado TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    actualizado_por INT REFERENCES usuarios(id)
+);
+
+INSERT INTO reglas_agenda (id) VALUES (TRUE) ON CONFLICT (id) DO NOTHING;
+
+-- Bookings let through despite a rule, one row per rule overridden
+CREATE TABLE IF NOT EXISTS excepciones_reglas (
+    id SERIAL PRIMARY KEY,
+    cita_id INT NOT NULL REFERENCES citas(id) ON DELETE CASCADE,
+    regla TEXT NOT NULL,
+    motivo TEXT NOT NULL DEFAULT '',
+    usuario_id INT REFERENCES usuarios(id),
+    creado TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_excepciones_reglas_cita ON excepciones_reglas (cita_id);
@@ -0,0 +1,13 @@
+-- Trail of the changes made to appointments, who made them & when. Kept even if
+-- the appointment row goes away. cambios holds the changed fields as
+-- {"field": {"before": ..., "after": ...}} using the API's field names
+CREATE TABLE IF NOT EXISTS citas_auditoria (
+    id SERIAL PRIMARY KEY,
+    cita_id INT NOT NULL,
+    accion TEXT NOT NULL,
+    usuario_id INT REFERENCES usuarios(id) ON DELETE SET NULL,
+    cambios JSONB NOT NULL DEFAULT '{}',
+    fecha TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_citas_auditoria_cita ON citas_auditoria (cita_id, fecha);
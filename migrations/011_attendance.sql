@@ -0,0 +1,7 @@
+-- Patients with this many no-shows need an override to book, 0 disables the rule
+ALTER TABLE reglas_agenda
+    ADD COLUMN IF NOT EXISTS max_inasistencias INT NOT NULL DEFAULT 0 CHECK (max_inasistencias >= 0);
+
+-- The no-show job looks for past appointments nobody checked in
+CREATE INDEX IF NOT EXISTS idx_citas_estado_fecha ON citas (estado, fecha);
+CREATE INDEX IF NOT EXISTS idx_citas_paciente ON citas (paciente_id);
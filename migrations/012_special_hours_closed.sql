@@ -0,0 +1,15 @@
+-- Special hours can close the clinic for the whole day, closed dates have a single
+-- row without hours. Before this an empty day fell back to the weekly schedule
+ALTER TABLE horarios_especiales
+    ADD COLUMN IF NOT EXISTS cerrado BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE horarios_especiales ALTER COLUMN hora_apertura DROP NOT NULL;
+ALTER TABLE horarios_especiales ALTER COLUMN hora_cierre DROP NOT NULL;
+
+-- Rows entered by hand before this aren't checked, the API validates them from now on
+ALTER TABLE horarios_especiales
+    ADD CONSTRAINT horarios_especiales_horas CHECK (
+        (cerrado AND hora_apertura IS NULL AND hora_cierre IS NULL)
+        OR (NOT cerrado AND hora_apertura IS NOT NULL AND hora_cierre > hora_apertura)
+    ) NOT VALID;
+
+CREATE INDEX IF NOT EXISTS idx_horarios_especiales_fecha ON horarios_especiales (fecha);
@@ -0,0 +1,17 @@
+-- Duplicate charts merged into a surviving patient. The merged patient row is deleted,
+-- so its data is kept here as it was along with how many rows were moved per table
+CREATE TABLE IF NOT EXISTS fusiones_pacientes (
+    id SERIAL PRIMARY KEY,
+    paciente_id INT NOT NULL REFERENCES pacientes(id) ON DELETE CASCADE,
+    paciente_fusionado_id INT NOT NULL,
+    datos_fusionado JSONB NOT NULL,
+    movidos JSONB NOT NULL DEFAULT '{}',
+    usuario_id INT REFERENCES usuarios(id) ON DELETE SET NULL,
+    fecha TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_fusiones_pacientes_paciente ON fusiones_pacientes (paciente_id, fecha);
+
+-- Duplicate candidates are paired on birth date or phone
+CREATE INDEX IF NOT EXISTS idx_pacientes_fecha_nacimiento ON pacientes (fecha_nacimiento);
+CREATE INDEX IF NOT EXISTS idx_pacientes_telefono ON pacientes (telefono);
@@ -0,0 +1,15 @@
+-- Accent-insensitive ranked patient search, see the patient repository
+CREATE EXTENSION IF NOT EXISTS unaccent;
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- unaccent() isn't IMMUTABLE as its dictionary could change, indexes need a wrapper
+-- that pins it
+CREATE OR REPLACE FUNCTION f_unaccent(text) RETURNS text
+    LANGUAGE sql IMMUTABLE PARALLEL SAFE STRICT
+    AS $$ SELECT public.unaccent('public.unaccent'::regdictionary, $1) $$;
+
+-- Queries have to use the exact same expressions for these to be picked
+CREATE INDEX IF NOT EXISTS idx_pacientes_nombre_trgm
+    ON pacientes USING gin (lower(f_unaccent(nombre)) gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_pacientes_telefono_trgm
+    ON pacientes USING gin (regexp_replace(telefono, '[^0-9]', '', 'g') gin_trgm_ops);
@@ -0,0 +1,38 @@
+-- Antecedentes are kept as append-only versions, a change adds a new row instead of
+-- overwriting the last one. The current antecedentes are the highest version
+-- The one row per patient constraint may not have the default name, it's looked up so
+-- the migration fails instead of leaving it in place
+DO $$
+DECLARE
+    unique_name TEXT;
+BEGIN
+    SELECT c.conname INTO unique_name
+    FROM pg_constraint c
+    JOIN pg_attribute a ON a.attrelid = c.conrelid AND a.attname = 'paciente_id'
+    WHERE c.conrelid = 'antecedentes'::regclass
+      AND c.contype = 'u'
+      AND c.conkey = ARRAY[a.attnum];
+    IF unique_name IS NULL THEN
+        RAISE EXCEPTION 'antecedentes has no unique constraint on paciente_id to drop';
+    END IF;
+    EXECUTE format('ALTER TABLE antecedentes DROP CONSTRAINT %I', unique_name);
+END;
+$$;
+
+ALTER TABLE antecedentes ADD COLUMN IF NOT EXISTS version INT NOT NULL DEFAULT 1;
+ALTER TABLE antecedentes ADD COLUMN IF NOT EXISTS usuario_id INT REFERENCES usuarios(id) ON DELETE SET NULL;
+ALTER TABLE antecedentes ADD COLUMN IF NOT EXISTS fecha TIMESTAMPTZ NOT NULL DEFAULT NOW();
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_antecedentes_paciente_version ON antecedentes (paciente_id, version);
+
+-- Versions are never edited, rows only go away along with their patient
+CREATE OR REPLACE FUNCTION antecedentes_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'antecedentes versions can''t be modified, add a new version instead';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS antecedentes_append_only ON antecedentes;
+CREATE TRIGGER antecedentes_append_only
+    BEFORE UPDATE ON antecedentes
+    FOR EACH ROW EXECUTE FUNCTION antecedentes_append_only();
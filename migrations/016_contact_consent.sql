@@ -0,0 +1,25 @@
+-- How a patient wants to be contacted, at most one row per patient. Patients without
+-- a row have no preferences set
+CREATE TABLE IF NOT EXISTS preferencias_contacto (
+    paciente_id INT PRIMARY KEY REFERENCES pacientes(id) ON DELETE CASCADE,
+    canal_preferido TEXT,
+    idioma TEXT,
+    no_contactar BOOLEAN NOT NULL DEFAULT FALSE,
+    usuario_id INT REFERENCES usuarios(id) ON DELETE SET NULL,
+    fecha_actualizacion TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Opt-ins & opt-outs per channel, never updated. The latest record of a channel is the
+-- one in force
+CREATE TABLE IF NOT EXISTS consentimientos_contacto (
+    id SERIAL PRIMARY KEY,
+    paciente_id INT NOT NULL REFERENCES pacientes(id) ON DELETE CASCADE,
+    canal TEXT NOT NULL,
+    estado TEXT NOT NULL CHECK (estado IN ('opt_in', 'opt_out')),
+    origen TEXT NOT NULL,
+    nota TEXT,
+    usuario_id INT REFERENCES usuarios(id) ON DELETE SET NULL,
+    fecha TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_consentimientos_contacto_paciente ON consentimientos_contacto (paciente_id, canal, fecha DESC);
@@ -0,0 +1,15 @@
+-- Relationships between patients, relacionado_id is the patient's guardian, parent,
+-- spouse or emergency contact. At most one is the patient's contact for notifications
+CREATE TABLE IF NOT EXISTS relaciones_pacientes (
+    id SERIAL PRIMARY KEY,
+    paciente_id INT NOT NULL REFERENCES pacientes(id) ON DELETE CASCADE,
+    relacionado_id INT NOT NULL REFERENCES pacientes(id) ON DELETE CASCADE,
+    tipo TEXT NOT NULL CHECK (tipo IN ('guardian', 'parent', 'spouse', 'emergency_contact')),
+    contacto_notificaciones BOOLEAN NOT NULL DEFAULT FALSE,
+    fecha TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CHECK (paciente_id <> relacionado_id),
+    UNIQUE (paciente_id, relacionado_id, tipo)
+);
+
+CREATE INDEX IF NOT EXISTS idx_relaciones_pacientes_relacionado ON relaciones_pacientes (relacionado_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_relaciones_pacientes_contacto ON relaciones_pacientes (paciente_id) WHERE contacto_notificaciones;
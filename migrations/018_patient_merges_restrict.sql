@@ -0,0 +1,6 @@
+-- Merge records are the only trace of a merged patient, they must outlive the
+-- surviving patient's chart instead of going away with it
+ALTER TABLE fusiones_pacientes
+    DROP CONSTRAINT IF EXISTS fusiones_pacientes_paciente_id_fkey,
+    ADD CONSTRAINT fusiones_pacientes_paciente_id_fkey
+        FOREIGN KEY (paciente_id) REFERENCES pacientes(id) ON DELETE RESTRICT;
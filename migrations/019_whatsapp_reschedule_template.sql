@@ -0,0 +1,3 @@
+-- WhatsApp template telling patients their appointment was moved by a reschedule
+ALTER TABLE whatsapp_config
+    ADD COLUMN IF NOT EXISTS template_name_rescheduled TEXT NOT NULL DEFAULT '';
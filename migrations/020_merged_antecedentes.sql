@@ -0,0 +1,15 @@
+-- Antecedentes versions of merged patients, the merged chart is deleted so its versions
+-- are kept along with the merge they went through
+CREATE TABLE IF NOT EXISTS antecedentes_fusionados (
+    id SERIAL PRIMARY KEY,
+    fusion_id INT NOT NULL REFERENCES fusiones_pacientes(id) ON DELETE RESTRICT,
+    version INT NOT NULL,
+    medicos TEXT,
+    familiares TEXT,
+    oculares TEXT,
+    alergicos TEXT,
+    otros TEXT,
+    usuario_id INT REFERENCES usuarios(id) ON DELETE SET NULL,
+    fecha TIMESTAMPTZ NOT NULL,
+    UNIQUE (fusion_id, version)
+);
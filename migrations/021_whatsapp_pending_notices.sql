@@ -0,0 +1,4 @@
+-- Reschedule notices are stored as pending notifications & sent by a scheduler so failed
+-- sends are retried, their template also needs the appointment's time before the move
+ALTER TABLE whatsapp_notifications
+    ADD COLUMN IF NOT EXISTS previous_start TIMESTAMPTZ;